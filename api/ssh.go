@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 )
 
 // SSH is used to return a client to invoke operations on SSH backend.
@@ -60,3 +61,46 @@ func (c *SSH) SignKey(role string, data map[string]interface{}) (*Secret, error)
 
 	return ParseSecret(resp.Body)
 }
+
+// SignHostKey signs the given host public key and returns a host certificate
+// along with the public key of the CA.
+func (c *SSH) SignHostKey(role string, data map[string]interface{}) (*Secret, error) {
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/%s/sign-host/%s", c.MountPoint, role))
+	if err := r.SetJSONBody(data); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+// KnownHosts returns a known_hosts line trusting the CA of the backend for
+// the host keys of the hosts matching the given comma separated patterns.
+func (c *SSH) KnownHosts(hostPatterns string) (string, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/%s/known_hosts", c.MountPoint))
+	if hostPatterns != "" {
+		r.Params.Set("host_patterns", hostPatterns)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,439 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+const (
+	// TransitDefaultMountPoint is the default path at which the transit
+	// secrets engine is mounted.
+	TransitDefaultMountPoint = "transit"
+
+	// TransitStreamDefaultChunkSize is the default number of plaintext bytes
+	// in each chunk of an encrypted stream.
+	TransitStreamDefaultChunkSize = 64 * 1024
+
+	// TransitStreamMaxChunkSize is the maximum number of plaintext bytes in
+	// each chunk of an encrypted stream.
+	TransitStreamMaxChunkSize = 16 * 1024 * 1024
+
+	transitStreamNoncePrefixSize = 7
+	transitStreamMaxHeaderSize   = 64 * 1024
+)
+
+// transitStreamMagic identifies version 1 of the encrypted stream format
+var transitStreamMagic = []byte("VTS\x01")
+
+// Transit is used to return a client to invoke operations on the transit
+// backend.
+type Transit struct {
+	c          *Client
+	MountPoint string
+}
+
+// Transit returns the client for the transit backend at its default mount
+// point.
+func (c *Client) Transit() *Transit {
+	return c.TransitWithMountPoint(TransitDefaultMountPoint)
+}
+
+// TransitWithMountPoint returns the client with specific transit mount point.
+func (c *Client) TransitWithMountPoint(mountPoint string) *Transit {
+	return &Transit{
+		c:          c,
+		MountPoint: mountPoint,
+	}
+}
+
+// TransitStreamOptions are the options for encrypting a stream.
+type TransitStreamOptions struct {
+	// ChunkSize is the number of plaintext bytes in each chunk. Defaults to
+	// TransitStreamDefaultChunkSize.
+	ChunkSize int
+
+	// Context is the key derivation context, required for derived keys. It
+	// is stored in the stream header in the clear.
+	Context []byte
+}
+
+// transitStreamHeader is stored at the start of an encrypted stream, and is
+// authenticated as additional data of every chunk.
+type transitStreamHeader struct {
+	Key         string `json:"key"`
+	Ciphertext  string `json:"ciphertext"`
+	ChunkSize   int    `json:"chunk_size"`
+	NoncePrefix []byte `json:"nonce_prefix"`
+	Context     []byte `json:"context,omitempty"`
+}
+
+// EncryptStream encrypts everything read from r under a new data key of the
+// named transit key, writing the encrypted stream to w. Only the data key
+// wrapped by Vault is stored alongside the data, so the stream can only be
+// decrypted with access to the transit key.
+//
+// The plaintext is split into chunks which are each encrypted with
+// AES-256-GCM. The chunk nonces bind the position of each chunk and mark the
+// final chunk, so that reordered, duplicated or truncated chunks fail to
+// decrypt.
+func (t *Transit) EncryptStream(ctx context.Context, key string, w io.Writer, r io.Reader, opts *TransitStreamOptions) error {
+	if opts == nil {
+		opts = &TransitStreamOptions{}
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = TransitStreamDefaultChunkSize
+	}
+	if chunkSize < 0 || chunkSize > TransitStreamMaxChunkSize {
+		return fmt.Errorf("chunk size must be between 1 and %d bytes", TransitStreamMaxChunkSize)
+	}
+
+	data := map[string]interface{}{
+		"bits": 256,
+	}
+	if len(opts.Context) != 0 {
+		data["context"] = base64.StdEncoding.EncodeToString(opts.Context)
+	}
+	secret, err := t.write(ctx, fmt.Sprintf("/v1/%s/datakey/plaintext/%s", t.MountPoint, key), data)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Data == nil {
+		return errors.New("no data key returned")
+	}
+	ciphertext, _ := secret.Data["ciphertext"].(string)
+	dataKey, err := base64.StdEncoding.DecodeString(fmt.Sprint(secret.Data["plaintext"]))
+	if err != nil || len(dataKey) != 32 || ciphertext == "" {
+		return errors.New("invalid data key returned")
+	}
+
+	header := &transitStreamHeader{
+		Key:         key,
+		Ciphertext:  ciphertext,
+		ChunkSize:   chunkSize,
+		NoncePrefix: make([]byte, transitStreamNoncePrefixSize),
+		Context:     opts.Context,
+	}
+	if _, err := io.ReadFull(rand.Reader, header.NoncePrefix); err != nil {
+		return err
+	}
+	headerBytes, err := marshalTransitStreamHeader(header)
+	if err != nil {
+		return err
+	}
+
+	aead, err := newTransitStreamAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(headerBytes); err != nil {
+		return err
+	}
+
+	br := bufio.NewReaderSize(r, chunkSize)
+	plaintext := make([]byte, chunkSize)
+	ciphertextBuf := make([]byte, 0, chunkSize+aead.Overhead())
+	for index := uint64(0); ; index++ {
+		if index > math.MaxUint32 {
+			return errors.New("stream is too large for the chunk size")
+		}
+
+		n, err := io.ReadFull(br, plaintext)
+		last := false
+		switch err {
+		case nil:
+			// A full chunk is only the last one if nothing follows it
+			if _, err := br.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return err
+		}
+
+		nonce := transitStreamNonce(header.NoncePrefix, uint32(index), last)
+		sealed := aead.Seal(ciphertextBuf[:0], nonce, plaintext[:n], headerBytes)
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// DecryptStream decrypts an encrypted stream created by EncryptStream read
+// from r, writing the plaintext to w. The data key is decrypted by the
+// transit key named in the stream header. An error is returned if any part of
+// the stream fails to authenticate, in which case the plaintext written so
+// far must be discarded.
+func (t *Transit) DecryptStream(ctx context.Context, w io.Writer, r io.Reader) error {
+	header, headerBytes, err := readTransitStreamHeader(r)
+	if err != nil {
+		return err
+	}
+	aead, err := t.streamAEAD(ctx, header)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := header.ChunkSize + aead.Overhead()
+	br := bufio.NewReaderSize(r, chunkSize)
+	ciphertext := make([]byte, chunkSize)
+	plaintext := make([]byte, 0, header.ChunkSize)
+	for index := uint64(0); ; index++ {
+		if index > math.MaxUint32 {
+			return errors.New("invalid encrypted stream: too many chunks")
+		}
+
+		n, err := io.ReadFull(br, ciphertext)
+		last := false
+		switch err {
+		case nil:
+			if _, err := br.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return err
+		}
+
+		nonce := transitStreamNonce(header.NoncePrefix, uint32(index), last)
+		opened, err := aead.Open(plaintext[:0], nonce, ciphertext[:n], headerBytes)
+		if err != nil {
+			return fmt.Errorf("invalid encrypted stream: chunk %d failed to authenticate", index)
+		}
+		if _, err := w.Write(opened); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// VerifyStream authenticates every chunk of an encrypted stream read from r
+// without returning the plaintext.
+func (t *Transit) VerifyStream(ctx context.Context, r io.Reader) error {
+	return t.DecryptStream(ctx, ioutil.Discard, r)
+}
+
+// TransitStreamReader provides random access to the plaintext of an
+// encrypted stream. Only the chunks covering the requested range are read
+// and authenticated.
+type TransitStreamReader struct {
+	r          io.ReaderAt
+	aead       cipher.AEAD
+	header     *transitStreamHeader
+	headerSize int64
+	headerAAD  []byte
+	numChunks  int64
+	size       int64
+}
+
+// NewStreamReader returns a reader for the plaintext of the encrypted stream
+// of the given size read from r.
+func (t *Transit) NewStreamReader(ctx context.Context, r io.ReaderAt, size int64) (*TransitStreamReader, error) {
+	header, headerBytes, err := readTransitStreamHeader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := t.streamAEAD(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every chunk but the last one is full, and the last one holds at
+	// least the authentication tag
+	dataSize := size - int64(len(headerBytes))
+	sealedChunkSize := int64(header.ChunkSize + aead.Overhead())
+	numChunks := (dataSize + sealedChunkSize - 1) / sealedChunkSize
+	if numChunks == 0 || numChunks > math.MaxUint32+1 || dataSize-(numChunks-1)*sealedChunkSize < int64(aead.Overhead()) {
+		return nil, errors.New("invalid encrypted stream: bad length")
+	}
+
+	return &TransitStreamReader{
+		r:          r,
+		aead:       aead,
+		header:     header,
+		headerSize: int64(len(headerBytes)),
+		headerAAD:  headerBytes,
+		numChunks:  numChunks,
+		size:       dataSize - numChunks*int64(aead.Overhead()),
+	}, nil
+}
+
+// Size returns the size of the plaintext.
+func (s *TransitStreamReader) Size() int64 {
+	return s.size
+}
+
+// ReadAt implements io.ReaderAt for the plaintext.
+func (s *TransitStreamReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	chunkSize := int64(s.header.ChunkSize)
+	sealedChunkSize := chunkSize + int64(s.aead.Overhead())
+	ciphertext := make([]byte, sealedChunkSize)
+	plaintext := make([]byte, 0, chunkSize)
+
+	read := 0
+	for read < len(p) && off < s.size {
+		index := off / chunkSize
+		last := index == s.numChunks-1
+
+		start := s.headerSize + index*sealedChunkSize
+		n, err := s.r.ReadAt(ciphertext, start)
+		if err != nil && !(err == io.EOF && last) {
+			return read, err
+		}
+
+		nonce := transitStreamNonce(s.header.NoncePrefix, uint32(index), last)
+		opened, err := s.aead.Open(plaintext[:0], nonce, ciphertext[:n], s.headerAAD)
+		if err != nil {
+			return read, fmt.Errorf("invalid encrypted stream: chunk %d failed to authenticate", index)
+		}
+
+		copied := copy(p[read:], opened[off-index*chunkSize:])
+		read += copied
+		off += int64(copied)
+	}
+
+	if read < len(p) {
+		return read, io.EOF
+	}
+	return read, nil
+}
+
+// streamAEAD decrypts the data key of the stream with Vault
+func (t *Transit) streamAEAD(ctx context.Context, header *transitStreamHeader) (cipher.AEAD, error) {
+	data := map[string]interface{}{
+		"ciphertext": header.Ciphertext,
+	}
+	if len(header.Context) != 0 {
+		data["context"] = base64.StdEncoding.EncodeToString(header.Context)
+	}
+	secret, err := t.write(ctx, fmt.Sprintf("/v1/%s/decrypt/%s", t.MountPoint, header.Key), data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("no data key returned")
+	}
+
+	dataKey, err := base64.StdEncoding.DecodeString(fmt.Sprint(secret.Data["plaintext"]))
+	if err != nil || len(dataKey) != 32 {
+		return nil, errors.New("invalid data key returned")
+	}
+
+	return newTransitStreamAEAD(dataKey)
+}
+
+func (t *Transit) write(ctx context.Context, path string, data map[string]interface{}) (*Secret, error) {
+	r := t.c.NewRequest("PUT", path)
+	if err := r.SetJSONBody(data); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	defer cancelFunc()
+	resp, err := t.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSecret(resp.Body)
+}
+
+func newTransitStreamAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// transitStreamNonce returns the nonce of the chunk at the given index: the
+// random prefix of the stream, the big-endian index and a flag marking the
+// final chunk
+func transitStreamNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, transitStreamNoncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[transitStreamNoncePrefixSize:], index)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// marshalTransitStreamHeader encodes the header as the magic bytes, followed
+// by the big-endian length of the JSON-encoded header and the header itself
+func marshalTransitStreamHeader(header *transitStreamHeader) ([]byte, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(transitStreamMagic)
+	binary.Write(&buf, binary.BigEndian, uint32(len(headerJSON)))
+	buf.Write(headerJSON)
+	return buf.Bytes(), nil
+}
+
+// readTransitStreamHeader reads the header of an encrypted stream, returning
+// it along with its encoding
+func readTransitStreamHeader(r io.Reader) (*transitStreamHeader, []byte, error) {
+	prefix := make([]byte, len(transitStreamMagic)+4)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, nil, errors.New("invalid encrypted stream: missing header")
+	}
+	if !bytes.Equal(prefix[:len(transitStreamMagic)], transitStreamMagic) {
+		return nil, nil, errors.New("invalid encrypted stream: unknown format")
+	}
+
+	length := binary.BigEndian.Uint32(prefix[len(transitStreamMagic):])
+	if length > transitStreamMaxHeaderSize {
+		return nil, nil, errors.New("invalid encrypted stream: header is too large")
+	}
+	headerJSON := make([]byte, length)
+	if _, err := io.ReadFull(r, headerJSON); err != nil {
+		return nil, nil, errors.New("invalid encrypted stream: truncated header")
+	}
+
+	var header transitStreamHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, nil, fmt.Errorf("invalid encrypted stream: %s", err)
+	}
+	if header.Key == "" || header.Ciphertext == "" || header.ChunkSize <= 0 || header.ChunkSize > TransitStreamMaxChunkSize || len(header.NoncePrefix) != transitStreamNoncePrefixSize {
+		return nil, nil, errors.New("invalid encrypted stream: bad header")
+	}
+
+	return &header, append(prefix, headerJSON...), nil
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// testTransitServer mocks the data key and decrypt endpoints of a transit
+// mount with a fixed data key
+func testTransitServer(t *testing.T) *Client {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		t.Fatal(err)
+	}
+	encodedKey := base64.StdEncoding.EncodeToString(dataKey)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/transit/datakey/plaintext/test", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": {"plaintext": %q, "ciphertext": "vault:v1:wrapped"}}`, encodedKey)
+	})
+	mux.HandleFunc("/v1/transit/decrypt/test", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["ciphertext"] != "vault:v1:wrapped" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errors": ["invalid ciphertext"]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data": {"plaintext": %q}}`, encodedKey)
+	})
+
+	config, ln := testHTTPServer(t, mux)
+	t.Cleanup(func() { ln.Close() })
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("foo")
+	return client
+}
+
+func TestTransit_Stream(t *testing.T) {
+	transit := testTransitServer(t).Transit()
+	ctx := context.Background()
+
+	encrypt := func(plaintext []byte, chunkSize int) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		err := transit.EncryptStream(ctx, "test", &buf, bytes.NewReader(plaintext), &TransitStreamOptions{
+			ChunkSize: chunkSize,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	for _, size := range []int{0, 1, 15, 16, 17, 64, 1000} {
+		plaintext := make([]byte, size)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatal(err)
+		}
+		encrypted := encrypt(plaintext, 16)
+		if bytes.Contains(encrypted, plaintext) && size > 0 {
+			t.Fatalf("plaintext found in encrypted stream")
+		}
+
+		var decrypted bytes.Buffer
+		if err := transit.DecryptStream(ctx, &decrypted, bytes.NewReader(encrypted)); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plaintext) {
+			t.Fatalf("size %d: decrypted stream does not match plaintext", size)
+		}
+
+		reader, err := transit.NewStreamReader(ctx, bytes.NewReader(encrypted), int64(len(encrypted)))
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if reader.Size() != int64(size) {
+			t.Fatalf("size %d: bad plaintext size %d", size, reader.Size())
+		}
+		for _, r := range [][2]int{{0, size}, {0, size / 2}, {size / 3, size / 3}, {size / 2, size - size/2}} {
+			got, err := ioutil.ReadAll(io.NewSectionReader(reader, int64(r[0]), int64(r[1])))
+			if err != nil {
+				t.Fatalf("size %d range %v: %v", size, r, err)
+			}
+			if !bytes.Equal(got, plaintext[r[0]:r[0]+r[1]]) {
+				t.Fatalf("size %d range %v: bad ranged read", size, r)
+			}
+		}
+	}
+
+	plaintext := bytes.Repeat([]byte("0123456789abcdef"), 8)
+	encrypted := encrypt(plaintext, 16)
+	if err := transit.VerifyStream(ctx, bytes.NewReader(encrypted)); err != nil {
+		t.Fatal(err)
+	}
+
+	headerSize := len(encrypted) - 8*(16+16)
+	chunk := func(i int) []byte {
+		return encrypted[headerSize+i*32 : headerSize+(i+1)*32]
+	}
+	concat := func(parts ...[]byte) []byte {
+		var out []byte
+		for _, p := range parts {
+			out = append(out, p...)
+		}
+		return out
+	}
+
+	for name, tampered := range map[string][]byte{
+		"flipped bit":      concat(encrypted[:len(encrypted)-1], []byte{encrypted[len(encrypted)-1] ^ 1}),
+		"truncated chunks": encrypted[:headerSize+4*32],
+		"truncated tag":    encrypted[:len(encrypted)-1],
+		"reordered chunks": concat(encrypted[:headerSize], chunk(1), chunk(0), encrypted[headerSize+2*32:]),
+		"header only":      encrypted[:headerSize],
+		"modified header":  concat(bytes.Replace(encrypted[:headerSize], []byte(`"chunk_size":16`), []byte(`"chunk_size":48`), 1), encrypted[headerSize:]),
+	} {
+		if err := transit.VerifyStream(ctx, bytes.NewReader(tampered)); err == nil {
+			t.Fatalf("%s: expected verification error", name)
+		}
+	}
+
+	// Ranged reads only authenticate the chunks they cover
+	tampered := concat(encrypted[:len(encrypted)-1], []byte{encrypted[len(encrypted)-1] ^ 1})
+	reader, err := transit.NewStreamReader(ctx, bytes.NewReader(tampered), int64(len(tampered)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 32)
+	if _, err := reader.ReadAt(buf, 0); err != nil || !bytes.Equal(buf, plaintext[:32]) {
+		t.Fatalf("bad ranged read of intact chunks: %v", err)
+	}
+	if _, err := reader.ReadAt(buf, int64(len(plaintext)-32)); err == nil {
+		t.Fatal("expected error reading tampered chunk")
+	}
+}
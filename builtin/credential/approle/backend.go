@@ -2,8 +2,10 @@ package approle
 
 import (
 	"context"
+	"net/http"
 	"sync"
 
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
@@ -56,6 +58,13 @@ type backend struct {
 	// secretIDListingLock is a dedicated lock for listing SecretIDAccessors
 	// for all the SecretIDs issued against an approle
 	secretIDListingLock sync.RWMutex
+
+	// deliveryNonceLock serializes the checks of the nonces of the delivery
+	// requests, so that a signed delivery request cannot be replayed
+	deliveryNonceLock sync.Mutex
+
+	// deliveryClient sends the SecretIDs to the delivery targets
+	deliveryClient *http.Client
 }
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -87,6 +96,8 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 		secretIDAccessorLocks: locksutil.CreateLocks(),
 
 		tidySecretIDCASGuard: new(uint32),
+
+		deliveryClient: cleanhttp.DefaultClient(),
 	}
 
 	// Attach the paths and secrets that are to be handled by the backend
@@ -98,6 +109,7 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 		PathsSpecial: &logical.Paths{
 			Unauthenticated: []string{
 				"login",
+				"deliver/*",
 			},
 			LocalStorage: []string{
 				secretIDLocalPrefix,
@@ -106,9 +118,11 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 		},
 		Paths: framework.PathAppend(
 			rolePaths(b),
+			deliveryTargetPaths(b),
 			[]*framework.Path{
 				pathLogin(b),
 				pathTidySecretID(b),
+				pathRoleSecretIDReport(b),
 			},
 		),
 		Invalidate:  b.invalidate,
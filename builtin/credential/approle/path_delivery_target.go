@@ -0,0 +1,786 @@
+package approle
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/cidrutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/helper/tokenutil"
+	"github.com/hashicorp/vault/sdk/helper/wrapping"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	deliveryTargetPrefix = "delivery_target/"
+	deliveryNoncePrefix  = "delivery_nonce/"
+
+	// Webhook targets receive a JSON payload holding the wrapping token
+	deliveryTargetTypeWebhook = "webhook"
+
+	// Agent sink targets receive the bare wrapping token, which is what the
+	// AppRole auto-auth method of Vault Agent reads from its secret_id file
+	deliveryTargetTypeAgentSink = "agent_sink"
+
+	// Cubbyhole targets have the SecretID returned to the signed caller,
+	// response-wrapped, so that it is only kept in the cubbyhole of the
+	// wrapping token
+	deliveryTargetTypeCubbyhole = "cubbyhole"
+
+	// deliveryMaxClockSkew bounds the age of the timestamp of a signed
+	// delivery request, and the time its nonce is remembered
+	deliveryMaxClockSkew = 5 * time.Minute
+
+	defaultDeliveryWrapTTL = 5 * time.Minute
+	deliveryWebhookTimeout = 10 * time.Second
+)
+
+// deliveryTargetStorageEntry is a destination to which SecretIDs of a role
+// are pushed, response-wrapped, upon a signed delivery request
+type deliveryTargetStorageEntry struct {
+	Type string `json:"type" mapstructure:"type"`
+
+	// URL receiving the wrapping tokens, unless the target is a cubbyhole
+	URL string `json:"url" mapstructure:"url"`
+
+	// SigningKey is the HMAC key with which delivery requests are signed
+	SigningKey string `json:"signing_key" mapstructure:"signing_key"`
+
+	// DeliveryBoundCIDRs restricts the addresses delivery requests may come
+	// from
+	DeliveryBoundCIDRs []string `json:"delivery_bound_cidrs" mapstructure:"delivery_bound_cidrs"`
+
+	// WrapTTL is the TTL of the wrapping tokens
+	WrapTTL time.Duration `json:"wrap_ttl" mapstructure:"wrap_ttl"`
+
+	// Properties of the delivered SecretIDs
+	SecretIDNumUses int               `json:"secret_id_num_uses" mapstructure:"secret_id_num_uses"`
+	CIDRList        []string          `json:"cidr_list" mapstructure:"cidr_list"`
+	TokenBoundCIDRs []string          `json:"token_bound_cidrs" mapstructure:"token_bound_cidrs"`
+	Metadata        map[string]string `json:"metadata" mapstructure:"metadata"`
+}
+
+// deliveryWebhookPayload is the body of the requests sent to webhooks
+type deliveryWebhookPayload struct {
+	RoleName         string `json:"role_name"`
+	TargetName       string `json:"target_name"`
+	WrappingToken    string `json:"wrapping_token"`
+	WrappingAccessor string `json:"wrapping_accessor"`
+	WrappingTTL      int64  `json:"wrapping_ttl"`
+	SecretIDAccessor string `json:"secret_id_accessor"`
+}
+
+// deliveryNonceStorageEntry records that a signed delivery request was
+// processed. It is kept until the timestamp of the request falls outside the
+// allowed clock skew, after which the request is refused anyway.
+type deliveryNonceStorageEntry struct {
+	ExpirationTime time.Time `json:"expiration_time" mapstructure:"expiration_time"`
+}
+
+// deliveryTargetPaths creates the paths that manage the delivery targets of
+// roles, and the unauthenticated path to which signed delivery requests are
+// sent.
+//
+// Paths returned:
+// role/<role_name>/delivery-target/ - For listing the delivery targets of a role
+// role/<role_name>/delivery-target/<target_name> - For managing a delivery target
+// deliver/<role_name>/<target_name> - For delivering a SecretID to a target
+func deliveryTargetPaths(b *backend) []*framework.Path {
+	defTokenFields := tokenutil.TokenFields()
+
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "role/" + framework.GenericNameRegex("role_name") + "/delivery-target/?$",
+			Fields: map[string]*framework.FieldSchema{
+				"role_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.pathDeliveryTargetList,
+			},
+			HelpSynopsis:    strings.TrimSpace(roleHelp["role-delivery-target-list"][0]),
+			HelpDescription: strings.TrimSpace(roleHelp["role-delivery-target-list"][1]),
+		},
+		&framework.Path{
+			Pattern: "role/" + framework.GenericNameRegex("role_name") + "/delivery-target/" + framework.GenericNameRegex("target_name"),
+			Fields: map[string]*framework.FieldSchema{
+				"role_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+				},
+				"target_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the delivery target.",
+				},
+				"type": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Default:     deliveryTargetTypeWebhook,
+					Description: `Type of the delivery target. One of "webhook", "agent_sink" or "cubbyhole".`,
+				},
+				"url": &framework.FieldSchema{
+					Type: framework.TypeString,
+					Description: `HTTPS URL to which the response-wrapped SecretIDs are sent. Required
+for the "webhook" and "agent_sink" types.`,
+				},
+				"delivery_bound_cidrs": &framework.FieldSchema{
+					Type: framework.TypeCommaStringSlice,
+					Description: `Comma separated string or list of CIDR blocks from which
+delivery requests are accepted.`,
+				},
+				"wrap_ttl": &framework.FieldSchema{
+					Type:        framework.TypeDurationSecond,
+					Default:     int(defaultDeliveryWrapTTL.Seconds()),
+					Description: "TTL of the response-wrapping tokens holding the delivered SecretIDs.",
+				},
+				"secret_id_num_uses": &framework.FieldSchema{
+					Type:    framework.TypeInt,
+					Default: 1,
+					Description: `Number of times a delivered SecretID can be used to log in.
+Must be positive, and cannot exceed the 'secret_id_num_uses' of the role when
+the role limits it.`,
+				},
+				"cidr_list": &framework.FieldSchema{
+					Type: framework.TypeCommaStringSlice,
+					Description: `Comma separated string or list of CIDR blocks enforcing the
+delivered SecretIDs to be used from specific set of IP addresses. If
+'secret_id_bound_cidrs' is set on the role, then the list of CIDR blocks listed
+here should be a subset of the CIDR blocks listed on the role.`,
+				},
+				"token_bound_cidrs": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: defTokenFields["token_bound_cidrs"].Description,
+				},
+				"metadata": &framework.FieldSchema{
+					Type: framework.TypeString,
+					Description: `Metadata to be tied to the delivered SecretIDs. This should be a
+JSON formatted string containing the metadata in key value pairs.`,
+				},
+				"rotate_signing_key": &framework.FieldSchema{
+					Type:        framework.TypeBool,
+					Description: "Generate a new signing key for the delivery requests.",
+				},
+			},
+			ExistenceCheck: b.pathDeliveryTargetExistenceCheck,
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.CreateOperation: b.pathDeliveryTargetCreateUpdate,
+				logical.UpdateOperation: b.pathDeliveryTargetCreateUpdate,
+				logical.ReadOperation:   b.pathDeliveryTargetRead,
+				logical.DeleteOperation: b.pathDeliveryTargetDelete,
+			},
+			HelpSynopsis:    strings.TrimSpace(roleHelp["role-delivery-target"][0]),
+			HelpDescription: strings.TrimSpace(roleHelp["role-delivery-target"][1]),
+		},
+		&framework.Path{
+			Pattern: "deliver/" + framework.GenericNameRegex("role_name") + "/" + framework.GenericNameRegex("target_name"),
+			Fields: map[string]*framework.FieldSchema{
+				"role_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+				},
+				"target_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the delivery target.",
+				},
+				"timestamp": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: "Time of the delivery request, in seconds since the Unix epoch.",
+				},
+				"nonce": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Random value which makes the delivery request unique.",
+				},
+				"signature": &framework.FieldSchema{
+					Type: framework.TypeString,
+					Description: `Hex encoded HMAC-SHA256, keyed with the signing key of the
+target, of "<role_name>\n<target_name>\n<timestamp>\n<nonce>".`,
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.pathDeliverUpdate,
+			},
+			HelpSynopsis:    strings.TrimSpace(roleHelp["deliver"][0]),
+			HelpDescription: strings.TrimSpace(roleHelp["deliver"][1]),
+		},
+	}
+}
+
+func deliveryTargetStoragePrefix(roleName string) string {
+	return deliveryTargetPrefix + strings.ToLower(roleName) + "/"
+}
+
+// deliveryTargetEntry reads a delivery target of a role. The caller must hold
+// the lock of the role.
+func (b *backend) deliveryTargetEntry(ctx context.Context, s logical.Storage, roleName, targetName string) (*deliveryTargetStorageEntry, error) {
+	entry, err := s.Get(ctx, deliveryTargetStoragePrefix(roleName)+targetName)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result deliveryTargetStorageEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// flushDeliveryTargets deletes the delivery targets of a role. The caller must
+// hold the write lock of the role.
+func (b *backend) flushDeliveryTargets(ctx context.Context, s logical.Storage, roleName string) error {
+	prefix := deliveryTargetStoragePrefix(roleName)
+	targetNames, err := s.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, targetName := range targetNames {
+		if err := s.Delete(ctx, prefix+targetName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *backend) pathDeliveryTargetExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	roleName := data.Get("role_name").(string)
+
+	lock := b.roleLock(roleName)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return false, err
+	}
+	if role == nil {
+		return false, nil
+	}
+
+	target, err := b.deliveryTargetEntry(ctx, req.Storage, role.name, data.Get("target_name").(string))
+	if err != nil {
+		return false, err
+	}
+	return target != nil, nil
+}
+
+func (b *backend) pathDeliveryTargetList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+
+	lock := b.roleLock(roleName)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q does not exist", roleName)), nil
+	}
+
+	targetNames, err := req.Storage.List(ctx, deliveryTargetStoragePrefix(role.name))
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(targetNames), nil
+}
+
+func (b *backend) pathDeliveryTargetCreateUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	targetName := data.Get("target_name").(string)
+
+	lock := b.roleLock(roleName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q does not exist", roleName)), nil
+	}
+	if !role.BindSecretID {
+		return logical.ErrorResponse("bind_secret_id is not set on the role"), nil
+	}
+
+	target, err := b.deliveryTargetEntry(ctx, req.Storage, role.name, targetName)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		target = &deliveryTargetStorageEntry{
+			Metadata: make(map[string]string),
+		}
+	}
+
+	if typeRaw, ok := data.GetOk("type"); ok {
+		target.Type = typeRaw.(string)
+	} else if req.Operation == logical.CreateOperation {
+		target.Type = data.Get("type").(string)
+	}
+	if urlRaw, ok := data.GetOk("url"); ok {
+		target.URL = urlRaw.(string)
+	}
+	switch target.Type {
+	case deliveryTargetTypeWebhook, deliveryTargetTypeAgentSink:
+		if target.URL == "" {
+			return logical.ErrorResponse("missing url"), nil
+		}
+		// The wrapping tokens must not be sent in the clear
+		if u, err := url.Parse(target.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return logical.ErrorResponse(fmt.Sprintf("invalid url %q, an https URL is required", target.URL)), nil
+		}
+	case deliveryTargetTypeCubbyhole:
+		if target.URL != "" {
+			return logical.ErrorResponse(fmt.Sprintf("url is not supported by %q delivery targets", target.Type)), nil
+		}
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unsupported delivery target type %q", target.Type)), nil
+	}
+
+	if cidrsRaw, ok := data.GetOk("delivery_bound_cidrs"); ok {
+		target.DeliveryBoundCIDRs = cidrsRaw.([]string)
+	}
+	if resp, err := validateDeliveryCIDRs(target.DeliveryBoundCIDRs); resp != nil || err != nil {
+		return resp, err
+	}
+
+	if _, ok := data.GetOk("wrap_ttl"); ok || req.Operation == logical.CreateOperation {
+		target.WrapTTL = time.Second * time.Duration(data.Get("wrap_ttl").(int))
+	}
+	if target.WrapTTL <= 0 {
+		return logical.ErrorResponse("wrap_ttl must be positive"), nil
+	}
+
+	if _, ok := data.GetOk("secret_id_num_uses"); ok || req.Operation == logical.CreateOperation {
+		target.SecretIDNumUses = data.Get("secret_id_num_uses").(int)
+	}
+	if target.SecretIDNumUses <= 0 {
+		return logical.ErrorResponse("secret_id_num_uses must be positive"), nil
+	}
+	if role.SecretIDNumUses > 0 && target.SecretIDNumUses > role.SecretIDNumUses {
+		return logical.ErrorResponse(fmt.Sprintf("secret_id_num_uses cannot exceed the secret_id_num_uses of the role, %d", role.SecretIDNumUses)), nil
+	}
+
+	if cidrsRaw, ok := data.GetOk("cidr_list"); ok {
+		target.CIDRList = cidrsRaw.([]string)
+	}
+	if resp, err := validateDeliveryCIDRs(target.CIDRList); resp != nil || err != nil {
+		return resp, err
+	}
+	if err := verifyCIDRRoleSecretIDSubset(target.CIDRList, role.SecretIDBoundCIDRs); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if cidrsRaw, ok := data.GetOk("token_bound_cidrs"); ok {
+		target.TokenBoundCIDRs = cidrsRaw.([]string)
+	}
+	if resp, err := validateDeliveryCIDRs(target.TokenBoundCIDRs); resp != nil || err != nil {
+		return resp, err
+	}
+	var roleCIDRs []string
+	for _, v := range role.TokenBoundCIDRs {
+		roleCIDRs = append(roleCIDRs, v.String())
+	}
+	if err := verifyCIDRRoleSecretIDSubset(target.TokenBoundCIDRs, roleCIDRs); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if metadataRaw, ok := data.GetOk("metadata"); ok {
+		metadata := make(map[string]string)
+		if err := strutil.ParseArbitraryKeyValues(metadataRaw.(string), metadata, ","); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse metadata: %v", err)), nil
+		}
+		target.Metadata = metadata
+	}
+
+	// The signing key is only returned when it is generated
+	var resp *logical.Response
+	if target.SigningKey == "" || data.Get("rotate_signing_key").(bool) {
+		target.SigningKey, err = uuid.GenerateUUID()
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to generate signing_key: {{err}}", err)
+		}
+		resp = &logical.Response{
+			Data: map[string]interface{}{
+				"signing_key": target.SigningKey,
+			},
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(deliveryTargetStoragePrefix(role.name)+targetName, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func validateDeliveryCIDRs(cidrs []string) (*logical.Response, error) {
+	if len(cidrs) == 0 {
+		return nil, nil
+	}
+	valid, err := cidrutil.ValidateCIDRListSlice(cidrs)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to validate CIDR blocks: {{err}}", err)
+	}
+	if !valid {
+		return logical.ErrorResponse("failed to validate CIDR blocks"), nil
+	}
+	return nil, nil
+}
+
+func (b *backend) pathDeliveryTargetRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+
+	lock := b.roleLock(roleName)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	target, err := b.deliveryTargetEntry(ctx, req.Storage, role.name, data.Get("target_name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"type":                 target.Type,
+			"url":                  target.URL,
+			"delivery_bound_cidrs": target.DeliveryBoundCIDRs,
+			"wrap_ttl":             int64(target.WrapTTL.Seconds()),
+			"secret_id_num_uses":   target.SecretIDNumUses,
+			"cidr_list":            target.CIDRList,
+			"token_bound_cidrs":    target.TokenBoundCIDRs,
+			"metadata":             target.Metadata,
+		},
+	}, nil
+}
+
+func (b *backend) pathDeliveryTargetDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+
+	lock := b.roleLock(roleName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return nil, req.Storage.Delete(ctx, deliveryTargetStoragePrefix(role.name)+data.Get("target_name").(string))
+}
+
+// deliverySignature returns the signature expected for a delivery request
+func deliverySignature(signingKey, roleName, targetName string, timestamp int64, nonce string) []byte {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s", roleName, targetName, timestamp, nonce)
+	return mac.Sum(nil)
+}
+
+// deliveryNonceStorageKey returns the storage key recording the nonce of a
+// delivery request to a target
+func deliveryNonceStorageKey(roleName, targetName, nonce string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(roleName) + "\n" + targetName + "\n" + nonce))
+	return deliveryNoncePrefix + hex.EncodeToString(sum[:])
+}
+
+// useDeliveryNonce records the nonce of a delivery request, and fails if the
+// nonce was already used. Nonces are kept in storage rather than in memory so
+// that requests cannot be replayed after a restart or to another node. On
+// performance standbys and secondaries, the write fails with ErrReadOnly and
+// the request is forwarded to the node that can record it.
+func (b *backend) useDeliveryNonce(ctx context.Context, s logical.Storage, key string, expirationTime time.Time) (bool, error) {
+	b.deliveryNonceLock.Lock()
+	defer b.deliveryNonceLock.Unlock()
+
+	entry, err := s.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if entry != nil {
+		var nonce deliveryNonceStorageEntry
+		if err := entry.DecodeJSON(&nonce); err != nil {
+			return false, err
+		}
+		if time.Now().Before(nonce.ExpirationTime) {
+			return false, nil
+		}
+	}
+
+	entry, err = logical.StorageEntryJSON(key, &deliveryNonceStorageEntry{
+		ExpirationTime: expirationTime,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// tidyDeliveryNonces deletes the nonces of the delivery requests whose
+// timestamp is no longer accepted
+func (b *backend) tidyDeliveryNonces(ctx context.Context, s logical.Storage) error {
+	keys, err := s.List(ctx, deliveryNoncePrefix)
+	if err != nil {
+		return err
+	}
+
+	b.deliveryNonceLock.Lock()
+	defer b.deliveryNonceLock.Unlock()
+
+	for _, key := range keys {
+		entry, err := s.Get(ctx, deliveryNoncePrefix+key)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		var nonce deliveryNonceStorageEntry
+		if err := entry.DecodeJSON(&nonce); err != nil {
+			return err
+		}
+		if time.Now().Before(nonce.ExpirationTime) {
+			continue
+		}
+		if err := s.Delete(ctx, deliveryNoncePrefix+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathDeliverUpdate verifies a signed delivery request, issues a SecretID
+// with the properties of the target, and delivers it response-wrapped. The
+// SecretID itself is only returned to the caller wrapped, for cubbyhole
+// targets.
+func (b *backend) pathDeliverUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	targetName := data.Get("target_name").(string)
+
+	lock := b.roleLock(roleName)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, logical.ErrPermissionDenied
+	}
+	target, err := b.deliveryTargetEntry(ctx, req.Storage, role.name, targetName)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, logical.ErrPermissionDenied
+	}
+
+	if len(target.DeliveryBoundCIDRs) != 0 {
+		if req.Connection == nil || req.Connection.RemoteAddr == "" {
+			return nil, fmt.Errorf("failed to get connection information")
+		}
+		belongs, err := cidrutil.IPBelongsToCIDRBlocksSlice(req.Connection.RemoteAddr, target.DeliveryBoundCIDRs)
+		if err != nil || !belongs {
+			return logical.ErrorResponse(errwrap.Wrapf(fmt.Sprintf("source address %q unauthorized by CIDR restrictions on the delivery target: {{err}}", req.Connection.RemoteAddr), err).Error()), logical.ErrPermissionDenied
+		}
+	}
+
+	timestamp := int64(data.Get("timestamp").(int))
+	nonce := data.Get("nonce").(string)
+	if nonce == "" {
+		return logical.ErrorResponse("missing nonce"), nil
+	}
+	signature, err := hex.DecodeString(data.Get("signature").(string))
+	if err != nil || !hmac.Equal(signature, deliverySignature(target.SigningKey, role.name, targetName, timestamp, nonce)) {
+		return logical.ErrorResponse("invalid signature"), logical.ErrPermissionDenied
+	}
+	if skew := time.Since(time.Unix(timestamp, 0)); skew > deliveryMaxClockSkew || skew < -deliveryMaxClockSkew {
+		return logical.ErrorResponse("timestamp of the delivery request is outside the allowed clock skew"), logical.ErrPermissionDenied
+	}
+
+	// A signed request is accepted once. Its nonce is remembered for as long
+	// as its timestamp is valid.
+	fresh, err := b.useDeliveryNonce(ctx, req.Storage, deliveryNonceStorageKey(role.name, targetName, nonce), time.Unix(timestamp, 0).Add(deliveryMaxClockSkew))
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return logical.ErrorResponse("delivery request was already processed"), logical.ErrPermissionDenied
+	}
+
+	if !role.BindSecretID {
+		return logical.ErrorResponse("bind_secret_id is not set on the role"), nil
+	}
+	// The role may have changed since the target was configured
+	if role.SecretIDNumUses > 0 && target.SecretIDNumUses > role.SecretIDNumUses {
+		return logical.ErrorResponse("secret_id_num_uses of the delivery target exceeds the one of the role"), nil
+	}
+	if err := verifyCIDRRoleSecretIDSubset(target.CIDRList, role.SecretIDBoundCIDRs); err != nil {
+		return nil, err
+	}
+
+	secretID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to generate secret_id: {{err}}", err)
+	}
+	metadata := make(map[string]string, len(target.Metadata)+1)
+	for k, v := range target.Metadata {
+		metadata[k] = v
+	}
+	metadata["delivery_target"] = targetName
+
+	secretIDStorage := &secretIDStorageEntry{
+		SecretIDNumUses: target.SecretIDNumUses,
+		SecretIDTTL:     role.SecretIDTTL,
+		Metadata:        metadata,
+		CIDRList:        target.CIDRList,
+		TokenBoundCIDRs: target.TokenBoundCIDRs,
+	}
+	if secretIDStorage, err = b.registerSecretIDEntry(ctx, req.Storage, role.name, secretID, role.HMACKey, role.SecretIDPrefix, secretIDStorage); err != nil {
+		return nil, errwrap.Wrapf("failed to store secret_id: {{err}}", err)
+	}
+
+	secretIDData := map[string]interface{}{
+		"secret_id":          secretID,
+		"secret_id_accessor": secretIDStorage.SecretIDAccessor,
+		"secret_id_ttl":      int64(b.deriveSecretIDTTL(secretIDStorage.SecretIDTTL).Seconds()),
+	}
+
+	// The response is wrapped by Vault, with the path of the delivery
+	// request as the creation path of the wrapping token
+	if target.Type == deliveryTargetTypeCubbyhole {
+		return &logical.Response{
+			Data: secretIDData,
+			WrapInfo: &wrapping.ResponseWrapInfo{
+				TTL: target.WrapTTL,
+			},
+		}, nil
+	}
+
+	wrapInfo, err := b.System().ResponseWrapData(ctx, secretIDData, target.WrapTTL, false)
+	if err == nil {
+		switch target.Type {
+		case deliveryTargetTypeAgentSink:
+			err = b.sendDelivery(ctx, http.MethodPut, target.URL, "text/plain", []byte(wrapInfo.Token))
+		default:
+			var body []byte
+			body, err = json.Marshal(&deliveryWebhookPayload{
+				RoleName:         role.name,
+				TargetName:       targetName,
+				WrappingToken:    wrapInfo.Token,
+				WrappingAccessor: wrapInfo.Accessor,
+				WrappingTTL:      int64(wrapInfo.TTL.Seconds()),
+				SecretIDAccessor: secretIDStorage.SecretIDAccessor,
+			})
+			if err == nil {
+				err = b.sendDelivery(ctx, http.MethodPost, target.URL, "application/json", body)
+			}
+		}
+	}
+	if err != nil {
+		// Do not leave behind a SecretID that nobody received
+		if destroyErr := b.destroySecretID(ctx, req.Storage, role, secretID); destroyErr != nil {
+			b.Logger().Error("failed to destroy undelivered secret_id", "role_name", role.name, "secret_id_accessor", secretIDStorage.SecretIDAccessor, "error", destroyErr)
+		}
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to deliver secret_id to target %q: {{err}}", targetName), err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"secret_id_accessor": secretIDStorage.SecretIDAccessor,
+			"wrapping_accessor":  wrapInfo.Accessor,
+		},
+	}, nil
+}
+
+// sendDelivery sends a delivery to the URL of a target, which must
+// acknowledge it with a 2xx status
+func (b *backend) sendDelivery(ctx context.Context, method, targetURL, contentType string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, deliveryWebhookTimeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequest(method, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header.Set("Content-Type", contentType)
+
+	httpResp, err := b.deliveryClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return fmt.Errorf("delivery target returned status %d", httpResp.StatusCode)
+	}
+	return nil
+}
+
+// destroySecretID deletes a SecretID of the role and its accessor. The
+// caller must hold the lock of the role.
+func (b *backend) destroySecretID(ctx context.Context, s logical.Storage, role *roleStorageEntry, secretID string) error {
+	secretIDHMAC, err := createHMAC(role.HMACKey, secretID)
+	if err != nil {
+		return errwrap.Wrapf("failed to create HMAC of secret_id: {{err}}", err)
+	}
+	roleNameHMAC, err := createHMAC(role.HMACKey, role.name)
+	if err != nil {
+		return errwrap.Wrapf("failed to create HMAC of role_name: {{err}}", err)
+	}
+
+	lock := b.secretIDLock(secretIDHMAC)
+	lock.Lock()
+	defer lock.Unlock()
+
+	entry, err := b.nonLockedSecretIDStorageEntry(ctx, s, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return nil
+	}
+
+	if err := b.deleteSecretIDAccessorEntry(ctx, s, entry.SecretIDAccessor, role.SecretIDPrefix); err != nil {
+		return err
+	}
+	return s.Delete(ctx, fmt.Sprintf("%s%s/%s", role.SecretIDPrefix, roleNameHMAC, secretIDHMAC))
+}
@@ -0,0 +1,299 @@
+package approle
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/wrapping"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// wrappingSystemView keeps the data it wraps, keyed by wrapping token
+type wrappingSystemView struct {
+	logical.SystemView
+	wrapped map[string]map[string]interface{}
+}
+
+func (s *wrappingSystemView) ResponseWrapData(_ context.Context, data map[string]interface{}, ttl time.Duration, _ bool) (*wrapping.ResponseWrapInfo, error) {
+	token := "wrapping-token-" + strconv.Itoa(len(s.wrapped))
+	s.wrapped[token] = data
+	return &wrapping.ResponseWrapInfo{
+		Token:    token,
+		Accessor: "accessor-" + token,
+		TTL:      ttl,
+	}, nil
+}
+
+func TestAppRole_DeliveryTarget(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	systemView := &wrappingSystemView{
+		SystemView: config.System,
+		wrapped:    make(map[string]map[string]interface{}),
+	}
+	config.System = systemView
+	b, err := Backend(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	storage := config.StorageView
+
+	var payloads []deliveryWebhookPayload
+	var sinkTokens []string
+	webhookStatus := http.StatusOK
+	webhook := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sink":
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil || r.Method != http.MethodPut {
+				t.Errorf("bad agent sink request: method: %s err: %v", r.Method, err)
+			}
+			sinkTokens = append(sinkTokens, string(body))
+		default:
+			var payload deliveryWebhookPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Errorf("bad webhook payload: %v", err)
+			}
+			payloads = append(payloads, payload)
+		}
+		w.WriteHeader(webhookStatus)
+	}))
+	defer webhook.Close()
+	b.deliveryClient = webhook.Client()
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+			Connection: &logical.Connection{
+				RemoteAddr: "10.0.0.5",
+			},
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	mustFail := func(op logical.Operation, path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+		}
+	}
+
+	createRole(t, b, storage, "role1", "a,b")
+	mustRequest(logical.UpdateOperation, "role/role1", map[string]interface{}{
+		"secret_id_num_uses":    5,
+		"secret_id_bound_cidrs": "10.0.0.0/8",
+	})
+
+	mustFail(logical.CreateOperation, "role/role1/delivery-target/bad", map[string]interface{}{
+		"type": "agent",
+		"url":  webhook.URL,
+	})
+	mustFail(logical.CreateOperation, "role/role1/delivery-target/bad", map[string]interface{}{
+		"url": "http://127.0.0.1:8080/webhook",
+	})
+	mustFail(logical.CreateOperation, "role/role1/delivery-target/bad", map[string]interface{}{
+		"type": "agent_sink",
+	})
+	mustFail(logical.CreateOperation, "role/role1/delivery-target/bad", map[string]interface{}{
+		"type": "cubbyhole",
+		"url":  webhook.URL,
+	})
+	mustFail(logical.CreateOperation, "role/role1/delivery-target/bad", map[string]interface{}{
+		"url":                webhook.URL,
+		"secret_id_num_uses": 6,
+	})
+	mustFail(logical.CreateOperation, "role/role1/delivery-target/bad", map[string]interface{}{
+		"url":       webhook.URL,
+		"cidr_list": "192.168.0.0/16",
+	})
+
+	resp := mustRequest(logical.CreateOperation, "role/role1/delivery-target/web", map[string]interface{}{
+		"url":                  webhook.URL,
+		"delivery_bound_cidrs": "10.0.0.0/24",
+		"cidr_list":            "10.1.0.0/16",
+		"wrap_ttl":             "2m",
+	})
+	signingKey := resp.Data["signing_key"].(string)
+	resp = mustRequest(logical.ReadOperation, "role/role1/delivery-target/web", nil)
+	if resp.Data["secret_id_num_uses"] != 1 || resp.Data["wrap_ttl"] != int64(120) || resp.Data["signing_key"] != nil {
+		t.Fatalf("bad target: %#v", resp.Data)
+	}
+	resp = mustRequest(logical.ListOperation, "role/role1/delivery-target/", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "web" {
+		t.Fatalf("bad list: %#v", resp.Data)
+	}
+
+	signedFor := func(targetName, key, nonce string, timestamp time.Time) map[string]interface{} {
+		return map[string]interface{}{
+			"timestamp": timestamp.Unix(),
+			"nonce":     nonce,
+			"signature": hex.EncodeToString(deliverySignature(key, "role1", targetName, timestamp.Unix(), nonce)),
+		}
+	}
+	signed := func(key, nonce string, timestamp time.Time) map[string]interface{} {
+		return signedFor("web", key, nonce, timestamp)
+	}
+
+	mustFail(logical.UpdateOperation, "deliver/role1/web", signed("wrong-key", "n1", time.Now()))
+	mustFail(logical.UpdateOperation, "deliver/role1/web", signed(signingKey, "n1", time.Now().Add(-10*time.Minute)))
+	if len(payloads) != 0 {
+		t.Fatalf("unsigned request was delivered: %#v", payloads)
+	}
+
+	resp = mustRequest(logical.UpdateOperation, "deliver/role1/web", signed(signingKey, "n1", time.Now()))
+	if len(payloads) != 1 {
+		t.Fatalf("bad deliveries: %#v", payloads)
+	}
+	payload := payloads[0]
+	if payload.WrappingTTL != 120 || payload.SecretIDAccessor != resp.Data["secret_id_accessor"] || payload.WrappingAccessor != resp.Data["wrapping_accessor"] {
+		t.Fatalf("bad payload: %#v resp: %#v", payload, resp.Data)
+	}
+	if _, ok := resp.Data["secret_id"]; ok {
+		t.Fatal("secret_id returned to the delivery request")
+	}
+
+	// Delivery requests cannot be replayed, including after a restart, as
+	// their nonces are kept in storage
+	mustFail(logical.UpdateOperation, "deliver/role1/web", signed(signingKey, "n1", time.Now()))
+	restarted, err := Backend(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	restarted.deliveryClient = webhook.Client()
+	resp, err = restarted.HandleRequest(context.Background(), &logical.Request{
+		Operation:  logical.UpdateOperation,
+		Path:       "deliver/role1/web",
+		Storage:    storage,
+		Data:       signed(signingKey, "n1", time.Now()),
+		Connection: &logical.Connection{RemoteAddr: "10.0.0.5"},
+	})
+	if err == nil {
+		t.Fatalf("delivery request was replayed after a restart: resp: %#v", resp)
+	}
+	if len(payloads) != 1 {
+		t.Fatalf("bad deliveries: %#v", payloads)
+	}
+
+	// The delivered SecretID has the properties of the target
+	secretID := systemView.wrapped[payload.WrappingToken]["secret_id"].(string)
+	resp = mustRequest(logical.UpdateOperation, "role/role1/secret-id/lookup", map[string]interface{}{
+		"secret_id": secretID,
+	})
+	if resp.Data["secret_id_num_uses"] != 1 || resp.Data["metadata"].(map[string]string)["delivery_target"] != "web" {
+		t.Fatalf("bad secret_id: %#v", resp.Data)
+	}
+
+	// Undelivered SecretIDs are destroyed
+	webhookStatus = http.StatusInternalServerError
+	mustFail(logical.UpdateOperation, "deliver/role1/web", signed(signingKey, "n2", time.Now()))
+	secretID = systemView.wrapped[payloads[1].WrappingToken]["secret_id"].(string)
+	resp, err = request(logical.UpdateOperation, "role/role1/secret-id/lookup", map[string]interface{}{
+		"secret_id": secretID,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("undelivered secret_id exists: err: %v resp: %#v", err, resp)
+	}
+
+	// Requests from outside the bound CIDRs are refused
+	webhookStatus = http.StatusOK
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "deliver/role1/web",
+		Storage:   storage,
+		Data:      signed(signingKey, "n3", time.Now()),
+		Connection: &logical.Connection{
+			RemoteAddr: "10.0.1.5",
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error: resp: %#v", resp)
+	}
+
+	// Rotating the signing key invalidates the previous one
+	resp = mustRequest(logical.UpdateOperation, "role/role1/delivery-target/web", map[string]interface{}{
+		"rotate_signing_key": true,
+	})
+	if resp.Data["signing_key"] == signingKey {
+		t.Fatal("signing key was not rotated")
+	}
+	mustFail(logical.UpdateOperation, "deliver/role1/web", signed(signingKey, "n4", time.Now()))
+
+	// Agent sinks receive the bare wrapping token
+	resp = mustRequest(logical.CreateOperation, "role/role1/delivery-target/agent", map[string]interface{}{
+		"type": "agent_sink",
+		"url":  webhook.URL + "/sink",
+	})
+	agentKey := resp.Data["signing_key"].(string)
+	mustRequest(logical.UpdateOperation, "deliver/role1/agent", signedFor("agent", agentKey, "n1", time.Now()))
+	if len(sinkTokens) != 1 || systemView.wrapped[sinkTokens[0]]["secret_id"] == nil {
+		t.Fatalf("bad agent sink deliveries: %#v", sinkTokens)
+	}
+
+	// Cubbyhole targets have the SecretID wrapped in the response
+	resp = mustRequest(logical.CreateOperation, "role/role1/delivery-target/cubby", map[string]interface{}{
+		"type":     "cubbyhole",
+		"wrap_ttl": "1m",
+	})
+	cubbyKey := resp.Data["signing_key"].(string)
+	resp = mustRequest(logical.UpdateOperation, "deliver/role1/cubby", signedFor("cubby", cubbyKey, "n1", time.Now()))
+	if resp.WrapInfo == nil || resp.WrapInfo.TTL != time.Minute || resp.Data["secret_id"] == nil {
+		t.Fatalf("bad cubbyhole delivery: %#v", resp)
+	}
+
+	// Nonces are tidied once their request can no longer be accepted
+	expired, err := logical.StorageEntryJSON(deliveryNonceStorageKey("role1", "web", "old"), &deliveryNonceStorageEntry{
+		ExpirationTime: time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Put(context.Background(), expired); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.tidyDeliveryNonces(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+	nonces, err := storage.List(context.Background(), deliveryNoncePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, nonce := range nonces {
+		if deliveryNoncePrefix+nonce == expired.Key {
+			t.Fatal("expired nonce was not tidied")
+		}
+	}
+	if len(nonces) == 0 {
+		t.Fatal("unexpired nonces were tidied")
+	}
+
+	// Deleting the role deletes its delivery targets
+	mustRequest(logical.DeleteOperation, "role/role1", nil)
+	createRole(t, b, storage, "role1", "a,b")
+	resp = mustRequest(logical.ListOperation, "role/role1/delivery-target/", nil)
+	if len(resp.Data) != 0 && len(resp.Data["keys"].([]string)) != 0 {
+		t.Fatalf("delivery targets survived the role: %#v", resp.Data)
+	}
+}
@@ -180,6 +180,37 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 					return logical.ErrorResponse(errwrap.Wrapf(fmt.Sprintf("source address %q unauthorized through CIDR restrictions on the secret ID: {{err}}", req.Connection.RemoteAddr), err).Error()), nil
 				}
 			}
+
+			//
+			// Record the use of the SecretID, unless it was recorded recently
+			// from the same address, so that logins do not all write to the
+			// storage. Switch the lock from a `read` to a `write` and update
+			// the storage entry.
+			//
+
+			if entry.useRecordOutdated(req, time.Now()) {
+				secretIDLock.RUnlock()
+				secretIDLock.Lock()
+				unlockFunc = secretIDLock.Unlock
+
+				// Lock switching may change the data. Refresh the contents.
+				entry, err = b.nonLockedSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC)
+				if err != nil {
+					return nil, err
+				}
+				if entry == nil {
+					return logical.ErrorResponse("invalid secret id"), nil
+				}
+
+				entry.recordUse(req, time.Now())
+				sEntry, err := logical.StorageEntryJSON(entryIndex, &entry)
+				if err != nil {
+					return nil, err
+				}
+				if err = req.Storage.Put(ctx, sEntry); err != nil {
+					return nil, err
+				}
+			}
 		default:
 			//
 			// If the SecretIDNumUses is non-zero, it means that its use-count should be updated
@@ -217,6 +248,7 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 				// If the use count is greater than one, decrement it and update the last updated time.
 				entry.SecretIDNumUses -= 1
 				entry.LastUpdatedTime = time.Now()
+				entry.recordUse(req, entry.LastUpdatedTime)
 
 				sEntry, err := logical.StorageEntryJSON(entryIndex, &entry)
 				if err != nil {
@@ -1099,6 +1099,11 @@ func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data
 		return nil, errwrap.Wrapf(fmt.Sprintf("failed to invalidate the secrets belonging to role %q: {{err}}", role.name), err)
 	}
 
+	// Delete the delivery targets of the role
+	if err = b.flushDeliveryTargets(ctx, req.Storage, role.name); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to delete the delivery targets of role %q: {{err}}", role.name), err)
+	}
+
 	// Delete the reverse mapping from RoleID to the role
 	if err = b.roleIDEntryDelete(ctx, req.Storage, role.RoleID); err != nil {
 		return nil, errwrap.Wrapf(fmt.Sprintf("failed to delete the mapping from RoleID to role %q: {{err}}", role.name), err)
@@ -1193,10 +1198,16 @@ func (entry *secretIDStorageEntry) ToResponseData() map[string]interface{} {
 		"metadata":           entry.Metadata,
 		"cidr_list":          entry.CIDRList,
 		"token_bound_cidrs":  entry.TokenBoundCIDRs,
+		"first_used_time":    entry.FirstUsedTime,
+		"last_used_time":     entry.LastUsedTime,
+		"source_cidrs":       entry.SourceCIDRs,
 	}
 	if len(entry.TokenBoundCIDRs) == 0 {
 		ret["token_bound_cidrs"] = []string{}
 	}
+	if len(entry.SourceCIDRs) == 0 {
+		ret["source_cidrs"] = []string{}
+	}
 	return ret
 }
 
@@ -2595,4 +2606,51 @@ will pick up the new value during its next renewal.`,
 This can only be set during role creation and once set, it can't be
 reset later.`,
 	},
+	"role-secret-id-report": {
+		"Report the creation and usage of the SecretIDs of the role.",
+		`For each SecretID of the role, keyed by its accessor, this endpoint reports
+its properties along with the times of its first and last logins and the
+addresses from which they were performed, so that stale SecretIDs can be found
+and destroyed through 'role/<role_name>/secret-id-accessor/destroy'.
+
+The optional filters are combined: 'older_than' selects the SecretIDs created
+longer ago than the given duration, 'unused_for' the ones not used for the
+given duration, 'never_used' the ones never used, and 'expected_cidrs' the
+ones used from addresses outside of the given CIDR blocks.`,
+	},
+	"role-delivery-target-list": {
+		"Lists the delivery targets of the role.",
+		"The list will contain the names of the delivery targets of the role.",
+	},
+	"role-delivery-target": {
+		"Register a destination to which SecretIDs of the role are delivered.",
+		`A delivery target lets a workload orchestrator have SecretIDs pushed to
+the workloads it starts without holding a Vault token. Each signed request to
+'deliver/<role_name>/<target_name>' generates a SecretID with the properties
+of the target and wraps it in a response-wrapping token. Depending on the
+type of the target, the token is posted in a JSON payload to a webhook, put
+as is to an agent sink, where it can be written to the SecretID file read by
+the AppRole auto-auth method of Vault Agent, or returned to the signed
+request, the SecretID being kept only in the cubbyhole of the token. The URLs
+of the targets must use https.
+
+The signing key of the delivery requests is returned when the target is
+created, or when 'rotate_signing_key' is set, and cannot be read back.`,
+	},
+	"deliver": {
+		"Deliver a response-wrapped SecretID to a delivery target.",
+		`This unauthenticated endpoint accepts delivery requests signed with the
+signing key of the delivery target. The signature is the hex encoded
+HMAC-SHA256 of the role name, the target name, the timestamp and the nonce
+of the request, separated by newlines. Requests whose timestamp is more than
+five minutes away from the time of Vault, replayed requests and requests from
+outside the 'delivery_bound_cidrs' of the target are refused.
+
+The nonces of the requests are kept in storage until their timestamp is no
+longer accepted, so that requests cannot be replayed after a restart or to
+another node. The SecretID is sent response-wrapped to the target, and is
+destroyed if the delivery fails. Only the accessors of the SecretID and of
+the wrapping token are returned, except for cubbyhole targets, for which the
+response itself is wrapped.`,
+	},
 }
@@ -0,0 +1,196 @@
+package approle
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/cidrutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathRoleSecretIDReport(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/" + framework.GenericNameRegex("role_name") + "/secret-id-report/?$",
+		Fields: map[string]*framework.FieldSchema{
+			"role_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+			"older_than": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Only report the SecretIDs created longer than this duration ago.",
+			},
+			"unused_for": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Only report the SecretIDs not used to log in for this duration,
+including the SecretIDs never used.`,
+			},
+			"never_used": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Only report the SecretIDs never used to log in.",
+			},
+			"expected_cidrs": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. Only report the
+SecretIDs used to log in from addresses outside of these blocks.`,
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRoleSecretIDReport,
+			logical.UpdateOperation: b.pathRoleSecretIDReport,
+		},
+		HelpSynopsis:    strings.TrimSpace(roleHelp["role-secret-id-report"][0]),
+		HelpDescription: strings.TrimSpace(roleHelp["role-secret-id-report"][1]),
+	}
+}
+
+// secretIDReportFilter selects the SecretIDs reported
+type secretIDReportFilter struct {
+	now           time.Time
+	olderThan     time.Duration
+	unusedFor     time.Duration
+	neverUsed     bool
+	expectedCIDRs []string
+}
+
+func (f *secretIDReportFilter) matches(entry *secretIDStorageEntry) (bool, error) {
+	if f.olderThan > 0 && f.now.Sub(entry.CreationTime) < f.olderThan {
+		return false, nil
+	}
+	if f.unusedFor > 0 && !entry.LastUsedTime.IsZero() && f.now.Sub(entry.LastUsedTime) < f.unusedFor {
+		return false, nil
+	}
+	if f.neverUsed && !entry.FirstUsedTime.IsZero() {
+		return false, nil
+	}
+	if len(f.expectedCIDRs) != 0 {
+		unexpected, err := unexpectedSourceCIDRs(entry.SourceCIDRs, f.expectedCIDRs)
+		if err != nil {
+			return false, err
+		}
+		if len(unexpected) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// unexpectedSourceCIDRs returns the source addresses of a SecretID lying
+// outside of the expected CIDR blocks
+func unexpectedSourceCIDRs(sourceCIDRs, expectedCIDRs []string) ([]string, error) {
+	var unexpected []string
+	for _, sourceCIDR := range sourceCIDRs {
+		ip, _, err := net.ParseCIDR(sourceCIDR)
+		if err != nil {
+			return nil, err
+		}
+		belongs, err := cidrutil.IPBelongsToCIDRBlocksSlice(ip.String(), expectedCIDRs)
+		if err != nil {
+			return nil, err
+		}
+		if !belongs {
+			unexpected = append(unexpected, sourceCIDR)
+		}
+	}
+	return unexpected, nil
+}
+
+// pathRoleSecretIDReport reports the creation and usage of the SecretIDs of
+// the role, keyed by their accessors, so that stale SecretIDs can be found and
+// destroyed
+func (b *backend) pathRoleSecretIDReport(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role_name"), nil
+	}
+
+	filter := &secretIDReportFilter{
+		now:           time.Now(),
+		olderThan:     time.Duration(data.Get("older_than").(int)) * time.Second,
+		unusedFor:     time.Duration(data.Get("unused_for").(int)) * time.Second,
+		neverUsed:     data.Get("never_used").(bool),
+		expectedCIDRs: data.Get("expected_cidrs").([]string),
+	}
+	if len(filter.expectedCIDRs) != 0 {
+		valid, err := cidrutil.ValidateCIDRListSlice(filter.expectedCIDRs)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to validate CIDR blocks: {{err}}", err)
+		}
+		if !valid {
+			return logical.ErrorResponse("failed to validate CIDR blocks"), nil
+		}
+	}
+
+	lock := b.roleLock(roleName)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q does not exist", roleName)), nil
+	}
+
+	// Guard the list operation with an outer lock
+	b.secretIDListingLock.RLock()
+	defer b.secretIDListingLock.RUnlock()
+
+	roleNameHMAC, err := createHMAC(role.HMACKey, role.name)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to create HMAC of role_name: {{err}}", err)
+	}
+
+	secretIDHMACs, err := req.Storage.List(ctx, fmt.Sprintf("%s%s/", role.SecretIDPrefix, roleNameHMAC))
+	if err != nil {
+		return nil, err
+	}
+
+	report := make(map[string]interface{})
+	for _, secretIDHMAC := range secretIDHMACs {
+		if secretIDHMAC == "" {
+			continue
+		}
+
+		secretIDLock := b.secretIDLock(secretIDHMAC)
+		secretIDLock.RLock()
+		entry, err := b.nonLockedSecretIDStorageEntry(ctx, req.Storage, role.SecretIDPrefix, roleNameHMAC, secretIDHMAC)
+		secretIDLock.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		matches, err := filter.matches(entry)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+
+		entryData := entry.ToResponseData()
+		entryData["never_used"] = entry.FirstUsedTime.IsZero()
+		if len(filter.expectedCIDRs) != 0 {
+			entryData["unexpected_source_cidrs"], err = unexpectedSourceCIDRs(entry.SourceCIDRs, filter.expectedCIDRs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		report[entry.SecretIDAccessor] = entryData
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"secret_ids": report,
+		},
+	}, nil
+}
@@ -0,0 +1,191 @@
+package approle
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestAppRole_SecretIDReport(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}, remoteAddr string) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+			Connection: &logical.Connection{
+				RemoteAddr: remoteAddr,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	report := func(data map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		resp := request(logical.ReadOperation, "role/role1/secret-id-report", data, "")
+		return resp.Data["secret_ids"].(map[string]interface{})
+	}
+
+	createRole(t, b, storage, "role1", "a,b")
+	roleID := request(logical.ReadOperation, "role/role1/role-id", nil, "").Data["role_id"]
+
+	var secretIDs, accessors []string
+	for i := 0; i < 3; i++ {
+		resp := request(logical.UpdateOperation, "role/role1/secret-id", nil, "")
+		secretIDs = append(secretIDs, resp.Data["secret_id"].(string))
+		accessors = append(accessors, resp.Data["secret_id_accessor"].(string))
+	}
+
+	// Logins are tracked for SecretIDs with and without use limits
+	for _, remoteAddr := range []string{"10.0.0.1", "192.168.1.1", "10.0.0.1"} {
+		request(logical.UpdateOperation, "login", map[string]interface{}{
+			"role_id":   roleID,
+			"secret_id": secretIDs[0],
+		}, remoteAddr)
+	}
+	resp := request(logical.UpdateOperation, "role/role1/secret-id/lookup", map[string]interface{}{
+		"secret_id": secretIDs[0],
+	}, "")
+	if sourceCIDRs := resp.Data["source_cidrs"].([]string); !reflect.DeepEqual(sourceCIDRs, []string{"192.168.1.1/32", "10.0.0.1/32"}) {
+		t.Fatalf("bad source_cidrs: %#v", resp.Data)
+	}
+
+	all := report(nil)
+	if len(all) != 3 {
+		t.Fatalf("bad report: %#v", all)
+	}
+	if all[accessors[0]].(map[string]interface{})["never_used"] != false || all[accessors[1]].(map[string]interface{})["never_used"] != true {
+		t.Fatalf("bad report: %#v", all)
+	}
+
+	neverUsed := report(map[string]interface{}{"never_used": true})
+	if len(neverUsed) != 2 || neverUsed[accessors[0]] != nil {
+		t.Fatalf("bad never_used report: %#v", neverUsed)
+	}
+
+	unexpected := report(map[string]interface{}{"expected_cidrs": "10.0.0.0/8"})
+	if len(unexpected) != 1 {
+		t.Fatalf("bad expected_cidrs report: %#v", unexpected)
+	}
+	entry := unexpected[accessors[0]].(map[string]interface{})
+	if !reflect.DeepEqual(entry["unexpected_source_cidrs"], []string{"192.168.1.1/32"}) {
+		t.Fatalf("bad expected_cidrs report: %#v", entry)
+	}
+
+	if old := report(map[string]interface{}{"older_than": "1h"}); len(old) != 0 {
+		t.Fatalf("bad older_than report: %#v", old)
+	}
+	if unused := report(map[string]interface{}{"unused_for": "1h"}); len(unused) != 2 {
+		t.Fatalf("bad unused_for report: %#v", unused)
+	}
+
+	// SecretIDs with use limits record their uses too
+	request(logical.UpdateOperation, "role/role1", map[string]interface{}{
+		"secret_id_num_uses": 5,
+	}, "")
+	resp = request(logical.UpdateOperation, "role/role1/secret-id", nil, "")
+	request(logical.UpdateOperation, "login", map[string]interface{}{
+		"role_id":   roleID,
+		"secret_id": resp.Data["secret_id"],
+	}, "10.0.0.2")
+	if neverUsed := report(map[string]interface{}{"never_used": true}); len(neverUsed) != 2 {
+		t.Fatalf("bad never_used report: %#v", neverUsed)
+	}
+}
+
+// putCountingStorage counts the writes to the storage entries of SecretIDs
+type putCountingStorage struct {
+	logical.Storage
+	secretIDPuts int
+}
+
+func (s *putCountingStorage) Put(ctx context.Context, entry *logical.StorageEntry) error {
+	if strings.HasPrefix(entry.Key, secretIDPrefix) {
+		s.secretIDPuts++
+	}
+	return s.Storage.Put(ctx, entry)
+}
+
+func TestAppRole_SecretIDUseRecordInterval(t *testing.T) {
+	b, inmemStorage := createBackendWithStorage(t)
+	storage := &putCountingStorage{Storage: inmemStorage}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}, remoteAddr string) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+			Connection: &logical.Connection{
+				RemoteAddr: remoteAddr,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	createRole(t, b, storage, "role1", "a,b")
+	request(logical.UpdateOperation, "role/role1", map[string]interface{}{
+		"secret_id_num_uses": 0,
+	}, "")
+	roleID := request(logical.ReadOperation, "role/role1/role-id", nil, "").Data["role_id"]
+	secretID := request(logical.UpdateOperation, "role/role1/secret-id", nil, "").Data["secret_id"]
+
+	login := func(remoteAddr string) {
+		t.Helper()
+		request(logical.UpdateOperation, "login", map[string]interface{}{
+			"role_id":   roleID,
+			"secret_id": secretID,
+		}, remoteAddr)
+	}
+	assertPuts := func(expected int) {
+		t.Helper()
+		if storage.secretIDPuts != expected {
+			t.Fatalf("expected %d writes of the secret_id, got %d", expected, storage.secretIDPuts)
+		}
+	}
+
+	// The first use is recorded, but not the following ones from the same
+	// address
+	storage.secretIDPuts = 0
+	login("10.0.0.1")
+	assertPuts(1)
+	login("10.0.0.1")
+	login("10.0.0.1")
+	assertPuts(1)
+
+	// A use from another address is recorded
+	login("10.0.0.2")
+	assertPuts(2)
+	resp := request(logical.UpdateOperation, "role/role1/secret-id/lookup", map[string]interface{}{
+		"secret_id": secretID,
+	}, "")
+	if sourceCIDRs := resp.Data["source_cidrs"].([]string); !reflect.DeepEqual(sourceCIDRs, []string{"10.0.0.1/32", "10.0.0.2/32"}) {
+		t.Fatalf("bad source_cidrs: %#v", resp.Data)
+	}
+
+	// Uses are recorded again once the interval has passed
+	entry := &secretIDStorageEntry{
+		LastUsedTime: time.Now().Add(-2 * useRecordInterval),
+		SourceCIDRs:  []string{"10.0.0.2/32"},
+	}
+	if !entry.useRecordOutdated(&logical.Request{Connection: &logical.Connection{RemoteAddr: "10.0.0.2"}}, time.Now()) {
+		t.Fatal("expected the use record to be outdated")
+	}
+	entry.LastUsedTime = time.Now()
+	if entry.useRecordOutdated(&logical.Request{Connection: &logical.Connection{RemoteAddr: "10.0.0.2"}}, time.Now()) {
+		t.Fatal("expected the use record to be up to date")
+	}
+}
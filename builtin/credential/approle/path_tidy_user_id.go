@@ -258,6 +258,11 @@ func (b *backend) tidySecretIDinternal(s logical.Storage) {
 		logger.Error("error tidying local secret IDs", "error", err)
 		return
 	}
+	err = b.tidyDeliveryNonces(ctx, s)
+	if err != nil {
+		logger.Error("error tidying delivery nonces", "error", err)
+		return
+	}
 }
 
 // pathTidySecretIDUpdate is used to delete the expired SecretID entries
@@ -265,7 +270,7 @@ func (b *backend) pathTidySecretIDUpdate(ctx context.Context, req *logical.Reque
 	return b.tidySecretID(ctx, req)
 }
 
-const pathTidySecretIDSyn = "Trigger the clean-up of expired SecretID entries."
+const pathTidySecretIDSyn = "Trigger the clean-up of expired SecretID entries and delivery request nonces."
 const pathTidySecretIDDesc = `SecretIDs will have expiration time attached to them. The periodic function
 of the backend will look for expired entries and delete them. This happens once in a minute. Invoking
 this endpoint will trigger the clean-up action, without waiting for the backend's periodic function.`
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/hashicorp/errwrap"
@@ -58,6 +59,77 @@ type secretIDStorageEntry struct {
 
 	// This is a deprecated field
 	SecretIDNumUsesDeprecated int `json:"SecretIDNumUses" mapstructure:"SecretIDNumUses"`
+
+	// The times of the first and of the last login performed with the
+	// SecretID
+	FirstUsedTime time.Time `json:"first_used_time" mapstructure:"first_used_time"`
+	LastUsedTime  time.Time `json:"last_used_time" mapstructure:"last_used_time"`
+
+	// SourceCIDRs holds the addresses, as single host CIDR blocks, from
+	// which logins were performed with the SecretID. Only the most recent
+	// maxSourceCIDRs addresses are kept, the most recent last.
+	SourceCIDRs []string `json:"source_cidrs" mapstructure:"source_cidrs"`
+}
+
+// maxSourceCIDRs is the number of source addresses tracked per SecretID
+const maxSourceCIDRs = 10
+
+// useRecordInterval is how often the use of a SecretID without use limit is
+// recorded, as long as it is used from the same address. Logins performed
+// with such SecretIDs do not otherwise write to the storage.
+const useRecordInterval = time.Minute
+
+// requestSourceCIDR returns the source address of the request as a single
+// host CIDR block, or an empty string if it is unknown
+func requestSourceCIDR(req *logical.Request) string {
+	if req.Connection == nil {
+		return ""
+	}
+	ip := net.ParseIP(req.Connection.RemoteAddr)
+	if ip == nil {
+		return ""
+	}
+	bits := 8 * net.IPv4len
+	if ip.To4() == nil {
+		bits = 8 * net.IPv6len
+	}
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String()
+}
+
+// useRecordOutdated tells whether a login performed with the SecretID should
+// be recorded: the SecretID was not used for useRecordInterval, or it is used
+// from another address than the last time
+func (entry *secretIDStorageEntry) useRecordOutdated(req *logical.Request, now time.Time) bool {
+	if entry.LastUsedTime.IsZero() || now.Sub(entry.LastUsedTime) >= useRecordInterval {
+		return true
+	}
+	sourceCIDR := requestSourceCIDR(req)
+	return sourceCIDR != "" && (len(entry.SourceCIDRs) == 0 || entry.SourceCIDRs[len(entry.SourceCIDRs)-1] != sourceCIDR)
+}
+
+// recordUse records a login performed with the SecretID
+func (entry *secretIDStorageEntry) recordUse(req *logical.Request, now time.Time) {
+	if entry.FirstUsedTime.IsZero() {
+		entry.FirstUsedTime = now
+	}
+	entry.LastUsedTime = now
+
+	sourceCIDR := requestSourceCIDR(req)
+	if sourceCIDR == "" {
+		return
+	}
+
+	sourceCIDRs := make([]string, 0, len(entry.SourceCIDRs)+1)
+	for _, cidr := range entry.SourceCIDRs {
+		if cidr != sourceCIDR {
+			sourceCIDRs = append(sourceCIDRs, cidr)
+		}
+	}
+	sourceCIDRs = append(sourceCIDRs, sourceCIDR)
+	if len(sourceCIDRs) > maxSourceCIDRs {
+		sourceCIDRs = sourceCIDRs[len(sourceCIDRs)-maxSourceCIDRs:]
+	}
+	entry.SourceCIDRs = sourceCIDRs
 }
 
 // Represents the payload of the storage entry of the accessor that maps to a
@@ -5,6 +5,7 @@ import (
 
 	"github.com/hashicorp/vault/helper/mfa"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -17,7 +18,9 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 }
 
 func Backend() *backend {
-	var b backend
+	b := backend{
+		userLocks: locksutil.CreateLocks(),
+	}
 	b.Backend = &framework.Backend{
 		Help: backendHelp,
 
@@ -26,6 +29,7 @@ func Backend() *backend {
 
 			Unauthenticated: []string{
 				"login/*",
+				"change-password/*",
 			},
 		},
 
@@ -34,6 +38,10 @@ func Backend() *backend {
 			pathUsersList(&b),
 			pathUserPolicies(&b),
 			pathUserPassword(&b),
+			pathUserLockout(&b),
+			pathUserUnlock(&b),
+			pathChangePassword(&b),
+			pathConfig(&b),
 		},
 			mfa.MFAPaths(b.Backend, pathLogin(&b))...,
 		),
@@ -47,6 +55,10 @@ func Backend() *backend {
 
 type backend struct {
 	*framework.Backend
+
+	// Locks to serialize the logins, password changes and lockout updates of
+	// each user
+	userLocks []*locksutil.LockEntry
 }
 
 const backendHelp = `
@@ -57,4 +69,8 @@ are supported.
 The username/password combination is configured using the "users/"
 endpoints by a user with root access. Authentication is then done
 by supplying the two fields for "login".
+
+The "config" endpoint sets the password policy the passwords must
+satisfy and the lockout of users after failed login attempts. Users
+can change their own password with the "change-password" endpoint.
 `
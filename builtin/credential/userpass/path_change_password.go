@@ -0,0 +1,117 @@
+package userpass
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/mfa"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathChangePassword(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "change-password/" + framework.GenericNameRegex("username"),
+		Fields: map[string]*framework.FieldSchema{
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Username of the user.",
+			},
+
+			"old_password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Current password of the user.",
+				DisplayAttrs: &framework.DisplayAttributes{
+					Sensitive: true,
+				},
+			},
+
+			"new_password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "New password of the user.",
+				DisplayAttrs: &framework.DisplayAttributes{
+					Sensitive: true,
+				},
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathChangePassword,
+		},
+
+		HelpSynopsis:    pathChangePasswordHelpSyn,
+		HelpDescription: pathChangePasswordHelpDesc,
+	}
+}
+
+func (b *backend) pathChangePassword(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := strings.ToLower(d.Get("username").(string))
+
+	oldPassword := d.Get("old_password").(string)
+	if oldPassword == "" {
+		return logical.ErrorResponse("missing old_password"), logical.ErrInvalidRequest
+	}
+	newPassword := d.Get("new_password").(string)
+	if newPassword == "" {
+		return logical.ErrorResponse("missing new_password"), logical.ErrInvalidRequest
+	}
+
+	// Only the password would be checked here, so that changing it would
+	// bypass the MFA required on login
+	mfaEnabled, err := mfa.MFAEnabled(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		return logical.ErrorResponse("password changes through this endpoint are disabled when MFA is configured"), logical.ErrPermissionDenied
+	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.LockoutThreshold > 0 {
+		lock := b.userLock(username)
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	// The old password is checked like a login, so that failed attempts count
+	// towards the lockout of the user
+	user, resp, err := b.checkPassword(ctx, req, config, username, oldPassword)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	if newPassword == oldPassword {
+		return logical.ErrorResponse("new_password must differ from old_password"), logical.ErrInvalidRequest
+	}
+
+	userErr, intErr := b.setUserPassword(ctx, req.Storage, newPassword, user)
+	if intErr != nil {
+		return nil, intErr
+	}
+	if userErr != nil {
+		return logical.ErrorResponse(userErr.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.setUser(ctx, req.Storage, username, user)
+}
+
+const pathChangePasswordHelpSyn = `
+Change the password of a user, given its current password.
+`
+
+const pathChangePasswordHelpDesc = `
+This endpoint allows users to change their own password without a token.
+The current password is checked like on login: failed attempts count towards
+the lockout of the user, and the token_bound_cidrs of the user apply. The new
+password must satisfy the password policy configured on the mount, if any.
+
+As only the password is checked, this endpoint is disabled when MFA is
+configured on the mount.
+
+Operators can reset the password of any user through the
+"users/<username>/password" endpoint instead.
+`
@@ -0,0 +1,162 @@
+package userpass
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	defaultLockoutDuration     = 15 * time.Minute
+	defaultLockoutCounterReset = 15 * time.Minute
+)
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config$",
+		Fields: map[string]*framework.FieldSchema{
+			"password_policy": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Name of the password policy, configured in sys/policies/password,
+that the passwords of the users must satisfy.`,
+			},
+
+			"lockout_threshold": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `Number of consecutive failed login attempts after which a user
+is locked out. Defaults to 0, which disables the lockout.`,
+			},
+
+			"lockout_duration": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Duration a user stays locked out for. Defaults to 15 minutes.",
+			},
+
+			"lockout_counter_reset": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Duration after the last failed login attempt after which the
+failed attempts are no longer counted. Defaults to 15 minutes.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Action: "Configure",
+		},
+	}
+}
+
+type configEntry struct {
+	PasswordPolicy      string        `json:"password_policy"`
+	LockoutThreshold    int           `json:"lockout_threshold"`
+	LockoutDuration     time.Duration `json:"lockout_duration"`
+	LockoutCounterReset time.Duration `json:"lockout_counter_reset"`
+}
+
+// config returns the configuration of the mount, filled with the defaults if
+// it was never written
+func (b *backend) config(ctx context.Context, s logical.Storage) (*configEntry, error) {
+	result := &configEntry{
+		LockoutDuration:     defaultLockoutDuration,
+		LockoutCounterReset: defaultLockoutCounterReset,
+	}
+
+	entry, err := s.Get(ctx, "config")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return result, nil
+	}
+
+	if err := entry.DecodeJSON(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"password_policy":       config.PasswordPolicy,
+			"lockout_threshold":     config.LockoutThreshold,
+			"lockout_duration":      int64(config.LockoutDuration.Seconds()),
+			"lockout_counter_reset": int64(config.LockoutCounterReset.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if passwordPolicy, ok := d.GetOk("password_policy"); ok {
+		config.PasswordPolicy = passwordPolicy.(string)
+	}
+	if config.PasswordPolicy != "" {
+		if _, ok := b.System().(logical.PasswordPolicyValidator); !ok {
+			return logical.ErrorResponse("password policies are not supported by this system"), nil
+		}
+		// Generating a password ensures the policy exists and is usable
+		if _, err := b.System().GeneratePasswordFromPolicy(ctx, config.PasswordPolicy); err != nil {
+			return logical.ErrorResponse("unable to use password policy %q: %s", config.PasswordPolicy, err), nil
+		}
+	}
+
+	if lockoutThreshold, ok := d.GetOk("lockout_threshold"); ok {
+		config.LockoutThreshold = lockoutThreshold.(int)
+	}
+	if config.LockoutThreshold < 0 {
+		return logical.ErrorResponse("lockout_threshold cannot be negative"), nil
+	}
+
+	if lockoutDuration, ok := d.GetOk("lockout_duration"); ok {
+		config.LockoutDuration = time.Duration(lockoutDuration.(int)) * time.Second
+	}
+	if config.LockoutDuration <= 0 {
+		return logical.ErrorResponse("lockout_duration must be greater than 0"), nil
+	}
+
+	if lockoutCounterReset, ok := d.GetOk("lockout_counter_reset"); ok {
+		config.LockoutCounterReset = time.Duration(lockoutCounterReset.(int)) * time.Second
+	}
+	if config.LockoutCounterReset <= 0 {
+		return logical.ErrorResponse("lockout_counter_reset must be greater than 0"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config", config)
+	if err != nil {
+		return nil, err
+	}
+	return nil, req.Storage.Put(ctx, entry)
+}
+
+const pathConfigHelpSyn = `
+Configure the password policy and the failed login lockout of the users.
+`
+
+const pathConfigHelpDesc = `
+This endpoint configures the password policy, defined in sys/policies/password,
+that the passwords of the users must satisfy when they are set or changed.
+Passwords set before the policy was configured are not affected.
+
+It also configures the lockout of the users after a number of consecutive
+failed login attempts. Locked out users cannot log in or change their password
+until the lockout expires or the user is unlocked through the
+"users/<username>/unlock" endpoint.
+`
@@ -0,0 +1,318 @@
+package userpass
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/random"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// passwordPolicySystemView serves password policies parsed from HCL
+type passwordPolicySystemView struct {
+	logical.SystemView
+	policies map[string]string
+}
+
+func (s *passwordPolicySystemView) passwordPolicy(policyName string) (random.StringGenerator, error) {
+	policy, ok := s.policies[policyName]
+	if !ok {
+		return random.StringGenerator{}, fmt.Errorf("no password policy found")
+	}
+	return random.ParsePolicy(policy)
+}
+
+func (s *passwordPolicySystemView) GeneratePasswordFromPolicy(ctx context.Context, policyName string) (string, error) {
+	policy, err := s.passwordPolicy(policyName)
+	if err != nil {
+		return "", err
+	}
+	return policy.Generate(ctx, nil)
+}
+
+func (s *passwordPolicySystemView) ValidatePasswordFromPolicy(_ context.Context, policyName string, password string) error {
+	policy, err := s.passwordPolicy(policyName)
+	if err != nil {
+		return err
+	}
+	return policy.Validate(password)
+}
+
+type testBackend struct {
+	t       *testing.T
+	b       logical.Backend
+	storage logical.Storage
+}
+
+func newTestBackend(t *testing.T, system logical.SystemView) *testBackend {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	if system != nil {
+		config.System = system
+	}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testBackend{t: t, b: b, storage: config.StorageView}
+}
+
+func (tb *testBackend) request(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	return tb.b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  op,
+		Path:       path,
+		Storage:    tb.storage,
+		Data:       data,
+		Connection: &logical.Connection{},
+	})
+}
+
+func (tb *testBackend) mustRequest(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	tb.t.Helper()
+	resp, err := tb.request(op, path, data)
+	if err != nil || (resp != nil && resp.IsError()) {
+		tb.t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+	}
+	return resp
+}
+
+func (tb *testBackend) mustFail(op logical.Operation, path string, data map[string]interface{}) {
+	tb.t.Helper()
+	resp, err := tb.request(op, path, data)
+	if err == nil && (resp == nil || !resp.IsError()) {
+		tb.t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+	}
+}
+
+func (tb *testBackend) login(username, password string) error {
+	resp, err := tb.request(logical.UpdateOperation, "login/"+username, map[string]interface{}{
+		"password": password,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return resp.Error()
+	}
+	return nil
+}
+
+func TestBackend_passwordPolicy(t *testing.T) {
+	tb := newTestBackend(t, &passwordPolicySystemView{
+		SystemView: logical.TestSystemView(),
+		policies: map[string]string{
+			"strong": `
+length = 12
+rule "charset" {
+  charset = "abcdefghijklmnopqrstuvwxyz"
+  min-chars = 1
+}
+rule "charset" {
+  charset = "0123456789"
+  min-chars = 2
+}`,
+		},
+	})
+
+	tb.mustFail(logical.UpdateOperation, "config", map[string]interface{}{
+		"password_policy": "missing",
+	})
+	tb.mustRequest(logical.UpdateOperation, "config", map[string]interface{}{
+		"password_policy": "strong",
+	})
+	resp := tb.mustRequest(logical.ReadOperation, "config", nil)
+	if resp.Data["password_policy"] != "strong" || resp.Data["lockout_threshold"] != 0 || resp.Data["lockout_duration"] != int64(900) {
+		t.Fatalf("bad config: %#v", resp.Data)
+	}
+
+	// Passwords are checked against the policy when users are created and
+	// when their password is reset
+	tb.mustFail(logical.CreateOperation, "users/alice", map[string]interface{}{
+		"password": "short12",
+	})
+	tb.mustFail(logical.CreateOperation, "users/alice", map[string]interface{}{
+		"password": "nodigitsatall",
+	})
+	tb.mustRequest(logical.CreateOperation, "users/alice", map[string]interface{}{
+		"password": "correcthorse42",
+	})
+	tb.mustFail(logical.UpdateOperation, "users/alice/password", map[string]interface{}{
+		"password": "Uppercase123",
+	})
+	tb.mustRequest(logical.UpdateOperation, "users/alice/password", map[string]interface{}{
+		"password": "batterystaple42",
+	})
+	if err := tb.login("alice", "batterystaple42"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Password policies cannot be used without support from the system
+	tb = newTestBackend(t, nil)
+	tb.mustFail(logical.UpdateOperation, "config", map[string]interface{}{
+		"password_policy": "strong",
+	})
+}
+
+func TestBackend_lockout(t *testing.T) {
+	tb := newTestBackend(t, nil)
+
+	tb.mustFail(logical.UpdateOperation, "config", map[string]interface{}{
+		"lockout_threshold": -1,
+	})
+	tb.mustRequest(logical.UpdateOperation, "config", map[string]interface{}{
+		"lockout_threshold": 3,
+		"lockout_duration":  "1h",
+	})
+	tb.mustRequest(logical.CreateOperation, "users/alice", map[string]interface{}{
+		"password": "secret",
+	})
+
+	lockoutStatus := func() map[string]interface{} {
+		t.Helper()
+		return tb.mustRequest(logical.ReadOperation, "users/alice/lockout", nil).Data
+	}
+
+	// A successful login resets the failed attempts
+	tb.login("alice", "wrong")
+	tb.login("alice", "wrong")
+	if status := lockoutStatus(); status["failed_attempts"] != 2 || status["locked"] != false {
+		t.Fatalf("bad lockout status: %#v", status)
+	}
+	if err := tb.login("alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if status := lockoutStatus(); status["failed_attempts"] != 0 {
+		t.Fatalf("bad lockout status: %#v", status)
+	}
+
+	for i := 0; i < 3; i++ {
+		tb.login("alice", "wrong")
+	}
+	if status := lockoutStatus(); status["locked"] != true {
+		t.Fatalf("bad lockout status: %#v", status)
+	}
+	if err := tb.login("alice", "secret"); err == nil {
+		t.Fatal("locked out user logged in")
+	}
+	tb.mustFail(logical.UpdateOperation, "change-password/alice", map[string]interface{}{
+		"old_password": "secret",
+		"new_password": "other",
+	})
+
+	tb.mustRequest(logical.UpdateOperation, "users/alice/unlock", nil)
+	if status := lockoutStatus(); status["locked"] != false {
+		t.Fatalf("bad lockout status: %#v", status)
+	}
+	if err := tb.login("alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Disabling the lockout lets locked out users in
+	for i := 0; i < 3; i++ {
+		tb.login("alice", "wrong")
+	}
+	tb.mustRequest(logical.UpdateOperation, "config", map[string]interface{}{
+		"lockout_threshold": 0,
+	})
+	if err := tb.login("alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackend_lockoutDisabled(t *testing.T) {
+	tb := newTestBackend(t, nil)
+
+	tb.mustRequest(logical.CreateOperation, "users/alice", map[string]interface{}{
+		"password": "secret",
+	})
+
+	// Without lockout, logins do not wait for the lock of the user
+	lock := tb.b.(*backend).userLock("alice")
+	lock.Lock()
+	loggedIn := make(chan error, 1)
+	go func() {
+		loggedIn <- tb.login("alice", "secret")
+	}()
+	select {
+	case err := <-loggedIn:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("login waited for the lock of the user")
+	}
+	lock.Unlock()
+
+	// Failed attempts are not counted
+	for i := 0; i < 3; i++ {
+		tb.login("alice", "wrong")
+	}
+	if status := tb.mustRequest(logical.ReadOperation, "users/alice/lockout", nil).Data; status["failed_attempts"] != 0 {
+		t.Fatalf("bad lockout status: %#v", status)
+	}
+}
+
+func TestBackend_changePassword(t *testing.T) {
+	tb := newTestBackend(t, nil)
+
+	tb.mustRequest(logical.UpdateOperation, "config", map[string]interface{}{
+		"lockout_threshold": 2,
+	})
+	tb.mustRequest(logical.CreateOperation, "users/alice", map[string]interface{}{
+		"password": "secret",
+	})
+
+	// Missing passwords are invalid requests
+	for _, data := range []map[string]interface{}{
+		{"old_password": "secret"},
+		{"new_password": "updated"},
+	} {
+		resp, err := tb.request(logical.UpdateOperation, "change-password/alice", data)
+		if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+			t.Fatalf("expected invalid request: data: %#v err: %v resp: %#v", data, err, resp)
+		}
+	}
+	tb.mustFail(logical.UpdateOperation, "change-password/alice", map[string]interface{}{
+		"old_password": "secret",
+		"new_password": "secret",
+	})
+	tb.mustFail(logical.UpdateOperation, "change-password/bob", map[string]interface{}{
+		"old_password": "secret",
+		"new_password": "updated",
+	})
+
+	// Wrong old passwords count towards the lockout
+	tb.mustFail(logical.UpdateOperation, "change-password/alice", map[string]interface{}{
+		"old_password": "wrong",
+		"new_password": "updated",
+	})
+	status := tb.mustRequest(logical.ReadOperation, "users/alice/lockout", nil).Data
+	if status["failed_attempts"] != 1 {
+		t.Fatalf("bad lockout status: %#v", status)
+	}
+
+	tb.mustRequest(logical.UpdateOperation, "change-password/alice", map[string]interface{}{
+		"old_password": "secret",
+		"new_password": "updated",
+	})
+	if err := tb.login("alice", "secret"); err == nil {
+		t.Fatal("logged in with the old password")
+	}
+	if err := tb.login("alice", "updated"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes would bypass the MFA of the mount
+	tb.mustRequest(logical.UpdateOperation, "mfa_config", map[string]interface{}{
+		"type": "duo",
+	})
+	resp, err := tb.request(logical.UpdateOperation, "change-password/alice", map[string]interface{}{
+		"old_password": "updated",
+		"new_password": "other",
+	})
+	if err != logical.ErrPermissionDenied || resp == nil || !resp.IsError() {
+		t.Fatalf("expected permission denied with MFA configured: err: %v resp: %#v", err, resp)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/subtle"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/cidrutil"
@@ -61,6 +62,44 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 		return nil, fmt.Errorf("missing password")
 	}
 
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logins are only serialized when failed attempts are counted
+	if config.LockoutThreshold > 0 {
+		lock := b.userLock(username)
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	user, resp, err := b.checkPassword(ctx, req, config, username, password)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	auth := &logical.Auth{
+		Metadata: map[string]string{
+			"username": username,
+		},
+		DisplayName: username,
+		Alias: &logical.Alias{
+			Name: username,
+		},
+	}
+	user.PopulateTokenAuth(auth)
+
+	return &logical.Response{
+		Auth: auth,
+	}, nil
+}
+
+// checkPassword authenticates the user with the password, counting failed
+// attempts towards the lockout of the user when it is configured. On success
+// the user is returned, otherwise the response or the error to return. Callers
+// must hold the lock of the user when the lockout is configured.
+func (b *backend) checkPassword(ctx context.Context, req *logical.Request, config *configEntry, username, password string) (*UserEntry, *logical.Response, error) {
 	// Get the user and validate auth
 	user, userError := b.user(ctx, req.Storage, username)
 
@@ -86,48 +125,67 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 	// Check for a password match. Check for a hash collision for Vault 0.2+,
 	// but handle the older legacy passwords with a constant time comparison.
 	passwordBytes := []byte(password)
+	var passwordMatch bool
 	if !legacyPassword {
-		if err := bcrypt.CompareHashAndPassword(userPassword, passwordBytes); err != nil {
-			return logical.ErrorResponse("invalid username or password"), nil
-		}
+		passwordMatch = bcrypt.CompareHashAndPassword(userPassword, passwordBytes) == nil
 	} else {
-		if subtle.ConstantTimeCompare(userPassword, passwordBytes) != 1 {
-			return logical.ErrorResponse("invalid username or password"), nil
-		}
+		passwordMatch = subtle.ConstantTimeCompare(userPassword, passwordBytes) == 1
 	}
 
+	if !passwordMatch && (userError != nil || user == nil) {
+		return nil, logical.ErrorResponse("invalid username or password"), nil
+	}
 	if userError != nil {
-		return nil, userError
+		return nil, nil, userError
 	}
 	if user == nil {
-		return logical.ErrorResponse("invalid username or password"), nil
+		return nil, logical.ErrorResponse("invalid username or password"), nil
+	}
+
+	// Locked out users are refused even with the right password, without
+	// telling them apart from a wrong password
+	var lockout *LockoutEntry
+	if config.LockoutThreshold > 0 {
+		var err error
+		lockout, err = b.lockout(ctx, req.Storage, username)
+		if err != nil {
+			return nil, nil, err
+		}
+		now := time.Now()
+		if lockout != nil && lockout.locked(now) {
+			return nil, logical.ErrorResponse("invalid username or password"), nil
+		}
+		if !passwordMatch {
+			if lockout == nil {
+				lockout = &LockoutEntry{}
+			}
+			lockout.recordFailure(config, now)
+			if err := b.setLockout(ctx, req.Storage, username, lockout); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if !passwordMatch {
+		return nil, logical.ErrorResponse("invalid username or password"), nil
+	}
+	if lockout != nil {
+		if err := b.deleteLockout(ctx, req.Storage, username); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Check for a CIDR match.
 	if len(user.TokenBoundCIDRs) > 0 {
 		if req.Connection == nil {
 			b.Logger().Warn("token bound CIDRs found but no connection information available for validation")
-			return nil, logical.ErrPermissionDenied
+			return nil, nil, logical.ErrPermissionDenied
 		}
 		if !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, user.TokenBoundCIDRs) {
-			return nil, logical.ErrPermissionDenied
+			return nil, nil, logical.ErrPermissionDenied
 		}
 	}
 
-	auth := &logical.Auth{
-		Metadata: map[string]string{
-			"username": username,
-		},
-		DisplayName: username,
-		Alias: &logical.Alias{
-			Name: username,
-		},
-	}
-	user.PopulateTokenAuth(auth)
-
-	return &logical.Response{
-		Auth: auth,
-	}, nil
+	return user, nil, nil
 }
 
 func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
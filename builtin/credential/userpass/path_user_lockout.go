@@ -0,0 +1,178 @@
+package userpass
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathUserLockout(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "users/" + framework.GenericNameRegex("username") + "/lockout$",
+		Fields: map[string]*framework.FieldSchema{
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Username for this user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathUserLockoutRead,
+		},
+
+		HelpSynopsis:    pathUserLockoutHelpSyn,
+		HelpDescription: pathUserLockoutHelpDesc,
+	}
+}
+
+func pathUserUnlock(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "users/" + framework.GenericNameRegex("username") + "/unlock$",
+		Fields: map[string]*framework.FieldSchema{
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Username for this user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathUserUnlock,
+		},
+
+		HelpSynopsis:    pathUserUnlockHelpSyn,
+		HelpDescription: pathUserUnlockHelpDesc,
+	}
+}
+
+// LockoutEntry tracks the failed login attempts of a user. It is stored apart
+// from the user so that failed logins do not rewrite the user entry.
+type LockoutEntry struct {
+	// FailedAttempts is the number of consecutive failed login attempts
+	// counted towards the lockout threshold
+	FailedAttempts int `json:"failed_attempts"`
+
+	// LastFailedTime is the time of the last failed login attempt
+	LastFailedTime time.Time `json:"last_failed_time"`
+
+	// LockedUntil is the time the current lockout of the user expires
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// locked returns whether the user is locked out at the given time
+func (l *LockoutEntry) locked(now time.Time) bool {
+	return now.Before(l.LockedUntil)
+}
+
+// recordFailure counts a failed login attempt and locks the user out when the
+// threshold is reached
+func (l *LockoutEntry) recordFailure(config *configEntry, now time.Time) {
+	if now.Sub(l.LastFailedTime) > config.LockoutCounterReset {
+		l.FailedAttempts = 0
+	}
+	l.FailedAttempts++
+	l.LastFailedTime = now
+
+	if l.FailedAttempts >= config.LockoutThreshold {
+		l.LockedUntil = now.Add(config.LockoutDuration)
+		l.FailedAttempts = 0
+	}
+}
+
+func (b *backend) userLock(username string) *locksutil.LockEntry {
+	return locksutil.LockForKey(b.userLocks, strings.ToLower(username))
+}
+
+func (b *backend) lockout(ctx context.Context, s logical.Storage, username string) (*LockoutEntry, error) {
+	entry, err := s.Get(ctx, "lockout/"+strings.ToLower(username))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result LockoutEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) setLockout(ctx context.Context, s logical.Storage, username string, lockoutEntry *LockoutEntry) error {
+	entry, err := logical.StorageEntryJSON("lockout/"+strings.ToLower(username), lockoutEntry)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+func (b *backend) deleteLockout(ctx context.Context, s logical.Storage, username string) error {
+	return s.Delete(ctx, "lockout/"+strings.ToLower(username))
+}
+
+func (b *backend) pathUserLockoutRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := strings.ToLower(d.Get("username").(string))
+
+	user, err := b.user(ctx, req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	lock := b.userLock(username)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	lockout, err := b.lockout(ctx, req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+	if lockout == nil {
+		lockout = &LockoutEntry{}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"locked":           lockout.locked(time.Now()),
+			"failed_attempts":  lockout.FailedAttempts,
+			"last_failed_time": lockout.LastFailedTime,
+			"locked_until":     lockout.LockedUntil,
+		},
+	}, nil
+}
+
+func (b *backend) pathUserUnlock(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := strings.ToLower(d.Get("username").(string))
+
+	lock := b.userLock(username)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return nil, b.deleteLockout(ctx, req.Storage, username)
+}
+
+const pathUserLockoutHelpSyn = `
+Read the failed login lockout status of a user.
+`
+
+const pathUserLockoutHelpDesc = `
+This endpoint returns whether the user is locked out, until when, and the
+number of consecutive failed login attempts counted towards the lockout
+threshold configured on the "config" endpoint.
+`
+
+const pathUserUnlockHelpSyn = `
+Unlock a user locked out after failed login attempts.
+`
+
+const pathUserUnlockHelpDesc = `
+This endpoint lifts the lockout of the user, if any, and resets the count of
+its failed login attempts.
+`
@@ -45,9 +45,9 @@ func (b *backend) pathUserPasswordUpdate(ctx context.Context, req *logical.Reque
 		return nil, fmt.Errorf("username does not exist")
 	}
 
-	userErr, intErr := b.updateUserPassword(req, d, userEntry)
+	userErr, intErr := b.updateUserPassword(ctx, req, d, userEntry)
 	if intErr != nil {
-		return nil, err
+		return nil, intErr
 	}
 	if userErr != nil {
 		return logical.ErrorResponse(userErr.Error()), logical.ErrInvalidRequest
@@ -56,11 +56,31 @@ func (b *backend) pathUserPasswordUpdate(ctx context.Context, req *logical.Reque
 	return nil, b.setUser(ctx, req.Storage, username, userEntry)
 }
 
-func (b *backend) updateUserPassword(req *logical.Request, d *framework.FieldData, userEntry *UserEntry) (error, error) {
-	password := d.Get("password").(string)
+func (b *backend) updateUserPassword(ctx context.Context, req *logical.Request, d *framework.FieldData, userEntry *UserEntry) (error, error) {
+	return b.setUserPassword(ctx, req.Storage, d.Get("password").(string), userEntry)
+}
+
+// setUserPassword checks the password against the password policy of the
+// mount, if any, and sets its hash on the user entry
+func (b *backend) setUserPassword(ctx context.Context, s logical.Storage, password string, userEntry *UserEntry) (error, error) {
 	if password == "" {
 		return fmt.Errorf("missing password"), nil
 	}
+
+	config, err := b.config(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if config.PasswordPolicy != "" {
+		validator, ok := b.System().(logical.PasswordPolicyValidator)
+		if !ok {
+			return nil, fmt.Errorf("password policies are not supported by this system")
+		}
+		if err := validator.ValidatePasswordFromPolicy(ctx, config.PasswordPolicy, password); err != nil {
+			return fmt.Errorf("password does not satisfy password policy %q: %w", config.PasswordPolicy, err), nil
+		}
+	}
+
 	// Generate a hash of the password
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -153,12 +153,17 @@ func (b *backend) pathUserList(ctx context.Context, req *logical.Request, d *fra
 }
 
 func (b *backend) pathUserDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	err := req.Storage.Delete(ctx, "user/"+strings.ToLower(d.Get("username").(string)))
+	username := strings.ToLower(d.Get("username").(string))
+	err := req.Storage.Delete(ctx, "user/"+username)
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	lock := b.userLock(username)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return nil, b.deleteLockout(ctx, req.Storage, username)
 }
 
 func (b *backend) pathUserRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -208,7 +213,7 @@ func (b *backend) userCreateUpdate(ctx context.Context, req *logical.Request, d
 	}
 
 	if _, ok := d.GetOk("password"); ok {
-		userErr, intErr := b.updateUserPassword(req, d, userEntry)
+		userErr, intErr := b.updateUserPassword(ctx, req, d, userEntry)
 		if intErr != nil {
 			return nil, intErr
 		}
@@ -32,6 +32,9 @@ type dbPluginInstance struct {
 	sync.RWMutex
 	database databaseVersionWrapper
 
+	// initResp is the response of the database to its initialization
+	initResp v5.InitializeResponse
+
 	id     string
 	name   string
 	closed bool
@@ -77,7 +80,9 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 			},
 			SealWrapStorage: []string{
 				"config/*",
+				"role/*",
 				"static-role/*",
+				"library/*",
 			},
 		},
 		Paths: framework.PathAppend(
@@ -88,12 +93,17 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 			},
 			pathListRoles(&b),
 			pathRoles(&b),
+			pathValidateRoles(&b),
 			pathCredsCreate(&b),
 			pathRotateRootCredentials(&b),
+			pathListLibrary(&b),
+			pathLibrary(&b),
+			pathLibraryCheckOut(&b),
 		),
 
 		Secrets: []*framework.Secret{
 			secretCreds(&b),
+			secretLibraryCreds(&b),
 		},
 		Clean:             b.clean,
 		Invalidate:        b.invalidate,
@@ -106,6 +116,7 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 	b.connections = make(map[string]*dbPluginInstance)
 
 	b.roleLocks = locksutil.CreateLocks()
+	b.libraryLocks = locksutil.CreateLocks()
 
 	return &b
 }
@@ -130,6 +141,10 @@ type databaseBackend struct {
 	// concurrent requests are not modifying the same role and possibly causing
 	// issues with the priority queue.
 	roleLocks []*locksutil.LockEntry
+
+	// libraryLocks serializes the check-outs and check-ins of the service
+	// accounts of each library set.
+	libraryLocks []*locksutil.LockEntry
 }
 
 func (b *databaseBackend) DatabaseConfig(ctx context.Context, s logical.Storage, name string) (*DatabaseConfig, error) {
@@ -269,7 +284,7 @@ func (b *databaseBackend) GetConnectionWithConfig(ctx context.Context, name stri
 		Config:           config.ConnectionDetails,
 		VerifyConnection: true,
 	}
-	_, err = dbw.Initialize(ctx, initReq)
+	initResp, err := dbw.Initialize(ctx, initReq)
 	if err != nil {
 		dbw.Close()
 		return nil, err
@@ -277,6 +292,7 @@ func (b *databaseBackend) GetConnectionWithConfig(ctx context.Context, name stri
 
 	dbi = &dbPluginInstance{
 		database: dbw,
+		initResp: initResp,
 		id:       id,
 		name:     name,
 	}
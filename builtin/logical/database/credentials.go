@@ -0,0 +1,312 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/template"
+	"github.com/mitchellh/mapstructure"
+)
+
+// passwordCredentialConfig is the credential_config of roles issuing
+// passwords
+type passwordCredentialConfig struct {
+	// PasswordPolicy overrides the password policy of the database
+	// connection
+	PasswordPolicy string `mapstructure:"password_policy"`
+}
+
+// rsaKeyCredentialConfig is the credential_config of roles issuing RSA
+// key pairs
+type rsaKeyCredentialConfig struct {
+	KeyBits int    `mapstructure:"key_bits"`
+	Format  string `mapstructure:"format"`
+}
+
+// clientCertificateCredentialConfig is the credential_config of roles
+// issuing client certificates signed by a CA held in the role
+type clientCertificateCredentialConfig struct {
+	// CommonNameTemplate generates the common name of the certificate,
+	// which is handed to the database plugin as the subject of the user
+	CommonNameTemplate string `mapstructure:"common_name_template"`
+	CACert             string `mapstructure:"ca_cert"`
+	CAPrivateKey       string `mapstructure:"ca_private_key"`
+	KeyType            string `mapstructure:"key_type"`
+	KeyBits            int    `mapstructure:"key_bits"`
+}
+
+// credential is the credential issued for a new user. The public material
+// is sent to the database plugin, the rest is returned to the client.
+type credential struct {
+	password  string
+	publicKey []byte
+	subject   string
+	respData  map[string]interface{}
+}
+
+func decodeCredentialConfig(raw map[string]string, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+		Result:           out,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(raw)
+}
+
+func parsePasswordCredentialConfig(raw map[string]string) (*passwordCredentialConfig, error) {
+	config := &passwordCredentialConfig{}
+	if err := decodeCredentialConfig(raw, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func parseRSAKeyCredentialConfig(raw map[string]string) (*rsaKeyCredentialConfig, error) {
+	config := &rsaKeyCredentialConfig{
+		KeyBits: 2048,
+		Format:  "pkcs8",
+	}
+	if err := decodeCredentialConfig(raw, config); err != nil {
+		return nil, err
+	}
+	switch config.KeyBits {
+	case 2048, 3072, 4096:
+	default:
+		return nil, fmt.Errorf("invalid key_bits %d, must be 2048, 3072 or 4096", config.KeyBits)
+	}
+	if config.Format != "pkcs8" {
+		return nil, fmt.Errorf("invalid format %q, must be pkcs8", config.Format)
+	}
+	return config, nil
+}
+
+func parseClientCertificateCredentialConfig(raw map[string]string) (*clientCertificateCredentialConfig, error) {
+	config := &clientCertificateCredentialConfig{
+		KeyType: "rsa",
+	}
+	if err := decodeCredentialConfig(raw, config); err != nil {
+		return nil, err
+	}
+
+	if config.CommonNameTemplate == "" {
+		return nil, fmt.Errorf("missing common_name_template")
+	}
+	if _, err := template.NewTemplate(template.Template(config.CommonNameTemplate)); err != nil {
+		return nil, errwrap.Wrapf("invalid common_name_template: {{err}}", err)
+	}
+
+	if config.KeyBits == 0 {
+		switch config.KeyType {
+		case "ec":
+			config.KeyBits = 256
+		default:
+			config.KeyBits = 2048
+		}
+	}
+	switch config.KeyType {
+	case "rsa", "ec":
+	default:
+		return nil, fmt.Errorf("invalid key_type %q, must be rsa or ec", config.KeyType)
+	}
+	if err := certutil.ValidateKeyTypeLength(config.KeyType, config.KeyBits); err != nil {
+		return nil, err
+	}
+
+	if config.CACert == "" || config.CAPrivateKey == "" {
+		return nil, fmt.Errorf("ca_cert and ca_private_key are required")
+	}
+	if _, err := config.signingBundle(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// validateCredentialConfig checks the credential_config of a role against
+// its credential type
+func validateCredentialConfig(credentialType v5.CredentialType, raw map[string]string) error {
+	var err error
+	switch credentialType {
+	case v5.CredentialTypePassword:
+		_, err = parsePasswordCredentialConfig(raw)
+	case v5.CredentialTypeRSAPrivateKey:
+		_, err = parseRSAKeyCredentialConfig(raw)
+	case v5.CredentialTypeClientCertificate:
+		_, err = parseClientCertificateCredentialConfig(raw)
+	default:
+		err = fmt.Errorf("unsupported credential type %s", credentialType)
+	}
+	return err
+}
+
+// signingBundle parses the CA of the role
+func (c *clientCertificateCredentialConfig) signingBundle() (*certutil.CAInfoBundle, error) {
+	parsed, err := certutil.ParsePEMBundle(strings.Join([]string{c.CACert, c.CAPrivateKey}, "\n"))
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid ca_cert or ca_private_key: {{err}}", err)
+	}
+	if parsed.Certificate == nil || parsed.PrivateKey == nil {
+		return nil, fmt.Errorf("ca_cert and ca_private_key must hold a certificate and its private key")
+	}
+	if !parsed.Certificate.IsCA {
+		return nil, fmt.Errorf("ca_cert is not a CA certificate")
+	}
+	if err := parsed.Verify(); err != nil {
+		return nil, errwrap.Wrapf("ca_private_key does not match ca_cert: {{err}}", err)
+	}
+	return &certutil.CAInfoBundle{
+		ParsedCertBundle: *parsed,
+		URLs:             &certutil.URLEntries{},
+	}, nil
+}
+
+// passwordPolicy returns the password policy of the passwords of the role,
+// which is the one of the database connection unless the role overrides it
+func (r *roleEntry) passwordPolicy(dbConfig *DatabaseConfig) (string, error) {
+	if r.CredentialType != v5.CredentialTypePassword {
+		return dbConfig.PasswordPolicy, nil
+	}
+	config, err := parsePasswordCredentialConfig(r.CredentialConfig)
+	if err != nil {
+		return "", err
+	}
+	if config.PasswordPolicy != "" {
+		return config.PasswordPolicy, nil
+	}
+	return dbConfig.PasswordPolicy, nil
+}
+
+// generateCredential issues the credential of a new user of the role.
+// Certificates are valid until the given expiration.
+func (b *databaseBackend) generateCredential(ctx context.Context, dbi *dbPluginInstance, dbConfig *DatabaseConfig, role *roleEntry, usernameConfig v5.UsernameMetadata, expiration time.Time) (*credential, error) {
+	switch role.CredentialType {
+	case v5.CredentialTypePassword:
+		passwordPolicy, err := role.passwordPolicy(dbConfig)
+		if err != nil {
+			return nil, err
+		}
+		password, err := dbi.database.GeneratePassword(ctx, b.System(), passwordPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate password: %w", err)
+		}
+		return &credential{
+			password: password,
+			respData: map[string]interface{}{},
+		}, nil
+
+	case v5.CredentialTypeRSAPrivateKey:
+		config, err := parseRSAKeyCredentialConfig(role.CredentialConfig)
+		if err != nil {
+			return nil, err
+		}
+		return generateRSAKeyCredential(config)
+
+	case v5.CredentialTypeClientCertificate:
+		config, err := parseClientCertificateCredentialConfig(role.CredentialConfig)
+		if err != nil {
+			return nil, err
+		}
+		return generateClientCertificateCredential(config, usernameConfig, expiration)
+
+	default:
+		return nil, fmt.Errorf("unsupported credential type %s", role.CredentialType)
+	}
+}
+
+func generateRSAKeyCredential(config *rsaKeyCredentialConfig) (*credential, error) {
+	key, err := rsa.GenerateKey(rand.Reader, config.KeyBits)
+	if err != nil {
+		return nil, errwrap.Wrapf("unable to generate RSA key: {{err}}", err)
+	}
+
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential{
+		publicKey: pem.EncodeToMemory(&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: publicKey,
+		}),
+		respData: map[string]interface{}{
+			"rsa_private_key": string(pem.EncodeToMemory(&pem.Block{
+				Type:  "PRIVATE KEY",
+				Bytes: privateKey,
+			})),
+		},
+	}, nil
+}
+
+func generateClientCertificateCredential(config *clientCertificateCredentialConfig, usernameConfig v5.UsernameMetadata, expiration time.Time) (*credential, error) {
+	signingBundle, err := config.signingBundle()
+	if err != nil {
+		return nil, err
+	}
+
+	cnTemplate, err := template.NewTemplate(template.Template(config.CommonNameTemplate))
+	if err != nil {
+		return nil, err
+	}
+	commonName, err := cnTemplate.Generate(usernameConfig)
+	if err != nil {
+		return nil, errwrap.Wrapf("unable to generate common name: {{err}}", err)
+	}
+	if commonName == "" {
+		return nil, fmt.Errorf("common_name_template generated an empty common name")
+	}
+
+	// The certificate cannot outlive its CA
+	if caNotAfter := signingBundle.Certificate.NotAfter; expiration.After(caNotAfter) {
+		expiration = caNotAfter
+	}
+
+	parsed, err := certutil.CreateCertificate(&certutil.CreationBundle{
+		Params: &certutil.CreationParameters{
+			Subject: pkix.Name{
+				CommonName: commonName,
+			},
+			KeyType:     config.KeyType,
+			KeyBits:     config.KeyBits,
+			NotAfter:    expiration,
+			KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			ExtKeyUsage: certutil.ClientAuthExtKeyUsage,
+			URLs:        signingBundle.URLs,
+		},
+		SigningBundle: signingBundle,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("unable to issue client certificate: {{err}}", err)
+	}
+	bundle, err := parsed.ToCertBundle()
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential{
+		subject: commonName,
+		respData: map[string]interface{}{
+			"client_certificate": bundle.Certificate,
+			"issuing_ca":         strings.TrimSpace(config.CACert),
+			"private_key":        bundle.PrivateKey,
+			"private_key_type":   string(bundle.PrivateKeyType),
+			"serial_number":      bundle.SerialNumber,
+		},
+	}, nil
+}
@@ -0,0 +1,306 @@
+package database
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/mock"
+)
+
+// testCA returns a self-signed CA certificate and its private key
+func testCA(t *testing.T) (string, string) {
+	t.Helper()
+	parsed, err := certutil.CreateCertificate(&certutil.CreationBundle{
+		Params: &certutil.CreationParameters{
+			Subject: pkix.Name{
+				CommonName: "Database CA",
+			},
+			KeyType:  "ec",
+			KeyBits:  256,
+			NotAfter: time.Now().Add(24 * time.Hour),
+			URLs:     &certutil.URLEntries{},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := parsed.ToCertBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundle.Certificate, bundle.PrivateKey
+}
+
+func TestCredential_RSAPrivateKey(t *testing.T) {
+	if _, err := parseRSAKeyCredentialConfig(map[string]string{"key_bits": "1024"}); err == nil {
+		t.Fatal("expected error for 1024 bit key")
+	}
+	if _, err := parseRSAKeyCredentialConfig(map[string]string{"bits": "2048"}); err == nil {
+		t.Fatal("expected error for unknown setting")
+	}
+
+	config, err := parseRSAKeyCredentialConfig(map[string]string{"key_bits": "3072"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred, err := generateRSAKeyCredential(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(cred.publicKey)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		t.Fatalf("bad public key: %s", cred.publicKey)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ = pem.Decode([]byte(cred.respData["rsa_private_key"].(string)))
+	if block == nil || block.Type != "PRIVATE KEY" {
+		t.Fatalf("bad private key: %#v", cred.respData)
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := privateKey.(*rsa.PrivateKey)
+	if key.N.BitLen() != 3072 || key.N.Cmp(publicKey.(*rsa.PublicKey).N) != 0 {
+		t.Fatal("private key does not match the public key")
+	}
+}
+
+func TestCredential_ClientCertificate(t *testing.T) {
+	caCert, caKey := testCA(t)
+
+	raw := map[string]string{
+		"common_name_template": "{{.RoleName}}-{{.DisplayName}}",
+		"ca_cert":              caCert,
+		"ca_private_key":       caKey,
+	}
+	if _, err := parseClientCertificateCredentialConfig(map[string]string{
+		"ca_cert":        caCert,
+		"ca_private_key": caKey,
+	}); err == nil {
+		t.Fatal("expected error for missing common_name_template")
+	}
+	if _, err := parseClientCertificateCredentialConfig(map[string]string{
+		"common_name_template": "{{.RoleName}}",
+		"ca_cert":              caCert,
+	}); err == nil {
+		t.Fatal("expected error for missing ca_private_key")
+	}
+
+	config, err := parseClientCertificateCredentialConfig(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiration := time.Now().Add(time.Hour).Truncate(time.Second)
+	cred, err := generateClientCertificateCredential(config, v5.UsernameMetadata{
+		DisplayName: "token",
+		RoleName:    "reader",
+	}, expiration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.subject != "reader-token" {
+		t.Fatalf("bad subject: %s", cred.subject)
+	}
+	if cred.respData["private_key_type"] != "rsa" {
+		t.Fatalf("bad private key type: %#v", cred.respData)
+	}
+
+	parsed, err := certutil.ParsePEMBundle(strings.Join([]string{
+		cred.respData["client_certificate"].(string),
+		cred.respData["private_key"].(string),
+	}, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := parsed.Certificate
+	if cert.Subject.CommonName != "reader-token" || !cert.NotAfter.Equal(expiration) {
+		t.Fatalf("bad certificate: %s until %s", cert.Subject, cert.NotAfter)
+	}
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageClientAuth {
+		t.Fatalf("bad key usage: %v", cert.ExtKeyUsage)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM([]byte(caCert))
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The certificate does not outlive its CA
+	cred, err = generateClientCertificateCredential(config, v5.UsernameMetadata{}, time.Now().Add(48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode([]byte(cred.respData["client_certificate"].(string)))
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.NotAfter.After(time.Now().Add(25 * time.Hour)) {
+		t.Fatalf("certificate outlives its CA: %s", cert.NotAfter)
+	}
+}
+
+func TestBackend_RoleCredentialType(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Cleanup(context.Background())
+
+	request := func(op logical.Operation, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      "roles/test",
+			Data:      data,
+		})
+	}
+	mustFail := func(data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(logical.CreateOperation, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: resp: %#v", resp)
+		}
+	}
+
+	mustFail(map[string]interface{}{
+		"db_name":         "mockv5",
+		"credential_type": "ssh_key",
+	})
+	mustFail(map[string]interface{}{
+		"db_name":           "mockv5",
+		"credential_type":   "rsa_private_key",
+		"credential_config": map[string]interface{}{"key_bits": "1024"},
+	})
+	mustFail(map[string]interface{}{
+		"db_name":           "mockv5",
+		"credential_type":   "client_certificate",
+		"credential_config": map[string]interface{}{"common_name_template": "{{.RoleName}}"},
+	})
+
+	// Roles issue passwords by default
+	resp, err := request(logical.CreateOperation, map[string]interface{}{
+		"db_name": "mockv5",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	resp, err = request(logical.ReadOperation, nil)
+	if err != nil || resp == nil {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["credential_type"] != "password" {
+		t.Fatalf("bad credential type: %#v", resp.Data)
+	}
+
+	caCert, caKey := testCA(t)
+	resp, err = request(logical.UpdateOperation, map[string]interface{}{
+		"credential_type": "client_certificate",
+		"credential_config": map[string]interface{}{
+			"common_name_template": "{{.RoleName}}",
+			"ca_cert":              caCert,
+			"ca_private_key":       caKey,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	resp, err = request(logical.ReadOperation, nil)
+	if err != nil || resp == nil {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	credentialConfig := resp.Data["credential_config"].(map[string]string)
+	if resp.Data["credential_type"] != "client_certificate" || credentialConfig["ca_cert"] != caCert {
+		t.Fatalf("bad role: %#v", resp.Data)
+	}
+	if _, ok := credentialConfig["ca_private_key"]; ok {
+		t.Fatal("CA private key was returned")
+	}
+}
+
+func TestBackend_credsCreate_unsupportedCredentialType(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	lb, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := lb.(*databaseBackend)
+	defer b.Cleanup(context.Background())
+
+	if err := storeConfig(context.Background(), config.StorageView, "plugin-db", &DatabaseConfig{
+		PluginName:   "mockv5",
+		AllowedRoles: []string{"*"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   config.StorageView,
+		Operation: logical.CreateOperation,
+		Path:      "roles/rsa",
+		Data: map[string]interface{}{
+			"db_name":             "plugin-db",
+			"creation_statements": []string{"create"},
+			"credential_type":     "rsa_private_key",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	readCreds := func() (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: logical.ReadOperation,
+			Path:      "creds/rsa",
+		})
+	}
+
+	// The plugin would ignore the credential type, so no user is created
+	mockDB := new(mockNewDatabase)
+	mockDB.On("Close").Return(nil)
+	b.connections["plugin-db"] = &dbPluginInstance{
+		database: databaseVersionWrapper{v5: mockDB},
+		name:     "plugin-db",
+	}
+	resp, err = readCreds()
+	if err != nil || resp == nil || !strings.Contains(resp.Error().Error(), "does not support credential type rsa_private_key") {
+		t.Fatalf("expected unsupported credential type: err: %v resp: %#v", err, resp)
+	}
+	mockDB.AssertNotCalled(t, "NewUser", mock.Anything, mock.Anything)
+
+	mockDB.On("NewUser", mock.Anything, mock.Anything).
+		Return(v5.NewUserResponse{Username: "rsa-user"}, nil)
+	b.connections["plugin-db"].initResp = v5.InitializeResponse{
+		SupportedCredentialTypes: []v5.CredentialType{v5.CredentialTypeRSAPrivateKey},
+	}
+	resp, err = readCreds()
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["username"] != "rsa-user" || resp.Data["rsa_private_key"] == nil {
+		t.Fatalf("bad credentials: %#v", resp.Data)
+	}
+	mockDB.AssertNumberOfCalls(t, "NewUser", 1)
+}
@@ -45,6 +45,18 @@ func (m *mockNewDatabase) Close() error {
 	return args.Error(0)
 }
 
+var _ v5.StatementValidator = &mockValidatingDatabase{}
+
+// mockValidatingDatabase is a mockNewDatabase supporting statement validation
+type mockValidatingDatabase struct {
+	mockNewDatabase
+}
+
+func (m *mockValidatingDatabase) ValidateStatements(ctx context.Context, req v5.ValidateStatementsRequest) (v5.ValidateStatementsResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(v5.ValidateStatementsResponse), args.Error(1)
+}
+
 var _ v4.Database = &mockLegacyDatabase{}
 
 type mockLegacyDatabase struct {
@@ -338,6 +338,7 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 
 		b.connections[name] = &dbPluginInstance{
 			database: dbw,
+			initResp: initResp,
 			name:     name,
 			id:       id,
 		}
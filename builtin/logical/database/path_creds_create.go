@@ -81,6 +81,12 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 		dbi.RLock()
 		defer dbi.RUnlock()
 
+		// Plugins which predate credential types would ignore the type and
+		// create a user without credentials
+		if !dbi.initResp.SupportsCredentialType(role.CredentialType) {
+			return logical.ErrorResponse(fmt.Sprintf("the %s plugin of database %q does not support credential type %s", dbConfig.PluginName, role.DBName, role.CredentialType)), nil
+		}
+
 		ttl, _, err := framework.CalculateTTL(b.System(), 0, role.DefaultTTL, 0, role.MaxTTL, 0, time.Time{})
 		if err != nil {
 			return nil, err
@@ -90,24 +96,37 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 		// to ensure the database credential does not expire before the lease
 		expiration = expiration.Add(5 * time.Second)
 
-		password, err := dbi.database.GeneratePassword(ctx, b.System(), dbConfig.PasswordPolicy)
+		usernameConfig := v5.UsernameMetadata{
+			DisplayName: req.DisplayName,
+			RoleName:    name,
+		}
+
+		// Certificates are valid for as long as the lease can be renewed
+		maxTTL := role.MaxTTL
+		if maxTTL == 0 || maxTTL > b.System().MaxLeaseTTL() {
+			maxTTL = b.System().MaxLeaseTTL()
+		}
+		if maxTTL < ttl {
+			maxTTL = ttl
+		}
+		cred, err := b.generateCredential(ctx, dbi, dbConfig, role, usernameConfig, time.Now().Add(maxTTL).Add(5*time.Second))
 		if err != nil {
-			return nil, fmt.Errorf("unable to generate password: %w", err)
+			return nil, err
 		}
 
 		newUserReq := v5.NewUserRequest{
-			UsernameConfig: v5.UsernameMetadata{
-				DisplayName: req.DisplayName,
-				RoleName:    name,
-			},
+			UsernameConfig: usernameConfig,
 			Statements: v5.Statements{
 				Commands: role.Statements.Creation,
 			},
 			RollbackStatements: v5.Statements{
 				Commands: role.Statements.Rollback,
 			},
-			Password:   password,
-			Expiration: expiration,
+			CredentialType: role.CredentialType,
+			Password:       cred.password,
+			PublicKey:      cred.publicKey,
+			Subject:        cred.subject,
+			Expiration:     expiration,
 		}
 
 		// Overwriting the password in the event this is a legacy database plugin and the provided password is ignored
@@ -117,9 +136,10 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 			return nil, err
 		}
 
-		respData := map[string]interface{}{
-			"username": newUserResp.Username,
-			"password": password,
+		respData := cred.respData
+		respData["username"] = newUserResp.Username
+		if role.CredentialType == v5.CredentialTypePassword {
+			respData["password"] = password
 		}
 		internal := map[string]interface{}{
 			"username":              newUserResp.Username,
@@ -157,14 +177,24 @@ func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
 			return nil, fmt.Errorf("%q is not an allowed role", name)
 		}
 
+		respData := map[string]interface{}{
+			"username":            role.StaticAccount.Username,
+			"password":            role.StaticAccount.Password,
+			"ttl":                 role.StaticAccount.PasswordTTL().Seconds(),
+			"last_vault_rotation": role.StaticAccount.LastVaultRotation,
+			"next_vault_rotation": role.StaticAccount.NextRotationTime(),
+		}
+		if role.StaticAccount.usesRotationSchedule() {
+			respData["rotation_schedule"] = role.StaticAccount.RotationSchedule
+			if role.StaticAccount.RotationWindow > 0 {
+				respData["rotation_window"] = role.StaticAccount.RotationWindow.Seconds()
+			}
+		} else {
+			respData["rotation_period"] = role.StaticAccount.RotationPeriod.Seconds()
+		}
+
 		return &logical.Response{
-			Data: map[string]interface{}{
-				"username":            role.StaticAccount.Username,
-				"password":            role.StaticAccount.Password,
-				"ttl":                 role.StaticAccount.PasswordTTL().Seconds(),
-				"rotation_period":     role.StaticAccount.RotationPeriod.Seconds(),
-				"last_vault_rotation": role.StaticAccount.LastVaultRotation,
-			},
+			Data: respData,
 		}, nil
 	}
 }
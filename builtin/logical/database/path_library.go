@@ -0,0 +1,411 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const databaseLibraryPath = "library/"
+
+// librarySet is a pool of existing database accounts that are checked out
+// exclusively, and whose passwords are rotated on check-in
+type librarySet struct {
+	DBName                    string                     `json:"db_name"`
+	ServiceAccountNames       []string                   `json:"service_account_names"`
+	TTL                       time.Duration              `json:"ttl"`
+	MaxTTL                    time.Duration              `json:"max_ttl"`
+	DisableCheckInEnforcement bool                       `json:"disable_check_in_enforcement"`
+	RotationStatements        []string                   `json:"rotation_statements"`
+	Accounts                  map[string]*libraryAccount `json:"accounts"`
+}
+
+// libraryAccount is the state of a service account of a library set
+type libraryAccount struct {
+	Password          string    `json:"password"`
+	LastVaultRotation time.Time `json:"last_vault_rotation"`
+
+	// CheckOutID identifies the current check-out, so that the lease of an
+	// earlier check-out does not check the account in
+	CheckOutID          string `json:"check_out_id,omitempty"`
+	BorrowerEntityID    string `json:"borrower_entity_id,omitempty"`
+	BorrowerClientToken string `json:"borrower_client_token,omitempty"`
+}
+
+func (a *libraryAccount) available() bool {
+	return a.CheckOutID == ""
+}
+
+func pathListLibrary(b *databaseBackend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "library/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.pathLibraryList,
+			},
+
+			HelpSynopsis:    pathLibraryHelpSyn,
+			HelpDescription: pathLibraryHelpDesc,
+		},
+	}
+}
+
+func pathLibrary(b *databaseBackend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "library/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the library set.",
+				},
+				"db_name": {
+					Type:        framework.TypeString,
+					Description: "Name of the database this set's accounts belong to.",
+				},
+				"service_account_names": {
+					Type: framework.TypeCommaStringSlice,
+					Description: `The usernames of the existing database accounts
+	that can be checked out. Their passwords are rotated when they are added
+	to the set.`,
+				},
+				"ttl": {
+					Type: framework.TypeDurationSecond,
+					Description: `Default lease of a check-out. Defaults to the
+	system/mount default TTL time.`,
+				},
+				"max_ttl": {
+					Type: framework.TypeDurationSecond,
+					Description: `Maximum lease of a check-out, including its
+	renewals. Defaults to the system/mount max TTL time.`,
+				},
+				"disable_check_in_enforcement": {
+					Type: framework.TypeBool,
+					Description: `Allow any client to check in the accounts of
+	the set, instead of only the entity or token that checked them out.`,
+				},
+				"rotation_statements": {
+					Type: framework.TypeStringSlice,
+					Description: `Specifies the database statements to be executed to
+	rotate the accounts credentials. Not every plugin type will support
+	this functionality. See the plugin's API page for more information on
+	support and formatting for this parameter.`,
+				},
+			},
+			ExistenceCheck: b.pathLibraryExistenceCheck,
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathLibraryRead,
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryCreateUpdate,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryCreateUpdate,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryDelete,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis:    pathLibraryHelpSyn,
+			HelpDescription: pathLibraryHelpDesc,
+		},
+	}
+}
+
+func (b *databaseBackend) librarySet(ctx context.Context, s logical.Storage, name string) (*librarySet, error) {
+	entry, err := s.Get(ctx, databaseLibraryPath+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var set librarySet
+	if err := entry.DecodeJSON(&set); err != nil {
+		return nil, err
+	}
+	if set.Accounts == nil {
+		set.Accounts = make(map[string]*libraryAccount)
+	}
+	return &set, nil
+}
+
+func storeLibrarySet(ctx context.Context, s logical.Storage, name string, set *librarySet) error {
+	entry, err := logical.StorageEntryJSON(databaseLibraryPath+name, set)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func (b *databaseBackend) pathLibraryExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	set, err := b.librarySet(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return set != nil, nil
+}
+
+func (b *databaseBackend) pathLibraryList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, databaseLibraryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *databaseBackend) pathLibraryRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	set, err := b.librarySet(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"db_name":                      set.DBName,
+			"service_account_names":        set.ServiceAccountNames,
+			"ttl":                          set.TTL.Seconds(),
+			"max_ttl":                      set.MaxTTL.Seconds(),
+			"disable_check_in_enforcement": set.DisableCheckInEnforcement,
+			"rotation_statements":          set.RotationStatements,
+		},
+	}, nil
+}
+
+func (b *databaseBackend) pathLibraryCreateUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.libraryLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	set, err := b.librarySet(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		if req.Operation == logical.UpdateOperation {
+			return nil, fmt.Errorf("no library set found with name %q", name)
+		}
+		set = &librarySet{
+			Accounts: make(map[string]*libraryAccount),
+		}
+	}
+
+	if dbNameRaw, ok := data.GetOk("db_name"); ok {
+		dbName := dbNameRaw.(string)
+		if dbName != set.DBName && len(set.Accounts) > 0 {
+			return logical.ErrorResponse("cannot update the database of a set with service accounts"), nil
+		}
+		set.DBName = dbName
+	}
+	if set.DBName == "" {
+		return logical.ErrorResponse("empty database name attribute"), nil
+	}
+
+	if ttlRaw, ok := data.GetOk("ttl"); ok {
+		set.TTL = time.Duration(ttlRaw.(int)) * time.Second
+	}
+	if maxTTLRaw, ok := data.GetOk("max_ttl"); ok {
+		set.MaxTTL = time.Duration(maxTTLRaw.(int)) * time.Second
+	}
+	if set.MaxTTL != 0 && set.TTL > set.MaxTTL {
+		return logical.ErrorResponse("ttl cannot be greater than max_ttl"), nil
+	}
+	if disableRaw, ok := data.GetOk("disable_check_in_enforcement"); ok {
+		set.DisableCheckInEnforcement = disableRaw.(bool)
+	}
+	if rotationRaw, ok := data.GetOk("rotation_statements"); ok {
+		set.RotationStatements = rotationRaw.([]string)
+	}
+
+	serviceAccountNames := set.ServiceAccountNames
+	if namesRaw, ok := data.GetOk("service_account_names"); ok {
+		serviceAccountNames = strutil.RemoveDuplicates(namesRaw.([]string), false)
+	}
+	if len(serviceAccountNames) == 0 {
+		return logical.ErrorResponse("at least one service account name is required"), nil
+	}
+
+	// Service accounts can be removed from the set only once they are
+	// checked in
+	for accountName, account := range set.Accounts {
+		if !strutil.StrListContains(serviceAccountNames, accountName) && !account.available() {
+			return logical.ErrorResponse(fmt.Sprintf("service account %q is checked out and cannot be removed", accountName)), nil
+		}
+	}
+
+	// A service account belongs to a single set of its database, since the
+	// sets rotate its password independently
+	setNames, err := req.Storage.List(ctx, databaseLibraryPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, setName := range setNames {
+		if setName == name {
+			continue
+		}
+		other, err := b.librarySet(ctx, req.Storage, setName)
+		if err != nil {
+			return nil, err
+		}
+		if other == nil || other.DBName != set.DBName {
+			continue
+		}
+		for _, accountName := range serviceAccountNames {
+			if strutil.StrListContains(other.ServiceAccountNames, accountName) {
+				return logical.ErrorResponse(fmt.Sprintf("service account %q already belongs to library set %q", accountName, setName)), nil
+			}
+		}
+	}
+
+	for accountName := range set.Accounts {
+		if !strutil.StrListContains(serviceAccountNames, accountName) {
+			delete(set.Accounts, accountName)
+		}
+	}
+	set.ServiceAccountNames = serviceAccountNames
+
+	// Take ownership of the new service accounts by rotating their
+	// passwords. The set is stored after each rotation so that no password
+	// is lost if a later one fails.
+	for _, accountName := range serviceAccountNames {
+		if _, ok := set.Accounts[accountName]; ok {
+			continue
+		}
+		account := &libraryAccount{}
+		if err := b.rotateLibraryAccount(ctx, req.Storage, name, set, accountName, account); err != nil {
+			return nil, err
+		}
+		set.Accounts[accountName] = account
+		if err := storeLibrarySet(ctx, req.Storage, name, set); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := storeLibrarySet(ctx, req.Storage, name, set); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *databaseBackend) pathLibraryDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.libraryLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	set, err := b.librarySet(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	var checkedOut []string
+	for accountName, account := range set.Accounts {
+		if !account.available() {
+			checkedOut = append(checkedOut, accountName)
+		}
+	}
+	if len(checkedOut) > 0 {
+		sort.Strings(checkedOut)
+		return logical.ErrorResponse(fmt.Sprintf("service accounts %q are checked out and must be checked in before deleting the set", checkedOut)), nil
+	}
+
+	if err := req.Storage.Delete(ctx, databaseLibraryPath+name); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// rotateLibraryAccount sets a new password for a service account of a
+// library set and records it in the account, which the caller must store.
+// The caller must hold the lock of the set.
+func (b *databaseBackend) rotateLibraryAccount(ctx context.Context, s logical.Storage, name string, set *librarySet, accountName string, account *libraryAccount) error {
+	dbConfig, err := b.DatabaseConfig(ctx, s, set.DBName)
+	if err != nil {
+		return err
+	}
+
+	// If the set name isn't in the database's allowed roles, send back a
+	// permission denied.
+	if !strutil.StrListContains(dbConfig.AllowedRoles, "*") && !strutil.StrListContainsGlob(dbConfig.AllowedRoles, name) {
+		return fmt.Errorf("%q is not an allowed role", name)
+	}
+
+	dbi, err := b.GetConnection(ctx, s, set.DBName)
+	if err != nil {
+		return err
+	}
+
+	dbi.RLock()
+	defer dbi.RUnlock()
+
+	newPassword, err := dbi.database.GeneratePassword(ctx, b.System(), dbConfig.PasswordPolicy)
+	if err != nil {
+		return err
+	}
+
+	updateReq := v5.UpdateUserRequest{
+		Username: accountName,
+		Password: &v5.ChangePassword{
+			NewPassword: newPassword,
+			Statements: v5.Statements{
+				Commands: set.RotationStatements,
+			},
+		},
+	}
+	_, err = dbi.database.UpdateUser(ctx, updateReq, false)
+	if err != nil {
+		b.CloseIfShutdown(dbi, err)
+		return errwrap.Wrapf(fmt.Sprintf("error setting credentials of service account %q: {{err}}", accountName), err)
+	}
+
+	account.Password = newPassword
+	account.LastVaultRotation = time.Now()
+	return nil
+}
+
+const pathLibraryHelpSyn = `
+Manage the library sets of service accounts that can be checked out.
+`
+
+const pathLibraryHelpDesc = `
+This path lets you manage library sets, which are pools of existing database
+accounts. Instead of creating users, Vault hands out one of the accounts of a
+set exclusively until it is checked in, and rotates its password on check-in.
+
+The "service_account_names" parameter lists the usernames of the accounts of
+the set. Vault rotates the password of each account when it is added to the
+set, so the accounts must exist in the database beforehand. An account can
+belong to a single set of its database, and can be removed from the set only
+while it is checked in.
+
+The "ttl" and "max_ttl" parameters bound the leases of the check-outs. When a
+lease expires or is revoked, the account is checked in automatically.
+`
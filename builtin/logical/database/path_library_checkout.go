@@ -0,0 +1,328 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathLibraryCheckOut(b *databaseBackend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "library/" + framework.GenericNameRegex("name") + "/check-out",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the library set.",
+				},
+				"ttl": {
+					Type: framework.TypeDurationSecond,
+					Description: `The lease of the check-out. Defaults to the ttl
+	of the set, and cannot exceed its max_ttl.`,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryCheckOut,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis:    pathLibraryCheckOutHelpSyn,
+			HelpDescription: pathLibraryCheckOutHelpDesc,
+		},
+		&framework.Path{
+			Pattern: "library/manage/" + framework.GenericNameRegex("name") + "/check-in",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the library set.",
+				},
+				"service_account_names": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "The service accounts to check in.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryManageCheckIn,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis:    pathLibraryCheckInHelpSyn,
+			HelpDescription: pathLibraryCheckInHelpDesc,
+		},
+		&framework.Path{
+			Pattern: "library/" + framework.GenericNameRegex("name") + "/check-in",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the library set.",
+				},
+				"service_account_names": {
+					Type: framework.TypeCommaStringSlice,
+					Description: `The service accounts to check in. May be
+	omitted if the caller has checked out a single account of the set.`,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                    b.pathLibraryCheckIn,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis:    pathLibraryCheckInHelpSyn,
+			HelpDescription: pathLibraryCheckInHelpDesc,
+		},
+		&framework.Path{
+			Pattern: "library/" + framework.GenericNameRegex("name") + "/status",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the library set.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.pathLibraryStatus,
+			},
+
+			HelpSynopsis:    pathLibraryStatusHelpSyn,
+			HelpDescription: pathLibraryStatusHelpDesc,
+		},
+	}
+}
+
+func (b *databaseBackend) pathLibraryCheckOut(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.libraryLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	set, err := b.librarySet(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return logical.ErrorResponse("unknown library set: %s", name), nil
+	}
+
+	ttl := set.TTL
+	if ttlRaw, ok := data.GetOk("ttl"); ok {
+		ttl = time.Duration(ttlRaw.(int)) * time.Second
+		if set.MaxTTL != 0 && ttl > set.MaxTTL {
+			ttl = set.MaxTTL
+		}
+	}
+
+	// Accounts are handed out in the order of the set
+	var accountName string
+	for _, serviceAccountName := range set.ServiceAccountNames {
+		if account, ok := set.Accounts[serviceAccountName]; ok && account.available() {
+			accountName = serviceAccountName
+			break
+		}
+	}
+	if accountName == "" {
+		return logical.ErrorResponse("no service accounts available for check-out"), nil
+	}
+
+	checkOutID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	account := set.Accounts[accountName]
+	account.CheckOutID = checkOutID
+	account.BorrowerEntityID = req.EntityID
+	account.BorrowerClientToken = req.ClientToken
+	if err := storeLibrarySet(ctx, req.Storage, name, set); err != nil {
+		return nil, err
+	}
+
+	respData := map[string]interface{}{
+		"service_account_name": accountName,
+		"password":             account.Password,
+	}
+	internal := map[string]interface{}{
+		"set_name":             name,
+		"service_account_name": accountName,
+		"check_out_id":         checkOutID,
+	}
+	resp := b.Secret(SecretLibraryCredsType).Response(respData, internal)
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = set.MaxTTL
+	return resp, nil
+}
+
+func (b *databaseBackend) pathLibraryCheckIn(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return b.checkIn(ctx, req, data, false)
+}
+
+func (b *databaseBackend) pathLibraryManageCheckIn(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return b.checkIn(ctx, req, data, true)
+}
+
+// checkIn rotates the passwords of the given service accounts and makes them
+// available again. Unless overridden, the caller must be the borrower of the
+// accounts.
+func (b *databaseBackend) checkIn(ctx context.Context, req *logical.Request, data *framework.FieldData, overrideEnforcement bool) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.libraryLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	set, err := b.librarySet(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return logical.ErrorResponse("unknown library set: %s", name), nil
+	}
+	enforce := !overrideEnforcement && !set.DisableCheckInEnforcement
+
+	accountNames := data.Get("service_account_names").([]string)
+	if len(accountNames) == 0 {
+		var borrowed []string
+		for _, accountName := range set.ServiceAccountNames {
+			account, ok := set.Accounts[accountName]
+			if !ok || account.available() {
+				continue
+			}
+			if !enforce || account.borrowedBy(req) {
+				borrowed = append(borrowed, accountName)
+			}
+		}
+		if len(borrowed) != 1 {
+			return logical.ErrorResponse("service_account_names must be provided unless exactly one account of the set is checked out by the caller"), nil
+		}
+		accountNames = borrowed
+	}
+
+	for _, accountName := range accountNames {
+		if !strutil.StrListContains(set.ServiceAccountNames, accountName) {
+			return logical.ErrorResponse(fmt.Sprintf("%q is not a service account of library set %q", accountName, name)), nil
+		}
+		account, ok := set.Accounts[accountName]
+		if ok && !account.available() && enforce && !account.borrowedBy(req) {
+			return logical.ErrorResponse(fmt.Sprintf("service account %q was not checked out by the caller", accountName)), nil
+		}
+	}
+
+	var checkedIn []string
+	for _, accountName := range accountNames {
+		account, ok := set.Accounts[accountName]
+		if !ok || account.available() {
+			continue
+		}
+		if err := b.checkInLibraryAccount(ctx, req.Storage, name, set, accountName, account); err != nil {
+			return nil, err
+		}
+		checkedIn = append(checkedIn, accountName)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"check_ins": checkedIn,
+		},
+	}, nil
+}
+
+// checkInLibraryAccount rotates the password of a checked out service account,
+// so that its borrower loses access, and makes the account available. The
+// account stays checked out if the rotation fails. The caller must hold the
+// lock of the set.
+func (b *databaseBackend) checkInLibraryAccount(ctx context.Context, s logical.Storage, name string, set *librarySet, accountName string, account *libraryAccount) error {
+	if err := b.rotateLibraryAccount(ctx, s, name, set, accountName, account); err != nil {
+		return err
+	}
+	account.CheckOutID = ""
+	account.BorrowerEntityID = ""
+	account.BorrowerClientToken = ""
+	return storeLibrarySet(ctx, s, name, set)
+}
+
+// borrowedBy returns whether the account was checked out by the identity of
+// the request, or by its token if the request has no entity
+func (a *libraryAccount) borrowedBy(req *logical.Request) bool {
+	if a.BorrowerEntityID != "" || req.EntityID != "" {
+		return a.BorrowerEntityID == req.EntityID
+	}
+	return a.BorrowerClientToken == req.ClientToken
+}
+
+func (b *databaseBackend) pathLibraryStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	set, err := b.librarySet(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	respData := make(map[string]interface{}, len(set.ServiceAccountNames))
+	for _, accountName := range set.ServiceAccountNames {
+		account, ok := set.Accounts[accountName]
+		if !ok {
+			continue
+		}
+		status := map[string]interface{}{
+			"available":           account.available(),
+			"last_vault_rotation": account.LastVaultRotation,
+		}
+		if !account.available() && account.BorrowerEntityID != "" {
+			status["borrower_entity_id"] = account.BorrowerEntityID
+		}
+		respData[accountName] = status
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+const pathLibraryCheckOutHelpSyn = `
+Check out a service account of a library set.
+`
+
+const pathLibraryCheckOutHelpDesc = `
+This path checks out the first available service account of the library set
+and returns its username and password. The account is reserved for the caller
+until it is checked in, or until the lease of the check-out expires or is
+revoked, at which point its password is rotated.
+`
+
+const pathLibraryCheckInHelpSyn = `
+Check in service accounts of a library set.
+`
+
+const pathLibraryCheckInHelpDesc = `
+This path rotates the passwords of the given checked out service accounts and
+makes them available for check-out again. Unless the set disables check-in
+enforcement, only the entity or token that checked out an account can check it
+in through "library/:name/check-in"; the "library/manage/:name/check-in" path
+checks in any account and is meant for operators.
+`
+
+const pathLibraryStatusHelpSyn = `
+Read the check-out status of the service accounts of a library set.
+`
+
+const pathLibraryStatusHelpDesc = `
+This path returns, for each service account of the library set, whether it is
+available for check-out, the time of its last password rotation and, when it
+is checked out by an entity, the ID of that entity.
+`
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBackend_Library(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	lb, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Cleanup(context.Background())
+	b := lb.(*databaseBackend)
+
+	// Connect the backend to a mock database
+	entry, err := logical.StorageEntryJSON("config/mockdb", &DatabaseConfig{
+		PluginName:   "mockv5",
+		AllowedRoles: []string{"*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.StorageView.Put(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	db := new(mockNewDatabase)
+	db.On("UpdateUser", mock.Anything, mock.Anything).Return(v5.UpdateUserResponse{}, nil)
+	db.On("Close").Return(nil)
+	b.connections["mockdb"] = &dbPluginInstance{
+		database: databaseVersionWrapper{v5: db},
+		name:     "mockdb",
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}, entityID string) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			EntityID:  entityID,
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}, entityID string) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data, entityID)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	mustFail := func(op logical.Operation, path string, data map[string]interface{}, entityID string) {
+		t.Helper()
+		resp, err := request(op, path, data, entityID)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+		}
+	}
+	available := func(accountName string) bool {
+		t.Helper()
+		resp := mustRequest(logical.ReadOperation, "library/test/status", nil, "")
+		return resp.Data[accountName].(map[string]interface{})["available"].(bool)
+	}
+
+	mustFail(logical.CreateOperation, "library/test", map[string]interface{}{
+		"db_name": "mockdb",
+	}, "")
+
+	// Adding the accounts rotates their passwords
+	mustRequest(logical.CreateOperation, "library/test", map[string]interface{}{
+		"db_name":               "mockdb",
+		"service_account_names": "alice,bob",
+		"ttl":                   "1h",
+		"max_ttl":               "2h",
+	}, "")
+	db.AssertNumberOfCalls(t, "UpdateUser", 2)
+
+	mustFail(logical.CreateOperation, "library/other", map[string]interface{}{
+		"db_name":               "mockdb",
+		"service_account_names": "bob",
+	}, "")
+
+	resp := mustRequest(logical.UpdateOperation, "library/test/check-out", nil, "entity-1")
+	if resp.Data["service_account_name"] != "alice" || resp.Data["password"] == "" {
+		t.Fatalf("bad check-out: %#v", resp.Data)
+	}
+	aliceSecret := resp.Secret
+	if aliceSecret.TTL.Hours() != 1 || aliceSecret.MaxTTL.Hours() != 2 {
+		t.Fatalf("bad lease: %#v", aliceSecret)
+	}
+
+	resp = mustRequest(logical.UpdateOperation, "library/test/check-out", map[string]interface{}{
+		"ttl": "3h",
+	}, "entity-2")
+	if resp.Data["service_account_name"] != "bob" || resp.Secret.TTL.Hours() != 2 {
+		t.Fatalf("bad check-out: %#v", resp)
+	}
+	mustFail(logical.UpdateOperation, "library/test/check-out", nil, "entity-3")
+	if available("alice") || available("bob") {
+		t.Fatal("checked out accounts are available")
+	}
+
+	// Accounts are checked in by their borrower, which rotates their
+	// passwords
+	mustFail(logical.UpdateOperation, "library/test/check-in", map[string]interface{}{
+		"service_account_names": "bob",
+	}, "entity-1")
+	mustFail(logical.DeleteOperation, "library/test", nil, "")
+	resp = mustRequest(logical.UpdateOperation, "library/test/check-in", nil, "entity-1")
+	if checkIns := resp.Data["check_ins"].([]string); len(checkIns) != 1 || checkIns[0] != "alice" {
+		t.Fatalf("bad check-in: %#v", resp.Data)
+	}
+	db.AssertNumberOfCalls(t, "UpdateUser", 3)
+	if !available("alice") {
+		t.Fatal("checked in account is not available")
+	}
+
+	// The lease of an earlier check-out does not check in the account
+	mustRequest(logical.UpdateOperation, "library/test/check-out", nil, "entity-3")
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   config.StorageView,
+		Operation: logical.RevokeOperation,
+		Secret:    aliceSecret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if available("alice") {
+		t.Fatal("stale lease checked in the account")
+	}
+
+	// Operators check in any account
+	mustRequest(logical.UpdateOperation, "library/manage/test/check-in", map[string]interface{}{
+		"service_account_names": "alice,bob",
+	}, "")
+	db.AssertNumberOfCalls(t, "UpdateUser", 5)
+	if !available("alice") || !available("bob") {
+		t.Fatal("checked in accounts are not available")
+	}
+
+	// Revoking the lease of a check-out checks the account in
+	resp = mustRequest(logical.UpdateOperation, "library/test/check-out", nil, "entity-1")
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   config.StorageView,
+		Operation: logical.RevokeOperation,
+		Secret:    resp.Secret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !available("alice") {
+		t.Fatal("revoked check-out is still checked out")
+	}
+	db.AssertNumberOfCalls(t, "UpdateUser", 6)
+
+	mustRequest(logical.DeleteOperation, "library/test", nil, "")
+	resp = mustRequest(logical.ListOperation, "library/", nil, "")
+	if len(resp.Data) != 0 && len(resp.Data["keys"].([]string)) != 0 {
+		t.Fatalf("bad list: %#v", resp.Data)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gorhill/cronexpr"
 	v4 "github.com/hashicorp/vault/sdk/database/dbplugin"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
@@ -14,6 +16,9 @@ import (
 	"github.com/hashicorp/vault/sdk/queue"
 )
 
+// minRotationWindow is the shortest rotation window of a static account
+const minRotationWindow = time.Hour
+
 func pathListRoles(b *databaseBackend) []*framework.Path {
 	return []*framework.Path{
 		&framework.Path{
@@ -144,6 +149,17 @@ func dynamicFields() map[string]*framework.FieldSchema {
 	type will support this functionality. See the plugin's API page for
 	more information on support and formatting for this parameter.`,
 		},
+		"credential_type": {
+			Type:    framework.TypeString,
+			Default: v5.CredentialTypePassword.String(),
+			Description: `The type of credential issued to users of the role.
+	One of "password", "rsa_private_key" or "client_certificate".`,
+		},
+		"credential_config": {
+			Type: framework.TypeKVPairs,
+			Description: `The settings of the credential generation, which
+	depend on the credential type.`,
+		},
 	}
 	return fields
 }
@@ -155,13 +171,28 @@ func staticFields() map[string]*framework.FieldSchema {
 		"username": {
 			Type: framework.TypeString,
 			Description: `Name of the static user account for Vault to manage.
-	Requires "rotation_period" to be specified`,
+	Requires "rotation_period" or "rotation_schedule" to be specified`,
 		},
 		"rotation_period": {
 			Type: framework.TypeDurationSecond,
 			Description: `Period for automatic
 	credential rotation of the given username. Not valid unless used with
-	"username".`,
+	"username". Mutually exclusive with "rotation_schedule".`,
+		},
+		"rotation_schedule": {
+			Type: framework.TypeString,
+			Description: `A cron-style schedule, such as "0 2 * * SUN", for
+	automatic credential rotation of the given username, evaluated in UTC.
+	Not valid unless used with "username". Mutually exclusive with
+	"rotation_period".`,
+		},
+		"rotation_window": {
+			Type: framework.TypeDurationSecond,
+			Description: `The amount of time, starting at each scheduled
+	rotation, during which the rotation may happen. A rotation that could
+	not happen within the window is skipped until the next scheduled time.
+	Must be at least one hour. Only valid with "rotation_schedule"; by
+	default a missed rotation happens as soon as possible.`,
 		},
 		"rotation_statements": {
 			Type: framework.TypeStringSlice,
@@ -236,7 +267,14 @@ func (b *databaseBackend) pathStaticRoleRead(ctx context.Context, req *logical.R
 	if role.StaticAccount != nil {
 		data["username"] = role.StaticAccount.Username
 		data["rotation_statements"] = role.Statements.Rotation
-		data["rotation_period"] = role.StaticAccount.RotationPeriod.Seconds()
+		if role.StaticAccount.usesRotationSchedule() {
+			data["rotation_schedule"] = role.StaticAccount.RotationSchedule
+			if role.StaticAccount.RotationWindow > 0 {
+				data["rotation_window"] = role.StaticAccount.RotationWindow.Seconds()
+			}
+		} else {
+			data["rotation_period"] = role.StaticAccount.RotationPeriod.Seconds()
+		}
 		if !role.StaticAccount.LastVaultRotation.IsZero() {
 			data["last_vault_rotation"] = role.StaticAccount.LastVaultRotation
 		}
@@ -268,6 +306,7 @@ func (b *databaseBackend) pathRoleRead(ctx context.Context, req *logical.Request
 		"renew_statements":      role.Statements.Renewal,
 		"default_ttl":           role.DefaultTTL.Seconds(),
 		"max_ttl":               role.MaxTTL.Seconds(),
+		"credential_type":       role.CredentialType.String(),
 	}
 	if len(role.Statements.Creation) == 0 {
 		data["creation_statements"] = []string{}
@@ -282,6 +321,17 @@ func (b *databaseBackend) pathRoleRead(ctx context.Context, req *logical.Request
 		data["renew_statements"] = []string{}
 	}
 
+	// The private key of the CA issuing client certificates is never
+	// returned
+	credentialConfig := make(map[string]string, len(role.CredentialConfig))
+	for k, v := range role.CredentialConfig {
+		if k == "ca_private_key" {
+			continue
+		}
+		credentialConfig[k] = v
+	}
+	data["credential_config"] = credentialConfig
+
 	return &logical.Response{
 		Data: data,
 	}, nil
@@ -385,6 +435,27 @@ func (b *databaseBackend) pathRoleCreateUpdate(ctx context.Context, req *logical
 		}
 	}
 
+	// Credentials
+	{
+		if credentialTypeRaw, ok := data.GetOk("credential_type"); ok {
+			credentialType, err := v5.ParseCredentialType(credentialTypeRaw.(string))
+			if err != nil {
+				return logical.ErrorResponse(err.Error()), nil
+			}
+			if credentialType != role.CredentialType && !createOperation {
+				// The settings of the previous type do not apply
+				role.CredentialConfig = nil
+			}
+			role.CredentialType = credentialType
+		}
+		if credentialConfigRaw, ok := data.GetOk("credential_config"); ok {
+			role.CredentialConfig = credentialConfigRaw.(map[string]string)
+		}
+		if err := validateCredentialConfig(role.CredentialType, role.CredentialConfig); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid credential_config: %s", err)), nil
+		}
+	}
+
 	// Store it
 	entry, err := logical.StorageEntryJSON(databaseRolePath+name, role)
 	if err != nil {
@@ -455,12 +526,17 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 	}
 	role.StaticAccount.Username = username
 
-	// If it's a Create operation, both username and rotation_period must be included
-	rotationPeriodSecondsRaw, ok := data.GetOk("rotation_period")
-	if !ok && createRole {
-		return logical.ErrorResponse("rotation_period is required to create static accounts"), nil
+	// If it's a Create operation, both username and one of rotation_period or
+	// rotation_schedule must be included
+	rotationPeriodSecondsRaw, periodOk := data.GetOk("rotation_period")
+	rotationScheduleRaw, scheduleOk := data.GetOk("rotation_schedule")
+	if periodOk && scheduleOk {
+		return logical.ErrorResponse("mutually exclusive fields rotation_period and rotation_schedule were both specified"), nil
+	}
+	if !periodOk && !scheduleOk && createRole {
+		return logical.ErrorResponse("one of rotation_period or rotation_schedule is required to create static accounts"), nil
 	}
-	if ok {
+	if periodOk {
 		rotationPeriodSeconds := rotationPeriodSecondsRaw.(int)
 		if rotationPeriodSeconds < defaultQueueTickSeconds {
 			// If rotation frequency is specified, and this is an update, the value
@@ -469,6 +545,36 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 			return logical.ErrorResponse(fmt.Sprintf("rotation_period must be %d seconds or more", defaultQueueTickSeconds)), nil
 		}
 		role.StaticAccount.RotationPeriod = time.Duration(rotationPeriodSeconds) * time.Second
+
+		// Switching to a rotation period drops the schedule
+		role.StaticAccount.RotationSchedule = ""
+		role.StaticAccount.RotationWindow = 0
+		role.StaticAccount.NextVaultRotation = time.Time{}
+	}
+	if scheduleOk {
+		rotationSchedule := strings.TrimSpace(rotationScheduleRaw.(string))
+		if _, err := parseRotationSchedule(rotationSchedule); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid rotation_schedule: %s", err)), nil
+		}
+		if rotationSchedule != role.StaticAccount.RotationSchedule {
+			role.StaticAccount.RotationSchedule = rotationSchedule
+			role.StaticAccount.RotationPeriod = 0
+
+			// The new schedule applies from now on, rather than from the last
+			// rotation
+			role.StaticAccount.NextVaultRotation = role.StaticAccount.nextScheduledRotation(time.Now())
+		}
+	}
+
+	if rotationWindowSecondsRaw, ok := data.GetOk("rotation_window"); ok {
+		rotationWindow := time.Duration(rotationWindowSecondsRaw.(int)) * time.Second
+		switch {
+		case !role.StaticAccount.usesRotationSchedule():
+			return logical.ErrorResponse("rotation_window is only valid with rotation_schedule"), nil
+		case rotationWindow != 0 && rotationWindow < minRotationWindow:
+			return logical.ErrorResponse(fmt.Sprintf("rotation_window must be %d seconds or more", int(minRotationWindow.Seconds()))), nil
+		}
+		role.StaticAccount.RotationWindow = rotationWindow
 	}
 
 	if rotationStmtsRaw, ok := data.GetOk("rotation_statements"); ok {
@@ -477,9 +583,6 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 		role.Statements.Rotation = data.Get("rotation_statements").([]string)
 	}
 
-	// lvr represents the roles' LastVaultRotation
-	lvr := role.StaticAccount.LastVaultRotation
-
 	// Only call setStaticAccount if we're creating the role for the
 	// first time
 	switch req.Operation {
@@ -494,7 +597,7 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 			return nil, err
 		}
 		// guard against RotationTime not being set or zero-value
-		lvr = resp.RotationTime
+		role.StaticAccount.LastVaultRotation = resp.RotationTime
 	case logical.UpdateOperation:
 		// store updated Role
 		entry, err := logical.StorageEntryJSON(databaseStaticRolePath+name, role)
@@ -513,7 +616,7 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 	// Add their rotation to the queue
 	if err := b.pushItem(&queue.Item{
 		Key:      name,
-		Priority: lvr.Add(role.StaticAccount.RotationPeriod).Unix(),
+		Priority: role.StaticAccount.NextRotationTime().Unix(),
 	}); err != nil {
 		return nil, err
 	}
@@ -527,6 +630,11 @@ type roleEntry struct {
 	DefaultTTL    time.Duration  `json:"default_ttl"`
 	MaxTTL        time.Duration  `json:"max_ttl"`
 	StaticAccount *staticAccount `json:"static_account" mapstructure:"static_account"`
+
+	// CredentialType is the type of credential issued to users of dynamic
+	// roles. Roles stored before it was introduced issue passwords.
+	CredentialType   v5.CredentialType `json:"credential_type"`
+	CredentialConfig map[string]string `json:"credential_config"`
 }
 
 type staticAccount struct {
@@ -546,17 +654,84 @@ type staticAccount struct {
 	// determine if a password needs to be rotated
 	RotationPeriod time.Duration `json:"rotation_period"`
 
+	// RotationSchedule is a cron expression of the times at which the password
+	// is rotated. It is used instead of RotationPeriod when set.
+	RotationSchedule string `json:"rotation_schedule"`
+
+	// RotationWindow is the time after each scheduled rotation during which
+	// the rotation may happen. Zero means the rotation happens as soon as
+	// possible, however late.
+	RotationWindow time.Duration `json:"rotation_window"`
+
+	// NextVaultRotation is the next scheduled rotation of accounts with a
+	// rotation schedule
+	NextVaultRotation time.Time `json:"next_vault_rotation"`
+
 	// RevokeUser is a boolean flag to indicate if Vault should revoke the
 	// database user when the role is deleted
 	RevokeUserOnDelete bool `json:"revoke_user_on_delete"`
 }
 
-// NextRotationTime calculates the next rotation by adding the Rotation Period
-// to the last known vault rotation
+// NextRotationTime calculates the next rotation, which is the next scheduled
+// time for accounts with a rotation schedule, or else the Rotation Period
+// added to the last known vault rotation
 func (s *staticAccount) NextRotationTime() time.Time {
+	if s.usesRotationSchedule() {
+		return s.NextVaultRotation
+	}
 	return s.LastVaultRotation.Add(s.RotationPeriod)
 }
 
+func (s *staticAccount) usesRotationSchedule() bool {
+	return s.RotationSchedule != ""
+}
+
+// nextScheduledRotation returns the first time after t that matches the
+// rotation schedule
+func (s *staticAccount) nextScheduledRotation(t time.Time) time.Time {
+	schedule, err := parseRotationSchedule(s.RotationSchedule)
+	if err != nil {
+		// The schedule is validated when the role is written; fall back to
+		// daily rotations rather than rotating continuously
+		return t.Add(24 * time.Hour)
+	}
+	return schedule.Next(t.UTC())
+}
+
+// setRotationTime records a rotation at the given time and schedules the
+// next one
+func (s *staticAccount) setRotationTime(t time.Time) {
+	s.LastVaultRotation = t
+	if s.usesRotationSchedule() {
+		s.NextVaultRotation = s.nextScheduledRotation(t)
+	}
+}
+
+// insideRotationWindow reports whether a rotation at time t falls within
+// the rotation window that starts at the scheduled rotation
+func (s *staticAccount) insideRotationWindow(t time.Time) bool {
+	if !s.usesRotationSchedule() || s.RotationWindow == 0 {
+		return true
+	}
+	return t.Before(s.NextVaultRotation.Add(s.RotationWindow))
+}
+
+// parseRotationSchedule parses a standard five field cron expression, or one
+// of the predefined schedules such as "@daily"
+func parseRotationSchedule(rotationSchedule string) (*cronexpr.Expression, error) {
+	if !strings.HasPrefix(rotationSchedule, "@") && len(strings.Fields(rotationSchedule)) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(strings.Fields(rotationSchedule)))
+	}
+	schedule, err := cronexpr.Parse(rotationSchedule)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Next(time.Now().UTC()).IsZero() {
+		return nil, fmt.Errorf("schedule %q never matches", rotationSchedule)
+	}
+	return schedule, nil
+}
+
 // PasswordTTL calculates the approximate time remaining until the password is
 // no longer valid. This is approximate because the periodic rotation is only
 // checked approximately every 5 seconds, and each rotation can take a small
@@ -621,12 +796,35 @@ The "renew_statements" parameter customizes the statement string used to renew a
 user.
 The "rollback_statements' parameter customizes the statement string used to
 rollback a change if needed.
+
+The "credential_type" parameter selects the credential issued to users of the
+role: "password" (the default), "rsa_private_key" or "client_certificate".
+The public key or certificate subject is sent to the database plugin, which
+must support the credential type, and the private half is returned by the
+"creds" endpoint. The "credential_config" parameter holds the settings of the
+credential generation:
+
+  * "password" - "password_policy" overrides the password policy of the
+    database connection.
+
+  * "rsa_private_key" - "key_bits" is 2048 (the default), 3072 or 4096.
+
+  * "client_certificate" - "common_name_template" generates the common name of
+    the certificate from the "DisplayName" and "RoleName" of the request,
+    which is signed by the "ca_cert" and "ca_private_key" PEM CA. "key_type"
+    is "rsa" (the default) or "ec", with "key_bits".
 `
 
 const pathStaticRoleHelpDesc = `
 This path lets you manage the static roles that can be created with this
 backend. Static Roles are associated with a single database user, and manage the
-password based on a rotation period, automatically rotating the password.
+password based on a rotation period or schedule, automatically rotating the
+password.
+
+The "rotation_schedule" parameter is a cron-style schedule evaluated in UTC,
+such as "0 2 * * SUN" for Sundays at 02:00. With a "rotation_window", a
+rotation that could not happen within the window after its scheduled time is
+skipped until the next scheduled time.
 
 The "db_name" parameter is required and configures the name of the database
 connection to use.
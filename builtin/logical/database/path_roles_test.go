@@ -10,9 +10,10 @@ import (
 	"github.com/hashicorp/vault/helper/namespace"
 	postgreshelper "github.com/hashicorp/vault/helper/testhelpers/postgresql"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/queue"
 )
 
-var dataKeys = []string{"username", "password", "last_vault_rotation", "rotation_period"}
+var dataKeys = []string{"username", "password", "last_vault_rotation", "rotation_period", "rotation_schedule", "rotation_window"}
 
 func TestBackend_StaticRole_Config(t *testing.T) {
 	cluster, sys := getCluster(t)
@@ -78,7 +79,50 @@ func TestBackend_StaticRole_Config(t *testing.T) {
 			account: map[string]interface{}{
 				"username": dbUser,
 			},
-			err: errors.New("rotation_period is required to create static accounts"),
+			err: errors.New("one of rotation_period or rotation_schedule is required to create static accounts"),
+		},
+		"rotation schedule": {
+			account: map[string]interface{}{
+				"username":          dbUser,
+				"rotation_schedule": "0 2 * * SUN",
+				"rotation_window":   "2h",
+			},
+			expected: map[string]interface{}{
+				"username":          dbUser,
+				"rotation_schedule": "0 2 * * SUN",
+				"rotation_window":   float64(7200),
+			},
+		},
+		"rotation period and schedule": {
+			account: map[string]interface{}{
+				"username":          dbUser,
+				"rotation_period":   "5400s",
+				"rotation_schedule": "0 2 * * SUN",
+			},
+			err: errors.New("mutually exclusive fields rotation_period and rotation_schedule were both specified"),
+		},
+		"invalid rotation schedule": {
+			account: map[string]interface{}{
+				"username":          dbUser,
+				"rotation_schedule": "0 2 * *",
+			},
+			err: errors.New("invalid rotation_schedule: expected 5 fields, got 4"),
+		},
+		"rotation window without schedule": {
+			account: map[string]interface{}{
+				"username":        dbUser,
+				"rotation_period": "5400s",
+				"rotation_window": "2h",
+			},
+			err: errors.New("rotation_window is only valid with rotation_schedule"),
+		},
+		"short rotation window": {
+			account: map[string]interface{}{
+				"username":          dbUser,
+				"rotation_schedule": "0 2 * * SUN",
+				"rotation_window":   "10m",
+			},
+			err: errors.New("rotation_window must be 3600 seconds or more"),
 		},
 	}
 
@@ -523,3 +567,123 @@ ALTER USER "{{name}}" WITH PASSWORD '{{password}}';
 const testRoleStaticUpdateRotation = `
 ALTER USER "{{name}}" WITH PASSWORD '{{password}}';GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO "{{name}}";
 `
+
+func TestStaticAccount_RotationSchedule(t *testing.T) {
+	s := &staticAccount{
+		RotationSchedule: "0 2 * * SUN",
+		RotationWindow:   time.Hour,
+	}
+
+	// Saturday, 2021-01-02 12:00 UTC
+	now := time.Date(2021, 1, 2, 12, 0, 0, 0, time.UTC)
+	sunday := time.Date(2021, 1, 3, 2, 0, 0, 0, time.UTC)
+	if next := s.nextScheduledRotation(now); !next.Equal(sunday) {
+		t.Fatalf("bad next rotation: %s", next)
+	}
+
+	// The schedule is evaluated in UTC
+	if next := s.nextScheduledRotation(now.In(time.FixedZone("UTC-8", -8*60*60))); !next.Equal(sunday) {
+		t.Fatalf("bad next rotation: %s", next)
+	}
+
+	s.setRotationTime(sunday)
+	if !s.LastVaultRotation.Equal(sunday) || !s.NextRotationTime().Equal(sunday.AddDate(0, 0, 7)) {
+		t.Fatalf("bad rotation times: %#v", s)
+	}
+
+	s.NextVaultRotation = sunday
+	if !s.insideRotationWindow(sunday.Add(59 * time.Minute)) {
+		t.Fatal("expected rotation to be inside the window")
+	}
+	if s.insideRotationWindow(sunday.Add(time.Hour)) {
+		t.Fatal("expected rotation to be outside the window")
+	}
+	s.RotationWindow = 0
+	if !s.insideRotationWindow(sunday.Add(24 * time.Hour)) {
+		t.Fatal("expected late rotation without a window")
+	}
+
+	// Accounts with a rotation period are unaffected
+	s = &staticAccount{
+		LastVaultRotation: now,
+		RotationPeriod:    time.Hour,
+	}
+	if !s.NextRotationTime().Equal(now.Add(time.Hour)) || !s.insideRotationWindow(now.Add(24*time.Hour)) {
+		t.Fatalf("bad rotation period account: %#v", s)
+	}
+
+	for _, schedule := range []string{"", "0 2 * * SUN 2021", "0 0 2 * * SUN *", "0 2 31 2 *", "61 * * * *"} {
+		if _, err := parseRotationSchedule(schedule); err == nil {
+			t.Fatalf("expected error for schedule %q", schedule)
+		}
+	}
+	for _, schedule := range []string{"*/15 * * * *", "@daily", "0 2 * * SUN"} {
+		if _, err := parseRotationSchedule(schedule); err != nil {
+			t.Fatalf("schedule %q: %s", schedule, err)
+		}
+	}
+}
+
+func TestBackend_StaticRole_RotationWindowMissed(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	b.credRotationQueue = queue.New()
+	defer b.Cleanup(context.Background())
+
+	// The scheduled rotation was a day ago and its window has passed
+	missed := time.Now().Add(-24 * time.Hour)
+	role := &roleEntry{
+		DBName: "plugin-test",
+		StaticAccount: &staticAccount{
+			Username:          dbUser,
+			LastVaultRotation: missed.Add(-time.Hour),
+			RotationSchedule:  "0 2 * * *",
+			RotationWindow:    time.Hour,
+			NextVaultRotation: missed,
+		},
+	}
+	entry, err := logical.StorageEntryJSON(databaseStaticRolePath+"plugin-role-test", role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.StorageView.Put(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.pushItem(&queue.Item{
+		Key:      "plugin-role-test",
+		Priority: missed.Unix(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rotation is skipped without contacting the database, which is not
+	// configured
+	if !b.rotateCredential(context.Background(), config.StorageView) {
+		t.Fatal("expected the queue item to be processed")
+	}
+
+	role, err = b.StaticRole(context.Background(), config.StorageView, "plugin-role-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := role.StaticAccount.NextVaultRotation
+	if !next.After(time.Now()) || next.UTC().Hour() != 2 || next.Minute() != 0 {
+		t.Fatalf("bad next rotation: %s", next)
+	}
+	if !role.StaticAccount.LastVaultRotation.Equal(missed.Add(-time.Hour)) {
+		t.Fatalf("credentials were rotated: %#v", role.StaticAccount)
+	}
+
+	item, err := b.popFromRotationQueueByKey("plugin-role-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Priority != next.Unix() {
+		t.Fatalf("bad priority: %d, expected %d", item.Priority, next.Unix())
+	}
+}
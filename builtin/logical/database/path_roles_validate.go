@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathValidateRoles(b *databaseBackend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "roles/" + framework.GenericNameRegex("name") + "/validate",
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.pathRoleValidate,
+			},
+
+			HelpSynopsis:    pathRoleValidateHelpSyn,
+			HelpDescription: pathRoleValidateHelpDesc,
+		},
+		&framework.Path{
+			Pattern: "static-roles/" + framework.GenericNameRegex("name") + "/validate",
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the static role.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.pathStaticRoleValidate,
+			},
+
+			HelpSynopsis:    pathRoleValidateHelpSyn,
+			HelpDescription: pathRoleValidateHelpDesc,
+		},
+	}
+}
+
+func (b *databaseBackend) pathRoleValidate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", name)), nil
+	}
+	if len(role.Statements.Creation) == 0 {
+		return logical.ErrorResponse("role has no creation statements to validate"), nil
+	}
+
+	ttl, _, err := framework.CalculateTTL(b.System(), 0, role.DefaultTTL, 0, role.MaxTTL, 0, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	return b.validateStatements(ctx, req, name, role, v5.ValidateStatementsRequest{
+		UsernameConfig: v5.UsernameMetadata{
+			DisplayName: req.DisplayName,
+			RoleName:    name,
+		},
+		Expiration: time.Now().Add(ttl),
+		CreationStatements: v5.Statements{
+			Commands: role.Statements.Creation,
+		},
+		RevocationStatements: v5.Statements{
+			Commands: role.Statements.Revocation,
+		},
+	})
+}
+
+func (b *databaseBackend) pathStaticRoleValidate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.StaticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil || role.StaticAccount == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", name)), nil
+	}
+	if len(role.Statements.Rotation) == 0 {
+		return logical.ErrorResponse("role has no rotation statements to validate"), nil
+	}
+
+	// The rotation statements apply to the existing user of the role, the
+	// password they set is discarded
+	return b.validateStatements(ctx, req, name, role, v5.ValidateStatementsRequest{
+		Username: role.StaticAccount.Username,
+		RotationStatements: v5.Statements{
+			Commands: role.Statements.Rotation,
+		},
+	})
+}
+
+// validateStatements checks the statements of the role against its database
+// without keeping their effects, and reports the first statement that failed
+// and the statements that could not be checked
+func (b *databaseBackend) validateStatements(ctx context.Context, req *logical.Request, name string, role *roleEntry, validateReq v5.ValidateStatementsRequest) (*logical.Response, error) {
+	dbConfig, err := b.DatabaseConfig(ctx, req.Storage, role.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	// If role name isn't in the database's allowed roles, send back a
+	// permission denied.
+	if !strutil.StrListContains(dbConfig.AllowedRoles, "*") && !strutil.StrListContainsGlob(dbConfig.AllowedRoles, name) {
+		return nil, fmt.Errorf("%q is not an allowed role", name)
+	}
+
+	dbi, err := b.GetConnection(ctx, req.Storage, role.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	dbi.RLock()
+	defer dbi.RUnlock()
+
+	passwordPolicy, err := role.passwordPolicy(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	validateReq.Password, err = dbi.database.GeneratePassword(ctx, b.System(), passwordPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate password: %w", err)
+	}
+
+	validateResp, err := dbi.database.ValidateStatements(ctx, validateReq)
+	if err == v5.ErrValidateStatementsUnsupported {
+		return logical.ErrorResponse(fmt.Sprintf("the plugin of database %q does not support statement validation", role.DBName)), nil
+	}
+	if err != nil {
+		b.CloseIfShutdown(dbi, err)
+		return nil, err
+	}
+
+	respData := map[string]interface{}{
+		"valid": validateResp.Failure == nil && len(validateResp.Unvalidated) == 0,
+	}
+	if failure := validateResp.Failure; failure != nil {
+		respData["failed_operation"] = failure.Operation
+		respData["failed_statement_index"] = failure.Index
+		respData["failed_statement"] = failure.Statement
+		respData["error"] = failure.Error
+	}
+	if len(validateResp.Unvalidated) > 0 {
+		unvalidated := make([]map[string]interface{}, 0, len(validateResp.Unvalidated))
+		for _, stmt := range validateResp.Unvalidated {
+			unvalidated = append(unvalidated, map[string]interface{}{
+				"operation":       stmt.Operation,
+				"statement_index": stmt.Index,
+				"statement":       stmt.Statement,
+				"reason":          stmt.Reason,
+			})
+		}
+		respData["unvalidated_statements"] = unvalidated
+	}
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+const pathRoleValidateHelpSyn = `
+Validate the statements of a role against its database.
+`
+
+const pathRoleValidateHelpDesc = `
+This path checks the statements of a role against its database without keeping
+their effects, so that mistakes are found before credentials are requested.
+
+For roles, the statements are those creating a test user and revoking it. For
+static roles, they are the rotation statements of the user of the role. How
+thoroughly the statements are checked depends on the database plugin: some run
+them in a transaction that is rolled back, others only prepare them, which
+checks their syntax and the objects they refer to but not that they would
+succeed. Plugins that can do neither do not support validation.
+
+The response reports whether all the statements were checked successfully. If
+a statement failed, it holds the operation ("creation", "rotation" or
+"revocation"), the index among the statements of the operation and the text of
+the statement, with the error returned by the database. Statements the plugin
+could not check are listed in "unvalidated_statements".
+`
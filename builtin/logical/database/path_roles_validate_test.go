@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBackend_RoleValidate_errors(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Cleanup(context.Background())
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustFail := func(path string) {
+		t.Helper()
+		resp, err := request(logical.UpdateOperation, path, nil)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+		}
+	}
+
+	mustFail("roles/missing/validate")
+	mustFail("static-roles/missing/validate")
+
+	// Roles without creation statements have nothing to validate
+	resp, err := request(logical.CreateOperation, "roles/test", map[string]interface{}{
+		"db_name": "mockv5",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	mustFail("roles/test/validate")
+}
+
+func TestBackend_RoleValidate_unvalidated(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	lb, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := lb.(*databaseBackend)
+	defer b.Cleanup(context.Background())
+
+	if err := storeConfig(context.Background(), config.StorageView, "plugin-db", &DatabaseConfig{
+		PluginName:   "mockv5",
+		AllowedRoles: []string{"*"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	request(logical.CreateOperation, "roles/test", map[string]interface{}{
+		"db_name":             "plugin-db",
+		"creation_statements": []string{"CREATE USER", "GRANT"},
+	})
+
+	mockDB := new(mockValidatingDatabase)
+	mockDB.On("Close").Return(nil)
+	b.connections["plugin-db"] = &dbPluginInstance{
+		database: databaseVersionWrapper{v5: mockDB},
+		name:     "plugin-db",
+	}
+
+	// Statements the plugin could not check do not count as valid
+	mockDB.On("ValidateStatements", mock.Anything, mock.Anything).
+		Return(v5.ValidateStatementsResponse{
+			Unvalidated: []v5.UnvalidatedStatement{
+				{
+					Operation: v5.StatementOperationCreation,
+					Index:     1,
+					Statement: "GRANT",
+					Reason:    "the statement cannot be prepared",
+				},
+			},
+		}, nil).Once()
+	resp := request(logical.UpdateOperation, "roles/test/validate", nil)
+	expected := map[string]interface{}{
+		"valid": false,
+		"unvalidated_statements": []map[string]interface{}{
+			{
+				"operation":       v5.StatementOperationCreation,
+				"statement_index": 1,
+				"statement":       "GRANT",
+				"reason":          "the statement cannot be prepared",
+			},
+		},
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad response: %#v", resp.Data)
+	}
+
+	mockDB.On("ValidateStatements", mock.Anything, mock.Anything).
+		Return(v5.ValidateStatementsResponse{}, nil).Once()
+	resp = request(logical.UpdateOperation, "roles/test/validate", nil)
+	if !reflect.DeepEqual(resp.Data, map[string]interface{}{"valid": true}) {
+		t.Fatalf("bad response: %#v", resp.Data)
+	}
+}
@@ -67,7 +67,7 @@ func (b *databaseBackend) populateQueue(ctx context.Context, s logical.Storage)
 
 		item := queue.Item{
 			Key:      roleName,
-			Priority: role.StaticAccount.LastVaultRotation.Add(role.StaticAccount.RotationPeriod).Unix(),
+			Priority: role.StaticAccount.NextRotationTime().Unix(),
 		}
 
 		// Check if role name is in map
@@ -187,6 +187,26 @@ func (b *databaseBackend) rotateCredential(ctx context.Context, s logical.Storag
 		return false
 	}
 
+	// A scheduled rotation that missed its window waits for the next scheduled
+	// time. Rotations resuming from a WAL entry always complete, since the
+	// password may already have been changed in the database.
+	if _, ok := item.Value.(string); !ok && !role.StaticAccount.insideRotationWindow(time.Now()) {
+		b.logger.Info("rotation window missed, skipping to next scheduled rotation", "role", item.Key)
+		role.StaticAccount.NextVaultRotation = role.StaticAccount.nextScheduledRotation(time.Now())
+		entry, err := logical.StorageEntryJSON(databaseStaticRolePath+item.Key, role)
+		if err == nil {
+			err = s.Put(ctx, entry)
+		}
+		if err != nil {
+			b.logger.Error("unable to store next rotation time", "role", item.Key, "error", err)
+		}
+		item.Priority = role.StaticAccount.NextRotationTime().Unix()
+		if err := b.pushItem(item); err != nil {
+			b.logger.Error("unable to push item on to queue", "error", err)
+		}
+		return true
+	}
+
 	input := &setStaticAccountInput{
 		RoleName: item.Key,
 		Role:     role,
@@ -228,14 +248,9 @@ func (b *databaseBackend) rotateCredential(ctx context.Context, s logical.Storag
 		return true
 	}
 
-	lvr := resp.RotationTime
-	if lvr.IsZero() {
-		lvr = time.Now()
-	}
-
-	// Update priority and push updated Item to the queue
-	nextRotation := lvr.Add(role.StaticAccount.RotationPeriod)
-	item.Priority = nextRotation.Unix()
+	// Update priority and push updated Item to the queue. setStaticAccount
+	// has recorded the rotation on the role.
+	item.Priority = role.StaticAccount.NextRotationTime().Unix()
 	if err := b.pushItem(item); err != nil {
 		b.logger.Warn("unable to push item on to queue", "error", err)
 	}
@@ -376,7 +391,7 @@ func (b *databaseBackend) setStaticAccount(ctx context.Context, s logical.Storag
 	// Store updated role information
 	// lvr is the known LastVaultRotation
 	lvr := time.Now()
-	input.Role.StaticAccount.LastVaultRotation = lvr
+	input.Role.StaticAccount.setRotationTime(lvr)
 	input.Role.StaticAccount.Password = newPassword
 	output.RotationTime = lvr
 
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const SecretLibraryCredsType = "library_creds"
+
+func secretLibraryCreds(b *databaseBackend) *framework.Secret {
+	return &framework.Secret{
+		Type:   SecretLibraryCredsType,
+		Fields: map[string]*framework.FieldSchema{},
+
+		Renew:  b.secretLibraryCredsRenew(),
+		Revoke: b.secretLibraryCredsRevoke(),
+	}
+}
+
+// libraryCheckOut returns the set and account of the check-out of a secret,
+// and whether the account is still checked out by it
+func (b *databaseBackend) libraryCheckOut(ctx context.Context, req *logical.Request) (string, *librarySet, string, *libraryAccount, error) {
+	setName, ok := req.Secret.InternalData["set_name"].(string)
+	if !ok {
+		return "", nil, "", nil, fmt.Errorf("secret is missing set_name internal data")
+	}
+	accountName, ok := req.Secret.InternalData["service_account_name"].(string)
+	if !ok {
+		return "", nil, "", nil, fmt.Errorf("secret is missing service_account_name internal data")
+	}
+	checkOutID, ok := req.Secret.InternalData["check_out_id"].(string)
+	if !ok {
+		return "", nil, "", nil, fmt.Errorf("secret is missing check_out_id internal data")
+	}
+
+	set, err := b.librarySet(ctx, req.Storage, setName)
+	if err != nil {
+		return "", nil, "", nil, err
+	}
+	if set == nil {
+		return setName, nil, accountName, nil, nil
+	}
+	account, ok := set.Accounts[accountName]
+	if !ok || account.CheckOutID != checkOutID {
+		return setName, set, accountName, nil, nil
+	}
+	return setName, set, accountName, account, nil
+}
+
+func (b *databaseBackend) secretLibraryCredsRenew() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		setName, set, accountName, account, err := b.libraryCheckOut(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if set == nil {
+			return nil, fmt.Errorf("error during renew: could not find library set with name %q", setName)
+		}
+		if account == nil {
+			return nil, fmt.Errorf("error during renew: service account %q was checked in", accountName)
+		}
+
+		resp := &logical.Response{Secret: req.Secret}
+		resp.Secret.TTL = set.TTL
+		resp.Secret.MaxTTL = set.MaxTTL
+		return resp, nil
+	}
+}
+
+// secretLibraryCredsRevoke checks in the account of an expired or revoked
+// check-out, unless it was already checked in
+func (b *databaseBackend) secretLibraryCredsRevoke() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		setName, ok := req.Secret.InternalData["set_name"].(string)
+		if !ok {
+			return nil, fmt.Errorf("secret is missing set_name internal data")
+		}
+
+		lock := locksutil.LockForKey(b.libraryLocks, setName)
+		lock.Lock()
+		defer lock.Unlock()
+
+		_, set, accountName, account, err := b.libraryCheckOut(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, nil
+		}
+
+		if err := b.checkInLibraryAccount(ctx, req.Storage, setName, set, accountName, account); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
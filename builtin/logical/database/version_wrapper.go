@@ -86,6 +86,9 @@ func (d databaseVersionWrapper) NewUser(ctx context.Context, req v5.NewUserReque
 	}
 
 	// v4 Database
+	if req.CredentialType != v5.CredentialTypePassword {
+		return resp, "", fmt.Errorf("credential type %s is not supported by v4 database plugins", req.CredentialType)
+	}
 	stmts := v4.Statements{
 		Creation: req.Statements.Commands,
 		Rollback: req.RollbackStatements.Commands,
@@ -200,6 +203,23 @@ func (d databaseVersionWrapper) DeleteUser(ctx context.Context, req v5.DeleteUse
 	return v5.DeleteUserResponse{}, err
 }
 
+// ValidateStatements runs the statements in the underlying database without keeping their effects.
+// Errors with v5.ErrValidateStatementsUnsupported if the database cannot validate statements, which
+// is always the case of v4 databases.
+func (d databaseVersionWrapper) ValidateStatements(ctx context.Context, req v5.ValidateStatementsRequest) (v5.ValidateStatementsResponse, error) {
+	if !d.isV5() && !d.isV4() {
+		return v5.ValidateStatementsResponse{}, fmt.Errorf("no underlying database specified")
+	}
+
+	// v5 Database
+	if d.isV5() {
+		return v5.ValidateStatements(ctx, d.v5, req)
+	}
+
+	// v4 Database
+	return v5.ValidateStatementsResponse{}, v5.ErrValidateStatementsUnsupported
+}
+
 // Type of the underlying database. Errors if the wrapper does not contain an underlying database.
 func (d databaseVersionWrapper) Type() (string, error) {
 	if !d.isV5() && !d.isV4() {
@@ -473,6 +473,17 @@ func TestNewUser_legacyDB(t *testing.T) {
 			createUserErr:   fmt.Errorf("test error"),
 			createUserCalls: 1,
 
+			expectedResp: v5.NewUserResponse{},
+			expectErr:    true,
+		},
+		"unsupported credential type": {
+			req: v5.NewUserRequest{
+				CredentialType: v5.CredentialTypeRSAPrivateKey,
+				PublicKey:      []byte("public_key"),
+			},
+
+			createUserCalls: 0,
+
 			expectedResp: v5.NewUserResponse{},
 			expectErr:    true,
 		},
@@ -908,6 +919,105 @@ func TestDeleteUser_legacyDB(t *testing.T) {
 	}
 }
 
+func TestValidateStatements_missingDB(t *testing.T) {
+	dbw := databaseVersionWrapper{}
+
+	req := v5.ValidateStatementsRequest{}
+	_, err := dbw.ValidateStatements(context.Background(), req)
+	if err == nil {
+		t.Fatalf("err expected, got nil")
+	}
+}
+
+func TestValidateStatements_newDB(t *testing.T) {
+	type testCase struct {
+		validateResp v5.ValidateStatementsResponse
+		validateErr  error
+
+		expectedResp v5.ValidateStatementsResponse
+		expectErr    bool
+	}
+
+	failure := &v5.StatementFailure{
+		Operation: v5.StatementOperationRevocation,
+		Index:     1,
+		Statement: "DROP ROLE",
+		Error:     "syntax error",
+	}
+
+	tests := map[string]testCase{
+		"success": {
+			validateResp: v5.ValidateStatementsResponse{},
+
+			expectedResp: v5.ValidateStatementsResponse{},
+			expectErr:    false,
+		},
+		"failed statement": {
+			validateResp: v5.ValidateStatementsResponse{
+				Failure: failure,
+			},
+
+			expectedResp: v5.ValidateStatementsResponse{
+				Failure: failure,
+			},
+			expectErr: false,
+		},
+		"error": {
+			validateErr: fmt.Errorf("test error"),
+
+			expectedResp: v5.ValidateStatementsResponse{},
+			expectErr:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			newDB := new(mockValidatingDatabase)
+			newDB.On("ValidateStatements", mock.Anything, mock.Anything).
+				Return(test.validateResp, test.validateErr)
+			defer newDB.AssertNumberOfCalls(t, "ValidateStatements", 1)
+
+			dbw := databaseVersionWrapper{
+				v5: newDB,
+			}
+
+			resp, err := dbw.ValidateStatements(context.Background(), v5.ValidateStatementsRequest{})
+			if test.expectErr && err == nil {
+				t.Fatalf("err expected, got nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+
+			if !reflect.DeepEqual(resp, test.expectedResp) {
+				t.Fatalf("Actual resp: %#v\nExpected resp: %#v", resp, test.expectedResp)
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		dbw := databaseVersionWrapper{
+			v5: new(mockNewDatabase),
+		}
+
+		_, err := dbw.ValidateStatements(context.Background(), v5.ValidateStatementsRequest{})
+		if err != v5.ErrValidateStatementsUnsupported {
+			t.Fatalf("Actual err: %v", err)
+		}
+	})
+}
+
+func TestValidateStatements_legacyDB(t *testing.T) {
+	dbw := databaseVersionWrapper{
+		v4: new(mockLegacyDatabase),
+	}
+
+	_, err := dbw.ValidateStatements(context.Background(), v5.ValidateStatementsRequest{})
+	if err != v5.ErrValidateStatementsUnsupported {
+		t.Fatalf("Actual err: %v", err)
+	}
+}
+
 type badValue struct{}
 
 func (badValue) MarshalJSON() ([]byte, error) {
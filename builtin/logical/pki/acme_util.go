@@ -0,0 +1,536 @@
+package pki
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	acmeProblemPrefix = "urn:ietf:params:acme:error:"
+
+	acmeStatusPending     = "pending"
+	acmeStatusReady       = "ready"
+	acmeStatusValid       = "valid"
+	acmeStatusInvalid     = "invalid"
+	acmeStatusDeactivated = "deactivated"
+
+	acmeChallengeHTTP01 = "http-01"
+	acmeChallengeDNS01  = "dns-01"
+
+	acmeNonceLifetime       = 15 * time.Minute
+	acmeNonceKeySize        = 32
+	acmeNonceRandomSize     = 16
+	acmeNonceMACSize        = 16
+	acmeMaxUsedNonces       = 100000
+	acmeOrderLifetime       = 24 * time.Hour
+	acmeValidationTimeout   = 10 * time.Second
+	acmeMaxChallengeBodyLen = 4096
+)
+
+// acmeAllowedAlgorithms are the JWS algorithms accepted from ACME clients;
+// RFC 8555 forbids "none" and MAC based algorithms
+var acmeAllowedAlgorithms = map[string]bool{
+	string(jose.RS256): true,
+	string(jose.RS384): true,
+	string(jose.RS512): true,
+	string(jose.PS256): true,
+	string(jose.PS384): true,
+	string(jose.PS512): true,
+	string(jose.ES256): true,
+	string(jose.ES384): true,
+	string(jose.ES512): true,
+	string(jose.EdDSA): true,
+}
+
+// acmeProblem is an RFC 7807 problem document, as returned to ACME clients
+type acmeProblem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+func newACMEProblem(problemType string, status int, format string, args ...interface{}) *acmeProblem {
+	return &acmeProblem{
+		Type:   acmeProblemPrefix + problemType,
+		Detail: fmt.Sprintf(format, args...),
+		Status: status,
+	}
+}
+
+// acmeAccount is identified by the thumbprint of its key
+type acmeAccount struct {
+	ID        string           `json:"id"`
+	Key       *jose.JSONWebKey `json:"key"`
+	Status    string           `json:"status"`
+	Contact   []string         `json:"contact"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type acmeIdentifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type acmeOrder struct {
+	ID               string           `json:"id"`
+	AccountID        string           `json:"account_id"`
+	Status           string           `json:"status"`
+	Expires          time.Time        `json:"expires"`
+	Identifiers      []acmeIdentifier `json:"identifiers"`
+	AuthorizationIDs []string         `json:"authorization_ids"`
+	Certificate      string           `json:"certificate"`
+	SerialNumber     string           `json:"serial_number"`
+}
+
+type acmeChallenge struct {
+	Type      string       `json:"type"`
+	Token     string       `json:"token"`
+	Status    string       `json:"status"`
+	Validated time.Time    `json:"validated"`
+	Error     *acmeProblem `json:"error"`
+}
+
+type acmeAuthorization struct {
+	ID         string           `json:"id"`
+	AccountID  string           `json:"account_id"`
+	Status     string           `json:"status"`
+	Expires    time.Time        `json:"expires"`
+	Identifier acmeIdentifier   `json:"identifier"`
+	Wildcard   bool             `json:"wildcard"`
+	Challenges []*acmeChallenge `json:"challenges"`
+}
+
+// acmeRequest is a verified JWS request from an ACME client
+type acmeRequest struct {
+	config  *acmeConfig
+	role    string
+	account *acmeAccount
+	jwk     *jose.JSONWebKey
+	payload []byte
+}
+
+// postAsGet reports whether the request is a POST-as-GET, i.e. has an empty
+// payload
+func (r *acmeRequest) postAsGet() bool {
+	return len(r.payload) == 0
+}
+
+// acmeURL returns the absolute URL of the given ACME path of the role
+func (r *acmeRequest) acmeURL(path string) string {
+	return acmeRoleURL(r.config, r.role, path)
+}
+
+func acmeRoleURL(config *acmeConfig, role, path string) string {
+	return fmt.Sprintf("%s/acme/%s/%s", config.BaseURL, role, path)
+}
+
+func acmeStoragePrefix(role string) string {
+	return "acme/roles/" + role + "/"
+}
+
+// addACMEJWSFields adds the members of a flattened JWS, the body of every
+// ACME POST request
+func addACMEJWSFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["role"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The role whose ACME server is used.`,
+	}
+	fields["protected"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The protected header of the JWS.`,
+	}
+	fields["payload"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The payload of the JWS.`,
+	}
+	fields["signature"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The signature of the JWS.`,
+	}
+
+	return fields
+}
+
+// acmeNonceMAC returns the MAC of the expiration time and random part of a
+// nonce
+func acmeNonceMAC(config *acmeConfig, payload []byte) []byte {
+	mac := hmac.New(sha256.New, config.NonceKey)
+	mac.Write(payload)
+	return mac.Sum(nil)[:acmeNonceMACSize]
+}
+
+// newACMENonce returns a fresh nonce, valid for a single request. Nonces are
+// not stored: they carry their expiration time and are authenticated with the
+// nonce key of the configuration, so that every node accepts them.
+func (b *backend) newACMENonce(config *acmeConfig) (string, error) {
+	if len(config.NonceKey) == 0 {
+		return "", fmt.Errorf("the ACME configuration has no nonce key")
+	}
+
+	payload := make([]byte, 8+acmeNonceRandomSize)
+	binary.BigEndian.PutUint64(payload, uint64(time.Now().Add(acmeNonceLifetime).Unix()))
+	if _, err := io.ReadFull(rand.Reader, payload[8:]); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(append(payload, acmeNonceMAC(config, payload)...)), nil
+}
+
+// consumeACMENonce reports whether the nonce was issued by the ACME server,
+// has not expired and has not been used yet on this node. Used nonces are
+// remembered until they expire, up to acmeMaxUsedNonces of them; nonces are
+// refused while the limit is reached.
+func (b *backend) consumeACMENonce(config *acmeConfig, nonce string) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(raw) != 8+acmeNonceRandomSize+acmeNonceMACSize || len(config.NonceKey) == 0 {
+		return false
+	}
+	payload := raw[:8+acmeNonceRandomSize]
+	if !hmac.Equal(raw[len(payload):], acmeNonceMAC(config, payload)) {
+		return false
+	}
+	expires := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	if !time.Now().Before(expires) {
+		return false
+	}
+
+	b.acmeNonceLock.Lock()
+	defer b.acmeNonceLock.Unlock()
+
+	if _, ok := b.acmeUsedNonces[nonce]; ok {
+		return false
+	}
+	if len(b.acmeUsedNonces) >= acmeMaxUsedNonces {
+		return false
+	}
+	b.acmeUsedNonces[nonce] = expires
+
+	return true
+}
+
+// purgeACMENonces forgets the used nonces which have expired, and would be
+// refused anyway
+func (b *backend) purgeACMENonces() {
+	b.acmeNonceLock.Lock()
+	defer b.acmeNonceLock.Unlock()
+
+	now := time.Now()
+	for nonce, expires := range b.acmeUsedNonces {
+		if !now.Before(expires) {
+			delete(b.acmeUsedNonces, nonce)
+		}
+	}
+}
+
+// acmeResponse builds a raw response carrying the headers every ACME response
+// needs
+func (b *backend) acmeResponse(config *acmeConfig, role string, status int, contentType string, body []byte) (*logical.Response, error) {
+	nonce, err := b.newACMENonce(config)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode: status,
+		},
+		Headers: map[string][]string{
+			"Replay-Nonce": []string{nonce},
+			"Link":         []string{fmt.Sprintf("<%s>;rel=\"index\"", acmeRoleURL(config, role, "directory"))},
+		},
+	}
+	if contentType != "" {
+		resp.Data[logical.HTTPContentType] = contentType
+		resp.Data[logical.HTTPRawBody] = body
+	}
+
+	return resp, nil
+}
+
+// acmeJSONResponse returns obj as the JSON body of an ACME response
+func (b *backend) acmeJSONResponse(config *acmeConfig, role string, status int, obj interface{}) (*logical.Response, error) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.acmeResponse(config, role, status, "application/json", body)
+}
+
+// acmeProblemResponse returns the problem document to the client
+func (b *backend) acmeProblemResponse(config *acmeConfig, role string, problem *acmeProblem) (*logical.Response, error) {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.acmeResponse(config, role, problem.Status, "application/problem+json", body)
+}
+
+// parseACMERequest verifies the JWS of an ACME POST request. Requests to
+// new-account are signed with the embedded key of the account, all others
+// reference an existing account by its URL.
+func (b *backend) parseACMERequest(ctx context.Context, req *logical.Request, data *framework.FieldData, config *acmeConfig, newAccount bool) (*acmeRequest, *acmeProblem, error) {
+	if req.Operation != logical.UpdateOperation {
+		return nil, newACMEProblem("malformed", http.StatusMethodNotAllowed, "ACME requests must be sent as POST"), nil
+	}
+
+	raw, err := json.Marshal(map[string]string{
+		"protected": data.Get("protected").(string),
+		"payload":   data.Get("payload").(string),
+		"signature": data.Get("signature").(string),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jws, err := jose.ParseSigned(string(raw))
+	if err != nil {
+		return nil, newACMEProblem("malformed", http.StatusBadRequest, "unable to parse JWS: %s", err), nil
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, newACMEProblem("malformed", http.StatusBadRequest, "JWS must have exactly one signature"), nil
+	}
+	header := jws.Signatures[0].Protected
+
+	if !acmeAllowedAlgorithms[header.Algorithm] {
+		return nil, newACMEProblem("badSignatureAlgorithm", http.StatusBadRequest, "unsupported JWS algorithm %q", header.Algorithm), nil
+	}
+	if header.Nonce == "" || !b.consumeACMENonce(config, header.Nonce) {
+		return nil, newACMEProblem("badNonce", http.StatusBadRequest, "invalid or expired nonce"), nil
+	}
+
+	role := data.Get("role").(string)
+	expectedURL := config.BaseURL + "/" + req.Path
+	if requestURL, _ := header.ExtraHeaders["url"].(string); requestURL != expectedURL {
+		return nil, newACMEProblem("unauthorized", http.StatusUnauthorized, "JWS url %q does not match the request URL", requestURL), nil
+	}
+
+	acmeReq := &acmeRequest{
+		config: config,
+		role:   role,
+	}
+
+	switch {
+	case header.JSONWebKey != nil && header.KeyID != "":
+		return nil, newACMEProblem("malformed", http.StatusBadRequest, "JWS must not contain both jwk and kid"), nil
+
+	case newAccount:
+		if header.JSONWebKey == nil {
+			return nil, newACMEProblem("malformed", http.StatusBadRequest, "new-account requests must be signed with an embedded jwk"), nil
+		}
+		if !header.JSONWebKey.Valid() || !header.JSONWebKey.IsPublic() {
+			return nil, newACMEProblem("badPublicKey", http.StatusBadRequest, "jwk is not a valid public key"), nil
+		}
+		acmeReq.jwk = header.JSONWebKey
+
+	default:
+		if header.KeyID == "" {
+			return nil, newACMEProblem("malformed", http.StatusBadRequest, "JWS must reference an account with kid"), nil
+		}
+		accountPrefix := acmeReq.acmeURL("account/")
+		if !strings.HasPrefix(header.KeyID, accountPrefix) {
+			return nil, newACMEProblem("accountDoesNotExist", http.StatusBadRequest, "unknown account %q", header.KeyID), nil
+		}
+		account, err := b.fetchACMEAccount(ctx, req.Storage, role, strings.TrimPrefix(header.KeyID, accountPrefix))
+		if err != nil {
+			return nil, nil, err
+		}
+		if account == nil {
+			return nil, newACMEProblem("accountDoesNotExist", http.StatusBadRequest, "unknown account %q", header.KeyID), nil
+		}
+		if account.Status != acmeStatusValid {
+			return nil, newACMEProblem("unauthorized", http.StatusUnauthorized, "account is %s", account.Status), nil
+		}
+		acmeReq.account = account
+		acmeReq.jwk = account.Key
+	}
+
+	payload, err := jws.Verify(acmeReq.jwk)
+	if err != nil {
+		return nil, newACMEProblem("malformed", http.StatusBadRequest, "unable to verify JWS signature"), nil
+	}
+	acmeReq.payload = payload
+
+	return acmeReq, nil, nil
+}
+
+// decodePayload unmarshals the JSON payload of the request
+func (r *acmeRequest) decodePayload(out interface{}) *acmeProblem {
+	if err := json.Unmarshal(r.payload, out); err != nil {
+		return newACMEProblem("malformed", http.StatusBadRequest, "unable to parse payload: %s", err)
+	}
+	return nil
+}
+
+func jwkThumbprint(jwk *jose.JSONWebKey) (string, error) {
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(thumbprint), nil
+}
+
+func (b *backend) fetchACMEAccount(ctx context.Context, s logical.Storage, role, id string) (*acmeAccount, error) {
+	account := &acmeAccount{}
+	found, err := fetchACMEEntry(ctx, s, acmeStoragePrefix(role)+"accounts/"+id, account)
+	if err != nil || !found {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (b *backend) fetchACMEOrder(ctx context.Context, s logical.Storage, role, id string) (*acmeOrder, error) {
+	order := &acmeOrder{}
+	found, err := fetchACMEEntry(ctx, s, acmeStoragePrefix(role)+"orders/"+id, order)
+	if err != nil || !found {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (b *backend) fetchACMEAuthorization(ctx context.Context, s logical.Storage, role, id string) (*acmeAuthorization, error) {
+	authz := &acmeAuthorization{}
+	found, err := fetchACMEEntry(ctx, s, acmeStoragePrefix(role)+"authorizations/"+id, authz)
+	if err != nil || !found {
+		return nil, err
+	}
+	return authz, nil
+}
+
+func fetchACMEEntry(ctx context.Context, s logical.Storage, path string, out interface{}) (bool, error) {
+	entry, err := s.Get(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	if entry == nil {
+		return false, nil
+	}
+	if err := entry.DecodeJSON(out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func writeACMEEntry(ctx context.Context, s logical.Storage, path string, in interface{}) error {
+	entry, err := logical.StorageEntryJSON(path, in)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// keyAuthorization returns the key authorization of the token, as defined in
+// section 8.1 of RFC 8555
+func keyAuthorization(token string, jwk *jose.JSONWebKey) (string, error) {
+	thumbprint, err := jwkThumbprint(jwk)
+	if err != nil {
+		return "", err
+	}
+	return token + "." + thumbprint, nil
+}
+
+// validateACMEChallenge checks the challenge against the identifier from this
+// node, returning a problem describing why validation failed
+func validateACMEChallenge(ctx context.Context, config *acmeConfig, authz *acmeAuthorization, challenge *acmeChallenge, keyAuthz string) *acmeProblem {
+	ctx, cancel := context.WithTimeout(ctx, acmeValidationTimeout)
+	defer cancel()
+
+	switch challenge.Type {
+	case acmeChallengeHTTP01:
+		return validateHTTP01(ctx, config, authz.Identifier.Value, challenge.Token, keyAuthz)
+	case acmeChallengeDNS01:
+		return validateDNS01(ctx, config, authz.Identifier.Value, keyAuthz)
+	default:
+		return newACMEProblem("malformed", http.StatusBadRequest, "unsupported challenge type %q", challenge.Type)
+	}
+}
+
+func validateHTTP01(ctx context.Context, config *acmeConfig, domain, token, keyAuthz string) *acmeProblem {
+	transport := &http.Transport{
+		DisableKeepAlives: true,
+	}
+	if config.TestMode && config.TestHTTPAddress != "" {
+		// Connect to the stand-in server while keeping the identifier as the
+		// Host of the request
+		transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, config.TestHTTPAddress)
+		}
+	}
+	client := &http.Client{
+		Transport: transport,
+	}
+
+	challengeURL := fmt.Sprintf("http://%s/.well-known/acme-challenge/%s", domain, token)
+	httpReq, err := http.NewRequest(http.MethodGet, challengeURL, nil)
+	if err != nil {
+		return newACMEProblem("malformed", http.StatusBadRequest, "invalid challenge URL: %s", err)
+	}
+
+	httpResp, err := client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return newACMEProblem("connection", http.StatusBadRequest, "unable to fetch %s: %s", challengeURL, err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return newACMEProblem("unauthorized", http.StatusForbidden, "fetching %s returned status %d", challengeURL, httpResp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(httpResp.Body, acmeMaxChallengeBodyLen))
+	if err != nil {
+		return newACMEProblem("connection", http.StatusBadRequest, "unable to read %s: %s", challengeURL, err)
+	}
+	if strings.TrimSpace(string(body)) != keyAuthz {
+		return newACMEProblem("unauthorized", http.StatusForbidden, "key authorization at %s does not match", challengeURL)
+	}
+
+	return nil
+}
+
+func validateDNS01(ctx context.Context, config *acmeConfig, domain, keyAuthz string) *acmeProblem {
+	resolver := net.DefaultResolver
+	if config.DNSResolver != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, config.DNSResolver)
+			},
+		}
+	}
+
+	digest := sha256.Sum256([]byte(keyAuthz))
+	expected := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	name := "_acme-challenge." + domain
+	records, err := resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return newACMEProblem("dns", http.StatusBadRequest, "unable to look up TXT records of %s: %s", name, err)
+	}
+	for _, record := range records {
+		if record == expected {
+			return nil
+		}
+	}
+
+	return newACMEProblem("unauthorized", http.StatusForbidden, "no TXT record of %s matches the key authorization", name)
+}
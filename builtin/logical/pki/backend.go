@@ -6,7 +6,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -33,12 +35,26 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"ca",
 				"crl/pem",
 				"crl",
+				"crl/delta",
+				"crl/delta/pem",
+				"cert/issuer/*",
+				"crl/issuer/*",
+				"ocsp",
+				"ocsp/*",
+				"acme/*",
 			},
 
 			LocalStorage: []string{
 				"revoked/",
 				"crl",
 				"certs/",
+				"cert-metadata/",
+				"crls/",
+				"delta-crl",
+				"delta-crls/",
+				"delta-wal/",
+				"crl-state",
+				"tidy-state",
 			},
 
 			Root: []string{
@@ -48,13 +64,15 @@ func Backend(conf *logical.BackendConfig) *backend {
 
 			SealWrapStorage: []string{
 				"config/ca_bundle",
+				"config/key/",
 			},
 		},
 
-		Paths: []*framework.Path{
+		Paths: framework.PathAppend([]*framework.Path{
 			pathListRoles(&b),
 			pathRoles(&b),
 			pathGenerateRoot(&b),
+			pathRotateRoot(&b),
 			pathSignIntermediate(&b),
 			pathSignSelfIssued(&b),
 			pathDeleteRoot(&b),
@@ -67,30 +85,115 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathSign(&b),
 			pathIssue(&b),
 			pathRotateCRL(&b),
+			pathRotateDeltaCRL(&b),
 			pathFetchCA(&b),
 			pathFetchCAChain(&b),
 			pathFetchCRL(&b),
 			pathFetchCRLViaCertPath(&b),
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
+			pathFetchListCertsDetailed(&b),
+			pathFetchListRevokedCerts(&b),
 			pathRevoke(&b),
 			pathTidy(&b),
+			pathTidyStatus(&b),
+			pathTidyCancel(&b),
+			pathConfigAutoTidy(&b),
+			pathOcspGet(&b),
+			pathOcspPost(&b),
+			pathListIssuers(&b),
+			pathIssuer(&b),
+			pathConfigIssuers(&b),
+			pathFetchIssuer(&b),
+			pathFetchIssuerCRL(&b),
+			pathListKeys(&b),
+			pathKey(&b),
+			pathConfigACME(&b),
 		},
+			pathsACME(&b),
+		),
 
 		Secrets: []*framework.Secret{
 			secretCerts(&b),
 		},
 
-		BackendType: logical.TypeLogical,
+		BackendType:    logical.TypeLogical,
+		InitializeFunc: b.initialize,
+		PeriodicFunc:   b.periodicFunc,
 	}
 
 	b.crlLifetime = time.Hour * 72
 	b.tidyCASGuard = new(uint32)
+	b.tidyCancelCAS = new(uint32)
+	b.tidyStatus = &tidyStatus{state: tidyStatusInactive}
+	b.lastTidy = time.Now()
 	b.storage = conf.StorageView
+	b.acmeUsedNonces = make(map[string]time.Time)
 
 	return &b
 }
 
+// initialize restores when the last tidy operation started, and migrates a CA
+// configured before multiple issuers were supported into the issuer and key
+// storage
+func (b *backend) initialize(ctx context.Context, req *logical.InitializationRequest) error {
+	// on standbys and DR secondaries we do not want to run any kind of upgrade logic
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby | consts.ReplicationDRSecondary) {
+		return nil
+	}
+
+	// Without a stored start time, the auto-tidy interval counts from now
+	state, err := getTidyState(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	if !state.LastStarted.IsZero() {
+		b.tidyStatusLock.Lock()
+		b.lastTidy = state.LastStarted
+		b.tidyStatusLock.Unlock()
+	}
+
+	// Initialize only if we are either:
+	//   (1) A local mount.
+	//   (2) Are _NOT_ a replicated performance secondary
+	if b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		migrated, err := migrateLegacyCertBundle(ctx, req.Storage)
+		if err != nil {
+			return err
+		}
+		if migrated {
+			b.Logger().Info("migrated legacy CA bundle to issuer storage")
+
+			// Populate the per-issuer CRL of the migrated issuer
+			if err := buildCRL(ctx, b, &logical.Request{Storage: req.Storage}, true); err != nil {
+				b.Logger().Warn("failed to rebuild CRL after migration", "error", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// periodicFunc purges the used ACME nonces, and rebuilds the CRLs and runs
+// the automatic tidy operation when they are due
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	// Every node remembers the ACME nonces used on it
+	b.purgeACMENonces()
+
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby | consts.ReplicationDRSecondary) {
+		return nil
+	}
+
+	var result error
+	if err := b.periodicRebuildCRL(ctx, req); err != nil {
+		result = multierror.Append(result, err)
+	}
+	if err := b.periodicTidy(ctx, req); err != nil {
+		result = multierror.Append(result, err)
+	}
+	return result
+}
+
 type backend struct {
 	*framework.Backend
 
@@ -98,6 +201,18 @@ type backend struct {
 	crlLifetime       time.Duration
 	revokeStorageLock sync.RWMutex
 	tidyCASGuard      *uint32
+	tidyCancelCAS     *uint32
+
+	// tidyStatus describes the current or last tidy operation
+	tidyStatus     *tidyStatus
+	tidyStatusLock sync.RWMutex
+	lastTidy       time.Time
+
+	// acmeUsedNonces holds the expiration times of the ACME nonces used on
+	// this node, so that they cannot be replayed
+	acmeUsedNonces map[string]time.Time
+	acmeNonceLock  sync.Mutex
+	acmeLock       sync.Mutex
 }
 
 const backendHelp = `
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -67,20 +68,13 @@ func getFormat(data *framework.FieldData) string {
 	return format
 }
 
-// Fetches the CA info. Unlike other certificates, the CA info is stored
-// in the backend as a CertBundle, because we are storing its private key
-func fetchCAInfo(ctx context.Context, req *logical.Request) (*certutil.CAInfoBundle, error) {
-	bundleEntry, err := req.Storage.Get(ctx, "config/ca_bundle")
+// Fetches the CA info for the given issuer reference. Unlike other
+// certificates, the CA info is stored in the backend along with its private
+// key, which is loaded here as well.
+func fetchCAInfo(ctx context.Context, req *logical.Request, issuerRef string) (*certutil.CAInfoBundle, error) {
+	_, bundle, err := fetchCertBundleByIssuerRef(ctx, req.Storage, issuerRef, true)
 	if err != nil {
-		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch local CA certificate/key: %v", err)}
-	}
-	if bundleEntry == nil {
-		return nil, errutil.UserError{Err: "backend must be configured with a CA certificate/key"}
-	}
-
-	var bundle certutil.CertBundle
-	if err := bundleEntry.DecodeJSON(&bundle); err != nil {
-		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode local CA certificate/key: %v", err)}
+		return nil, err
 	}
 
 	parsedBundle, err := bundle.ToParsedCertBundle()
@@ -110,6 +104,39 @@ func fetchCAInfo(ctx context.Context, req *logical.Request) (*certutil.CAInfoBun
 	return caInfo, nil
 }
 
+// certMetadata holds information about an issued certificate which can't be
+// derived from the certificate itself
+type certMetadata struct {
+	Role string `json:"role"`
+}
+
+// fetchCertMetadata returns the metadata stored for the given serial, which
+// is empty for certificates issued before metadata was stored
+func fetchCertMetadata(ctx context.Context, s logical.Storage, serial string) (*certMetadata, error) {
+	metadata := &certMetadata{}
+
+	entry, err := s.Get(ctx, certMetadataPrefix+normalizeSerial(serial))
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching metadata of certificate %s: %s", serial, err)}
+	}
+	if entry == nil {
+		return metadata, nil
+	}
+	if err := entry.DecodeJSON(metadata); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error decoding metadata of certificate %s: %s", serial, err)}
+	}
+
+	return metadata, nil
+}
+
+// deleteCertEntry removes a stored certificate along with its metadata
+func deleteCertEntry(ctx context.Context, s logical.Storage, serial string) error {
+	if err := s.Delete(ctx, "certs/"+serial); err != nil {
+		return err
+	}
+	return s.Delete(ctx, certMetadataPrefix+normalizeSerial(serial))
+}
+
 // Allows fetching certificates from the backend; it handles the slightly
 // separate pathing for CA, CRL, and revoked certificates.
 func fetchCertBySerial(ctx context.Context, req *logical.Request, prefix, serial string) (*logical.StorageEntry, error) {
@@ -130,6 +157,8 @@ func fetchCertBySerial(ctx context.Context, req *logical.Request, prefix, serial
 		path = "ca"
 	case serial == "crl":
 		path = "crl"
+	case serial == "delta-crl":
+		path = deltaCRLPath
 	default:
 		legacyPath = "certs/" + colonSerial
 		path = "certs/" + hyphenSerial
@@ -594,6 +623,18 @@ func signCert(b *backend,
 				pubKey.Params().BitSize)}
 		}
 
+	case "ed25519":
+		// Verify that the key matches the role type; Ed25519 keys have a
+		// fixed size so there are no bit checks
+		if csr.PublicKeyAlgorithm != x509.Ed25519 {
+			return nil, errutil.UserError{Err: fmt.Sprintf(
+				"role requires keys of type %s",
+				data.role.KeyType)}
+		}
+		if _, ok := csr.PublicKey.(ed25519.PublicKey); !ok {
+			return nil, errutil.UserError{Err: "could not parse CSR's public key"}
+		}
+
 	case "any":
 		// We only care about running RSA < 2048 bit checks, so if not RSA
 		// break out
@@ -1140,6 +1181,9 @@ func convertRespToPKCS8(resp *logical.Response) error {
 		signer, err = x509.ParsePKCS1PrivateKey(keyData)
 	case certutil.ECPrivateKey:
 		signer, err = x509.ParseECPrivateKey(keyData)
+	case certutil.Ed25519PrivateKey:
+		// Ed25519 keys are always stored as PKCS8 already
+		return nil
 	default:
 		return fmt.Errorf("unknown private key type %q", privKeyType)
 	}
@@ -1,8 +1,14 @@
 package pki
 
 import (
+	"bytes"
+	"context"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	vaulthttp "github.com/hashicorp/vault/http"
@@ -122,3 +128,165 @@ func TestBackend_CRL_EnableDisable(t *testing.T) {
 	toggle(false)
 	test(6)
 }
+
+func TestPki_DeltaCRL(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	write := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(logical.UpdateOperation, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	rotate := func(path string) {
+		t.Helper()
+		resp, err := request(logical.ReadOperation, path, nil)
+		if err != nil || resp == nil || resp.IsError() {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+	}
+	readCRL := func(path string) *pkix.CertificateList {
+		t.Helper()
+		resp, err := request(logical.ReadOperation, path, nil)
+		if err != nil || resp == nil {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		crl, err := x509.ParseCRL(resp.Data[logical.HTTPRawBody].([]byte))
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", path, err)
+		}
+		return crl
+	}
+	extension := func(crl *pkix.CertificateList, oid asn1.ObjectIdentifier) *big.Int {
+		t.Helper()
+		for _, ext := range crl.TBSCertList.Extensions {
+			if !ext.Id.Equal(oid) {
+				continue
+			}
+			number := new(big.Int)
+			if _, err := asn1.Unmarshal(ext.Value, &number); err != nil {
+				t.Fatal(err)
+			}
+			return number
+		}
+		return nil
+	}
+	oidCRLNumber := asn1.ObjectIdentifier{2, 5, 29, 20}
+
+	write("root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "48h",
+	})
+	write("roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+	})
+	var serials []string
+	for i := 0; i < 3; i++ {
+		resp := write("issue/test", map[string]interface{}{
+			"common_name": "cert.myvault.com",
+		})
+		serials = append(serials, resp.Data["serial_number"].(string))
+	}
+
+	// Delta CRLs need the CRL to be rebuilt automatically
+	resp, err := request(logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"enable_delta": true,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error enabling delta CRLs, got err: %v resp: %#v", err, resp)
+	}
+	resp, err = request(logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"auto_rebuild":          true,
+		"auto_rebuild_interval": "96h",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error for interval beyond expiry, got err: %v resp: %#v", err, resp)
+	}
+	write("config/crl", map[string]interface{}{
+		"auto_rebuild":           true,
+		"enable_delta":           true,
+		"delta_rebuild_interval": "1s",
+	})
+
+	// Revoking no longer touches the complete CRL
+	write("revoke", map[string]interface{}{
+		"serial_number": serials[0],
+	})
+	full := readCRL("crl")
+	if len(full.TBSCertList.RevokedCertificates) != 0 {
+		t.Fatalf("expected complete CRL to be left alone")
+	}
+	baseNumber := extension(full, oidCRLNumber)
+	if baseNumber == nil {
+		t.Fatalf("expected complete CRL to carry a CRL number")
+	}
+
+	rotate("crl/rotate-delta")
+	delta := readCRL("crl/delta")
+	if len(delta.TBSCertList.RevokedCertificates) != 1 {
+		t.Fatalf("expected one revoked cert in delta CRL, got %d", len(delta.TBSCertList.RevokedCertificates))
+	}
+	if indicator := extension(delta, oidDeltaCRLIndicator); indicator == nil || indicator.Cmp(baseNumber) != 0 {
+		t.Fatalf("expected delta CRL to refer to complete CRL %v, got %v", baseNumber, indicator)
+	}
+	if number := extension(delta, oidCRLNumber); number == nil || number.Cmp(baseNumber) <= 0 {
+		t.Fatalf("expected delta CRL number beyond %v, got %v", baseNumber, number)
+	}
+	if issuerDelta := readCRL("crl/issuer/default/delta"); !bytes.Equal(issuerDelta.TBSCertList.Raw, delta.TBSCertList.Raw) {
+		t.Fatalf("expected issuer delta CRL to match the default delta CRL")
+	}
+
+	// The periodic function picks up further revocations once the delta
+	// rebuild interval has passed
+	write("revoke", map[string]interface{}{
+		"serial_number": serials[1],
+	})
+	time.Sleep(time.Second)
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if delta = readCRL("crl/delta/pem"); len(delta.TBSCertList.RevokedCertificates) != 2 {
+		t.Fatalf("expected two revoked certs in delta CRL, got %d", len(delta.TBSCertList.RevokedCertificates))
+	}
+
+	// A complete rebuild folds the delta into the complete CRL
+	rotate("crl/rotate")
+	full = readCRL("crl")
+	if len(full.TBSCertList.RevokedCertificates) != 2 {
+		t.Fatalf("expected two revoked certs in complete CRL, got %d", len(full.TBSCertList.RevokedCertificates))
+	}
+	delta = readCRL("crl/delta")
+	if len(delta.TBSCertList.RevokedCertificates) != 0 {
+		t.Fatalf("expected empty delta CRL after complete rebuild")
+	}
+	if indicator := extension(delta, oidDeltaCRLIndicator); indicator.Cmp(extension(full, oidCRLNumber)) != 0 {
+		t.Fatalf("expected delta CRL to refer to the new complete CRL")
+	}
+
+	// Disabling delta CRLs removes them and revocation rebuilds the CRL again
+	write("config/crl", map[string]interface{}{
+		"auto_rebuild": false,
+		"enable_delta": false,
+	})
+	resp, err = request(logical.ReadOperation, "crl/delta", nil)
+	if err != nil || resp.Data[logical.HTTPStatusCode] != 204 {
+		t.Fatalf("expected no delta CRL, got err: %v resp: %#v", err, resp)
+	}
+	write("revoke", map[string]interface{}{
+		"serial_number": serials[2],
+	})
+	if full = readCRL("crl"); len(full.TBSCertList.RevokedCertificates) != 3 {
+		t.Fatalf("expected three revoked certs in complete CRL, got %d", len(full.TBSCertList.RevokedCertificates))
+	}
+}
@@ -1,12 +1,14 @@
 package pki
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
-	"errors"
+	"encoding/asn1"
 	"fmt"
+	"math/big"
 	"strings"
 	"time"
 
@@ -16,6 +18,83 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+const (
+	// deltaCRLPath holds the delta CRL of the default issuer, mirroring the
+	// legacy "crl" location
+	deltaCRLPath         = "delta-crl"
+	issuerDeltaCRLPrefix = "delta-crls/"
+
+	// deltaWALPrefix holds the serials revoked since the last complete CRL
+	// was built
+	deltaWALPrefix = "delta-wal/"
+	crlStatePath   = "crl-state"
+)
+
+// oidDeltaCRLIndicator marks a CRL as a delta CRL, see RFC 5280 section 5.2.4
+var oidDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+
+// crlState tracks the CRL numbers of every issuer and when the CRLs were last
+// built. Issuers are keyed by ID, or by the empty string for a CA bundle that
+// has not been migrated to issuer storage yet.
+type crlState struct {
+	// NextNumbers holds the next CRL number of each issuer; complete and
+	// delta CRLs share the same sequence
+	NextNumbers map[string]int64 `json:"next_numbers"`
+
+	// BaseNumbers holds the number of the last complete CRL of each issuer,
+	// which delta CRLs refer to
+	BaseNumbers map[string]int64 `json:"base_numbers"`
+
+	LastRebuild      time.Time `json:"last_rebuild"`
+	LastDeltaRebuild time.Time `json:"last_delta_rebuild"`
+}
+
+func (s *crlState) nextNumber(issuerID string) int64 {
+	number := s.NextNumbers[issuerID]
+	if number == 0 {
+		number = 1
+	}
+	s.NextNumbers[issuerID] = number + 1
+	return number
+}
+
+func getCRLState(ctx context.Context, s logical.Storage) (*crlState, error) {
+	state := &crlState{
+		NextNumbers: make(map[string]int64),
+		BaseNumbers: make(map[string]int64),
+	}
+
+	entry, err := s.Get(ctx, crlStatePath)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching CRL state: %s", err)}
+	}
+	if entry == nil {
+		return state, nil
+	}
+	if err := entry.DecodeJSON(state); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error decoding CRL state: %s", err)}
+	}
+	if state.NextNumbers == nil {
+		state.NextNumbers = make(map[string]int64)
+	}
+	if state.BaseNumbers == nil {
+		state.BaseNumbers = make(map[string]int64)
+	}
+
+	return state, nil
+}
+
+func setCRLState(ctx context.Context, s logical.Storage, state *crlState) error {
+	entry, err := logical.StorageEntryJSON(crlStatePath, state)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error encoding CRL state: %s", err)}
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL state: %s", err)}
+	}
+	return nil
+}
+
 type revocationInfo struct {
 	CertificateBytes  []byte    `json:"certificate_bytes"`
 	RevocationTime    int64     `json:"revocation_time"`
@@ -33,19 +112,18 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 		return nil, nil
 	}
 
-	signingBundle, caErr := fetchCAInfo(ctx, req)
-	switch caErr.(type) {
+	issuerSerials, err := fetchIssuerSerials(ctx, req.Storage)
+	switch err.(type) {
 	case errutil.UserError:
-		return logical.ErrorResponse(fmt.Sprintf("could not fetch the CA certificate: %s", caErr)), nil
+		return logical.ErrorResponse(fmt.Sprintf("could not fetch the CA certificate: %s", err)), nil
 	case errutil.InternalError:
-		return nil, fmt.Errorf("error fetching CA certificate: %s", caErr)
-	}
-	if signingBundle == nil {
-		return nil, errors.New("CA info not found")
+		return nil, fmt.Errorf("error fetching CA certificate: %s", err)
 	}
 	colonSerial := strings.Replace(strings.ToLower(serial), "-", ":", -1)
-	if colonSerial == certutil.GetHexFormatted(signingBundle.Certificate.SerialNumber.Bytes(), ":") {
-		return logical.ErrorResponse("adding CA to CRL is not allowed"), nil
+	for _, issuerSerial := range issuerSerials {
+		if colonSerial == issuerSerial {
+			return logical.ErrorResponse("adding CA to CRL is not allowed"), nil
+		}
 	}
 
 	alreadyRevoked := false
@@ -127,7 +205,27 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 
 	}
 
-	crlErr := buildCRL(ctx, b, req, false)
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return nil, errwrap.Wrapf("error fetching CRL config information: {{err}}", err)
+	}
+
+	var crlErr error
+	switch {
+	case crlInfo != nil && crlInfo.AutoRebuild && !crlInfo.Disable:
+		// The CRL is rebuilt by the periodic function; only remember the
+		// serial for the next delta CRL
+		if crlInfo.EnableDelta && !alreadyRevoked {
+			walEntry := &logical.StorageEntry{
+				Key: deltaWALPrefix + normalizeSerial(serial),
+			}
+			if err := req.Storage.Put(ctx, walEntry); err != nil {
+				return nil, fmt.Errorf("error saving delta CRL entry")
+			}
+		}
+	default:
+		crlErr = buildCRL(ctx, b, req, false)
+	}
 	switch crlErr.(type) {
 	case errutil.UserError:
 		return logical.ErrorResponse(fmt.Sprintf("Error during CRL building: %s", crlErr)), nil
@@ -146,95 +244,448 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 	return resp, nil
 }
 
-// Builds a CRL by going through the list of revoked certificates and building
-// a new CRL with the stored revocation times and serial numbers.
+// fetchIssuerSerials returns the colon-separated serial numbers of all CA
+// certificates known to this mount.
+func fetchIssuerSerials(ctx context.Context, s logical.Storage) ([]string, error) {
+	issuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to list issuers: %v", err)}
+	}
+
+	if len(issuers) == 0 {
+		_, legacy, err := fetchCertBundleByIssuerRef(ctx, s, defaultRef, false)
+		if err != nil {
+			return nil, err
+		}
+		return []string{strings.ToLower(legacy.SerialNumber)}, nil
+	}
+
+	var serials []string
+	for _, issuerID := range issuers {
+		issuer, err := fetchIssuerByID(ctx, s, issuerID)
+		if err != nil {
+			return nil, err
+		}
+		serials = append(serials, strings.ToLower(issuer.SerialNumber))
+	}
+
+	return serials, nil
+}
+
+// Builds a complete CRL for every issuer with a key by going through the list
+// of revoked certificates and building a new CRL with the stored revocation
+// times and serial numbers of those it issued. The default issuer's CRL is
+// also stored at the legacy "crl" location. When delta CRLs are enabled, the
+// delta CRLs are rebuilt on top of the new complete CRLs.
 func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bool) error {
 	crlInfo, err := b.CRL(ctx, req.Storage)
 	if err != nil {
 		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
 	}
 
-	crlLifetime := b.crlLifetime
-	var revokedCerts []pkix.RevokedCertificate
-	var revInfo revocationInfo
-	var revokedSerials []string
+	crlLifetime, err := crlInfo.lifetime(b.crlLifetime)
+	if err != nil {
+		return err
+	}
 
-	if crlInfo != nil {
-		if crlInfo.Expiry != "" {
-			crlDur, err := time.ParseDuration(crlInfo.Expiry)
-			if err != nil {
-				return errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s", crlInfo.Expiry)}
-			}
-			crlLifetime = crlDur
+	var revokedCerts []*revokedCertEntry
+
+	if crlInfo != nil && crlInfo.Disable {
+		if !forceNew {
+			return nil
+		}
+		goto WRITE
+	}
+
+	revokedCerts, err = fetchRevokedCerts(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+WRITE:
+	crlIssuers, err := fetchCRLIssuers(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	state, err := getCRLState(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	// Everything revoked so far is part of the new complete CRLs
+	walSerials, err := req.Storage.List(ctx, deltaWALPrefix)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching list of delta CRL entries: %s", err)}
+	}
+
+	for _, crlIssuer := range crlIssuers {
+		var number int64
+		if supportsCRLNumbers(crlIssuer.bundle.Certificate) {
+			number = state.nextNumber(crlIssuer.id)
 		}
+		issued := crlIssuer.filterIssued(revokedCerts)
 
-		if crlInfo.Disable {
-			if !forceNew {
-				return nil
+		if crlIssuer.id != "" {
+			if err := writeCRL(ctx, req.Storage, crlIssuer.bundle, issued, crlLifetime, number, 0, issuerCRLPrefix+crlIssuer.id); err != nil {
+				return err
+			}
+		}
+		if crlIssuer.isDefault {
+			if err := writeCRL(ctx, req.Storage, crlIssuer.bundle, issued, crlLifetime, number, 0, "crl"); err != nil {
+				return err
 			}
-			goto WRITE
 		}
+		state.BaseNumbers[crlIssuer.id] = number
+	}
+
+	for _, serial := range walSerials {
+		if err := req.Storage.Delete(ctx, deltaWALPrefix+serial); err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("error removing delta CRL entry for serial %s: %s", serial, err)}
+		}
+	}
+
+	state.LastRebuild = time.Now()
+	if err := setCRLState(ctx, req.Storage, state); err != nil {
+		return err
 	}
 
-	revokedSerials, err = req.Storage.List(ctx, "revoked/")
+	if crlInfo != nil && crlInfo.EnableDelta && !crlInfo.Disable {
+		return buildDeltaCRL(ctx, b, req)
+	}
+
+	return nil
+}
+
+// Builds a delta CRL for every issuer with a key, holding only the
+// certificates revoked since its last complete CRL was built
+func buildDeltaCRL(ctx context.Context, b *backend, req *logical.Request) error {
+	crlInfo, err := b.CRL(ctx, req.Storage)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error fetching list of revoked certs: %s", err)}
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
+	}
+	if crlInfo == nil || !crlInfo.EnableDelta || crlInfo.Disable {
+		return nil
 	}
 
-	for _, serial := range revokedSerials {
-		revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
+	crlLifetime, err := crlInfo.lifetime(b.crlLifetime)
+	if err != nil {
+		return err
+	}
+
+	walSerials, err := req.Storage.List(ctx, deltaWALPrefix)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching list of delta CRL entries: %s", err)}
+	}
+
+	var revokedCerts []*revokedCertEntry
+	for _, serial := range walSerials {
+		revokedCert, err := fetchRevokedCert(ctx, req.Storage, serial)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
+			return err
+		}
+		if revokedCert == nil {
+			// Removed by tidy in the meantime
+			continue
+		}
+		revokedCerts = append(revokedCerts, revokedCert)
+	}
+
+	crlIssuers, err := fetchCRLIssuers(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	state, err := getCRLState(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	for _, crlIssuer := range crlIssuers {
+		baseNumber := state.BaseNumbers[crlIssuer.id]
+		if baseNumber == 0 {
+			// The complete CRL has no number, so there is nothing a delta CRL
+			// could refer to
+			continue
 		}
-		if revokedEntry == nil {
-			return errutil.InternalError{Err: fmt.Sprintf("revoked certificate entry for serial %s is nil", serial)}
+
+		number := state.nextNumber(crlIssuer.id)
+		issued := crlIssuer.filterIssued(revokedCerts)
+
+		if crlIssuer.id != "" {
+			if err := writeCRL(ctx, req.Storage, crlIssuer.bundle, issued, crlLifetime, number, baseNumber, issuerDeltaCRLPrefix+crlIssuer.id); err != nil {
+				return err
+			}
 		}
-		if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
-			// TODO: In this case, remove it and continue? How likely is this to
-			// happen? Alternately, could skip it entirely, or could implement a
-			// delete function so that there is a way to remove these
-			return errutil.InternalError{Err: fmt.Sprintf("found revoked serial but actual certificate is empty")}
+		if crlIssuer.isDefault {
+			if err := writeCRL(ctx, req.Storage, crlIssuer.bundle, issued, crlLifetime, number, baseNumber, deltaCRLPath); err != nil {
+				return err
+			}
 		}
+	}
 
-		err = revokedEntry.DecodeJSON(&revInfo)
+	state.LastDeltaRebuild = time.Now()
+	return setCRLState(ctx, req.Storage, state)
+}
+
+// deleteDeltaCRLs removes all delta CRLs and pending delta CRL entries, used
+// once delta CRLs are disabled
+func deleteDeltaCRLs(ctx context.Context, s logical.Storage) error {
+	for _, prefix := range []string{issuerDeltaCRLPrefix, deltaWALPrefix} {
+		keys, err := s.List(ctx, prefix)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
+			return err
+		}
+		for _, key := range keys {
+			if err := s.Delete(ctx, prefix+key); err != nil {
+				return err
+			}
 		}
+	}
+
+	return s.Delete(ctx, deltaCRLPath)
+}
+
+// periodicRebuildCRL rebuilds the complete and delta CRLs once their rebuild
+// intervals have passed, if automatic rebuilding is enabled
+func (b *backend) periodicRebuildCRL(ctx context.Context, req *logical.Request) error {
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	if crlInfo == nil || !crlInfo.AutoRebuild || crlInfo.Disable {
+		return nil
+	}
+
+	rebuildInterval, deltaInterval, err := crlInfo.rebuildIntervals()
+	if err != nil {
+		return err
+	}
+
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	state, err := getCRLState(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case time.Since(state.LastRebuild) >= rebuildInterval:
+		err = buildCRL(ctx, b, req, false)
+	case crlInfo.EnableDelta && time.Since(state.LastDeltaRebuild) >= deltaInterval:
+		err = buildDeltaCRL(ctx, b, req)
+	}
+	if err != nil {
+		return errwrap.Wrapf("error encountered during CRL building: {{err}}", err)
+	}
+
+	return nil
+}
+
+// crlIssuer is an issuer able to sign CRLs
+type crlIssuer struct {
+	// id is empty for a CA bundle which has not been migrated yet
+	id        string
+	isDefault bool
+	bundle    *certutil.CAInfoBundle
+}
+
+// filterIssued returns the revoked certificates issued by this issuer
+func (i *crlIssuer) filterIssued(revokedCerts []*revokedCertEntry) []*revokedCertEntry {
+	if i.id == "" {
+		return revokedCerts
+	}
+
+	var issued []*revokedCertEntry
+	for _, revoked := range revokedCerts {
+		if !bytes.Equal(revoked.cert.RawIssuer, i.bundle.Certificate.RawSubject) {
+			continue
+		}
+		if err := revoked.cert.CheckSignatureFrom(i.bundle.Certificate); err != nil {
+			continue
+		}
+		issued = append(issued, revoked)
+	}
+	return issued
+}
+
+// fetchCRLIssuers returns every issuer with a key, or the legacy CA bundle if
+// it has not been migrated to issuer storage yet
+func fetchCRLIssuers(ctx context.Context, req *logical.Request) ([]*crlIssuer, error) {
+	issuers, err := listIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to list issuers: %s", err)}
+	}
+
+	if len(issuers) == 0 {
+		// Not yet migrated; sign with the legacy bundle
+		signingBundle, caErr := fetchCAInfo(ctx, req, defaultRef)
+		switch caErr.(type) {
+		case errutil.UserError:
+			return nil, errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
+		case errutil.InternalError:
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+		}
+		return []*crlIssuer{{isDefault: true, bundle: signingBundle}}, nil
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching issuer configuration: %s", err)}
+	}
 
-		revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
+	var crlIssuers []*crlIssuer
+	for _, issuerID := range issuers {
+		issuer, err := fetchIssuerByID(ctx, req.Storage, issuerID)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("unable to parse stored revoked certificate with serial %s: %s", serial, err)}
+			return nil, err
+		}
+		if issuer.KeyID == "" {
+			// Without a key this issuer cannot sign a CRL
+			continue
 		}
 
-		// NOTE: We have to change this to UTC time because the CRL standard
-		// mandates it but Go will happily encode the CRL without this.
-		newRevCert := pkix.RevokedCertificate{
-			SerialNumber: revokedCert.SerialNumber,
+		signingBundle, caErr := fetchCAInfo(ctx, req, issuerID)
+		switch caErr.(type) {
+		case errutil.UserError:
+			return nil, errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate for issuer %s: %s", issuerID, caErr)}
+		case errutil.InternalError:
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate for issuer %s: %s", issuerID, caErr)}
+		}
+
+		crlIssuers = append(crlIssuers, &crlIssuer{
+			id:        issuerID,
+			isDefault: issuerID == config.DefaultIssuerID,
+			bundle:    signingBundle,
+		})
+	}
+
+	return crlIssuers, nil
+}
+
+type revokedCertEntry struct {
+	cert    *x509.Certificate
+	revoked pkix.RevokedCertificate
+}
+
+func fetchRevokedCerts(ctx context.Context, s logical.Storage) ([]*revokedCertEntry, error) {
+	var revokedCerts []*revokedCertEntry
+
+	revokedSerials, err := s.List(ctx, "revoked/")
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching list of revoked certs: %s", err)}
+	}
+
+	for _, serial := range revokedSerials {
+		revokedCert, err := fetchRevokedCert(ctx, s, serial)
+		if err != nil {
+			return nil, err
 		}
-		if !revInfo.RevocationTimeUTC.IsZero() {
-			newRevCert.RevocationTime = revInfo.RevocationTimeUTC
-		} else {
-			newRevCert.RevocationTime = time.Unix(revInfo.RevocationTime, 0).UTC()
+		if revokedCert == nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("revoked certificate entry for serial %s is nil", serial)}
 		}
-		revokedCerts = append(revokedCerts, newRevCert)
+		revokedCerts = append(revokedCerts, revokedCert)
 	}
 
-WRITE:
-	signingBundle, caErr := fetchCAInfo(ctx, req)
-	switch caErr.(type) {
-	case errutil.UserError:
-		return errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
-	case errutil.InternalError:
-		return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+	return revokedCerts, nil
+}
+
+// fetchRevokedCert loads the revocation entry of the given serial, as stored
+// under revoked/, returning nil if there is none
+func fetchRevokedCert(ctx context.Context, s logical.Storage, serial string) (*revokedCertEntry, error) {
+	var revInfo revocationInfo
+
+	revokedEntry, err := s.Get(ctx, "revoked/"+serial)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
+	}
+	if revokedEntry == nil {
+		return nil, nil
+	}
+	if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
+		// TODO: In this case, remove it and continue? How likely is this to
+		// happen? Alternately, could skip it entirely, or could implement a
+		// delete function so that there is a way to remove these
+		return nil, errutil.InternalError{Err: fmt.Sprintf("found revoked serial but actual certificate is empty")}
+	}
+
+	err = revokedEntry.DecodeJSON(&revInfo)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
+	}
+
+	revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse stored revoked certificate with serial %s: %s", serial, err)}
+	}
+
+	// NOTE: We have to change this to UTC time because the CRL standard
+	// mandates it but Go will happily encode the CRL without this.
+	newRevCert := pkix.RevokedCertificate{
+		SerialNumber: revokedCert.SerialNumber,
+	}
+	if !revInfo.RevocationTimeUTC.IsZero() {
+		newRevCert.RevocationTime = revInfo.RevocationTimeUTC
+	} else {
+		newRevCert.RevocationTime = time.Unix(revInfo.RevocationTime, 0).UTC()
+	}
+
+	return &revokedCertEntry{
+		cert:    revokedCert,
+		revoked: newRevCert,
+	}, nil
+}
+
+// supportsCRLNumbers reports whether CRLs with a CRL number, and thus delta
+// CRLs, can be built for the issuer. This requires the CRL signing key usage
+// and a subject key identifier, which imported CAs may lack.
+func supportsCRLNumbers(cert *x509.Certificate) bool {
+	return cert.KeyUsage&x509.KeyUsageCRLSign != 0 && len(cert.SubjectKeyId) != 0
+}
+
+// writeCRL signs and stores a CRL with the given number, or without a number
+// if it is zero. A non-zero baseNumber makes it a delta CRL on top of the
+// complete CRL with that number.
+func writeCRL(ctx context.Context, s logical.Storage, signingBundle *certutil.CAInfoBundle, revokedCerts []*revokedCertEntry, crlLifetime time.Duration, number, baseNumber int64, path string) error {
+	var entries []pkix.RevokedCertificate
+	for _, revoked := range revokedCerts {
+		entries = append(entries, revoked.revoked)
 	}
 
-	crlBytes, err := signingBundle.Certificate.CreateCRL(rand.Reader, signingBundle.PrivateKey, revokedCerts, time.Now(), time.Now().Add(crlLifetime))
+	var crlBytes []byte
+	var err error
+	now := time.Now()
+	if number == 0 {
+		crlBytes, err = signingBundle.Certificate.CreateCRL(rand.Reader, signingBundle.PrivateKey, entries, now, now.Add(crlLifetime))
+	} else {
+		template := &x509.RevocationList{
+			RevokedCertificates: entries,
+			Number:              big.NewInt(number),
+			ThisUpdate:          now,
+			NextUpdate:          now.Add(crlLifetime),
+		}
+		if baseNumber != 0 {
+			indicator, err := asn1.Marshal(big.NewInt(baseNumber))
+			if err != nil {
+				return errutil.InternalError{Err: fmt.Sprintf("error encoding delta CRL indicator: %s", err)}
+			}
+			template.ExtraExtensions = []pkix.Extension{
+				{
+					Id:       oidDeltaCRLIndicator,
+					Critical: true,
+					Value:    indicator,
+				},
+			}
+		}
+		crlBytes, err = x509.CreateRevocationList(rand.Reader, template, signingBundle.Certificate, signingBundle.PrivateKey)
+	}
 	if err != nil {
 		return errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
 	}
 
-	err = req.Storage.Put(ctx, &logical.StorageEntry{
-		Key:   "crl",
+	err = s.Put(ctx, &logical.StorageEntry{
+		Key:   path,
 		Value: crlBytes,
 	})
 	if err != nil {
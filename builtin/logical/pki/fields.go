@@ -248,7 +248,7 @@ the private key!`,
 		Default: 2048,
 		Description: `The number of bits to use. You will almost
 certainly want to change this if you adjust
-the key_type.`,
+the key_type. Ignored for ed25519 keys.`,
 		DisplayAttrs: &framework.DisplayAttributes{
 			Value: 2048,
 		},
@@ -257,9 +257,9 @@ the key_type.`,
 	fields["key_type"] = &framework.FieldSchema{
 		Type:    framework.TypeString,
 		Default: "rsa",
-		Description: `The type of key to use; defaults to RSA. "rsa"
-and "ec" are the only valid values.`,
-		AllowedValues: []interface{}{"rsa", "ec"},
+		Description: `The type of key to use; defaults to RSA. "rsa",
+"ec" and "ed25519" are the only valid values.`,
+		AllowedValues: []interface{}{"rsa", "ec", "ed25519"},
 		DisplayAttrs: &framework.DisplayAttributes{
 			Value: "rsa",
 		},
@@ -286,3 +286,63 @@ func addCAIssueFields(fields map[string]*framework.FieldSchema) map[string]*fram
 
 	return fields
 }
+
+// addIssuerRefField adds the field used to select which of the mount's
+// issuers signs the request
+func addIssuerRefField(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["issuer_ref"] = &framework.FieldSchema{
+		Type:    framework.TypeString,
+		Default: defaultRef,
+		Description: `Reference to the issuer used to sign the
+certificate; either "default", an issuer ID or
+an issuer name.`,
+	}
+
+	return fields
+}
+
+// addKeyAndIssuerNameFields adds the fields used to name the key and issuer
+// created when configuring a CA
+func addKeyAndIssuerNameFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["issuer_name"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `Optional name for the issuer created by
+this request, usable in place of its ID.`,
+	}
+
+	fields["key_name"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `Optional name for the key created by this
+request, usable in place of its ID.`,
+	}
+
+	return fields
+}
+
+// addTidyFields adds the fields selecting what a tidy operation removes,
+// shared by the tidy and auto-tidy endpoints
+func addTidyFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["tidy_cert_store"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `Set to true to enable tidying up
+the certificate store`,
+	}
+
+	fields["tidy_revoked_certs"] = &framework.FieldSchema{
+		Type: framework.TypeBool,
+		Description: `Set to true to expire all revoked
+and expired certificates, removing them both from the CRL and from storage. The
+CRL will be rotated if this causes any values to be removed.`,
+	}
+
+	fields["safety_buffer"] = &framework.FieldSchema{
+		Type: framework.TypeDurationSecond,
+		Description: `The amount of extra time that must have passed
+beyond certificate expiration before it is removed
+from the backend storage and/or revocation list.
+Defaults to 72 hours.`,
+		Default: 259200, //72h, but TypeDurationSecond currently requires defaults to be int
+	}
+
+	return fields
+}
@@ -0,0 +1,828 @@
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// acmeOperationFunc handles an ACME request whose JWS has been verified
+type acmeOperationFunc func(context.Context, *logical.Request, *framework.FieldData, *acmeRequest, *roleEntry) (*logical.Response, error)
+
+func acmeIDRegex(name string) string {
+	return fmt.Sprintf("(?P<%s>[a-zA-Z0-9_-]+)", name)
+}
+
+func acmePathPrefix() string {
+	return "acme/" + framework.GenericNameRegex("role") + "/"
+}
+
+func pathACMEDirectory(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: acmePathPrefix() + "directory",
+		Fields: map[string]*framework.FieldSchema{
+			"role": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The role whose ACME server is used.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathACMEDirectoryRead,
+		},
+
+		HelpSynopsis:    pathACMEDirectoryHelpSyn,
+		HelpDescription: pathACMEDirectoryHelpDesc,
+	}
+}
+
+func pathACMENewNonce(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: acmePathPrefix() + "new-nonce",
+		Fields: map[string]*framework.FieldSchema{
+			"role": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The role whose ACME server is used.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathACMENewNonce,
+			logical.HeaderOperation: b.pathACMENewNonce,
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+// acmePath returns a path accepting JWS signed ACME requests
+func acmePath(b *backend, pattern string, fields map[string]*framework.FieldSchema, newAccount bool, op acmeOperationFunc) *framework.Path {
+	return &framework.Path{
+		Pattern: acmePathPrefix() + pattern,
+		Fields:  addACMEJWSFields(fields),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.acmeHandler(newAccount, op),
+		},
+
+		HelpSynopsis:    pathACMEHelpSyn,
+		HelpDescription: pathACMEHelpDesc,
+	}
+}
+
+func pathsACME(b *backend) []*framework.Path {
+	idField := func(name, description string) map[string]*framework.FieldSchema {
+		return map[string]*framework.FieldSchema{
+			name: &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: description,
+			},
+		}
+	}
+	challengeFields := idField("authz_id", `The ID of the authorization.`)
+	challengeFields["challenge_type"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The type of the challenge.`,
+	}
+
+	return []*framework.Path{
+		pathACMEDirectory(b),
+		pathACMENewNonce(b),
+		acmePath(b, "new-account", map[string]*framework.FieldSchema{}, true, b.pathACMENewAccount),
+		acmePath(b, "account/"+acmeIDRegex("kid"), idField("kid", `The ID of the account.`), false, b.pathACMEAccount),
+		acmePath(b, "account/"+acmeIDRegex("kid")+"/orders", idField("kid", `The ID of the account.`), false, b.pathACMEAccountOrders),
+		acmePath(b, "new-order", map[string]*framework.FieldSchema{}, false, b.pathACMENewOrder),
+		acmePath(b, "order/"+acmeIDRegex("order_id"), idField("order_id", `The ID of the order.`), false, b.pathACMEOrder),
+		acmePath(b, "order/"+acmeIDRegex("order_id")+"/finalize", idField("order_id", `The ID of the order.`), false, b.pathACMEFinalize),
+		acmePath(b, "order/"+acmeIDRegex("order_id")+"/cert", idField("order_id", `The ID of the order.`), false, b.pathACMECertificate),
+		acmePath(b, "authorization/"+acmeIDRegex("authz_id"), idField("authz_id", `The ID of the authorization.`), false, b.pathACMEAuthorization),
+		acmePath(b, "challenge/"+acmeIDRegex("authz_id")+"/"+acmeIDRegex("challenge_type"), challengeFields, false, b.pathACMEChallenge),
+	}
+}
+
+// acmeRole loads the ACME configuration and the role of the request. Either
+// of the responses is set if ACME is not available for the role.
+func (b *backend) acmeRole(ctx context.Context, req *logical.Request, data *framework.FieldData) (*acmeConfig, *roleEntry, *logical.Response, error) {
+	config, err := b.acmeConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !config.Enabled {
+		return nil, nil, logical.ErrorResponse("the ACME server is not enabled on this mount"), nil
+	}
+
+	roleName := data.Get("role").(string)
+	role, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if role == nil {
+		resp, err := b.acmeProblemResponse(config, roleName, newACMEProblem("malformed", http.StatusNotFound, "unknown role: %s", roleName))
+		return nil, nil, resp, err
+	}
+
+	return config, role, nil, nil
+}
+
+// acmeHandler verifies the JWS of the request before passing it to op
+func (b *backend) acmeHandler(newAccount bool, op acmeOperationFunc) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		config, role, resp, err := b.acmeRole(ctx, req, data)
+		if resp != nil || err != nil {
+			return resp, err
+		}
+
+		acmeReq, problem, err := b.parseACMERequest(ctx, req, data, config, newAccount)
+		if err != nil {
+			return nil, err
+		}
+		if problem != nil {
+			return b.acmeProblemResponse(config, data.Get("role").(string), problem)
+		}
+
+		return op(ctx, req, data, acmeReq, role)
+	}
+}
+
+// acmeReply returns obj to the client, along with a Location header if set
+func (b *backend) acmeReply(r *acmeRequest, status int, location string, obj interface{}) (*logical.Response, error) {
+	resp, err := b.acmeJSONResponse(r.config, r.role, status, obj)
+	if err != nil {
+		return nil, err
+	}
+	if location != "" {
+		resp.Headers["Location"] = []string{location}
+	}
+	return resp, nil
+}
+
+func (b *backend) acmeProblem(r *acmeRequest, problem *acmeProblem) (*logical.Response, error) {
+	return b.acmeProblemResponse(r.config, r.role, problem)
+}
+
+func (b *backend) pathACMEDirectoryRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, _, resp, err := b.acmeRole(ctx, req, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	role := data.Get("role").(string)
+	return b.acmeJSONResponse(config, role, http.StatusOK, map[string]interface{}{
+		"newNonce":   acmeRoleURL(config, role, "new-nonce"),
+		"newAccount": acmeRoleURL(config, role, "new-account"),
+		"newOrder":   acmeRoleURL(config, role, "new-order"),
+		"meta": map[string]interface{}{
+			"externalAccountRequired": false,
+		},
+	})
+}
+
+func (b *backend) pathACMENewNonce(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, _, resp, err := b.acmeRole(ctx, req, data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	// HEAD requests are answered with 200, GET requests with 204
+	status := http.StatusNoContent
+	if req.Operation == logical.HeaderOperation {
+		status = http.StatusOK
+	}
+
+	resp, err = b.acmeResponse(config, data.Get("role").(string), status, "text/plain", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Data[logical.HTTPRawCacheControl] = "no-store"
+
+	return resp, nil
+}
+
+func (b *backend) pathACMENewAccount(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	var payload struct {
+		Contact              []string `json:"contact"`
+		TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
+		OnlyReturnExisting   bool     `json:"onlyReturnExisting"`
+	}
+	if problem := r.decodePayload(&payload); problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+
+	id, err := jwkThumbprint(r.jwk)
+	if err != nil {
+		return nil, err
+	}
+
+	b.acmeLock.Lock()
+	defer b.acmeLock.Unlock()
+
+	account, err := b.fetchACMEAccount(ctx, req.Storage, r.role, id)
+	if err != nil {
+		return nil, err
+	}
+	if account != nil {
+		return b.acmeReply(r, http.StatusOK, r.acmeURL("account/"+id), accountObject(r, account))
+	}
+	if payload.OnlyReturnExisting {
+		return b.acmeProblem(r, newACMEProblem("accountDoesNotExist", http.StatusBadRequest, "no account exists for this key"))
+	}
+	if problem := validateACMEContacts(payload.Contact); problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+
+	account = &acmeAccount{
+		ID:        id,
+		Key:       r.jwk,
+		Status:    acmeStatusValid,
+		Contact:   payload.Contact,
+		CreatedAt: time.Now(),
+	}
+	if err := writeACMEEntry(ctx, req.Storage, acmeStoragePrefix(r.role)+"accounts/"+id, account); err != nil {
+		return nil, err
+	}
+
+	return b.acmeReply(r, http.StatusCreated, r.acmeURL("account/"+id), accountObject(r, account))
+}
+
+func (b *backend) pathACMEAccount(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	if data.Get("kid").(string) != r.account.ID {
+		return b.acmeProblem(r, newACMEProblem("unauthorized", http.StatusForbidden, "request is not signed by the key of this account"))
+	}
+	if r.postAsGet() {
+		return b.acmeReply(r, http.StatusOK, "", accountObject(r, r.account))
+	}
+
+	var payload struct {
+		Contact []string `json:"contact"`
+		Status  string   `json:"status"`
+	}
+	if problem := r.decodePayload(&payload); problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+
+	b.acmeLock.Lock()
+	defer b.acmeLock.Unlock()
+
+	account := r.account
+	switch payload.Status {
+	case "":
+	case acmeStatusDeactivated:
+		account.Status = acmeStatusDeactivated
+	default:
+		return b.acmeProblem(r, newACMEProblem("malformed", http.StatusBadRequest, "accounts can only be deactivated"))
+	}
+	if payload.Contact != nil {
+		if problem := validateACMEContacts(payload.Contact); problem != nil {
+			return b.acmeProblem(r, problem)
+		}
+		account.Contact = payload.Contact
+	}
+
+	if err := writeACMEEntry(ctx, req.Storage, acmeStoragePrefix(r.role)+"accounts/"+account.ID, account); err != nil {
+		return nil, err
+	}
+
+	return b.acmeReply(r, http.StatusOK, "", accountObject(r, account))
+}
+
+func (b *backend) pathACMEAccountOrders(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	if data.Get("kid").(string) != r.account.ID {
+		return b.acmeProblem(r, newACMEProblem("unauthorized", http.StatusForbidden, "request is not signed by the key of this account"))
+	}
+
+	orderIDs, err := req.Storage.List(ctx, acmeStoragePrefix(r.role)+"orders/")
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []string{}
+	for _, orderID := range orderIDs {
+		order, err := b.fetchACMEOrder(ctx, req.Storage, r.role, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if order == nil || order.AccountID != r.account.ID {
+			continue
+		}
+		orders = append(orders, r.acmeURL("order/"+order.ID))
+	}
+
+	return b.acmeReply(r, http.StatusOK, "", map[string]interface{}{
+		"orders": orders,
+	})
+}
+
+func (b *backend) pathACMENewOrder(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	var payload struct {
+		Identifiers []acmeIdentifier `json:"identifiers"`
+		NotBefore   string           `json:"notBefore"`
+		NotAfter    string           `json:"notAfter"`
+	}
+	if problem := r.decodePayload(&payload); problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+	if payload.NotBefore != "" || payload.NotAfter != "" {
+		return b.acmeProblem(r, newACMEProblem("malformed", http.StatusBadRequest, "notBefore and notAfter are not supported; the validity is set by the role"))
+	}
+	if len(payload.Identifiers) == 0 {
+		return b.acmeProblem(r, newACMEProblem("malformed", http.StatusBadRequest, "an order must contain at least one identifier"))
+	}
+
+	seen := map[string]bool{}
+	var identifiers []acmeIdentifier
+	var names []string
+	for _, identifier := range payload.Identifiers {
+		if identifier.Type != "dns" {
+			return b.acmeProblem(r, newACMEProblem("unsupportedIdentifier", http.StatusBadRequest, "unsupported identifier type %q", identifier.Type))
+		}
+		name := strings.ToLower(identifier.Value)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		identifiers = append(identifiers, acmeIdentifier{Type: "dns", Value: name})
+		names = append(names, name)
+	}
+
+	// Refuse names the role would refuse at finalization, before any
+	// challenge is attempted
+	if badName := validateNames(b, &inputBundle{role: role, req: req}, names); badName != "" {
+		return b.acmeProblem(r, newACMEProblem("rejectedIdentifier", http.StatusForbidden, "name %q not allowed by this role", badName))
+	}
+
+	orderID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(acmeOrderLifetime).UTC()
+	order := &acmeOrder{
+		ID:          orderID,
+		AccountID:   r.account.ID,
+		Status:      acmeStatusPending,
+		Expires:     expires,
+		Identifiers: identifiers,
+	}
+
+	for _, identifier := range identifiers {
+		authz, err := newACMEAuthorization(r.account.ID, identifier, expires)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeACMEEntry(ctx, req.Storage, acmeStoragePrefix(r.role)+"authorizations/"+authz.ID, authz); err != nil {
+			return nil, err
+		}
+		order.AuthorizationIDs = append(order.AuthorizationIDs, authz.ID)
+	}
+
+	if err := writeACMEEntry(ctx, req.Storage, acmeStoragePrefix(r.role)+"orders/"+order.ID, order); err != nil {
+		return nil, err
+	}
+
+	return b.acmeReply(r, http.StatusCreated, r.acmeURL("order/"+order.ID), orderObject(r, order))
+}
+
+func (b *backend) pathACMEOrder(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	order, problem, err := b.fetchACMEOrderForRequest(ctx, req, data, r)
+	if err != nil {
+		return nil, err
+	}
+	if problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+
+	return b.acmeReply(r, http.StatusOK, "", orderObject(r, order))
+}
+
+func (b *backend) pathACMEFinalize(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	var payload struct {
+		CSR string `json:"csr"`
+	}
+	if problem := r.decodePayload(&payload); problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+
+	// Serialize finalization so an order is never issued twice
+	b.acmeLock.Lock()
+	defer b.acmeLock.Unlock()
+
+	order, problem, err := b.fetchACMEOrderForRequest(ctx, req, data, r)
+	if err != nil {
+		return nil, err
+	}
+	if problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+	if order.Status != acmeStatusReady {
+		return b.acmeProblem(r, newACMEProblem("orderNotReady", http.StatusForbidden, "order is %s", order.Status))
+	}
+
+	csrBytes, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(payload.CSR, "="))
+	if err != nil {
+		return b.acmeProblem(r, newACMEProblem("badCSR", http.StatusBadRequest, "unable to decode CSR: %s", err))
+	}
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	if err != nil {
+		return b.acmeProblem(r, newACMEProblem("badCSR", http.StatusBadRequest, "unable to parse CSR: %s", err))
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return b.acmeProblem(r, newACMEProblem("badCSR", http.StatusBadRequest, "invalid CSR signature: %s", err))
+	}
+	if len(csr.IPAddresses) > 0 || len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0 {
+		return b.acmeProblem(r, newACMEProblem("badCSR", http.StatusBadRequest, "CSR may only contain DNS names"))
+	}
+
+	// The CSR must request exactly the identifiers of the order
+	requested := map[string]bool{}
+	for _, name := range csr.DNSNames {
+		requested[strings.ToLower(name)] = true
+	}
+	commonName := strings.ToLower(csr.Subject.CommonName)
+	if commonName != "" {
+		requested[commonName] = true
+	}
+	ordered := map[string]bool{}
+	for _, identifier := range order.Identifiers {
+		ordered[identifier.Value] = true
+		if !requested[identifier.Value] {
+			return b.acmeProblem(r, newACMEProblem("badCSR", http.StatusBadRequest, "CSR does not request %q", identifier.Value))
+		}
+	}
+	var altNames []string
+	for name := range requested {
+		if !ordered[name] {
+			return b.acmeProblem(r, newACMEProblem("badCSR", http.StatusBadRequest, "CSR requests %q which is not part of the order", name))
+		}
+		altNames = append(altNames, name)
+	}
+	sort.Strings(altNames)
+	if commonName == "" {
+		commonName = order.Identifiers[0].Value
+	}
+
+	// Issue through the sign endpoint logic so all role restrictions apply;
+	// ACME certificates are never leased
+	signData := &framework.FieldData{
+		Raw: map[string]interface{}{
+			"role":        r.role,
+			"csr":         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrBytes})),
+			"common_name": commonName,
+			"alt_names":   strings.Join(altNames, ","),
+			"format":      "pem",
+		},
+		Schema: pathSign(b).Fields,
+	}
+	acmeRole := *role
+	acmeRole.GenerateLease = new(bool)
+
+	resp, err := b.pathIssueSignCert(ctx, req, signData, &acmeRole, true, false)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return b.acmeProblem(r, newACMEProblem("badCSR", http.StatusBadRequest, "%s", resp.Error()))
+	}
+
+	chain := []string{resp.Data["certificate"].(string)}
+	if caChain, ok := resp.Data["ca_chain"].([]string); ok && len(caChain) > 0 {
+		chain = append(chain, caChain...)
+	} else {
+		chain = append(chain, resp.Data["issuing_ca"].(string))
+	}
+
+	order.Status = acmeStatusValid
+	order.Certificate = strings.Join(chain, "\n") + "\n"
+	order.SerialNumber = resp.Data["serial_number"].(string)
+	if err := writeACMEEntry(ctx, req.Storage, acmeStoragePrefix(r.role)+"orders/"+order.ID, order); err != nil {
+		return nil, err
+	}
+
+	return b.acmeReply(r, http.StatusOK, r.acmeURL("order/"+order.ID), orderObject(r, order))
+}
+
+func (b *backend) pathACMECertificate(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	order, problem, err := b.fetchACMEOrderForRequest(ctx, req, data, r)
+	if err != nil {
+		return nil, err
+	}
+	if problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+	if order.Status != acmeStatusValid {
+		return b.acmeProblem(r, newACMEProblem("orderNotReady", http.StatusForbidden, "order is %s", order.Status))
+	}
+
+	return b.acmeResponse(r.config, r.role, http.StatusOK, "application/pem-certificate-chain", []byte(order.Certificate))
+}
+
+func (b *backend) pathACMEAuthorization(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	authz, problem, err := b.fetchACMEAuthorizationForRequest(ctx, req, data, r)
+	if err != nil {
+		return nil, err
+	}
+	if problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+
+	if !r.postAsGet() {
+		var payload struct {
+			Status string `json:"status"`
+		}
+		if problem := r.decodePayload(&payload); problem != nil {
+			return b.acmeProblem(r, problem)
+		}
+		if payload.Status != acmeStatusDeactivated {
+			return b.acmeProblem(r, newACMEProblem("malformed", http.StatusBadRequest, "authorizations can only be deactivated"))
+		}
+
+		b.acmeLock.Lock()
+		defer b.acmeLock.Unlock()
+
+		authz.Status = acmeStatusDeactivated
+		if err := writeACMEEntry(ctx, req.Storage, acmeStoragePrefix(r.role)+"authorizations/"+authz.ID, authz); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.acmeReply(r, http.StatusOK, "", authorizationObject(r, authz))
+}
+
+func (b *backend) pathACMEChallenge(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest, role *roleEntry) (*logical.Response, error) {
+	authz, problem, err := b.fetchACMEAuthorizationForRequest(ctx, req, data, r)
+	if err != nil {
+		return nil, err
+	}
+	if problem != nil {
+		return b.acmeProblem(r, problem)
+	}
+
+	var challenge *acmeChallenge
+	challengeType := data.Get("challenge_type").(string)
+	for _, c := range authz.Challenges {
+		if c.Type == challengeType {
+			challenge = c
+		}
+	}
+	if challenge == nil {
+		return b.acmeProblem(r, newACMEProblem("malformed", http.StatusNotFound, "unknown challenge %q", challengeType))
+	}
+
+	// Any non-empty payload asks for the challenge to be validated; this is
+	// done synchronously so the response reflects the final state
+	if !r.postAsGet() && authz.Status == acmeStatusPending && challenge.Status == acmeStatusPending {
+		keyAuthz, err := keyAuthorization(challenge.Token, r.account.Key)
+		if err != nil {
+			return nil, err
+		}
+		problem := validateACMEChallenge(ctx, r.config, authz, challenge, keyAuthz)
+
+		b.acmeLock.Lock()
+		defer b.acmeLock.Unlock()
+
+		challenge.Validated = time.Now().UTC()
+		if problem == nil {
+			challenge.Status = acmeStatusValid
+			authz.Status = acmeStatusValid
+		} else {
+			challenge.Status = acmeStatusInvalid
+			challenge.Error = problem
+			authz.Status = acmeStatusInvalid
+		}
+		if err := writeACMEEntry(ctx, req.Storage, acmeStoragePrefix(r.role)+"authorizations/"+authz.ID, authz); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := b.acmeReply(r, http.StatusOK, "", challengeObject(r, authz, challenge))
+	if err != nil {
+		return nil, err
+	}
+	resp.Headers["Link"] = append(resp.Headers["Link"], fmt.Sprintf("<%s>;rel=\"up\"", r.acmeURL("authorization/"+authz.ID)))
+
+	return resp, nil
+}
+
+// fetchACMEOrderForRequest loads the order of the request, ensuring it
+// belongs to the requesting account, and brings its status up to date
+func (b *backend) fetchACMEOrderForRequest(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest) (*acmeOrder, *acmeProblem, error) {
+	orderID := data.Get("order_id").(string)
+	order, err := b.fetchACMEOrder(ctx, req.Storage, r.role, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order == nil {
+		return nil, newACMEProblem("malformed", http.StatusNotFound, "unknown order %q", orderID), nil
+	}
+	if order.AccountID != r.account.ID {
+		return nil, newACMEProblem("unauthorized", http.StatusForbidden, "order belongs to another account"), nil
+	}
+
+	if order.Status != acmeStatusPending && order.Status != acmeStatusReady {
+		return order, nil, nil
+	}
+
+	status := acmeStatusReady
+	for _, authzID := range order.AuthorizationIDs {
+		authz, err := b.fetchACMEAuthorization(ctx, req.Storage, r.role, authzID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if authz == nil {
+			status = acmeStatusInvalid
+			break
+		}
+		authzStatus := authzStatus(authz)
+		if authzStatus == acmeStatusPending {
+			status = acmeStatusPending
+		} else if authzStatus != acmeStatusValid {
+			status = acmeStatusInvalid
+			break
+		}
+	}
+	if time.Now().After(order.Expires) {
+		status = acmeStatusInvalid
+	}
+
+	if status != order.Status {
+		order.Status = status
+		if err := writeACMEEntry(ctx, req.Storage, acmeStoragePrefix(r.role)+"orders/"+order.ID, order); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return order, nil, nil
+}
+
+// fetchACMEAuthorizationForRequest loads the authorization of the request,
+// ensuring it belongs to the requesting account
+func (b *backend) fetchACMEAuthorizationForRequest(ctx context.Context, req *logical.Request, data *framework.FieldData, r *acmeRequest) (*acmeAuthorization, *acmeProblem, error) {
+	authzID := data.Get("authz_id").(string)
+	authz, err := b.fetchACMEAuthorization(ctx, req.Storage, r.role, authzID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if authz == nil {
+		return nil, newACMEProblem("malformed", http.StatusNotFound, "unknown authorization %q", authzID), nil
+	}
+	if authz.AccountID != r.account.ID {
+		return nil, newACMEProblem("unauthorized", http.StatusForbidden, "authorization belongs to another account"), nil
+	}
+
+	authz.Status = authzStatus(authz)
+	return authz, nil, nil
+}
+
+// authzStatus returns the status of the authorization, taking its expiry into
+// account
+func authzStatus(authz *acmeAuthorization) string {
+	if authz.Status == acmeStatusPending && time.Now().After(authz.Expires) {
+		return acmeStatusInvalid
+	}
+	return authz.Status
+}
+
+func newACMEAuthorization(accountID string, identifier acmeIdentifier, expires time.Time) (*acmeAuthorization, error) {
+	authzID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	authz := &acmeAuthorization{
+		ID:         authzID,
+		AccountID:  accountID,
+		Status:     acmeStatusPending,
+		Expires:    expires,
+		Identifier: identifier,
+	}
+
+	// Wildcard names can only be proven through DNS
+	challengeTypes := []string{acmeChallengeHTTP01, acmeChallengeDNS01}
+	if strings.HasPrefix(identifier.Value, "*.") {
+		authz.Identifier.Value = strings.TrimPrefix(identifier.Value, "*.")
+		authz.Wildcard = true
+		challengeTypes = []string{acmeChallengeDNS01}
+	}
+
+	for _, challengeType := range challengeTypes {
+		token := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, token); err != nil {
+			return nil, err
+		}
+		authz.Challenges = append(authz.Challenges, &acmeChallenge{
+			Type:   challengeType,
+			Token:  base64.RawURLEncoding.EncodeToString(token),
+			Status: acmeStatusPending,
+		})
+	}
+
+	return authz, nil
+}
+
+func validateACMEContacts(contacts []string) *acmeProblem {
+	for _, contact := range contacts {
+		if !strings.HasPrefix(contact, "mailto:") {
+			return newACMEProblem("unsupportedContact", http.StatusBadRequest, "unsupported contact %q; only mailto: is supported", contact)
+		}
+	}
+	return nil
+}
+
+func accountObject(r *acmeRequest, account *acmeAccount) map[string]interface{} {
+	contact := account.Contact
+	if contact == nil {
+		contact = []string{}
+	}
+	return map[string]interface{}{
+		"status":  account.Status,
+		"contact": contact,
+		"orders":  r.acmeURL("account/" + account.ID + "/orders"),
+	}
+}
+
+func orderObject(r *acmeRequest, order *acmeOrder) map[string]interface{} {
+	authorizations := make([]string, 0, len(order.AuthorizationIDs))
+	for _, authzID := range order.AuthorizationIDs {
+		authorizations = append(authorizations, r.acmeURL("authorization/"+authzID))
+	}
+
+	obj := map[string]interface{}{
+		"status":         order.Status,
+		"expires":        order.Expires.Format(time.RFC3339),
+		"identifiers":    order.Identifiers,
+		"authorizations": authorizations,
+		"finalize":       r.acmeURL("order/" + order.ID + "/finalize"),
+	}
+	if order.Status == acmeStatusValid {
+		obj["certificate"] = r.acmeURL("order/" + order.ID + "/cert")
+	}
+
+	return obj
+}
+
+func authorizationObject(r *acmeRequest, authz *acmeAuthorization) map[string]interface{} {
+	challenges := make([]map[string]interface{}, 0, len(authz.Challenges))
+	for _, challenge := range authz.Challenges {
+		challenges = append(challenges, challengeObject(r, authz, challenge))
+	}
+
+	obj := map[string]interface{}{
+		"status":     authz.Status,
+		"expires":    authz.Expires.Format(time.RFC3339),
+		"identifier": authz.Identifier,
+		"challenges": challenges,
+	}
+	if authz.Wildcard {
+		obj["wildcard"] = true
+	}
+
+	return obj
+}
+
+func challengeObject(r *acmeRequest, authz *acmeAuthorization, challenge *acmeChallenge) map[string]interface{} {
+	obj := map[string]interface{}{
+		"type":   challenge.Type,
+		"url":    r.acmeURL("challenge/" + authz.ID + "/" + challenge.Type),
+		"status": challenge.Status,
+		"token":  challenge.Token,
+	}
+	if !challenge.Validated.IsZero() {
+		obj["validated"] = challenge.Validated.Format(time.RFC3339)
+	}
+	if challenge.Error != nil {
+		obj["error"] = challenge.Error
+	}
+
+	return obj
+}
+
+const pathACMEDirectoryHelpSyn = `
+Fetch the ACME directory of a role.
+`
+
+const pathACMEDirectoryHelpDesc = `
+This returns the RFC 8555 directory object listing the URLs of the ACME server
+of the role. The ACME server must be enabled through "config/acme".
+`
+
+const pathACMEHelpSyn = `
+ACME protocol endpoint.
+`
+
+const pathACMEHelpDesc = `
+This endpoint is part of the RFC 8555 ACME server of the role and is meant to
+be used by ACME clients, starting from the role's "directory" endpoint.
+Requests are authenticated by the JWS signature of the ACME account rather
+than by a Vault token.
+`
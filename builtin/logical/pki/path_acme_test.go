@@ -0,0 +1,429 @@
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/miekg/dns"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const acmeTestBaseURL = "https://vault.example.com/v1/pki"
+
+// acmeTestClient is a minimal ACME client talking to the backend directly
+type acmeTestClient struct {
+	t       *testing.T
+	b       *backend
+	storage logical.Storage
+	role    string
+	key     *ecdsa.PrivateKey
+	kid     string
+}
+
+func (c *acmeTestClient) path(name string) string {
+	return "acme/" + c.role + "/" + name
+}
+
+func (c *acmeTestClient) Nonce() (string, error) {
+	resp, err := c.b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.HeaderOperation,
+		Path:      c.path("new-nonce"),
+		Storage:   c.storage,
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Data[logical.HTTPStatusCode] != http.StatusOK {
+		c.t.Fatalf("bad new-nonce response: %#v", resp)
+	}
+	return resp.Headers["Replay-Nonce"][0], nil
+}
+
+// post signs payload and posts it to the absolute ACME URL, returning the
+// status, headers and body of the response
+func (c *acmeTestClient) post(url string, payload interface{}) (int, map[string][]string, []byte) {
+	c.t.Helper()
+
+	var raw []byte
+	if payload != nil {
+		var err error
+		if raw, err = json.Marshal(payload); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+
+	opts := (&jose.SignerOptions{NonceSource: c}).WithHeader("url", url)
+	signingKey := jose.SigningKey{Algorithm: jose.ES256, Key: c.key}
+	if c.kid == "" {
+		opts.EmbedJWK = true
+	} else {
+		signingKey.Key = jose.JSONWebKey{Key: c.key, KeyID: c.kid}
+	}
+	signer, err := jose.NewSigner(signingKey, opts)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	jws, err := signer.Sign(raw)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(jws.FullSerialize()), &data); err != nil {
+		c.t.Fatal(err)
+	}
+
+	resp, err := c.b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      strings.TrimPrefix(url, acmeTestBaseURL+"/"),
+		Storage:   c.storage,
+		Data:      data,
+	})
+	if err != nil || resp == nil {
+		c.t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	if len(resp.Headers["Replay-Nonce"]) != 1 {
+		c.t.Fatalf("missing Replay-Nonce in response: %#v", resp)
+	}
+
+	return resp.Data[logical.HTTPStatusCode].(int), resp.Headers, resp.Data[logical.HTTPRawBody].([]byte)
+}
+
+// postJSON posts payload and decodes the response, failing if the status
+// does not match
+func (c *acmeTestClient) postJSON(url string, payload interface{}, expectedStatus int, out interface{}) map[string][]string {
+	c.t.Helper()
+
+	status, headers, body := c.post(url, payload)
+	if status != expectedStatus {
+		c.t.Fatalf("expected status %d from %s, got %d: %s", expectedStatus, url, status, body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	return headers
+}
+
+type acmeTestOrder struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+}
+
+type acmeTestAuthorization struct {
+	Status     string `json:"status"`
+	Identifier struct {
+		Value string `json:"value"`
+	} `json:"identifier"`
+	Challenges []struct {
+		Type   string `json:"type"`
+		URL    string `json:"url"`
+		Token  string `json:"token"`
+		Status string `json:"status"`
+	} `json:"challenges"`
+}
+
+func setupACMETest(t *testing.T, config map[string]interface{}) *acmeTestClient {
+	b, storage := createBackendWithStorage(t)
+
+	for _, req := range []struct {
+		path string
+		data map[string]interface{}
+	}{
+		{"root/generate/internal", map[string]interface{}{"common_name": "myvault.com", "ttl": "48h"}},
+		{"roles/web", map[string]interface{}{"allowed_domains": "example.com", "allow_subdomains": true, "key_type": "ec", "key_bits": 256, "ttl": "1h"}},
+		{"config/acme", config},
+	} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      req.path,
+			Storage:   storage,
+			Data:      req.data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", req.path, err, resp)
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &acmeTestClient{
+		t:       t,
+		b:       b,
+		storage: storage,
+		role:    "web",
+		key:     key,
+	}
+}
+
+// newAccountAndOrder registers the client's account and orders a
+// certificate for names
+func (c *acmeTestClient) newAccountAndOrder(names ...string) (string, *acmeTestOrder) {
+	c.t.Helper()
+
+	resp, err := c.b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      c.path("directory"),
+		Storage:   c.storage,
+	})
+	if err != nil || resp == nil {
+		c.t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	var directory map[string]interface{}
+	if err := json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &directory); err != nil {
+		c.t.Fatal(err)
+	}
+
+	headers := c.postJSON(directory["newAccount"].(string), map[string]interface{}{
+		"contact":              []string{"mailto:admin@example.com"},
+		"termsOfServiceAgreed": true,
+	}, http.StatusCreated, nil)
+	c.kid = headers["Location"][0]
+
+	var identifiers []map[string]string
+	for _, name := range names {
+		identifiers = append(identifiers, map[string]string{"type": "dns", "value": name})
+	}
+	order := &acmeTestOrder{}
+	headers = c.postJSON(directory["newOrder"].(string), map[string]interface{}{
+		"identifiers": identifiers,
+	}, http.StatusCreated, order)
+	if order.Status != acmeStatusPending || len(order.Authorizations) != len(names) {
+		c.t.Fatalf("bad order: %#v", order)
+	}
+
+	return headers["Location"][0], order
+}
+
+// finalize submits a CSR for names and returns the issued chain
+func (c *acmeTestClient) finalize(orderURL string, order *acmeTestOrder, names ...string) []*x509.Certificate {
+	c.t.Helper()
+
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: names[0]},
+		DNSNames: names,
+	}, certKey)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	c.postJSON(order.Finalize, map[string]interface{}{
+		"csr": base64.RawURLEncoding.EncodeToString(csr),
+	}, http.StatusOK, order)
+	if order.Status != acmeStatusValid || order.Certificate == "" {
+		c.t.Fatalf("bad finalized order: %#v", order)
+	}
+
+	status, headers, body := c.post(order.Certificate, nil)
+	if status != http.StatusOK || !strings.Contains(headers["Link"][0], "directory") {
+		c.t.Fatalf("bad certificate response: %d %s", status, body)
+	}
+
+	var chain []*x509.Certificate
+	for _, block := range strings.SplitAfter(strings.TrimSpace(string(body)), "-----END CERTIFICATE-----") {
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		chain = append(chain, parseCertPEM(c.t, strings.TrimSpace(block)))
+	}
+	if len(chain) != 2 {
+		c.t.Fatalf("expected certificate and issuer, got %d certificates", len(chain))
+	}
+	if err := chain[0].CheckSignatureFrom(chain[1]); err != nil {
+		c.t.Fatal(err)
+	}
+	if len(chain[0].DNSNames) != len(names) || chain[0].DNSNames[0] != names[0] {
+		c.t.Fatalf("unexpected names in certificate: %v", chain[0].DNSNames)
+	}
+
+	return chain
+}
+
+func TestPki_ACMEHTTP01(t *testing.T) {
+	var keyAuthz string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "www.example.com" || !strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(keyAuthz))
+	}))
+	defer server.Close()
+
+	c := setupACMETest(t, map[string]interface{}{
+		"enabled":           true,
+		"base_url":          acmeTestBaseURL + "/",
+		"test_mode":         true,
+		"test_http_address": strings.TrimPrefix(server.URL, "http://"),
+	})
+
+	// Names outside of the role are refused up front
+	orderURL, order := c.newAccountAndOrder("www.example.com")
+	status, _, body := c.post(acmeTestBaseURL+"/"+c.path("new-order"), map[string]interface{}{
+		"identifiers": []map[string]string{{"type": "dns", "value": "www.example.org"}},
+	})
+	if status != http.StatusForbidden || !strings.Contains(string(body), "rejectedIdentifier") {
+		t.Fatalf("expected rejected identifier, got %d: %s", status, body)
+	}
+
+	// Finalizing before the challenge is solved fails
+	status, _, body = c.post(order.Finalize, map[string]interface{}{"csr": ""})
+	if status != http.StatusForbidden || !strings.Contains(string(body), "orderNotReady") {
+		t.Fatalf("expected order not ready, got %d: %s", status, body)
+	}
+
+	authz := &acmeTestAuthorization{}
+	c.postJSON(order.Authorizations[0], nil, http.StatusOK, authz)
+	if authz.Identifier.Value != "www.example.com" || len(authz.Challenges) != 2 {
+		t.Fatalf("bad authorization: %#v", authz)
+	}
+	for _, challenge := range authz.Challenges {
+		if challenge.Type != acmeChallengeHTTP01 {
+			continue
+		}
+		thumbprint, err := jwkThumbprint(&jose.JSONWebKey{Key: c.key.Public()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyAuthz = challenge.Token + "." + thumbprint
+
+		var result map[string]interface{}
+		c.postJSON(challenge.URL, map[string]interface{}{}, http.StatusOK, &result)
+		if result["status"] != acmeStatusValid {
+			t.Fatalf("expected valid challenge, got %#v", result)
+		}
+	}
+
+	c.postJSON(orderURL, nil, http.StatusOK, order)
+	if order.Status != acmeStatusReady {
+		t.Fatalf("expected ready order, got %#v", order)
+	}
+
+	// The CSR must match the order
+	status, _, body = c.post(order.Finalize, map[string]interface{}{"csr": "bogus"})
+	if status != http.StatusBadRequest || !strings.Contains(string(body), "badCSR") {
+		t.Fatalf("expected bad CSR, got %d: %s", status, body)
+	}
+
+	c.finalize(orderURL, order, "www.example.com")
+
+	// Replayed nonces are refused
+	config, err := c.b.acmeConfig(context.Background(), c.storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := c.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.b.consumeACMENonce(config, nonce) || c.b.consumeACMENonce(config, nonce) {
+		t.Fatalf("expected nonce to be usable exactly once")
+	}
+
+	// Nonces are accepted by the other nodes, but not forged ones
+	nonce, err = c.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := Backend(&logical.BackendConfig{})
+	if !other.consumeACMENonce(config, nonce) {
+		t.Fatalf("expected nonce to be accepted by another node")
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(nonce)
+	raw[0]++
+	if other.consumeACMENonce(config, base64.RawURLEncoding.EncodeToString(raw)) {
+		t.Fatalf("expected forged nonce to be refused")
+	}
+
+	// Expired nonces are purged
+	c.b.acmeNonceLock.Lock()
+	for n := range c.b.acmeUsedNonces {
+		c.b.acmeUsedNonces[n] = time.Now().Add(-time.Second)
+	}
+	c.b.acmeNonceLock.Unlock()
+	c.b.purgeACMENonces()
+	if len(c.b.acmeUsedNonces) != 0 {
+		t.Fatalf("expected used nonces to be purged, got %d", len(c.b.acmeUsedNonces))
+	}
+}
+
+func TestPki_ACMEDNS01(t *testing.T) {
+	// Written by the test and read by the DNS server goroutine
+	var expectedTXT atomic.Value
+	expectedTXT.Store("")
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{
+		PacketConn: conn,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(r)
+			q := r.Question[0]
+			if q.Qtype == dns.TypeTXT && q.Name == "_acme-challenge.example.com." {
+				m.Answer = append(m.Answer, &dns.TXT{
+					Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+					Txt: []string{expectedTXT.Load().(string)},
+				})
+			}
+			w.WriteMsg(m)
+		}),
+	}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	c := setupACMETest(t, map[string]interface{}{
+		"enabled":      true,
+		"base_url":     acmeTestBaseURL,
+		"dns_resolver": conn.LocalAddr().String(),
+	})
+
+	orderURL, order := c.newAccountAndOrder("*.example.com")
+
+	authz := &acmeTestAuthorization{}
+	c.postJSON(order.Authorizations[0], nil, http.StatusOK, authz)
+	if authz.Identifier.Value != "example.com" || len(authz.Challenges) != 1 || authz.Challenges[0].Type != acmeChallengeDNS01 {
+		t.Fatalf("expected a single dns-01 challenge for a wildcard, got %#v", authz)
+	}
+
+	thumbprint, err := jwkThumbprint(&jose.JSONWebKey{Key: c.key.Public()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(authz.Challenges[0].Token + "." + thumbprint))
+	expectedTXT.Store(base64.RawURLEncoding.EncodeToString(digest[:]))
+
+	var result map[string]interface{}
+	c.postJSON(authz.Challenges[0].URL, map[string]interface{}{}, http.StatusOK, &result)
+	if result["status"] != acmeStatusValid {
+		t.Fatalf("expected valid challenge, got %#v", result)
+	}
+
+	c.postJSON(orderURL, nil, http.StatusOK, order)
+	c.finalize(orderURL, order, "*.example.com")
+}
@@ -0,0 +1,185 @@
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// acmeConfig holds the configuration of the ACME server
+type acmeConfig struct {
+	Enabled         bool   `json:"enabled"`
+	BaseURL         string `json:"base_url"`
+	DNSResolver     string `json:"dns_resolver"`
+	TestMode        bool   `json:"test_mode"`
+	TestHTTPAddress string `json:"test_http_address"`
+
+	// NonceKey authenticates the nonces issued by the ACME server, so that
+	// every node accepts them. It is never returned.
+	NonceKey []byte `json:"nonce_key"`
+}
+
+func pathConfigACME(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/acme",
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `If set to true, enables the ACME server for every role of this mount.`,
+			},
+			"base_url": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The externally reachable URL of this mount, e.g.
+"https://vault.example.com:8200/v1/pki". ACME clients are given
+absolute URLs derived from it. Required to enable the ACME server.`,
+			},
+			"dns_resolver": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The host:port of a DNS server to use when
+validating dns-01 challenges, instead of the system resolver.`,
+			},
+			"test_mode": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, http-01 challenges are validated
+against test_http_address instead of port 80 of the identifier. Only
+intended for testing.`,
+			},
+			"test_http_address": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The host:port of a stand-in HTTP server used to
+validate http-01 challenges when test_mode is enabled.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathACMEConfigRead,
+			logical.UpdateOperation: b.pathACMEConfigWrite,
+		},
+
+		HelpSynopsis:    pathConfigACMEHelpSyn,
+		HelpDescription: pathConfigACMEHelpDesc,
+	}
+}
+
+func (b *backend) acmeConfig(ctx context.Context, s logical.Storage) (*acmeConfig, error) {
+	entry, err := s.Get(ctx, "config/acme")
+	if err != nil {
+		return nil, err
+	}
+
+	config := &acmeConfig{}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (b *backend) pathACMEConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.acmeConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":           config.Enabled,
+			"base_url":          config.BaseURL,
+			"dns_resolver":      config.DNSResolver,
+			"test_mode":         config.TestMode,
+			"test_http_address": config.TestHTTPAddress,
+		},
+	}, nil
+}
+
+func (b *backend) pathACMEConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.acmeConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := data.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+	if baseURLRaw, ok := data.GetOk("base_url"); ok {
+		config.BaseURL = strings.TrimSuffix(baseURLRaw.(string), "/")
+	}
+	if resolverRaw, ok := data.GetOk("dns_resolver"); ok {
+		config.DNSResolver = resolverRaw.(string)
+	}
+	if testModeRaw, ok := data.GetOk("test_mode"); ok {
+		config.TestMode = testModeRaw.(bool)
+	}
+	testHTTPRaw, setTestHTTP := data.GetOk("test_http_address")
+	if setTestHTTP {
+		config.TestHTTPAddress = testHTTPRaw.(string)
+	}
+	if !config.TestMode && !setTestHTTP {
+		// The stand-in server only has meaning in test mode
+		config.TestHTTPAddress = ""
+	}
+
+	if config.BaseURL != "" {
+		u, err := url.Parse(config.BaseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return logical.ErrorResponse(fmt.Sprintf("invalid base_url %q; must be an absolute URL", config.BaseURL)), nil
+		}
+	}
+	if config.Enabled && config.BaseURL == "" {
+		return logical.ErrorResponse("base_url must be set to enable the ACME server"), nil
+	}
+	if config.DNSResolver != "" {
+		if _, _, err := net.SplitHostPort(config.DNSResolver); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid dns_resolver: %s", err)), nil
+		}
+	}
+	if config.TestHTTPAddress != "" {
+		if !config.TestMode {
+			return logical.ErrorResponse("test_http_address may only be set when test_mode is enabled"), nil
+		}
+		if _, _, err := net.SplitHostPort(config.TestHTTPAddress); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid test_http_address: %s", err)), nil
+		}
+	}
+
+	if len(config.NonceKey) == 0 {
+		config.NonceKey = make([]byte, acmeNonceKeySize)
+		if _, err := io.ReadFull(rand.Reader, config.NonceKey); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON("config/acme", config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+const pathConfigACMEHelpSyn = `
+Configure the ACME server of this mount.
+`
+
+const pathConfigACMEHelpDesc = `
+When enabled, every role of this mount exposes an RFC 8555 ACME directory at
+"acme/<role>/directory". Certificates ordered through ACME are issued against
+the role in the same way as the "sign" endpoint.
+
+As ACME responses rely on the Replay-Nonce, Location and Link headers, these
+must be added to the mount's allowed_response_headers.
+`
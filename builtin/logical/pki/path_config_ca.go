@@ -11,7 +11,7 @@ import (
 )
 
 func pathConfigCA(b *backend) *framework.Path {
-	return &framework.Path{
+	ret := &framework.Path{
 		Pattern: "config/ca",
 		Fields: map[string]*framework.FieldSchema{
 			"pem_bundle": &framework.FieldSchema{
@@ -28,6 +28,10 @@ secret key and certificate.`,
 		HelpSynopsis:    pathConfigCAHelpSyn,
 		HelpDescription: pathConfigCAHelpDesc,
 	}
+
+	ret.Fields = addKeyAndIssuerNameFields(ret.Fields)
+
+	return ret
 }
 
 func (b *backend) pathCAWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -68,27 +72,34 @@ func (b *backend) pathCAWrite(ctx context.Context, req *logical.Request, data *f
 		return nil, errwrap.Wrapf("error converting raw values into cert bundle: {{err}}", err)
 	}
 
-	entry, err := logical.StorageEntryJSON("config/ca_bundle", cb)
+	issuer, key, err := writeCaBundle(ctx, req.Storage, cb, data.Get("issuer_name").(string), data.Get("key_name").(string))
 	if err != nil {
-		return nil, err
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
 	}
-	err = req.Storage.Put(ctx, entry)
+
+	// For ease of later use, also store the default issuer's certificate at a
+	// known location, plus a fresh CRL
+	err = writeDefaultIssuerCert(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
 
-	// For ease of later use, also store just the certificate at a known
-	// location, plus a fresh CRL
-	entry.Key = "ca"
-	entry.Value = parsedBundle.CertificateBytes
-	err = req.Storage.Put(ctx, entry)
+	err = buildCRL(ctx, b, req, true)
 	if err != nil {
 		return nil, err
 	}
 
-	err = buildCRL(ctx, b, req, true)
-
-	return nil, err
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id": issuer.ID,
+			"key_id":    key.ID,
+		},
+	}, nil
 }
 
 const pathConfigCAHelpSyn = `
@@ -96,9 +107,10 @@ Set the CA certificate and private key used for generated credentials.
 `
 
 const pathConfigCAHelpDesc = `
-This sets the CA information used for credentials generated by this
-by this mount. This must be a PEM-format, concatenated unencrypted
-secret key and certificate.
+This imports CA information used for credentials generated by this
+mount. This must be a PEM-format, concatenated unencrypted secret key
+and certificate. The certificate is added as a new issuer; it becomes
+the default issuer only if the mount does not already have one.
 
 For security reasons, the secret key cannot be retrieved later.
 `
@@ -8,15 +8,66 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
 // CRLConfig holds basic CRL configuration information
 type crlConfig struct {
-	Expiry  string `json:"expiry" mapstructure:"expiry"`
-	Disable bool   `json:"disable"`
+	Expiry      string `json:"expiry" mapstructure:"expiry"`
+	Disable     bool   `json:"disable"`
+	OcspDisable bool   `json:"ocsp_disable"`
+	OcspExpiry  string `json:"ocsp_expiry"`
+
+	AutoRebuild          bool   `json:"auto_rebuild"`
+	AutoRebuildInterval  string `json:"auto_rebuild_interval"`
+	EnableDelta          bool   `json:"enable_delta"`
+	DeltaRebuildInterval string `json:"delta_rebuild_interval"`
+}
+
+// lifetime returns the configured validity of built CRLs, or the given
+// default if none is configured
+func (c *crlConfig) lifetime(defaultLifetime time.Duration) (time.Duration, error) {
+	if c == nil || c.Expiry == "" {
+		return defaultLifetime, nil
+	}
+
+	crlDur, err := time.ParseDuration(c.Expiry)
+	if err != nil {
+		return 0, errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s", c.Expiry)}
+	}
+	return crlDur, nil
 }
 
+// rebuildIntervals returns how often the complete and delta CRLs are rebuilt
+// when automatic rebuilding is enabled
+func (c *crlConfig) rebuildIntervals() (time.Duration, time.Duration, error) {
+	rebuildRaw := c.AutoRebuildInterval
+	if rebuildRaw == "" {
+		rebuildRaw = defaultAutoRebuildInterval
+	}
+	rebuild, err := parseutil.ParseDurationSecond(rebuildRaw)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing auto_rebuild_interval of %s: %s", rebuildRaw, err)
+	}
+
+	deltaRaw := c.DeltaRebuildInterval
+	if deltaRaw == "" {
+		deltaRaw = defaultDeltaRebuildInterval
+	}
+	delta, err := parseutil.ParseDurationSecond(deltaRaw)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing delta_rebuild_interval of %s: %s", deltaRaw, err)
+	}
+
+	return rebuild, delta, nil
+}
+
+const (
+	defaultAutoRebuildInterval  = "12h"
+	defaultDeltaRebuildInterval = "15m"
+)
+
 func pathConfigCRL(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "config/crl",
@@ -31,6 +82,42 @@ valid; defaults to 72 hours`,
 				Type:        framework.TypeBool,
 				Description: `If set to true, disables generating the CRL entirely.`,
 			},
+			"ocsp_disable": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `If set to true, the OCSP responder will answer every request with an unauthorized response.`,
+			},
+			"ocsp_expiry": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The amount of time an OCSP response should be
+considered fresh by clients; defaults to 12 hours. Set to 0 to omit the
+NextUpdate field from responses.`,
+				Default: "12h",
+			},
+			"auto_rebuild": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, revoking a certificate no longer
+rebuilds the CRL; instead it is rebuilt periodically, every
+auto_rebuild_interval.`,
+			},
+			"auto_rebuild_interval": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `How often the CRL is rebuilt when auto_rebuild is
+enabled; defaults to 12 hours. Must be shorter than expiry.`,
+				Default: defaultAutoRebuildInterval,
+			},
+			"enable_delta": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, delta CRLs holding only the
+certificates revoked since the last complete CRL are built. Requires
+auto_rebuild.`,
+			},
+			"delta_rebuild_interval": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `How often the delta CRL is rebuilt when
+enable_delta is set; defaults to 15 minutes. Must be shorter than
+auto_rebuild_interval.`,
+				Default: defaultDeltaRebuildInterval,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -71,8 +158,15 @@ func (b *backend) pathCRLRead(ctx context.Context, req *logical.Request, data *f
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"expiry":  config.Expiry,
-			"disable": config.Disable,
+			"expiry":       config.Expiry,
+			"disable":      config.Disable,
+			"ocsp_disable": config.OcspDisable,
+			"ocsp_expiry":  config.OcspExpiry,
+
+			"auto_rebuild":           config.AutoRebuild,
+			"auto_rebuild_interval":  config.AutoRebuildInterval,
+			"enable_delta":           config.EnableDelta,
+			"delta_rebuild_interval": config.DeltaRebuildInterval,
 		},
 	}, nil
 }
@@ -101,6 +195,52 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 		config.Disable = disableRaw.(bool)
 	}
 
+	if ocspDisableRaw, ok := d.GetOk("ocsp_disable"); ok {
+		config.OcspDisable = ocspDisableRaw.(bool)
+	}
+
+	if ocspExpiryRaw, ok := d.GetOk("ocsp_expiry"); ok {
+		ocspExpiry := ocspExpiryRaw.(string)
+		if _, err := parseutil.ParseDurationSecond(ocspExpiry); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("given ocsp_expiry could not be decoded: %s", err)), nil
+		}
+		config.OcspExpiry = ocspExpiry
+	}
+
+	oldAutoRebuild, oldEnableDelta := config.AutoRebuild, config.EnableDelta
+	if autoRebuildRaw, ok := d.GetOk("auto_rebuild"); ok {
+		config.AutoRebuild = autoRebuildRaw.(bool)
+	}
+	if enableDeltaRaw, ok := d.GetOk("enable_delta"); ok {
+		config.EnableDelta = enableDeltaRaw.(bool)
+	}
+	if rebuildRaw, ok := d.GetOk("auto_rebuild_interval"); ok {
+		config.AutoRebuildInterval = rebuildRaw.(string)
+	}
+	if deltaRaw, ok := d.GetOk("delta_rebuild_interval"); ok {
+		config.DeltaRebuildInterval = deltaRaw.(string)
+	}
+
+	if config.EnableDelta && !config.AutoRebuild {
+		return logical.ErrorResponse("enable_delta requires auto_rebuild to be set"), nil
+	}
+	if config.AutoRebuild {
+		rebuildInterval, deltaInterval, err := config.rebuildIntervals()
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		crlLifetime, err := config.lifetime(b.crlLifetime)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if rebuildInterval <= 0 || rebuildInterval >= crlLifetime {
+			return logical.ErrorResponse("auto_rebuild_interval must be positive and shorter than the CRL expiry"), nil
+		}
+		if config.EnableDelta && (deltaInterval <= 0 || deltaInterval >= rebuildInterval) {
+			return logical.ErrorResponse("delta_rebuild_interval must be positive and shorter than auto_rebuild_interval"), nil
+		}
+	}
+
 	entry, err := logical.StorageEntryJSON("config/crl", config)
 	if err != nil {
 		return nil, err
@@ -110,8 +250,18 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 		return nil, err
 	}
 
-	if oldDisable != config.Disable {
-		// It wasn't disabled but now it is, rotate
+	if oldEnableDelta && !config.EnableDelta {
+		if err := deleteDeltaCRLs(ctx, req.Storage); err != nil {
+			return nil, errwrap.Wrapf("error removing delta CRLs: {{err}}", err)
+		}
+	}
+
+	if oldDisable != config.Disable || oldAutoRebuild != config.AutoRebuild || oldEnableDelta != config.EnableDelta {
+		// It wasn't disabled but now it is, or the way the CRL is built
+		// changed, rotate
+		b.revokeStorageLock.Lock()
+		defer b.revokeStorageLock.Unlock()
+
 		crlErr := buildCRL(ctx, b, req, true)
 		switch crlErr.(type) {
 		case errutil.UserError:
@@ -125,9 +275,16 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 }
 
 const pathConfigCRLHelpSyn = `
-Configure the CRL expiration.
+Configure the CRL expiration and OCSP responder.
 `
 
 const pathConfigCRLHelpDesc = `
-This endpoint allows configuration of the CRL lifetime.
+This endpoint allows configuration of the CRL lifetime, as well as whether the
+built-in OCSP responder is enabled and how long its responses are valid.
+
+With auto_rebuild set, revoking a certificate only records the revocation and
+the CRL is rebuilt periodically instead, which keeps revocation cheap on mounts
+with many revoked certificates. Additionally setting enable_delta builds delta
+CRLs, served at "crl/delta", which only hold the certificates revoked since the
+last complete CRL and are rebuilt more frequently.
 `
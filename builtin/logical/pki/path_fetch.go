@@ -2,11 +2,16 @@ package pki
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -39,10 +44,10 @@ func pathFetchCAChain(b *backend) *framework.Path {
 	}
 }
 
-// Returns the CRL in raw format
+// Returns the CRL or delta CRL in raw format
 func pathFetchCRL(b *backend) *framework.Path {
 	return &framework.Path{
-		Pattern: `crl(/pem)?`,
+		Pattern: `crl(/delta)?(/pem)?`,
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation: b.pathFetchRead,
@@ -75,10 +80,10 @@ hyphen-separated octal`,
 	}
 }
 
-// This returns the CRL in a non-raw format
+// This returns the CRL or delta CRL in a non-raw format
 func pathFetchCRLViaCertPath(b *backend) *framework.Path {
 	return &framework.Path{
-		Pattern: `cert/crl`,
+		Pattern: `cert/(crl|delta-crl)`,
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation: b.pathFetchRead,
@@ -112,6 +117,178 @@ func (b *backend) pathFetchCertList(ctx context.Context, req *logical.Request, d
 	return logical.ListResponse(entries), nil
 }
 
+// This returns the list of serial numbers for certs along with their metadata,
+// optionally filtered
+func pathFetchListCertsDetailed(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "certs/detailed$",
+		Fields: map[string]*framework.FieldSchema{
+			"role": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Only list certificates issued by the given
+role.`,
+			},
+			"expiring_within": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Only list certificates which have not expired
+yet, but expire within the given duration.`,
+			},
+			"revoked": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, only list revoked certificates when true
+or only certificates which are not revoked when false.`,
+			},
+			"after": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Only list certificates whose serial number sorts
+after the given one; used to page through the results.`,
+			},
+			"limit": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The maximum number of certificates to list. The
+default of 0 lists all matching certificates.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathFetchCertListDetailed,
+			logical.UpdateOperation: b.pathFetchCertListDetailed,
+		},
+
+		HelpSynopsis:    pathFetchListCertsDetailedHelpSyn,
+		HelpDescription: pathFetchListCertsDetailedHelpDesc,
+	}
+}
+
+// This returns the list of serial numbers for revoked certs
+func pathFetchListRevokedCerts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "certs/revoked/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathFetchRevokedCertList,
+		},
+
+		HelpSynopsis:    pathFetchHelpSyn,
+		HelpDescription: pathFetchHelpDesc,
+	}
+}
+
+func (b *backend) pathFetchRevokedCertList(ctx context.Context, req *logical.Request, data *framework.FieldData) (response *logical.Response, retErr error) {
+	entries, err := req.Storage.List(ctx, "revoked/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathFetchCertListDetailed(ctx context.Context, req *logical.Request, data *framework.FieldData) (response *logical.Response, retErr error) {
+	roleFilter := data.Get("role").(string)
+	expiringWithin := time.Duration(data.Get("expiring_within").(int)) * time.Second
+	revokedFilter, filterRevoked := data.GetOk("revoked")
+	after := normalizeSerial(data.Get("after").(string))
+	limit := data.Get("limit").(int)
+
+	if expiringWithin < 0 {
+		return logical.ErrorResponse("expiring_within must not be negative"), nil
+	}
+	if limit < 0 {
+		return logical.ErrorResponse("limit must not be negative"), nil
+	}
+
+	entries, err := req.Storage.List(ctx, "certs/")
+	if err != nil {
+		return nil, err
+	}
+	revokedEntries, err := req.Storage.List(ctx, "revoked/")
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string]bool, len(revokedEntries))
+	for _, serial := range revokedEntries {
+		revoked[normalizeSerial(serial)] = true
+	}
+
+	// Certificates may still be stored under their legacy colon-separated
+	// serial, so normalize before sorting to get a stable order for paging
+	serials := make([]string, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for _, serial := range entries {
+		serial = normalizeSerial(serial)
+		if !seen[serial] {
+			seen[serial] = true
+			serials = append(serials, serial)
+		}
+	}
+	sort.Strings(serials)
+
+	now := time.Now()
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	for _, serial := range serials {
+		if limit > 0 && len(keys) >= limit {
+			break
+		}
+		if after != "" && serial <= after {
+			continue
+		}
+		if filterRevoked && revoked[serial] != revokedFilter.(bool) {
+			continue
+		}
+
+		certEntry, err := fetchCertBySerial(ctx, req, "certs/", serial)
+		if err != nil {
+			return nil, err
+		}
+		if certEntry == nil {
+			continue
+		}
+		cert, err := x509.ParseCertificate(certEntry.Value)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("unable to parse stored certificate with serial %q: {{err}}", serial), err)
+		}
+		if expiringWithin > 0 && (cert.NotAfter.Before(now) || cert.NotAfter.After(now.Add(expiringWithin))) {
+			continue
+		}
+
+		metadata, err := fetchCertMetadata(ctx, req.Storage, serial)
+		if err != nil {
+			return nil, err
+		}
+		if roleFilter != "" && metadata.Role != roleFilter {
+			continue
+		}
+
+		info := map[string]interface{}{
+			"serial_number": certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":"),
+			"common_name":   cert.Subject.CommonName,
+			"role":          metadata.Role,
+			"not_after":     cert.NotAfter.UTC().Format(time.RFC3339),
+			"revoked":       revoked[serial],
+		}
+		if revoked[serial] {
+			revokedEntry, err := fetchCertBySerial(ctx, req, "revoked/", serial)
+			if err != nil {
+				return nil, err
+			}
+			if revokedEntry != nil {
+				var revInfo revocationInfo
+				if err := revokedEntry.DecodeJSON(&revInfo); err != nil {
+					return nil, errwrap.Wrapf(fmt.Sprintf("error decoding revocation entry for serial %q: {{err}}", serial), err)
+				}
+				info["revocation_time"] = revInfo.RevocationTime
+			}
+		}
+
+		keys = append(keys, serial)
+		keyInfo[serial] = info
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
 func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (response *logical.Response, retErr error) {
 	var serial, pemType, contentType string
 	var certEntry, revokedEntry *logical.StorageEntry
@@ -145,9 +322,18 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 		if req.Path == "crl/pem" {
 			pemType = "X509 CRL"
 		}
+	case req.Path == "crl/delta" || req.Path == "crl/delta/pem":
+		serial = "delta-crl"
+		contentType = "application/pkix-crl"
+		if req.Path == "crl/delta/pem" {
+			pemType = "X509 CRL"
+		}
 	case req.Path == "cert/crl":
 		serial = "crl"
 		pemType = "X509 CRL"
+	case req.Path == "cert/delta-crl":
+		serial = "delta-crl"
+		pemType = "X509 CRL"
 	default:
 		serial = data.Get("serial").(string)
 		pemType = "CERTIFICATE"
@@ -158,7 +344,7 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 	}
 
 	if serial == "ca_chain" {
-		caInfo, err := fetchCAInfo(ctx, req)
+		caInfo, err := fetchCAInfo(ctx, req, defaultRef)
 		switch err.(type) {
 		case errutil.UserError:
 			response = logical.ErrorResponse(err.Error())
@@ -272,5 +458,24 @@ This allows certificates to be fetched. If using the fetch/ prefix any non-revok
 
 Using "ca" or "crl" as the value fetches the appropriate information in DER encoding. Add "/pem" to either to get PEM encoding.
 
+Using "crl/delta" fetches the delta CRL in DER encoding, when delta CRLs are enabled. Add "/pem" to get PEM encoding.
+
 Using "ca_chain" as the value fetches the certificate authority trust chain in PEM encoding.
 `
+
+const pathFetchListCertsDetailedHelpSyn = `
+List certificates along with their metadata.
+`
+
+const pathFetchListCertsDetailedHelpDesc = `
+This lists the serial numbers of stored certificates along with their common
+name, the role which issued them, their expiration and whether they were
+revoked. Certificates issued before the role was recorded are listed with an
+empty role.
+
+The results can be filtered by "role", by "expiring_within" a duration and by
+their "revoked" state, passed as query parameters of a read or in the body of a
+write. Serial numbers are listed in sorted order; pass the last
+serial number of a page as "after", along with a "limit", to fetch the next
+page.
+`
@@ -0,0 +1,124 @@
+package pki
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPki_ListCertsDetailed(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	list := func(data map[string]interface{}) []string {
+		t.Helper()
+		resp := request(logical.ReadOperation, "certs/detailed", data)
+		keys, _ := resp.Data["keys"].([]string)
+		return keys
+	}
+
+	resp := request(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "48h",
+	})
+	rootSerial := normalizeSerial(resp.Data["serial_number"].(string))
+	for _, role := range []string{"web", "db"} {
+		request(logical.UpdateOperation, "roles/"+role, map[string]interface{}{
+			"allowed_domains":  "myvault.com",
+			"allow_subdomains": true,
+		})
+	}
+	issue := func(role, cn, ttl string) string {
+		t.Helper()
+		resp := request(logical.UpdateOperation, "issue/"+role, map[string]interface{}{
+			"common_name": cn,
+			"ttl":         ttl,
+		})
+		return normalizeSerial(resp.Data["serial_number"].(string))
+	}
+	web1 := issue("web", "one.myvault.com", "1h")
+	web2 := issue("web", "two.myvault.com", "24h")
+	db := issue("db", "db.myvault.com", "1h")
+
+	all := []string{rootSerial, web1, web2, db}
+	sort.Strings(all)
+	if keys := list(nil); !reflect.DeepEqual(keys, all) {
+		t.Fatalf("expected %v, got %v", all, keys)
+	}
+
+	resp = request(logical.ReadOperation, "certs/detailed", nil)
+	info := resp.Data["key_info"].(map[string]interface{})[web1].(map[string]interface{})
+	if info["common_name"] != "one.myvault.com" || info["role"] != "web" || info["revoked"] != false {
+		t.Fatalf("bad certificate info: %#v", info)
+	}
+
+	webCerts := []string{web1, web2}
+	sort.Strings(webCerts)
+	if keys := list(map[string]interface{}{"role": "web"}); !reflect.DeepEqual(keys, webCerts) {
+		t.Fatalf("expected %v, got %v", webCerts, keys)
+	}
+
+	expiring := []string{web1, db}
+	sort.Strings(expiring)
+	if keys := list(map[string]interface{}{"expiring_within": "2h"}); !reflect.DeepEqual(keys, expiring) {
+		t.Fatalf("expected %v, got %v", expiring, keys)
+	}
+
+	request(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": db,
+	})
+	if keys := list(map[string]interface{}{"revoked": true}); !reflect.DeepEqual(keys, []string{db}) {
+		t.Fatalf("expected only %s to be revoked, got %v", db, keys)
+	}
+	if keys := list(map[string]interface{}{"revoked": false}); len(keys) != 3 {
+		t.Fatalf("expected 3 unrevoked certificates, got %v", keys)
+	}
+	resp = request(logical.ReadOperation, "certs/detailed", map[string]interface{}{"revoked": true})
+	info = resp.Data["key_info"].(map[string]interface{})[db].(map[string]interface{})
+	if info["revocation_time"] == nil {
+		t.Fatalf("expected revocation time, got %#v", info)
+	}
+	resp = request(logical.ListOperation, "certs/revoked", nil)
+	if keys := resp.Data["keys"].([]string); !reflect.DeepEqual(keys, []string{db}) {
+		t.Fatalf("expected only %s to be revoked, got %v", db, keys)
+	}
+
+	// Page through the certificates two at a time
+	var paged []string
+	after := ""
+	for {
+		keys := list(map[string]interface{}{"after": after, "limit": 2})
+		if len(keys) == 0 {
+			break
+		}
+		paged = append(paged, keys...)
+		after = keys[len(keys)-1]
+	}
+	if !reflect.DeepEqual(paged, all) {
+		t.Fatalf("expected %v, got %v", all, paged)
+	}
+
+	// Removing the certificate also removes its metadata
+	if err := deleteCertEntry(context.Background(), storage, db); err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := storage.Get(context.Background(), certMetadataPrefix+db)
+	if err != nil || metadata != nil {
+		t.Fatalf("expected metadata to be removed, got err: %v entry: %#v", err, metadata)
+	}
+}
@@ -26,6 +26,11 @@ func pathGenerateIntermediate(b *backend) *framework.Path {
 
 	ret.Fields = addCACommonFields(map[string]*framework.FieldSchema{})
 	ret.Fields = addCAKeyGenerationFields(ret.Fields)
+	ret.Fields["key_name"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `Optional name for the key created by this
+request, usable in place of its ID.`,
+	}
 	ret.Fields["add_basic_constraints"] = &framework.FieldSchema{
 		Type: framework.TypeBool,
 		Description: `Whether to add a Basic Constraints
@@ -49,6 +54,11 @@ certificate with a public key matching the
 previously-generated key from the generation
 endpoint.`,
 			},
+			"issuer_name": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Optional name for the issuer created by
+this request, usable in place of its ID.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -126,18 +136,16 @@ func (b *backend) pathGenerateIntermediate(ctx context.Context, req *logical.Req
 		}
 	}
 
-	cb := &certutil.CertBundle{}
-	cb.PrivateKey = csrb.PrivateKey
-	cb.PrivateKeyType = csrb.PrivateKeyType
-
-	entry, err := logical.StorageEntryJSON("config/ca_bundle", cb)
+	key, _, err := importKey(ctx, req.Storage, csrb.PrivateKey, data.Get("key_name").(string))
 	if err != nil {
-		return nil, err
-	}
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		return nil, err
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
 	}
+	resp.Data["key_id"] = key.ID
 
 	return resp, nil
 }
@@ -163,24 +171,22 @@ func (b *backend) pathSetSignedIntermediate(ctx context.Context, req *logical.Re
 		return logical.ErrorResponse("supplied certificate could not be successfully parsed"), nil
 	}
 
-	cb := &certutil.CertBundle{}
-	entry, err := req.Storage.Get(ctx, "config/ca_bundle")
-	if err != nil {
-		return nil, err
-	}
-	if entry == nil {
-		return logical.ErrorResponse("could not find any existing entry with a private key"), nil
+	if !inputBundle.Certificate.IsCA {
+		return logical.ErrorResponse("the given certificate is not marked for CA use and cannot be used with this backend"), nil
 	}
 
-	err = entry.DecodeJSON(cb)
+	key, err := findKeyForCertificate(ctx, req.Storage, inputBundle.Certificate)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(cb.PrivateKey) == 0 || cb.PrivateKeyType == "" {
-		return logical.ErrorResponse("could not find an existing private key"), nil
+	if key == nil {
+		return logical.ErrorResponse("could not find an existing private key matching the certificate"), nil
 	}
 
+	cb := &certutil.CertBundle{
+		PrivateKeyType: key.PrivateKeyType,
+		PrivateKey:     key.PrivateKey,
+	}
 	parsedCB, err := cb.ToParsedCertBundle()
 	if err != nil {
 		return nil, err
@@ -193,10 +199,6 @@ func (b *backend) pathSetSignedIntermediate(ctx context.Context, req *logical.Re
 	inputBundle.PrivateKeyType = parsedCB.PrivateKeyType
 	inputBundle.PrivateKeyBytes = parsedCB.PrivateKeyBytes
 
-	if !inputBundle.Certificate.IsCA {
-		return logical.ErrorResponse("the given certificate is not marked for CA use and cannot be used with this backend"), nil
-	}
-
 	if err := inputBundle.Verify(); err != nil {
 		return nil, errwrap.Wrapf("verification of parsed bundle failed: {{err}}", err)
 	}
@@ -206,35 +208,48 @@ func (b *backend) pathSetSignedIntermediate(ctx context.Context, req *logical.Re
 		return nil, errwrap.Wrapf("error converting raw values into cert bundle: {{err}}", err)
 	}
 
-	entry, err = logical.StorageEntryJSON("config/ca_bundle", cb)
+	issuer, existing, err := importIssuer(ctx, req.Storage, cb.Certificate, cb.CAChain, data.Get("issuer_name").(string))
 	if err != nil {
-		return nil, err
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
 	}
-	err = req.Storage.Put(ctx, entry)
+
+	err = req.Storage.Put(ctx, &logical.StorageEntry{
+		Key:   "certs/" + normalizeSerial(cb.SerialNumber),
+		Value: inputBundle.CertificateBytes,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	entry.Key = "certs/" + normalizeSerial(cb.SerialNumber)
-	entry.Value = inputBundle.CertificateBytes
-	err = req.Storage.Put(ctx, entry)
+	// For ease of later use, also store the default issuer's certificate at a
+	// known location
+	err = writeDefaultIssuerCert(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
 
-	// For ease of later use, also store just the certificate at a known
-	// location
-	entry.Key = "ca"
-	entry.Value = inputBundle.CertificateBytes
-	err = req.Storage.Put(ctx, entry)
+	// Build a fresh CRL
+	err = buildCRL(ctx, b, req, true)
 	if err != nil {
 		return nil, err
 	}
 
-	// Build a fresh CRL
-	err = buildCRL(ctx, b, req, true)
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id": issuer.ID,
+			"key_id":    key.ID,
+		},
+	}
+	if existing {
+		resp.AddWarning("This certificate was already imported as an existing issuer.")
+	}
 
-	return nil, err
+	return resp, nil
 }
 
 const pathGenerateIntermediateHelpSyn = `
@@ -101,6 +101,8 @@ this value to an empty list.`,
 		Description: `A comma-separated string or list of extended key usage oids.`,
 	}
 
+	ret.Fields = addIssuerRefField(ret.Fields)
+
 	return ret
 }
 
@@ -168,6 +170,7 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 		KeyUsage:             data.Get("key_usage").([]string),
 		ExtKeyUsage:          data.Get("ext_key_usage").([]string),
 		ExtKeyUsageOIDs:      data.Get("ext_key_usage_oids").([]string),
+		Issuer:               data.Get("issuer_ref").(string),
 	}
 
 	*entry.GenerateLease = false
@@ -183,6 +186,9 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 			*entry.GenerateLease = *role.GenerateLease
 		}
 		entry.NoStore = role.NoStore
+		if _, ok := data.GetOk("issuer_ref"); !ok {
+			entry.Issuer = role.Issuer
+		}
 	}
 
 	return b.pathIssueSignCert(ctx, req, data, entry, true, true)
@@ -201,7 +207,7 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchCAInfo(ctx, req, role.Issuer)
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
@@ -324,6 +330,16 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		if err != nil {
 			return nil, errwrap.Wrapf("unable to store certificate locally: {{err}}", err)
 		}
+
+		metadataEntry, err := logical.StorageEntryJSON(certMetadataPrefix+normalizeSerial(cb.SerialNumber), &certMetadata{
+			Role: data.Get("role").(string),
+		})
+		if err != nil {
+			return nil, errwrap.Wrapf("error creating certificate metadata entry: {{err}}", err)
+		}
+		if err := req.Storage.Put(ctx, metadataEntry); err != nil {
+			return nil, errwrap.Wrapf("unable to store certificate metadata locally: {{err}}", err)
+		}
 	}
 
 	if useCSR {
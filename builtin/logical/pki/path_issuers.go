@@ -0,0 +1,435 @@
+package pki
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathListIssuers(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathListIssuers,
+		},
+
+		HelpSynopsis:    pathListIssuersHelpSyn,
+		HelpDescription: pathListIssuersHelpDesc,
+	}
+}
+
+func pathIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuer/" + framework.GenericNameRegex("issuer_ref"),
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_ref": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Reference to the issuer; either "default",
+an issuer ID or an issuer name.`,
+			},
+			"issuer_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Name for the issuer, usable in place of its ID.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathIssuerRead,
+			logical.UpdateOperation: b.pathIssuerUpdate,
+			logical.DeleteOperation: b.pathIssuerDelete,
+		},
+
+		HelpSynopsis:    pathIssuerHelpSyn,
+		HelpDescription: pathIssuerHelpDesc,
+	}
+}
+
+func pathConfigIssuers(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/issuers",
+		Fields: map[string]*framework.FieldSchema{
+			"default": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Reference (ID or name) to the issuer to use by default.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigIssuersRead,
+			logical.UpdateOperation: b.pathConfigIssuersWrite,
+		},
+
+		HelpSynopsis:    pathConfigIssuersHelpSyn,
+		HelpDescription: pathConfigIssuersHelpDesc,
+	}
+}
+
+// Returns an issuer's certificate and chain in a non-raw format
+func pathFetchIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "cert/issuer/" + framework.GenericNameRegex("issuer_ref"),
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_ref": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Reference to the issuer; either "default",
+an issuer ID or an issuer name.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchIssuer,
+		},
+
+		HelpSynopsis:    pathFetchIssuerHelpSyn,
+		HelpDescription: pathFetchIssuerHelpDesc,
+	}
+}
+
+// Returns an issuer's CRL or delta CRL in raw format
+func pathFetchIssuerCRL(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "crl/issuer/" + framework.GenericNameRegex("issuer_ref") + "(/delta)?(/pem)?",
+		Fields: map[string]*framework.FieldSchema{
+			"issuer_ref": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Reference to the issuer; either "default",
+an issuer ID or an issuer name.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchIssuerCRL,
+		},
+
+		HelpSynopsis:    pathFetchIssuerCRLHelpSyn,
+		HelpDescription: pathFetchIssuerCRLHelpDesc,
+	}
+}
+
+func (b *backend) pathListIssuers(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuers, err := listIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := make(map[string]interface{}, len(issuers))
+	for _, issuerID := range issuers {
+		issuer, err := fetchIssuerByID(ctx, req.Storage, issuerID)
+		if err != nil {
+			return nil, err
+		}
+		keyInfo[issuerID] = map[string]interface{}{
+			"issuer_name": issuer.Name,
+			"is_default":  issuerID == config.DefaultIssuerID,
+		}
+	}
+
+	return logical.ListResponseWithInfo(issuers, keyInfo), nil
+}
+
+func (b *backend) pathIssuerRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuer, errResp, err := fetchIssuerFromRef(ctx, req.Storage, data.Get("issuer_ref").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	return issuerResponse(ctx, req.Storage, issuer)
+}
+
+func (b *backend) pathIssuerUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuer, errResp, err := fetchIssuerFromRef(ctx, req.Storage, data.Get("issuer_ref").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	if nameRaw, ok := data.GetOk("issuer_name"); ok {
+		name := nameRaw.(string)
+		if name != issuer.Name {
+			if name != "" {
+				if err := checkNameAvailable(ctx, req.Storage, issuerPrefix, name); err != nil {
+					return logical.ErrorResponse(err.Error()), nil
+				}
+			}
+			issuer.Name = name
+			if err := writeIssuer(ctx, req.Storage, issuer); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return issuerResponse(ctx, req.Storage, issuer)
+}
+
+func (b *backend) pathIssuerDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuerID, err := resolveIssuerReference(ctx, req.Storage, data.Get("issuer_ref").(string))
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			// Deleting a missing issuer is not an error
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	wasDefault, err := deleteIssuer(ctx, req.Storage, issuerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wasDefault {
+		if err := writeDefaultIssuerCert(ctx, req.Storage); err != nil {
+			return nil, err
+		}
+		resp := &logical.Response{}
+		resp.AddWarning(fmt.Sprintf("Deleted the default issuer; set a new one through %sconfig/issuers before issuing further certificates.", req.MountPoint))
+		return resp, nil
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigIssuersRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"default": config.DefaultIssuerID,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigIssuersWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	newDefault := data.Get("default").(string)
+	if newDefault == "" || newDefault == defaultRef {
+		return logical.ErrorResponse(`"default" must reference an existing issuer by ID or name`), nil
+	}
+
+	issuerID, err := resolveIssuerReference(ctx, req.Storage, newDefault)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.DefaultIssuerID != issuerID {
+		config.DefaultIssuerID = issuerID
+		if err := setIssuersConfig(ctx, req.Storage, config); err != nil {
+			return nil, err
+		}
+
+		// Refresh the legacy CA and CRL entries, which track the default
+		if err := writeDefaultIssuerCert(ctx, req.Storage); err != nil {
+			return nil, err
+		}
+		crlErr := buildCRL(ctx, b, req, true)
+		switch crlErr.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(fmt.Sprintf("error during CRL building: %s", crlErr)), nil
+		case errutil.InternalError:
+			return nil, fmt.Errorf("error encountered during CRL building: %s", crlErr)
+		}
+	}
+
+	return b.pathConfigIssuersRead(ctx, req, data)
+}
+
+func (b *backend) pathFetchIssuer(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuer, errResp, err := fetchIssuerFromRef(ctx, req.Storage, data.Get("issuer_ref").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	caChain, err := issuerCAChain(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id":   issuer.ID,
+			"certificate": issuer.Certificate,
+			"ca_chain":    caChain,
+		},
+	}, nil
+}
+
+func (b *backend) pathFetchIssuerCRL(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	response := &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/pkix-crl",
+			logical.HTTPStatusCode:  204,
+		},
+	}
+
+	issuerRef := data.Get("issuer_ref").(string)
+	issuerID, err := resolveIssuerReference(ctx, req.Storage, issuerRef)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return response, nil
+		}
+		return nil, err
+	}
+
+	path := issuerCRLPrefix + issuerID
+	if strings.HasPrefix(strings.TrimPrefix(req.Path, "crl/issuer/"+issuerRef), "/delta") {
+		path = issuerDeltaCRLPrefix + issuerID
+	}
+
+	entry, err := req.Storage.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || len(entry.Value) == 0 {
+		return response, nil
+	}
+
+	crl := entry.Value
+	if strings.HasSuffix(req.Path, "/pem") {
+		crl = []byte(strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  "X509 CRL",
+			Bytes: entry.Value,
+		}))))
+	}
+
+	response.Data[logical.HTTPRawBody] = crl
+	response.Data[logical.HTTPStatusCode] = 200
+	return response, nil
+}
+
+// fetchIssuerFromRef resolves the reference and loads the issuer, returning
+// an error response if it does not exist
+func fetchIssuerFromRef(ctx context.Context, s logical.Storage, issuerRef string) (*issuerEntry, *logical.Response, error) {
+	issuerID, err := resolveIssuerReference(ctx, s, issuerRef)
+	if err == nil {
+		var issuer *issuerEntry
+		issuer, err = fetchIssuerByID(ctx, s, issuerID)
+		if err == nil {
+			return issuer, nil, nil
+		}
+	}
+
+	switch err.(type) {
+	case errutil.UserError:
+		return nil, logical.ErrorResponse(err.Error()), nil
+	default:
+		return nil, nil, err
+	}
+}
+
+func issuerResponse(ctx context.Context, s logical.Storage, issuer *issuerEntry) (*logical.Response, error) {
+	config, err := getIssuersConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	caChain, err := issuerCAChain(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id":     issuer.ID,
+			"issuer_name":   issuer.Name,
+			"key_id":        issuer.KeyID,
+			"certificate":   issuer.Certificate,
+			"ca_chain":      caChain,
+			"serial_number": issuer.SerialNumber,
+			"is_default":    issuer.ID == config.DefaultIssuerID,
+		},
+	}, nil
+}
+
+// issuerCAChain returns the PEM-encoded chain of the issuer, not including
+// the root authority, in the same way as the ca_chain endpoint does for the
+// default issuer.
+func issuerCAChain(issuer *issuerEntry) ([]string, error) {
+	cb := &certutil.CertBundle{
+		Certificate: issuer.Certificate,
+		CAChain:     issuer.CAChain,
+	}
+	parsed, err := cb.ToParsedCertBundle()
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse stored issuer %s: %v", issuer.ID, err)}
+	}
+
+	caChain := []string{}
+	for _, ca := range (&certutil.CAInfoBundle{ParsedCertBundle: *parsed}).GetCAChain() {
+		caChain = append(caChain, strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: ca.Bytes,
+		}))))
+	}
+
+	return caChain, nil
+}
+
+const pathListIssuersHelpSyn = `
+List the issuers of this mount.
+`
+
+const pathListIssuersHelpDesc = `
+This lists the IDs of all CA certificates stored in this mount, along with
+their names and whether they are the default issuer.
+`
+
+const pathIssuerHelpSyn = `
+Read, rename or delete an issuer of this mount.
+`
+
+const pathIssuerHelpDesc = `
+This endpoint manages a single issuer, referenced by its ID, its name or
+"default". Deleting an issuer leaves its key in place; deleting the default
+issuer requires a new default to be set through "config/issuers".
+`
+
+const pathConfigIssuersHelpSyn = `
+Read and set the default issuer of this mount.
+`
+
+const pathConfigIssuersHelpDesc = `
+The default issuer is used by roles with an issuer_ref of "default", and is
+served by the legacy "ca", "ca_chain" and "crl" endpoints.
+`
+
+const pathFetchIssuerHelpSyn = `
+Fetch an issuer's CA certificate and chain.
+`
+
+const pathFetchIssuerHelpDesc = `
+This allows the certificate and CA chain of any issuer of this mount to be
+fetched without authentication.
+`
+
+const pathFetchIssuerCRLHelpSyn = `
+Fetch an issuer's CRL.
+`
+
+const pathFetchIssuerCRLHelpDesc = `
+This returns the CRL of the given issuer in raw DER format, or PEM format when
+"/pem" is appended. Each issuer's CRL only lists revoked certificates which
+were issued by it. Insert "/delta" before "/pem" to fetch the issuer's delta
+CRL instead, when delta CRLs are enabled.
+`
@@ -0,0 +1,426 @@
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPki_MultipleIssuers(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	write := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	read := func(path string) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      path,
+			Storage:   storage,
+		})
+		if err != nil {
+			t.Fatalf("bad: path: %s err: %v", path, err)
+		}
+		return resp
+	}
+
+	resp := write("root/generate/internal", map[string]interface{}{
+		"common_name": "root-a.myvault.com",
+		"ttl":         "48h",
+		"issuer_name": "root-a",
+		"key_name":    "key-a",
+	})
+	rootA := parseCertPEM(t, resp.Data["certificate"].(string))
+	rootAID := resp.Data["issuer_id"].(string)
+
+	// Generating over an existing root is still refused
+	resp = write("root/generate/internal", map[string]interface{}{
+		"common_name": "other.myvault.com",
+	})
+	if resp == nil || len(resp.Warnings) == 0 || resp.Data != nil {
+		t.Fatalf("expected refusal warning, got %#v", resp)
+	}
+
+	// Rotating adds a second root without touching the default
+	resp = write("root/rotate/internal", map[string]interface{}{
+		"common_name": "root-b.myvault.com",
+		"ttl":         "48h",
+		"issuer_name": "root-b",
+	})
+	rootB := parseCertPEM(t, resp.Data["certificate"].(string))
+	rootBID := resp.Data["issuer_id"].(string)
+
+	// Names must be unique
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "root/rotate/internal",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"common_name": "root-c.myvault.com",
+			"issuer_name": "root-b",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error for duplicate name, got err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "issuers",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 2 {
+		t.Fatalf("expected two issuers, got %v", keys)
+	}
+
+	resp = read("config/issuers")
+	if resp.Data["default"] != rootAID {
+		t.Fatalf("expected default issuer %s, got %v", rootAID, resp.Data["default"])
+	}
+
+	resp = read("issuer/root-b")
+	if resp.Data["issuer_id"] != rootBID || resp.Data["is_default"] != false {
+		t.Fatalf("bad issuer response: %#v", resp.Data)
+	}
+	resp = read("key/key-a")
+	if resp.Data["key_type"] != certutil.RSAPrivateKey {
+		t.Fatalf("bad key response: %#v", resp.Data)
+	}
+
+	write("roles/default-issuer", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"ttl":              "1h",
+	})
+	write("roles/pinned", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"ttl":              "1h",
+		"issuer_ref":       "root-b",
+	})
+	resp = read("roles/pinned")
+	if resp.Data["issuer_ref"] != "root-b" {
+		t.Fatalf("bad role response: %#v", resp.Data)
+	}
+
+	// Roles may not reference issuers which do not exist
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/missing",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"allow_any_name": true,
+			"issuer_ref":     "missing",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error for unknown issuer, got err: %v resp: %#v", err, resp)
+	}
+
+	issue := func(role string, expectedIssuer *x509.Certificate) (*x509.Certificate, string) {
+		t.Helper()
+		resp := write("issue/"+role, map[string]interface{}{
+			"common_name": "cert.myvault.com",
+		})
+		cert := parseCertPEM(t, resp.Data["certificate"].(string))
+		if err := cert.CheckSignatureFrom(expectedIssuer); err != nil {
+			t.Fatalf("certificate not issued by expected issuer: %v", err)
+		}
+		return cert, resp.Data["serial_number"].(string)
+	}
+
+	issue("default-issuer", rootA)
+	revokedB, revokedSerial := issue("pinned", rootB)
+
+	// Revoking only affects the CRL of the issuer which signed the cert
+	write("revoke", map[string]interface{}{
+		"serial_number": revokedSerial,
+	})
+	checkCRL := func(path string, issuer *x509.Certificate, expected int) {
+		t.Helper()
+		resp := read(path)
+		crl, err := x509.ParseCRL(resp.Data[logical.HTTPRawBody].([]byte))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := issuer.CheckCRLSignature(crl); err != nil {
+			t.Fatalf("CRL at %s not signed by expected issuer: %v", path, err)
+		}
+		revoked := crl.TBSCertList.RevokedCertificates
+		if len(revoked) != expected {
+			t.Fatalf("expected %d revoked certs in %s, got %d", expected, path, len(revoked))
+		}
+		if expected > 0 && revoked[0].SerialNumber.Cmp(revokedB.SerialNumber) != 0 {
+			t.Fatalf("unexpected serial in %s", path)
+		}
+	}
+	checkCRL("crl", rootA, 0)
+	checkCRL("crl/issuer/root-a", rootA, 0)
+	checkCRL("crl/issuer/"+rootBID, rootB, 1)
+
+	// Issuer certificates can't be revoked
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "revoke",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"serial_number": certutil.GetHexFormatted(rootB.SerialNumber.Bytes(), ":"),
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error revoking issuer, got err: %v resp: %#v", err, resp)
+	}
+
+	// Switching the default updates the legacy endpoints
+	write("config/issuers", map[string]interface{}{
+		"default": "root-b",
+	})
+	resp = read("cert/ca")
+	if parseCertPEM(t, resp.Data["certificate"].(string)).Equal(rootB) == false {
+		t.Fatalf("expected cert/ca to return the new default issuer")
+	}
+	checkCRL("crl", rootB, 1)
+	issue("default-issuer", rootB)
+
+	resp = read("cert/issuer/root-a")
+	if resp.Data["issuer_id"] != rootAID || !parseCertPEM(t, resp.Data["certificate"].(string)).Equal(rootA) {
+		t.Fatalf("bad issuer fetch response: %#v", resp.Data)
+	}
+
+	// The key of the default issuer can't be deleted, others can
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "key/" + read("issuer/root-b").Data["key_id"].(string),
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error deleting default key, got err: %v resp: %#v", err, resp)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "issuer/root-a",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "key/key-a",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	// Deleting the root removes everything
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "root",
+		Storage:   storage,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	for _, prefix := range []string{issuerPrefix, keyPrefix} {
+		entries, err := storage.List(context.Background(), prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("expected no entries under %s, got %v", prefix, entries)
+		}
+	}
+}
+
+func TestPki_MigrateLegacyCertBundle(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "root/generate/exported",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"common_name": "myvault.com",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	// Rewind storage to the layout used before multiple issuers
+	cb := &certutil.CertBundle{
+		Certificate:    resp.Data["certificate"].(string),
+		PrivateKey:     resp.Data["private_key"].(string),
+		PrivateKeyType: resp.Data["private_key_type"].(certutil.PrivateKeyType),
+		SerialNumber:   resp.Data["serial_number"].(string),
+	}
+	ctx := context.Background()
+	if _, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "root",
+		Storage:   storage,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := logical.StorageEntryJSON(legacyCertBundlePath, cb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	// The legacy bundle is served as the default issuer until migrated
+	caInfo, err := fetchCAInfo(ctx, &logical.Request{Storage: storage}, defaultRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caInfo.Certificate == nil || caInfo.PrivateKey == nil {
+		t.Fatalf("expected legacy CA info")
+	}
+
+	if err := b.Initialize(ctx, &logical.InitializationRequest{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err = storage.Get(ctx, legacyCertBundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil {
+		t.Fatalf("expected legacy bundle to be kept")
+	}
+	entry, err = storage.Get(ctx, legacyBundleMigrationLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil {
+		t.Fatalf("expected migration to be recorded")
+	}
+
+	issuer, migrated, err := fetchCertBundleByIssuerRef(ctx, storage, defaultRef, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issuer.ID == "" || issuer.KeyID == "" {
+		t.Fatalf("expected migrated issuer with key, got %#v", issuer)
+	}
+	if migrated.Certificate != cb.Certificate || migrated.PrivateKey != cb.PrivateKey {
+		t.Fatalf("migrated bundle does not match legacy bundle")
+	}
+
+	// The bundle is not migrated again on the next initialization
+	if err := b.Initialize(ctx, &logical.InitializationRequest{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	issuers, err := listIssuers(ctx, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issuers) != 1 {
+		t.Fatalf("expected a single issuer, got %d", len(issuers))
+	}
+
+	// Once migrated, the legacy bundle is no longer served, even when the
+	// issuer is deleted
+	if _, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "issuer/" + issuer.ID,
+		Storage:   storage,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := fetchCertBundleByIssuerRef(ctx, storage, defaultRef, false); err == nil {
+		t.Fatalf("expected no default issuer after deleting the migrated issuer")
+	}
+}
+
+func TestPki_SignSelfIssued_issuerRef(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	write := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	resp := write("root/generate/internal", map[string]interface{}{
+		"common_name": "root-a.myvault.com",
+		"issuer_name": "root-a",
+	})
+	rootA := parseCertPEM(t, resp.Data["certificate"].(string))
+	resp = write("root/rotate/internal", map[string]interface{}{
+		"common_name": "root-b.myvault.com",
+		"issuer_name": "root-b",
+	})
+	rootB := parseCertPEM(t, resp.Data["certificate"].(string))
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName: "self-issued.myvault.com",
+		},
+		SerialNumber:          big.NewInt(1234),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	selfIssued, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selfIssuedPEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: selfIssued,
+	}))
+
+	for issuerRef, expectedIssuer := range map[string]*x509.Certificate{
+		"":       rootA,
+		"root-b": rootB,
+	} {
+		data := map[string]interface{}{
+			"certificate": selfIssuedPEM,
+		}
+		if issuerRef != "" {
+			data["issuer_ref"] = issuerRef
+		}
+		resp := write("root/sign-self-issued", data)
+		cert := parseCertPEM(t, resp.Data["certificate"].(string))
+		if err := cert.CheckSignatureFrom(expectedIssuer); err != nil {
+			t.Fatalf("issuer_ref %q: certificate not signed by expected issuer: %v", issuerRef, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package pki
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathListKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathListKeys,
+		},
+
+		HelpSynopsis:    pathListKeysHelpSyn,
+		HelpDescription: pathListKeysHelpDesc,
+	}
+}
+
+func pathKey(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "key/" + framework.GenericNameRegex("key_ref"),
+		Fields: map[string]*framework.FieldSchema{
+			"key_ref": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Reference to the key; either a key ID or a key name.`,
+			},
+			"key_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Name for the key, usable in place of its ID.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathKeyRead,
+			logical.UpdateOperation: b.pathKeyUpdate,
+			logical.DeleteOperation: b.pathKeyDelete,
+		},
+
+		HelpSynopsis:    pathKeyHelpSyn,
+		HelpDescription: pathKeyHelpDesc,
+	}
+}
+
+func (b *backend) pathListKeys(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	keys, err := listKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := make(map[string]interface{}, len(keys))
+	for _, keyID := range keys {
+		key, err := fetchKeyByID(ctx, req.Storage, keyID)
+		if err != nil {
+			return nil, err
+		}
+		keyInfo[keyID] = map[string]interface{}{
+			"key_name": key.Name,
+		}
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	key, errResp, err := fetchKeyFromRef(ctx, req.Storage, data.Get("key_ref").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	return keyResponse(key), nil
+}
+
+func (b *backend) pathKeyUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	key, errResp, err := fetchKeyFromRef(ctx, req.Storage, data.Get("key_ref").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	if nameRaw, ok := data.GetOk("key_name"); ok {
+		name := nameRaw.(string)
+		if name != key.Name {
+			if name != "" {
+				if err := checkNameAvailable(ctx, req.Storage, keyPrefix, name); err != nil {
+					return logical.ErrorResponse(err.Error()), nil
+				}
+			}
+			key.Name = name
+			if err := writeKey(ctx, req.Storage, key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return keyResponse(key), nil
+}
+
+func (b *backend) pathKeyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	keyID, err := resolveKeyReference(ctx, req.Storage, data.Get("key_ref").(string))
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			// Deleting a missing key is not an error
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	config, err := getIssuersConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config.DefaultIssuerID != "" {
+		defaultIssuer, err := fetchIssuerByID(ctx, req.Storage, config.DefaultIssuerID)
+		if err != nil {
+			return nil, err
+		}
+		if defaultIssuer.KeyID == keyID {
+			return logical.ErrorResponse("unable to delete the key of the default issuer"), nil
+		}
+	}
+
+	return nil, deleteKey(ctx, req.Storage, keyID)
+}
+
+// fetchKeyFromRef resolves the reference and loads the key, returning an
+// error response if it does not exist
+func fetchKeyFromRef(ctx context.Context, s logical.Storage, keyRef string) (*keyEntry, *logical.Response, error) {
+	keyID, err := resolveKeyReference(ctx, s, keyRef)
+	if err == nil {
+		var key *keyEntry
+		key, err = fetchKeyByID(ctx, s, keyID)
+		if err == nil {
+			return key, nil, nil
+		}
+	}
+
+	switch err.(type) {
+	case errutil.UserError:
+		return nil, logical.ErrorResponse(err.Error()), nil
+	default:
+		return nil, nil, err
+	}
+}
+
+// keyResponse never includes the private key itself
+func keyResponse(key *keyEntry) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"key_id":   key.ID,
+			"key_name": key.Name,
+			"key_type": key.PrivateKeyType,
+		},
+	}
+}
+
+const pathListKeysHelpSyn = `
+List the private keys of this mount.
+`
+
+const pathListKeysHelpDesc = `
+This lists the IDs and names of all private keys stored in this mount.
+`
+
+const pathKeyHelpSyn = `
+Read, rename or delete a private key of this mount.
+`
+
+const pathKeyHelpDesc = `
+This endpoint manages a single key, referenced by its ID or name. The private
+key itself can never be read back. Issuers using a deleted key can no longer
+sign certificates or CRLs; the key of the default issuer cannot be deleted.
+`
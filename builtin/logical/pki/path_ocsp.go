@@ -0,0 +1,309 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	ocspResponseContentType = "application/ocsp-response"
+
+	// The maximum size of a DER encoded OCSP request we are willing to read
+	// from a POST body. Real requests are a few hundred bytes at most.
+	ocspMaxRequestSize = 64 * 1024
+
+	defaultOcspExpiry = 12 * time.Hour
+)
+
+var errOcspUnauthorized = errors.New("OCSP request is not for a certificate issued by this CA")
+
+// Handles OCSP requests sent via HTTP POST with a DER encoded request body
+func pathOcspPost(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `ocsp`,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathOcspPost,
+		},
+
+		HelpSynopsis:    pathOcspHelpSyn,
+		HelpDescription: pathOcspHelpDesc,
+	}
+}
+
+// Handles OCSP requests sent via HTTP GET with the base64 encoded request
+// appended to the path
+func pathOcspGet(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `ocsp/` + framework.MatchAllRegex("req"),
+		Fields: map[string]*framework.FieldSchema{
+			"req": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Base64 encoded, DER formatted OCSP request`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathOcspGet,
+		},
+
+		HelpSynopsis:    pathOcspHelpSyn,
+		HelpDescription: pathOcspHelpDesc,
+	}
+}
+
+func (b *backend) pathOcspGet(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	encoded := data.Get("req").(string)
+	if len(encoded) == 0 {
+		return ocspRawResponse(http.StatusBadRequest, ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	// Some clients strip the base64 padding or use the URL safe alphabet,
+	// so be lenient in what we accept.
+	encoded = strings.TrimRight(encoded, "=")
+	der, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		der, err = base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			return ocspRawResponse(http.StatusBadRequest, ocsp.MalformedRequestErrorResponse), nil
+		}
+	}
+
+	return b.handleOcspRequest(ctx, req, der)
+}
+
+func (b *backend) pathOcspPost(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if req.HTTPRequest == nil || req.HTTPRequest.Body == nil {
+		return ocspRawResponse(http.StatusBadRequest, ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	der, err := ioutil.ReadAll(io.LimitReader(req.HTTPRequest.Body, ocspMaxRequestSize))
+	if err != nil {
+		return ocspRawResponse(http.StatusBadRequest, ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	return b.handleOcspRequest(ctx, req, der)
+}
+
+func (b *backend) handleOcspRequest(ctx context.Context, req *logical.Request, der []byte) (*logical.Response, error) {
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		b.Logger().Error("failed to fetch CRL configuration for OCSP request", "error", err)
+		return ocspRawResponse(http.StatusInternalServerError, ocsp.InternalErrorErrorResponse), nil
+	}
+	if crlInfo != nil && crlInfo.OcspDisable {
+		return ocspRawResponse(http.StatusUnauthorized, ocsp.UnauthorizedErrorResponse), nil
+	}
+
+	ocspReq, err := ocsp.ParseRequest(der)
+	if err != nil {
+		return ocspRawResponse(http.StatusBadRequest, ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	caInfo, err := findOcspIssuer(ctx, req, ocspReq)
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		// No CA is configured, so no certificate can be vouched for
+		return ocspRawResponse(http.StatusUnauthorized, ocsp.UnauthorizedErrorResponse), nil
+	case errutil.InternalError:
+		b.Logger().Error("failed to fetch CA for OCSP request", "error", err)
+		return ocspRawResponse(http.StatusInternalServerError, ocsp.InternalErrorErrorResponse), nil
+	default:
+		if err == errOcspUnauthorized {
+			return ocspRawResponse(http.StatusUnauthorized, ocsp.UnauthorizedErrorResponse), nil
+		}
+		return ocspRawResponse(http.StatusBadRequest, ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	template, err := b.ocspStatus(ctx, req, ocspReq)
+	if err != nil {
+		b.Logger().Error("failed to look up certificate status for OCSP request", "error", err)
+		return ocspRawResponse(http.StatusInternalServerError, ocsp.InternalErrorErrorResponse), nil
+	}
+
+	now := time.Now()
+	template.ThisUpdate = now
+	ocspExpiry := defaultOcspExpiry
+	if crlInfo != nil && crlInfo.OcspExpiry != "" {
+		ocspExpiry, err = parseutil.ParseDurationSecond(crlInfo.OcspExpiry)
+		if err != nil {
+			b.Logger().Error("failed to parse stored OCSP expiry", "error", err)
+			return ocspRawResponse(http.StatusInternalServerError, ocsp.InternalErrorErrorResponse), nil
+		}
+	}
+	if ocspExpiry > 0 {
+		template.NextUpdate = now.Add(ocspExpiry)
+	}
+
+	respBytes, err := ocsp.CreateResponse(caInfo.Certificate, caInfo.Certificate, *template, caInfo.PrivateKey)
+	if err != nil {
+		b.Logger().Error("failed to sign OCSP response", "error", err)
+		return ocspRawResponse(http.StatusInternalServerError, ocsp.InternalErrorErrorResponse), nil
+	}
+
+	return ocspRawResponse(http.StatusOK, respBytes), nil
+}
+
+// Looks up the serial from the request in the revoked/ and certs/ storage and
+// returns a response template holding its status
+func (b *backend) ocspStatus(ctx context.Context, req *logical.Request, ocspReq *ocsp.Request) (*ocsp.Response, error) {
+	template := &ocsp.Response{
+		SerialNumber: ocspReq.SerialNumber,
+		IssuerHash:   ocspReq.HashAlgorithm,
+		Status:       ocsp.Unknown,
+	}
+
+	serial := certutil.GetHexFormatted(ocspReq.SerialNumber.Bytes(), ":")
+
+	b.revokeStorageLock.RLock()
+	defer b.revokeStorageLock.RUnlock()
+
+	revokedEntry, err := fetchCertBySerial(ctx, req, "revoked/", serial)
+	if err != nil {
+		return nil, err
+	}
+	if revokedEntry != nil {
+		var revInfo revocationInfo
+		if err := revokedEntry.DecodeJSON(&revInfo); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error decoding revocation entry for serial %s: {{err}}", serial), err)
+		}
+
+		template.Status = ocsp.Revoked
+		template.RevocationReason = ocsp.Unspecified
+		if !revInfo.RevocationTimeUTC.IsZero() {
+			template.RevokedAt = revInfo.RevocationTimeUTC
+		} else {
+			template.RevokedAt = time.Unix(revInfo.RevocationTime, 0).UTC()
+		}
+		return template, nil
+	}
+
+	certEntry, err := fetchCertBySerial(ctx, req, "certs/", serial)
+	if err != nil {
+		return nil, err
+	}
+	if certEntry != nil {
+		template.Status = ocsp.Good
+	}
+
+	return template, nil
+}
+
+// Finds the issuer of this mount which the request refers to, returning
+// errOcspUnauthorized if there is none. Issuers without a key cannot sign
+// responses and are skipped.
+func findOcspIssuer(ctx context.Context, req *logical.Request, ocspReq *ocsp.Request) (*certutil.CAInfoBundle, error) {
+	issuers, err := listIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+	if len(issuers) == 0 {
+		// Not yet migrated, so fall back to the legacy CA bundle
+		issuers = []string{defaultRef}
+	}
+
+	for _, issuerRef := range issuers {
+		if issuerRef != defaultRef {
+			issuer, err := fetchIssuerByID(ctx, req.Storage, issuerRef)
+			if err != nil {
+				return nil, err
+			}
+			if issuer.KeyID == "" {
+				continue
+			}
+		}
+
+		caInfo, err := fetchCAInfo(ctx, req, issuerRef)
+		if err != nil {
+			return nil, err
+		}
+		if caInfo.PrivateKeyType == certutil.Ed25519PrivateKey {
+			// The OCSP library can not sign with Ed25519 keys
+			continue
+		}
+
+		err = validateOcspIssuer(ocspReq, caInfo.Certificate)
+		switch {
+		case err == nil:
+			return caInfo, nil
+		case err != errOcspUnauthorized:
+			return nil, err
+		}
+	}
+
+	return nil, errOcspUnauthorized
+}
+
+// Verifies that the issuer name and key hashes of the request match the
+// given CA certificate
+func validateOcspIssuer(ocspReq *ocsp.Request, caCert *x509.Certificate) error {
+	hashAlg := ocspReq.HashAlgorithm
+	if hashAlg == 0 || !hashAlg.Available() {
+		return fmt.Errorf("unsupported OCSP request hash algorithm")
+	}
+
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(caCert.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(ocspHash(hashAlg, caCert.RawSubject), ocspReq.IssuerNameHash) ||
+		!bytes.Equal(ocspHash(hashAlg, publicKeyInfo.PublicKey.RightAlign()), ocspReq.IssuerKeyHash) {
+		return errOcspUnauthorized
+	}
+
+	return nil
+}
+
+func ocspHash(hashAlg crypto.Hash, data []byte) []byte {
+	h := hashAlg.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func ocspRawResponse(statusCode int, body []byte) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: ocspResponseContentType,
+			logical.HTTPRawBody:     body,
+			logical.HTTPStatusCode:  statusCode,
+		},
+	}
+}
+
+const pathOcspHelpSyn = `
+Query a certificate's revocation status through OCSP.
+`
+
+const pathOcspHelpDesc = `
+This endpoint implements an RFC 6960 OCSP responder for certificates issued by
+this mount's CA. Requests may be sent either as a DER encoded POST body with a
+content type of "application/ocsp-request", or as a GET request with the base64
+encoded request appended to the path. Responses are signed with the CA key and
+report a status of good, revoked or unknown based on the certificates stored in
+this mount.
+`
@@ -0,0 +1,210 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestPki_OcspResponder(t *testing.T) {
+	var resp *logical.Response
+	var err error
+	b, storage := createBackendWithStorage(t)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "root/generate/internal",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"common_name": "myvault.com",
+			"ttl":         "5h",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	caCert := parseCertPEM(t, resp.Data["certificate"].(string))
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/testrole",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"allowed_domains":  "myvault.com",
+			"allow_subdomains": true,
+			"ttl":              "2h",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	issue := func() (*x509.Certificate, string) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "issue/testrole",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"common_name": "cert.myvault.com",
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: err: %v resp: %#v", err, resp)
+		}
+		return parseCertPEM(t, resp.Data["certificate"].(string)), resp.Data["serial_number"].(string)
+	}
+
+	goodCert, _ := issue()
+	revokedCert, revokedSerial := issue()
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "revoke",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"serial_number": revokedSerial,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	// A certificate for which the issuer matches but we have no record
+	unknownCert := &x509.Certificate{
+		SerialNumber: new(big.Int).Add(goodCert.SerialNumber, revokedCert.SerialNumber),
+	}
+
+	queryGet := func(cert *x509.Certificate) *logical.Response {
+		ocspReq, err := ocsp.CreateRequest(cert, caCert, &ocsp.RequestOptions{Hash: crypto.SHA256})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "ocsp/" + base64.StdEncoding.EncodeToString(ocspReq),
+			Storage:   storage,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	checkStatus := func(resp *logical.Response, cert *x509.Certificate, expected int) {
+		t.Helper()
+		if resp.Data[logical.HTTPStatusCode] != http.StatusOK {
+			t.Fatalf("bad status code: %#v", resp.Data)
+		}
+		if resp.Data[logical.HTTPContentType] != "application/ocsp-response" {
+			t.Fatalf("bad content type: %#v", resp.Data)
+		}
+		ocspResp, err := ocsp.ParseResponseForCert(resp.Data[logical.HTTPRawBody].([]byte), cert, caCert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ocspResp.Status != expected {
+			t.Fatalf("expected status %d, got %d", expected, ocspResp.Status)
+		}
+		if ocspResp.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+			t.Fatalf("serial number mismatch")
+		}
+		if ocspResp.NextUpdate.IsZero() {
+			t.Fatalf("expected next update to be set")
+		}
+	}
+
+	checkStatus(queryGet(goodCert), goodCert, ocsp.Good)
+	checkStatus(queryGet(revokedCert), revokedCert, ocsp.Revoked)
+	checkStatus(queryGet(unknownCert), unknownCert, ocsp.Unknown)
+	checkStatus(queryGet(caCert), caCert, ocsp.Good)
+
+	// POST requests carry the DER encoded request in the body
+	ocspReq, err := ocsp.CreateRequest(revokedCert, caCert, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq, err := http.NewRequest("POST", "/v1/pki/ocsp", bytes.NewReader(ocspReq))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "ocsp",
+		Storage:     storage,
+		HTTPRequest: httpReq,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(resp, revokedCert, ocsp.Revoked)
+
+	// Garbage should yield a malformed request response
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "ocsp/" + base64.StdEncoding.EncodeToString([]byte("not an ocsp request")),
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.Data[logical.HTTPRawBody].([]byte), ocsp.MalformedRequestErrorResponse) {
+		t.Fatalf("expected malformed request response, got %#v", resp.Data)
+	}
+
+	// Requests for a different issuer should be refused
+	otherCA := *caCert
+	otherCA.RawSubject = []byte("some other subject")
+	otherReq, err := ocsp.CreateRequest(goodCert, &otherCA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "ocsp/" + base64.StdEncoding.EncodeToString(otherReq),
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.Data[logical.HTTPRawBody].([]byte), ocsp.UnauthorizedErrorResponse) {
+		t.Fatalf("expected unauthorized response, got %#v", resp.Data)
+	}
+
+	// Disabling the responder should refuse everything
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/crl",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"ocsp_disable": true,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	resp = queryGet(goodCert)
+	if !bytes.Equal(resp.Data[logical.HTTPRawBody].([]byte), ocsp.UnauthorizedErrorResponse) {
+		t.Fatalf("expected unauthorized response, got %#v", resp.Data)
+	}
+}
+
+func parseCertPEM(t *testing.T, certPEM string) *x509.Certificate {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		t.Fatalf("unable to decode certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
@@ -193,9 +193,9 @@ protection use. Defaults to false.`,
 			"key_type": &framework.FieldSchema{
 				Type:    framework.TypeString,
 				Default: "rsa",
-				Description: `The type of key to use; defaults to RSA. "rsa"
-and "ec" are the only valid values.`,
-				AllowedValues: []interface{}{"rsa", "ec"},
+				Description: `The type of key to use; defaults to RSA. "rsa",
+"ec" and "ed25519" are the only valid values.`,
+				AllowedValues: []interface{}{"rsa", "ec", "ed25519"},
 			},
 
 			"key_bits": &framework.FieldSchema{
@@ -203,7 +203,7 @@ and "ec" are the only valid values.`,
 				Default: 2048,
 				Description: `The number of bits to use. You will almost
 certainly want to change this if you adjust
-the key_type.`,
+the key_type. Ignored for ed25519 keys.`,
 			},
 
 			"key_usage": &framework.FieldSchema{
@@ -341,6 +341,14 @@ non-sensitive, or extremely short-lived. This option implies a value of "false"
 for "generate_lease".`,
 			},
 
+			"issuer_ref": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: defaultRef,
+				Description: `Reference to the issuer used to sign certificates
+issued against this role; either "default", an issuer ID or an issuer name.
+Defaults to "default", which tracks the mount's current default issuer.`,
+			},
+
 			"require_cn": &framework.FieldSchema{
 				Type:        framework.TypeBool,
 				Default:     true,
@@ -483,6 +491,12 @@ func (b *backend) getRole(ctx context.Context, s logical.Storage, n string) (*ro
 		modified = true
 	}
 
+	// Roles created before multiple issuers were supported use the default
+	if result.Issuer == "" {
+		result.Issuer = defaultRef
+		modified = true
+	}
+
 	if modified && (b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary)) {
 		jsonEntry, err := logical.StorageEntryJSON("role/"+n, &result)
 		if err != nil {
@@ -579,6 +593,7 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		PolicyIdentifiers:             data.Get("policy_identifiers").([]string),
 		BasicConstraintsValidForNonCA: data.Get("basic_constraints_valid_for_non_ca").(bool),
 		NotBeforeDuration:             time.Duration(data.Get("not_before_duration").(int)) * time.Second,
+		Issuer:                        data.Get("issuer_ref").(string),
 	}
 
 	allowedOtherSANs := data.Get("allowed_other_sans").([]string)
@@ -600,6 +615,17 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		*entry.GenerateLease = data.Get("generate_lease").(bool)
 	}
 
+	if entry.Issuer == "" {
+		entry.Issuer = defaultRef
+	}
+	if entry.Issuer != defaultRef {
+		// Referencing by name allows the issuer to be replaced later on,
+		// but it must exist at the time the role is written
+		if _, err := resolveIssuerReference(ctx, req.Storage, entry.Issuer); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("unable to resolve issuer_ref: %s", err)), nil
+		}
+	}
+
 	if entry.KeyType == "rsa" && entry.KeyBits < 2048 {
 		return logical.ErrorResponse("RSA keys < 2048 bits are unsafe and not supported"), nil
 	}
@@ -774,6 +800,7 @@ type roleEntry struct {
 	ExtKeyUsageOIDs               []string      `json:"ext_key_usage_oids" mapstructure:"ext_key_usage_oids"`
 	BasicConstraintsValidForNonCA bool          `json:"basic_constraints_valid_for_non_ca" mapstructure:"basic_constraints_valid_for_non_ca"`
 	NotBeforeDuration             time.Duration `json:"not_before_duration" mapstructure:"not_before_duration"`
+	Issuer                        string        `json:"issuer_ref" mapstructure:"issuer_ref"`
 
 	// Used internally for signing intermediates
 	AllowExpirationPastCA bool
@@ -819,6 +846,7 @@ func (r *roleEntry) ToResponseData() map[string]interface{} {
 		"policy_identifiers":                 r.PolicyIdentifiers,
 		"basic_constraints_valid_for_non_ca": r.BasicConstraintsValidForNonCA,
 		"not_before_duration":                int64(r.NotBeforeDuration.Seconds()),
+		"issuer_ref":                         r.Issuer,
 	}
 	if r.MaxPathLength != nil {
 		responseData["max_path_length"] = r.MaxPathLength
@@ -34,6 +34,27 @@ func pathGenerateRoot(b *backend) *framework.Path {
 	ret.Fields = addCACommonFields(map[string]*framework.FieldSchema{})
 	ret.Fields = addCAKeyGenerationFields(ret.Fields)
 	ret.Fields = addCAIssueFields(ret.Fields)
+	ret.Fields = addKeyAndIssuerNameFields(ret.Fields)
+
+	return ret
+}
+
+func pathRotateRoot(b *backend) *framework.Path {
+	ret := &framework.Path{
+		Pattern: "root/rotate/" + framework.GenericNameRegex("exported"),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCARotateRoot,
+		},
+
+		HelpSynopsis:    pathRotateRootHelpSyn,
+		HelpDescription: pathRotateRootHelpDesc,
+	}
+
+	ret.Fields = addCACommonFields(map[string]*framework.FieldSchema{})
+	ret.Fields = addCAKeyGenerationFields(ret.Fields)
+	ret.Fields = addCAIssueFields(ret.Fields)
+	ret.Fields = addKeyAndIssuerNameFields(ret.Fields)
 
 	return ret
 }
@@ -67,6 +88,7 @@ func pathSignIntermediate(b *backend) *framework.Path {
 
 	ret.Fields = addCACommonFields(map[string]*framework.FieldSchema{})
 	ret.Fields = addCAIssueFields(ret.Fields)
+	ret.Fields = addIssuerRefField(ret.Fields)
 
 	ret.Fields["csr"] = &framework.FieldSchema{
 		Type:        framework.TypeString,
@@ -110,26 +132,65 @@ func pathSignSelfIssued(b *backend) *framework.Path {
 		HelpDescription: pathSignSelfIssuedHelpDesc,
 	}
 
+	ret.Fields = addIssuerRefField(ret.Fields)
 	return ret
 }
 
 func (b *backend) pathCADeleteRoot(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	return nil, req.Storage.Delete(ctx, "config/ca_bundle")
+	issuers, err := listIssuers(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	for _, issuerID := range issuers {
+		if _, err := deleteIssuer(ctx, req.Storage, issuerID); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := listKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	for _, keyID := range keys {
+		if err := req.Storage.Delete(ctx, keyPrefix+keyID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range []string{storageIssuerConfig, legacyCertBundlePath, legacyBundleMigrationLogPath, "ca", "crl"} {
+		if err := req.Storage.Delete(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
 }
 
 func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	var err error
-
-	entry, err := req.Storage.Get(ctx, "config/ca_bundle")
+	configured, err := caConfigured(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
-	if entry != nil {
+	if configured {
 		resp := &logical.Response{}
-		resp.AddWarning(fmt.Sprintf("Refusing to generate a root certificate over an existing root certificate. If you really want to destroy the original root certificate, please issue a delete against %sroot.", req.MountPoint))
+		resp.AddWarning(fmt.Sprintf("Refusing to generate a root certificate over an existing root certificate. If you really want to destroy the original root certificate, please issue a delete against %sroot. To add another root certificate alongside the existing one, use %sroot/rotate.", req.MountPoint, req.MountPoint))
 		return resp, nil
 	}
 
+	return b.generateRoot(ctx, req, data)
+}
+
+// pathCARotateRoot generates an additional root certificate and key. Unlike
+// the generate endpoint this works when a CA is already configured, and
+// leaves the default issuer unchanged so that the new root can be
+// distributed before switching over to it.
+func (b *backend) pathCARotateRoot(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return b.generateRoot(ctx, req, data)
+}
+
+func (b *backend) generateRoot(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	var err error
+
 	exported, format, role, errorResp := b.getGenerationParams(data)
 	if errorResp != nil {
 		return errorResp, nil
@@ -204,15 +265,18 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		}
 	}
 
-	// Store it as the CA bundle
-	entry, err = logical.StorageEntryJSON("config/ca_bundle", cb)
+	// Store the key and certificate as a new issuer
+	issuer, key, err := writeCaBundle(ctx, req.Storage, cb, data.Get("issuer_name").(string), data.Get("key_name").(string))
 	if err != nil {
-		return nil, err
-	}
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		return nil, err
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
 	}
+	resp.Data["issuer_id"] = issuer.ID
+	resp.Data["key_id"] = key.ID
 
 	// Also store it as just the certificate identified by serial number, so it
 	// can be revoked
@@ -224,11 +288,9 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		return nil, errwrap.Wrapf("unable to store certificate locally: {{err}}", err)
 	}
 
-	// For ease of later use, also store just the certificate at a known
-	// location
-	entry.Key = "ca"
-	entry.Value = parsedBundle.CertificateBytes
-	err = req.Storage.Put(ctx, entry)
+	// For ease of later use, also store the default issuer's certificate at a
+	// known location
+	err = writeDefaultIssuerCert(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
@@ -280,7 +342,7 @@ func (b *backend) pathCASignIntermediate(ctx context.Context, req *logical.Reque
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchCAInfo(ctx, req, data.Get("issuer_ref").(string))
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
@@ -406,7 +468,7 @@ func (b *backend) pathCASignSelfIssued(ctx context.Context, req *logical.Request
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchCAInfo(ctx, req, data.Get("issuer_ref").(string))
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
@@ -457,8 +519,19 @@ const pathGenerateRootHelpDesc = `
 See the API documentation for more information.
 `
 
+const pathRotateRootHelpSyn = `
+Generate an additional CA certificate and private key used for signing.
+`
+
+const pathRotateRootHelpDesc = `
+This generates a new self-signed root certificate and key alongside any
+existing issuers of this mount. The default issuer is left unchanged unless the
+mount had none; update it through the "config/issuers" endpoint once the new
+root has been distributed.
+`
+
 const pathDeleteRootHelpSyn = `
-Deletes the root CA key to allow a new one to be generated.
+Deletes all CA certificates and keys of this mount to allow a new one to be generated.
 `
 
 const pathDeleteRootHelpDesc = `
@@ -3,55 +3,156 @@ package pki
 import (
 	"context"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/errwrap"
+	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+var errTidyCancelled = errors.New("tidy operation cancelled")
+
+type tidyStatusState int
+
+const (
+	tidyStatusInactive tidyStatusState = iota
+	tidyStatusStarted
+	tidyStatusFinished
+	tidyStatusError
+	tidyStatusCancelling
+	tidyStatusCancelled
+)
+
+func (s tidyStatusState) String() string {
+	switch s {
+	case tidyStatusStarted:
+		return "Running"
+	case tidyStatusFinished:
+		return "Finished"
+	case tidyStatusError:
+		return "Error"
+	case tidyStatusCancelling:
+		return "Cancelling"
+	case tidyStatusCancelled:
+		return "Cancelled"
+	default:
+		return "Inactive"
+	}
+}
+
+// tidyStatus describes the progress and outcome of a tidy operation
+type tidyStatus struct {
+	// Parameters used to start the operation
+	safetyBuffer     time.Duration
+	tidyCertStore    bool
+	tidyRevokedCerts bool
+
+	state        tidyStatusState
+	err          error
+	timeStarted  time.Time
+	timeFinished time.Time
+	message      string
+
+	certStoreScannedCount   uint
+	certStoreDeletedCount   uint
+	revokedCertScannedCount uint
+	revokedCertDeletedCount uint
+}
+
+// tidyConfig holds the parameters of a tidy operation. When stored as the
+// auto-tidy configuration, it also holds whether and how often the operation
+// is run automatically.
+type tidyConfig struct {
+	Enabled      bool          `json:"enabled"`
+	Interval     time.Duration `json:"interval_duration"`
+	CertStore    bool          `json:"tidy_cert_store"`
+	RevokedCerts bool          `json:"tidy_revoked_certs"`
+	SafetyBuffer time.Duration `json:"safety_buffer"`
+}
+
+var defaultTidyConfig = tidyConfig{
+	Enabled:      false,
+	Interval:     12 * time.Hour,
+	CertStore:    false,
+	RevokedCerts: false,
+	SafetyBuffer: 72 * time.Hour,
+}
+
 func pathTidy(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "tidy",
-		Fields: map[string]*framework.FieldSchema{
-			"tidy_cert_store": &framework.FieldSchema{
-				Type: framework.TypeBool,
-				Description: `Set to true to enable tidying up
-the certificate store`,
-			},
-
+		Fields: addTidyFields(map[string]*framework.FieldSchema{
 			"tidy_revocation_list": &framework.FieldSchema{
 				Type:        framework.TypeBool,
 				Description: `Deprecated; synonym for 'tidy_revoked_certs`,
 			},
+		}),
 
-			"tidy_revoked_certs": &framework.FieldSchema{
-				Type: framework.TypeBool,
-				Description: `Set to true to expire all revoked
-and expired certificates, removing them both from the CRL and from storage. The
-CRL will be rotated if this causes any values to be removed.`,
-			},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTidyWrite,
+		},
+
+		HelpSynopsis:    pathTidyHelpSyn,
+		HelpDescription: pathTidyHelpDesc,
+	}
+}
+
+func pathTidyStatus(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy-status$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathTidyStatusRead,
+		},
 
-			"safety_buffer": &framework.FieldSchema{
+		HelpSynopsis:    pathTidyStatusHelpSyn,
+		HelpDescription: pathTidyStatusHelpDesc,
+	}
+}
+
+func pathTidyCancel(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy-cancel$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTidyCancelWrite,
+		},
+
+		HelpSynopsis:    pathTidyCancelHelpSyn,
+		HelpDescription: pathTidyCancelHelpDesc,
+	}
+}
+
+func pathConfigAutoTidy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/auto-tidy",
+		Fields: addTidyFields(map[string]*framework.FieldSchema{
+			"enabled": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `Set to true to enable automatic tidy operations.`,
+			},
+			"interval_duration": &framework.FieldSchema{
 				Type: framework.TypeDurationSecond,
-				Description: `The amount of extra time that must have passed
-beyond certificate expiration before it is removed
-from the backend storage and/or revocation list.
-Defaults to 72 hours.`,
-				Default: 259200, //72h, but TypeDurationSecond currently requires defaults to be int
+				Description: `Interval at which to run an auto-tidy operation.
+This is the time between the start of one tidy operation and the start of
+the next. Defaults to 12 hours.`,
+				Default: int(defaultTidyConfig.Interval / time.Second),
 			},
-		},
+		}),
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
-			logical.UpdateOperation: b.pathTidyWrite,
+			logical.ReadOperation:   b.pathConfigAutoTidyRead,
+			logical.UpdateOperation: b.pathConfigAutoTidyWrite,
 		},
 
-		HelpSynopsis:    pathTidyHelpSyn,
-		HelpDescription: pathTidyHelpDesc,
+		HelpSynopsis:    pathConfigAutoTidySyn,
+		HelpDescription: pathConfigAutoTidyDesc,
 	}
 }
 
@@ -70,7 +171,12 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 		return logical.ErrorResponse("safety_buffer must be greater than zero"), nil
 	}
 
-	bufferDuration := time.Duration(safetyBuffer) * time.Second
+	config := &tidyConfig{
+		Enabled:      true,
+		CertStore:    tidyCertStore,
+		RevokedCerts: tidyRevokedCerts || tidyRevocationList,
+		SafetyBuffer: time.Duration(safetyBuffer) * time.Second,
+	}
 
 	if !atomic.CompareAndSwapUint32(b.tidyCASGuard, 0, 1) {
 		resp := &logical.Response{}
@@ -78,142 +184,486 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 		return resp, nil
 	}
 
+	b.startTidyOperation(req, config)
+
+	resp := &logical.Response{}
+	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs and reported by the tidy-status endpoint.")
+	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+}
+
+// startTidyOperation runs the tidy operation in the background. The caller
+// must have acquired the tidyCASGuard.
+func (b *backend) startTidyOperation(req *logical.Request, config *tidyConfig) {
 	// Tests using framework will screw up the storage so make a locally
 	// scoped req to hold a reference
 	req = &logical.Request{
 		Storage: req.Storage,
 	}
 
+	started := b.tidyStatusStart(config)
+
 	go func() {
 		defer atomic.StoreUint32(b.tidyCASGuard, 0)
 
 		// Don't cancel when the original client request goes away
-		ctx = context.Background()
+		ctx := context.Background()
 
 		logger := b.Logger().Named("tidy")
 
+		// Remember the start time, so that auto-tidy keeps to its interval
+		// across restarts and leader changes
+		if err := setTidyState(ctx, req.Storage, &tidyState{LastStarted: started}); err != nil {
+			logger.Warn("failed to store the tidy start time", "error", err)
+		}
+
 		doTidy := func() error {
-			if tidyCertStore {
-				serials, err := req.Storage.List(ctx, "certs/")
-				if err != nil {
-					return errwrap.Wrapf("error fetching list of certs: {{err}}", err)
+			if config.CertStore {
+				if err := b.doTidyCertStore(ctx, req, logger, config.SafetyBuffer); err != nil {
+					return err
 				}
+			}
 
-				for _, serial := range serials {
-					certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("error fetching certificate %q: {{err}}", serial), err)
-					}
-
-					if certEntry == nil {
-						logger.Warn("certificate entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting nil entry with serial %s: {{err}}", serial), err)
-						}
-						continue
-					}
-
-					if certEntry.Value == nil || len(certEntry.Value) == 0 {
-						logger.Warn("certificate entry has no value; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting entry with nil value with serial %s: {{err}}", serial), err)
-						}
-						continue
-					}
-
-					cert, err := x509.ParseCertificate(certEntry.Value)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("unable to parse stored certificate with serial %q: {{err}}", serial), err)
-					}
-
-					if time.Now().After(cert.NotAfter.Add(bufferDuration)) {
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from storage: {{err}}", serial), err)
-						}
-					}
+			if config.RevokedCerts {
+				if err := b.doTidyRevocationStore(ctx, req, logger, config.SafetyBuffer); err != nil {
+					return err
 				}
 			}
 
-			if tidyRevokedCerts || tidyRevocationList {
-				b.revokeStorageLock.Lock()
-				defer b.revokeStorageLock.Unlock()
+			return nil
+		}
 
-				tidiedRevoked := false
+		err := doTidy()
+		switch err {
+		case nil:
+		case errTidyCancelled:
+			logger.Info("tidy operation cancelled")
+		default:
+			logger.Error("error running tidy", "error", err)
+		}
+		b.tidyStatusStop(err)
+	}()
+}
 
-				revokedSerials, err := req.Storage.List(ctx, "revoked/")
-				if err != nil {
-					return errwrap.Wrapf("error fetching list of revoked certs: {{err}}", err)
-				}
+func (b *backend) doTidyCertStore(ctx context.Context, req *logical.Request, logger hclog.Logger, bufferDuration time.Duration) error {
+	serials, err := req.Storage.List(ctx, "certs/")
+	if err != nil {
+		return errwrap.Wrapf("error fetching list of certs: {{err}}", err)
+	}
 
-				var revInfo revocationInfo
-				for _, serial := range revokedSerials {
-					revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("unable to fetch revoked cert with serial %q: {{err}}", serial), err)
-					}
-
-					if revokedEntry == nil {
-						logger.Warn("revoked entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting nil revoked entry with serial %s: {{err}}", serial), err)
-						}
-						continue
-					}
-
-					if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
-						logger.Warn("revoked entry has nil value; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting revoked entry with nil value with serial %s: {{err}}", serial), err)
-						}
-						continue
-					}
-
-					err = revokedEntry.DecodeJSON(&revInfo)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("error decoding revocation entry for serial %q: {{err}}", serial), err)
-					}
-
-					revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("unable to parse stored revoked certificate with serial %q: {{err}}", serial), err)
-					}
-
-					// Remove the matched certificate entries from revoked/ and
-					// cert/ paths. We compare against both the NotAfter time
-					// within the cert itself and the time from the revocation
-					// entry, and perform tidy if either one tells us that the
-					// certificate has already been revoked.
-					now := time.Now()
-					if now.After(revokedCert.NotAfter.Add(bufferDuration)) || now.After(revInfo.RevocationTimeUTC.Add(bufferDuration)) {
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from revoked list: {{err}}", serial), err)
-						}
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from store when tidying revoked: {{err}}", serial), err)
-						}
-						tidiedRevoked = true
-					}
-				}
+	for i, serial := range serials {
+		if atomic.LoadUint32(b.tidyCancelCAS) == 1 {
+			return errTidyCancelled
+		}
+		b.tidyStatusMessage(fmt.Sprintf("Tidying certificate store: checking entry %d of %d", i+1, len(serials)))
+		b.tidyStatusIncCertStoreCount(false)
 
-				if tidiedRevoked {
-					if err := buildCRL(ctx, b, req, false); err != nil {
-						return err
-					}
-				}
+		certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error fetching certificate %q: {{err}}", serial), err)
+		}
+
+		if certEntry == nil {
+			logger.Warn("certificate entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
+			if err := deleteCertEntry(ctx, req.Storage, serial); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error deleting nil entry with serial %s: {{err}}", serial), err)
 			}
+			b.tidyStatusIncCertStoreCount(true)
+			continue
+		}
 
-			return nil
+		if certEntry.Value == nil || len(certEntry.Value) == 0 {
+			logger.Warn("certificate entry has no value; tidying up since it is no longer useful for any server operations", "serial", serial)
+			if err := deleteCertEntry(ctx, req.Storage, serial); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error deleting entry with nil value with serial %s: {{err}}", serial), err)
+			}
+			b.tidyStatusIncCertStoreCount(true)
+			continue
 		}
 
-		if err := doTidy(); err != nil {
-			logger.Error("error running tidy", "error", err)
-			return
+		cert, err := x509.ParseCertificate(certEntry.Value)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("unable to parse stored certificate with serial %q: {{err}}", serial), err)
 		}
-	}()
 
-	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
-	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+		if time.Now().After(cert.NotAfter.Add(bufferDuration)) {
+			if err := deleteCertEntry(ctx, req.Storage, serial); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from storage: {{err}}", serial), err)
+			}
+			b.tidyStatusIncCertStoreCount(true)
+		}
+	}
+
+	return nil
+}
+
+func (b *backend) doTidyRevocationStore(ctx context.Context, req *logical.Request, logger hclog.Logger, bufferDuration time.Duration) error {
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	tidiedRevoked := false
+
+	revokedSerials, err := req.Storage.List(ctx, "revoked/")
+	if err != nil {
+		return errwrap.Wrapf("error fetching list of revoked certs: {{err}}", err)
+	}
+
+	var tidyErr error
+	var revInfo revocationInfo
+	for i, serial := range revokedSerials {
+		if atomic.LoadUint32(b.tidyCancelCAS) == 1 {
+			// Still rebuild the CRL below for whatever was removed so far
+			tidyErr = errTidyCancelled
+			break
+		}
+		b.tidyStatusMessage(fmt.Sprintf("Tidying revoked certificates: checking certificate %d of %d", i+1, len(revokedSerials)))
+		b.tidyStatusIncRevokedCertCount(false)
+
+		revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("unable to fetch revoked cert with serial %q: {{err}}", serial), err)
+		}
+
+		if revokedEntry == nil {
+			logger.Warn("revoked entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
+			if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error deleting nil revoked entry with serial %s: {{err}}", serial), err)
+			}
+			b.tidyStatusIncRevokedCertCount(true)
+			continue
+		}
+
+		if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
+			logger.Warn("revoked entry has nil value; tidying up since it is no longer useful for any server operations", "serial", serial)
+			if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error deleting revoked entry with nil value with serial %s: {{err}}", serial), err)
+			}
+			b.tidyStatusIncRevokedCertCount(true)
+			continue
+		}
+
+		err = revokedEntry.DecodeJSON(&revInfo)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error decoding revocation entry for serial %q: {{err}}", serial), err)
+		}
+
+		revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("unable to parse stored revoked certificate with serial %q: {{err}}", serial), err)
+		}
+
+		// Remove the matched certificate entries from revoked/ and
+		// cert/ paths. We compare against both the NotAfter time
+		// within the cert itself and the time from the revocation
+		// entry, and perform tidy if either one tells us that the
+		// certificate has already been revoked.
+		now := time.Now()
+		if now.After(revokedCert.NotAfter.Add(bufferDuration)) || now.After(revInfo.RevocationTimeUTC.Add(bufferDuration)) {
+			if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from revoked list: {{err}}", serial), err)
+			}
+			if err := deleteCertEntry(ctx, req.Storage, serial); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from store when tidying revoked: {{err}}", serial), err)
+			}
+			tidiedRevoked = true
+			b.tidyStatusIncRevokedCertCount(true)
+		}
+	}
+
+	if tidiedRevoked {
+		if err := buildCRL(ctx, b, req, false); err != nil {
+			return err
+		}
+	}
+
+	return tidyErr
+}
+
+// periodicTidy starts a tidy operation with the auto-tidy configuration once
+// its interval has passed since the last tidy operation started
+func (b *backend) periodicTidy(ctx context.Context, req *logical.Request) error {
+	config, err := getAutoTidyConfig(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	if !config.Enabled {
+		return nil
+	}
+
+	b.tidyStatusLock.RLock()
+	nextTidy := b.lastTidy.Add(config.Interval)
+	b.tidyStatusLock.RUnlock()
+	if time.Now().Before(nextTidy) {
+		return nil
+	}
+
+	if !atomic.CompareAndSwapUint32(b.tidyCASGuard, 0, 1) {
+		// A tidy operation is already running
+		return nil
+	}
+
+	b.startTidyOperation(req, config)
+	return nil
+}
+
+func (b *backend) pathTidyStatusRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	// If we are a performance standby forward the request to the active node
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	b.tidyStatusLock.RLock()
+	defer b.tidyStatusLock.RUnlock()
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"safety_buffer":              nil,
+			"tidy_cert_store":            nil,
+			"tidy_revoked_certs":         nil,
+			"state":                      b.tidyStatus.state.String(),
+			"error":                      nil,
+			"time_started":               nil,
+			"time_finished":              nil,
+			"message":                    nil,
+			"cert_store_scanned_count":   nil,
+			"cert_store_deleted_count":   nil,
+			"revoked_cert_scanned_count": nil,
+			"revoked_cert_deleted_count": nil,
+		},
+	}
+
+	if b.tidyStatus.state == tidyStatusInactive {
+		return resp, nil
+	}
+
+	resp.Data["safety_buffer"] = int64(b.tidyStatus.safetyBuffer.Seconds())
+	resp.Data["tidy_cert_store"] = b.tidyStatus.tidyCertStore
+	resp.Data["tidy_revoked_certs"] = b.tidyStatus.tidyRevokedCerts
+	resp.Data["time_started"] = b.tidyStatus.timeStarted
+	resp.Data["message"] = b.tidyStatus.message
+	resp.Data["cert_store_scanned_count"] = b.tidyStatus.certStoreScannedCount
+	resp.Data["cert_store_deleted_count"] = b.tidyStatus.certStoreDeletedCount
+	resp.Data["revoked_cert_scanned_count"] = b.tidyStatus.revokedCertScannedCount
+	resp.Data["revoked_cert_deleted_count"] = b.tidyStatus.revokedCertDeletedCount
+
+	switch b.tidyStatus.state {
+	case tidyStatusFinished, tidyStatusCancelled:
+		resp.Data["time_finished"] = b.tidyStatus.timeFinished
+	case tidyStatusError:
+		resp.Data["time_finished"] = b.tidyStatus.timeFinished
+		resp.Data["error"] = b.tidyStatus.err.Error()
+		// Don't clear the message so that it serves as a hint about when
+		// the error occurred.
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathTidyCancelWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	// If we are a performance standby forward the request to the active node
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	if atomic.LoadUint32(b.tidyCASGuard) == 0 {
+		return logical.ErrorResponse("Tidy operation cannot be cancelled as none is currently running."), nil
+	}
+
+	// Grab the status lock before writing the cancel atomic. This lets us
+	// update the status correctly as well, avoiding writing it if we're
+	// already done.
+	b.tidyStatusLock.Lock()
+	if b.tidyStatus.state == tidyStatusStarted {
+		atomic.StoreUint32(b.tidyCancelCAS, 1)
+		b.tidyStatus.state = tidyStatusCancelling
+	}
+	b.tidyStatusLock.Unlock()
+
+	return b.pathTidyStatusRead(ctx, req, d)
+}
+
+func (b *backend) pathConfigAutoTidyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := getAutoTidyConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":            config.Enabled,
+			"interval_duration":  int(config.Interval / time.Second),
+			"tidy_cert_store":    config.CertStore,
+			"tidy_revoked_certs": config.RevokedCerts,
+			"safety_buffer":      int(config.SafetyBuffer / time.Second),
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigAutoTidyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := getAutoTidyConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+	if intervalRaw, ok := d.GetOk("interval_duration"); ok {
+		config.Interval = time.Duration(intervalRaw.(int)) * time.Second
+		if config.Interval < 1*time.Second {
+			return logical.ErrorResponse(fmt.Sprintf("given interval_duration must be greater than zero seconds; got: %v", intervalRaw)), nil
+		}
+	}
+	if certStoreRaw, ok := d.GetOk("tidy_cert_store"); ok {
+		config.CertStore = certStoreRaw.(bool)
+	}
+	if revokedCertsRaw, ok := d.GetOk("tidy_revoked_certs"); ok {
+		config.RevokedCerts = revokedCertsRaw.(bool)
+	}
+	if safetyBufferRaw, ok := d.GetOk("safety_buffer"); ok {
+		config.SafetyBuffer = time.Duration(safetyBufferRaw.(int)) * time.Second
+		if config.SafetyBuffer < 1*time.Second {
+			return logical.ErrorResponse(fmt.Sprintf("given safety_buffer must be greater than zero seconds; got: %v", safetyBufferRaw)), nil
+		}
+	}
+
+	if config.Enabled && !(config.CertStore || config.RevokedCerts) {
+		return logical.ErrorResponse("Auto-tidy enabled but no tidy operations were requested. Enable at least one tidy operation to be run (tidy_cert_store or tidy_revoked_certs)."), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(autoTidyConfigPath, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return b.pathConfigAutoTidyRead(ctx, req, d)
+}
+
+const (
+	autoTidyConfigPath = "config/auto-tidy"
+	tidyStatePath      = "tidy-state"
+)
+
+// tidyState is kept in local storage, as every cluster tidies its own
+// certificates
+type tidyState struct {
+	LastStarted time.Time `json:"last_started"`
+}
+
+func getTidyState(ctx context.Context, s logical.Storage) (*tidyState, error) {
+	state := &tidyState{}
+
+	entry, err := s.Get(ctx, tidyStatePath)
+	if err != nil {
+		return nil, errwrap.Wrapf("error fetching tidy state: {{err}}", err)
+	}
+	if entry == nil {
+		return state, nil
+	}
+	if err := entry.DecodeJSON(state); err != nil {
+		return nil, errwrap.Wrapf("error decoding tidy state: {{err}}", err)
+	}
+
+	return state, nil
+}
+
+func setTidyState(ctx context.Context, s logical.Storage, state *tidyState) error {
+	entry, err := logical.StorageEntryJSON(tidyStatePath, state)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func getAutoTidyConfig(ctx context.Context, s logical.Storage) (*tidyConfig, error) {
+	entry, err := s.Get(ctx, autoTidyConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := defaultTidyConfig
+	if entry == nil {
+		return &config, nil
+	}
+
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// tidyStatusStart resets the status for a new tidy operation, returning its
+// start time
+func (b *backend) tidyStatusStart(config *tidyConfig) time.Time {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus = &tidyStatus{
+		safetyBuffer:     config.SafetyBuffer,
+		tidyCertStore:    config.CertStore,
+		tidyRevokedCerts: config.RevokedCerts,
+
+		state:       tidyStatusStarted,
+		timeStarted: time.Now(),
+	}
+	b.lastTidy = b.tidyStatus.timeStarted
+
+	// Clear any cancellation left over from an operation which finished
+	// before noticing it
+	atomic.StoreUint32(b.tidyCancelCAS, 0)
+
+	return b.lastTidy
+}
+
+func (b *backend) tidyStatusStop(err error) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.timeFinished = time.Now()
+	b.tidyStatus.err = err
+	switch err {
+	case nil:
+		b.tidyStatus.state = tidyStatusFinished
+		b.tidyStatus.message = ""
+	case errTidyCancelled:
+		b.tidyStatus.state = tidyStatusCancelled
+	default:
+		b.tidyStatus.state = tidyStatusError
+	}
+
+	atomic.StoreUint32(b.tidyCancelCAS, 0)
+}
+
+func (b *backend) tidyStatusMessage(msg string) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.message = msg
+}
+
+func (b *backend) tidyStatusIncCertStoreCount(deleted bool) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	if deleted {
+		b.tidyStatus.certStoreDeletedCount++
+	} else {
+		b.tidyStatus.certStoreScannedCount++
+	}
+}
+
+func (b *backend) tidyStatusIncRevokedCertCount(deleted bool) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	if deleted {
+		b.tidyStatus.revokedCertDeletedCount++
+	} else {
+		b.tidyStatus.revokedCertScannedCount++
+	}
 }
 
 const pathTidyHelpSyn = `
@@ -241,4 +691,48 @@ certificate/revocation information of each certificate being held in
 certificate storage or in revocation information will then be checked. If the
 current time, minus the value of 'safety_buffer', is greater than the
 expiration, it will be removed.
+
+The operation runs in the background; its progress can be followed through the
+tidy-status endpoint and it can be stopped through the tidy-cancel endpoint.
+`
+
+const pathTidyStatusHelpSyn = `
+Returns the status of the tidy operation.
+`
+
+const pathTidyStatusHelpDesc = `
+This is a read only endpoint that returns information about the current tidy
+operation, or the most recent one if none is running: when it started and
+finished, which parameters it was started with, how many certificates it
+scanned and deleted, and any error it encountered.
+
+The information is kept in memory of the active node, and is lost when the
+mount is reloaded or the active node changes.
+`
+
+const pathTidyCancelHelpSyn = `
+Cancels the currently running tidy operation.
+`
+
+const pathTidyCancelHelpDesc = `
+This endpoint allows cancelling the currently running tidy operation.
+
+Cancellation is checked before each certificate is processed, so the operation
+stops after finishing with the current certificate. If revoked certificates
+were already removed, the CRL is still rebuilt before the operation stops. The
+state reported by tidy-status changes to "Cancelling" and then to "Cancelled".
+`
+
+const pathConfigAutoTidySyn = `
+Modifies the current configuration for automatic tidy execution.
+`
+
+const pathConfigAutoTidyDesc = `
+This endpoint accepts parameters to a tidy operation (see the tidy endpoint)
+that will be used for automatic tidy execution. This has two extra
+parameters, enabled (to enable or disable auto-tidy) and interval_duration
+(which controls the frequency of auto-tidy execution).
+
+Once enabled, a tidy operation will be kicked off automatically, as if it were
+executed with the posted configuration.
 `
@@ -0,0 +1,238 @@
+package pki
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// waitForTidy polls tidy-status until the running tidy operation is done,
+// returning the final status
+func waitForTidy(t *testing.T, b *backend, storage logical.Storage) map[string]interface{} {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "tidy-status",
+			Storage:   storage,
+		})
+		if err != nil || resp == nil {
+			t.Fatalf("bad: err: %v resp: %#v", err, resp)
+		}
+		switch resp.Data["state"] {
+		case "Running", "Cancelling":
+			time.Sleep(100 * time.Millisecond)
+		default:
+			return resp.Data
+		}
+	}
+
+	t.Fatalf("tidy operation did not finish in time")
+	return nil
+}
+
+func TestPki_TidyStatusAndCancel(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	request(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "48h",
+	})
+	request(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+	})
+	issue := func(ttl string) string {
+		t.Helper()
+		resp := request(logical.UpdateOperation, "issue/test", map[string]interface{}{
+			"common_name": "cert.myvault.com",
+			"ttl":         ttl,
+		})
+		return resp.Data["serial_number"].(string)
+	}
+	longLived := issue("1h")
+	shortLived := issue("4s")
+	issue("4s")
+
+	resp := request(logical.ReadOperation, "tidy-status", nil)
+	if resp.Data["state"] != "Inactive" {
+		t.Fatalf("expected inactive tidy status, got %#v", resp.Data)
+	}
+
+	// Nothing to cancel yet
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy-cancel",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error cancelling without a running tidy, got err: %v resp: %#v", err, resp)
+	}
+
+	request(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": longLived,
+	})
+	request(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": shortLived,
+	})
+
+	// Hold the revocation lock so that the tidy operation can't make progress
+	// before it is cancelled
+	b.revokeStorageLock.Lock()
+	resp = request(logical.UpdateOperation, "tidy", map[string]interface{}{
+		"tidy_revoked_certs": true,
+		"safety_buffer":      "1s",
+	})
+	if resp.Data[logical.HTTPStatusCode] != 202 {
+		t.Fatalf("expected tidy to be accepted, got %#v", resp)
+	}
+	resp = request(logical.UpdateOperation, "tidy-cancel", nil)
+	b.revokeStorageLock.Unlock()
+	if resp.Data["state"] != "Cancelling" {
+		t.Fatalf("expected cancelling tidy status, got %#v", resp.Data)
+	}
+	status := waitForTidy(t, b, storage)
+	if status["state"] != "Cancelled" || status["revoked_cert_deleted_count"] != uint(0) {
+		t.Fatalf("expected cancelled tidy status, got %#v", status)
+	}
+
+	time.Sleep(5 * time.Second)
+	request(logical.UpdateOperation, "tidy", map[string]interface{}{
+		"tidy_cert_store":    true,
+		"tidy_revoked_certs": true,
+		"safety_buffer":      "1s",
+	})
+	status = waitForTidy(t, b, storage)
+	if status["state"] != "Finished" || status["error"] != nil {
+		t.Fatalf("expected finished tidy status, got %#v", status)
+	}
+	if status["cert_store_deleted_count"] != uint(2) || status["cert_store_scanned_count"].(uint) < 3 {
+		t.Fatalf("bad cert store counts: %#v", status)
+	}
+	// Revocation entries are also tidied once the safety buffer has passed
+	// since the revocation
+	if status["revoked_cert_deleted_count"] != uint(2) || status["revoked_cert_scanned_count"] != uint(2) {
+		t.Fatalf("bad revoked cert counts: %#v", status)
+	}
+	if status["time_finished"].(time.Time).Before(status["time_started"].(time.Time)) {
+		t.Fatalf("bad tidy times: %#v", status)
+	}
+
+	resp = request(logical.ReadOperation, "cert/"+shortLived, nil)
+	if resp != nil {
+		t.Fatalf("expected expired certificate to be tidied, got %#v", resp)
+	}
+	resp = request(logical.ReadOperation, "cert/"+longLived, nil)
+	if resp != nil {
+		t.Fatalf("expected revoked certificate to be tidied, got %#v", resp)
+	}
+}
+
+func TestPki_AutoTidy(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/auto-tidy",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"enabled": true,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error enabling auto-tidy without operations, got err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/auto-tidy",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"enabled":           true,
+			"tidy_cert_store":   true,
+			"interval_duration": "1h",
+			"safety_buffer":     "1s",
+		},
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["interval_duration"] != 3600 || resp.Data["safety_buffer"] != 1 || resp.Data["tidy_revoked_certs"] != false {
+		t.Fatalf("bad auto-tidy config: %#v", resp.Data)
+	}
+
+	// The interval hasn't passed since the mount was set up
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if status := waitForTidy(t, b, storage); status["state"] != "Inactive" {
+		t.Fatalf("expected no tidy operation yet, got %#v", status)
+	}
+
+	b.tidyStatusLock.Lock()
+	b.lastTidy = time.Now().Add(-2 * time.Hour)
+	b.tidyStatusLock.Unlock()
+
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	status := waitForTidy(t, b, storage)
+	if status["state"] != "Finished" || status["tidy_cert_store"] != true || status["safety_buffer"] != int64(1) {
+		t.Fatalf("expected finished auto-tidy, got %#v", status)
+	}
+
+	// The next run waits for the interval again
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if again := waitForTidy(t, b, storage); again["time_started"] != status["time_started"] {
+		t.Fatalf("expected no further tidy operation, got %#v", again)
+	}
+
+	// The interval counts from the stored start time after a restart
+	restart := func() *backend {
+		t.Helper()
+		config := logical.TestBackendConfig()
+		config.StorageView = storage
+		restarted := Backend(config)
+		if err := restarted.Setup(context.Background(), config); err != nil {
+			t.Fatal(err)
+		}
+		if err := restarted.Initialize(context.Background(), &logical.InitializationRequest{Storage: storage}); err != nil {
+			t.Fatal(err)
+		}
+		return restarted
+	}
+	restarted := restart()
+	if !restarted.lastTidy.Equal(status["time_started"].(time.Time)) {
+		t.Fatalf("expected last tidy to be restored, got %v", restarted.lastTidy)
+	}
+
+	if err := setTidyState(context.Background(), storage, &tidyState{LastStarted: time.Now().Add(-2 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	restarted = restart()
+	if err := restarted.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if status := waitForTidy(t, restarted, storage); status["state"] != "Finished" {
+		t.Fatalf("expected overdue auto-tidy to run after a restart, got %#v", status)
+	}
+}
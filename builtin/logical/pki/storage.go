@@ -0,0 +1,714 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// Prior to supporting multiple issuers, the single CA certificate and
+	// key lived together in this entry. It is migrated into the issuer and
+	// key storage on initialization.
+	legacyCertBundlePath = "config/ca_bundle"
+
+	// The legacy bundle is kept after its migration, so that older versions
+	// keep working; this entry records the migrated bundle instead.
+	legacyBundleMigrationLogPath = "config/legacy-bundle-migration"
+
+	keyPrefix           = "config/key/"
+	issuerPrefix        = "config/issuer/"
+	storageIssuerConfig = "config/issuers"
+	issuerCRLPrefix     = "crls/"
+	certMetadataPrefix  = "cert-metadata/"
+
+	// defaultRef may be used anywhere an issuer reference is accepted to
+	// refer to the mount's default issuer.
+	defaultRef = "default"
+)
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// keyEntry holds a private key managed by this mount. A key may back any
+// number of issuers, e.g. a root and its cross-signed counterpart.
+type keyEntry struct {
+	ID             string                  `json:"id"`
+	Name           string                  `json:"name"`
+	PrivateKeyType certutil.PrivateKeyType `json:"private_key_type"`
+	PrivateKey     string                  `json:"private_key"`
+}
+
+// issuerEntry holds a CA certificate this mount can issue from, provided
+// the matching key is present.
+type issuerEntry struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	KeyID        string   `json:"key_id"`
+	Certificate  string   `json:"certificate"`
+	CAChain      []string `json:"ca_chain"`
+	SerialNumber string   `json:"serial_number"`
+}
+
+// legacyBundleMigrationLog records the hash of the legacy bundle last
+// migrated, so that a bundle written afterwards is migrated again.
+type legacyBundleMigrationLog struct {
+	Hash     string    `json:"hash"`
+	Migrated time.Time `json:"migrated"`
+}
+
+type issuerConfigEntry struct {
+	DefaultIssuerID string `json:"default"`
+}
+
+func (k *keyEntry) getSigner() (crypto.Signer, error) {
+	parsed, err := (&certutil.CertBundle{PrivateKey: k.PrivateKey}).ToParsedCertBundle()
+	if err != nil {
+		return nil, err
+	}
+	if parsed.PrivateKey == nil {
+		return nil, fmt.Errorf("unable to parse stored private key %s", k.ID)
+	}
+	return parsed.PrivateKey, nil
+}
+
+func (i *issuerEntry) getCertificate() (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(i.Certificate))
+	if block == nil {
+		return nil, fmt.Errorf("unable to decode stored certificate for issuer %s", i.ID)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func listKeys(ctx context.Context, s logical.Storage) ([]string, error) {
+	return s.List(ctx, keyPrefix)
+}
+
+func fetchKeyByID(ctx context.Context, s logical.Storage, keyID string) (*keyEntry, error) {
+	entry, err := s.Get(ctx, keyPrefix+keyID)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch key %s: %v", keyID, err)}
+	}
+	if entry == nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("key %s does not exist", keyID)}
+	}
+
+	var key keyEntry
+	if err := entry.DecodeJSON(&key); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode key %s: %v", keyID, err)}
+	}
+
+	return &key, nil
+}
+
+func writeKey(ctx context.Context, s logical.Storage, key *keyEntry) error {
+	entry, err := logical.StorageEntryJSON(keyPrefix+key.ID, key)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// deleteKey removes the key. Issuers referencing it are kept, but can no
+// longer be used for signing.
+func deleteKey(ctx context.Context, s logical.Storage, keyID string) error {
+	issuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return err
+	}
+	for _, issuerID := range issuers {
+		issuer, err := fetchIssuerByID(ctx, s, issuerID)
+		if err != nil {
+			return err
+		}
+		if issuer.KeyID == keyID {
+			issuer.KeyID = ""
+			if err := writeIssuer(ctx, s, issuer); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.Delete(ctx, keyPrefix+keyID)
+}
+
+// importKey stores the given PEM-encoded private key, returning the
+// existing entry instead if the key is already known to this mount. Any
+// issuers matching the key are updated to reference it.
+func importKey(ctx context.Context, s logical.Storage, keyPEM string, keyName string) (*keyEntry, bool, error) {
+	parsed, err := (&certutil.CertBundle{PrivateKey: keyPEM}).ToParsedCertBundle()
+	if err != nil {
+		return nil, false, err
+	}
+	if parsed.PrivateKey == nil || parsed.PrivateKeyType == certutil.UnknownPrivateKey {
+		return nil, false, errutil.UserError{Err: "unable to parse private key"}
+	}
+
+	knownKeys, err := listKeys(ctx, s)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, keyID := range knownKeys {
+		existing, err := fetchKeyByID(ctx, s, keyID)
+		if err != nil {
+			return nil, false, err
+		}
+		signer, err := existing.getSigner()
+		if err != nil {
+			return nil, false, err
+		}
+		equal, err := publicKeysEqual(signer.Public(), parsed.PrivateKey.Public())
+		if err != nil {
+			return nil, false, err
+		}
+		if equal {
+			return existing, true, nil
+		}
+	}
+
+	if keyName != "" {
+		if err := checkNameAvailable(ctx, s, keyPrefix, keyName); err != nil {
+			return nil, false, err
+		}
+	}
+
+	keyID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := &keyEntry{
+		ID:             keyID,
+		Name:           keyName,
+		PrivateKeyType: parsed.PrivateKeyType,
+		PrivateKey:     strings.TrimSpace(keyPEM),
+	}
+	if err := writeKey(ctx, s, key); err != nil {
+		return nil, false, err
+	}
+
+	// Link any issuers which were imported before their key
+	issuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, issuerID := range issuers {
+		issuer, err := fetchIssuerByID(ctx, s, issuerID)
+		if err != nil {
+			return nil, false, err
+		}
+		if issuer.KeyID != "" {
+			continue
+		}
+		cert, err := issuer.getCertificate()
+		if err != nil {
+			return nil, false, err
+		}
+		equal, err := publicKeysEqual(cert.PublicKey, parsed.PrivateKey.Public())
+		if err != nil {
+			return nil, false, err
+		}
+		if equal {
+			issuer.KeyID = key.ID
+			if err := writeIssuer(ctx, s, issuer); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	return key, false, nil
+}
+
+func listIssuers(ctx context.Context, s logical.Storage) ([]string, error) {
+	return s.List(ctx, issuerPrefix)
+}
+
+func fetchIssuerByID(ctx context.Context, s logical.Storage, issuerID string) (*issuerEntry, error) {
+	entry, err := s.Get(ctx, issuerPrefix+issuerID)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch issuer %s: %v", issuerID, err)}
+	}
+	if entry == nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("issuer %s does not exist", issuerID)}
+	}
+
+	var issuer issuerEntry
+	if err := entry.DecodeJSON(&issuer); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode issuer %s: %v", issuerID, err)}
+	}
+
+	return &issuer, nil
+}
+
+func writeIssuer(ctx context.Context, s logical.Storage, issuer *issuerEntry) error {
+	entry, err := logical.StorageEntryJSON(issuerPrefix+issuer.ID, issuer)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// deleteIssuer removes the issuer and its CRLs, clearing the default issuer
+// if it referenced this one. The returned bool indicates whether the mount
+// no longer has a default issuer as a result.
+func deleteIssuer(ctx context.Context, s logical.Storage, issuerID string) (bool, error) {
+	config, err := getIssuersConfig(ctx, s)
+	if err != nil {
+		return false, err
+	}
+
+	wasDefault := config.DefaultIssuerID == issuerID
+	if wasDefault {
+		config.DefaultIssuerID = ""
+		if err := setIssuersConfig(ctx, s, config); err != nil {
+			return false, err
+		}
+		if err := s.Delete(ctx, "crl"); err != nil {
+			return false, err
+		}
+		if err := s.Delete(ctx, deltaCRLPath); err != nil {
+			return false, err
+		}
+	}
+
+	if err := s.Delete(ctx, issuerCRLPrefix+issuerID); err != nil {
+		return false, err
+	}
+	if err := s.Delete(ctx, issuerDeltaCRLPrefix+issuerID); err != nil {
+		return false, err
+	}
+
+	return wasDefault, s.Delete(ctx, issuerPrefix+issuerID)
+}
+
+// importIssuer stores the given PEM-encoded CA certificate, returning the
+// existing entry instead if the certificate is already known to this mount.
+// The issuer is linked to its key if present, and becomes the default
+// issuer if the mount does not have one yet.
+func importIssuer(ctx context.Context, s logical.Storage, certPEM string, caChain []string, issuerName string) (*issuerEntry, bool, error) {
+	certPEM = strings.TrimSpace(certPEM)
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, false, errutil.UserError{Err: "unable to decode certificate PEM"}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, false, errutil.UserError{Err: fmt.Sprintf("unable to parse certificate: %v", err)}
+	}
+	if !cert.IsCA {
+		return nil, false, errutil.UserError{Err: "the given certificate is not marked for CA use and cannot be used with this backend"}
+	}
+
+	knownIssuers, err := listIssuers(ctx, s)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, issuerID := range knownIssuers {
+		existing, err := fetchIssuerByID(ctx, s, issuerID)
+		if err != nil {
+			return nil, false, err
+		}
+		existingCert, err := existing.getCertificate()
+		if err != nil {
+			return nil, false, err
+		}
+		if bytes.Equal(existingCert.Raw, cert.Raw) {
+			return existing, true, nil
+		}
+	}
+
+	if issuerName != "" {
+		if err := checkNameAvailable(ctx, s, issuerPrefix, issuerName); err != nil {
+			return nil, false, err
+		}
+	}
+
+	issuerID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	issuer := &issuerEntry{
+		ID:           issuerID,
+		Name:         issuerName,
+		Certificate:  certPEM,
+		CAChain:      caChain,
+		SerialNumber: certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":"),
+	}
+
+	key, err := findKeyForCertificate(ctx, s, cert)
+	if err != nil {
+		return nil, false, err
+	}
+	if key != nil {
+		issuer.KeyID = key.ID
+	}
+
+	if err := writeIssuer(ctx, s, issuer); err != nil {
+		return nil, false, err
+	}
+
+	config, err := getIssuersConfig(ctx, s)
+	if err != nil {
+		return nil, false, err
+	}
+	if config.DefaultIssuerID == "" {
+		config.DefaultIssuerID = issuer.ID
+		if err := setIssuersConfig(ctx, s, config); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return issuer, false, nil
+}
+
+// writeCaBundle stores the key and certificate of the given bundle, reusing
+// any matching existing entries.
+func writeCaBundle(ctx context.Context, s logical.Storage, cb *certutil.CertBundle, issuerName, keyName string) (*issuerEntry, *keyEntry, error) {
+	key, _, err := importKey(ctx, s, cb.PrivateKey, keyName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	issuer, _, err := importIssuer(ctx, s, cb.Certificate, cb.CAChain, issuerName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return issuer, key, nil
+}
+
+func getIssuersConfig(ctx context.Context, s logical.Storage) (*issuerConfigEntry, error) {
+	entry, err := s.Get(ctx, storageIssuerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &issuerConfigEntry{}
+	if entry != nil {
+		if err := entry.DecodeJSON(config); err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode issuer configuration: %v", err)}
+		}
+	}
+
+	return config, nil
+}
+
+func setIssuersConfig(ctx context.Context, s logical.Storage, config *issuerConfigEntry) error {
+	entry, err := logical.StorageEntryJSON(storageIssuerConfig, config)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// resolveIssuerReference turns the default reference, an issuer ID or an
+// issuer name into an issuer ID.
+func resolveIssuerReference(ctx context.Context, s logical.Storage, reference string) (string, error) {
+	if reference == "" || reference == defaultRef {
+		config, err := getIssuersConfig(ctx, s)
+		if err != nil {
+			return "", err
+		}
+		if config.DefaultIssuerID == "" {
+			return "", errutil.UserError{Err: "no default issuer currently configured"}
+		}
+		return config.DefaultIssuerID, nil
+	}
+
+	return resolveReference(ctx, s, issuerPrefix, "issuer", reference)
+}
+
+// resolveKeyReference turns a key ID or key name into a key ID.
+func resolveKeyReference(ctx context.Context, s logical.Storage, reference string) (string, error) {
+	return resolveReference(ctx, s, keyPrefix, "key", reference)
+}
+
+func resolveReference(ctx context.Context, s logical.Storage, prefix, kind, reference string) (string, error) {
+	entry, err := s.Get(ctx, prefix+reference)
+	if err != nil {
+		return "", err
+	}
+	if entry != nil {
+		return reference, nil
+	}
+
+	id, err := findIDByName(ctx, s, prefix, reference)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", errutil.UserError{Err: fmt.Sprintf("unable to find %s for reference: %s", kind, reference)}
+	}
+
+	return id, nil
+}
+
+func findIDByName(ctx context.Context, s logical.Storage, prefix, name string) (string, error) {
+	ids, err := s.List(ctx, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	for _, id := range ids {
+		entry, err := s.Get(ctx, prefix+id)
+		if err != nil {
+			return "", err
+		}
+		if entry == nil {
+			continue
+		}
+
+		var named struct {
+			Name string `json:"name"`
+		}
+		if err := entry.DecodeJSON(&named); err != nil {
+			return "", err
+		}
+		if named.Name == name {
+			return id, nil
+		}
+	}
+
+	return "", nil
+}
+
+func checkNameAvailable(ctx context.Context, s logical.Storage, prefix, name string) error {
+	if name == defaultRef {
+		return errutil.UserError{Err: fmt.Sprintf("%q is a reserved name", defaultRef)}
+	}
+	if !nameRegex.MatchString(name) {
+		return errutil.UserError{Err: fmt.Sprintf("name %q may only contain alphanumeric characters, hyphens and underscores", name)}
+	}
+
+	id, err := findIDByName(ctx, s, prefix, name)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		return errutil.UserError{Err: fmt.Sprintf("name %q is already in use", name)}
+	}
+
+	return nil
+}
+
+// fetchCertBundleByIssuerRef returns the issuer along with a certificate
+// bundle for it. The private key is only included when loadKey is set; it
+// is an error to request the key of an issuer without one.
+func fetchCertBundleByIssuerRef(ctx context.Context, s logical.Storage, issuerRef string, loadKey bool) (*issuerEntry, *certutil.CertBundle, error) {
+	issuerID, err := resolveIssuerReference(ctx, s, issuerRef)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); !ok {
+			return nil, nil, errutil.InternalError{Err: err.Error()}
+		}
+		if issuerRef != "" && issuerRef != defaultRef {
+			return nil, nil, err
+		}
+
+		// Until the legacy bundle has been migrated on the active node,
+		// keep serving it as the default issuer.
+		legacy, _, legacyErr := getLegacyCertBundle(ctx, s)
+		if legacyErr != nil {
+			return nil, nil, legacyErr
+		}
+		if legacy == nil || legacy.Certificate == "" {
+			return nil, nil, errutil.UserError{Err: "backend must be configured with a CA certificate/key"}
+		}
+		return &issuerEntry{
+			Certificate:  legacy.Certificate,
+			CAChain:      legacy.CAChain,
+			SerialNumber: legacy.SerialNumber,
+		}, legacy, nil
+	}
+
+	issuer, err := fetchIssuerByID(ctx, s, issuerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cb := &certutil.CertBundle{
+		Certificate:  issuer.Certificate,
+		CAChain:      issuer.CAChain,
+		SerialNumber: issuer.SerialNumber,
+	}
+
+	if loadKey {
+		if issuer.KeyID == "" {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf("issuer %s does not have an associated key", issuer.ID)}
+		}
+		key, err := fetchKeyByID(ctx, s, issuer.KeyID)
+		if err != nil {
+			return nil, nil, err
+		}
+		cb.PrivateKeyType = key.PrivateKeyType
+		cb.PrivateKey = key.PrivateKey
+	}
+
+	return issuer, cb, nil
+}
+
+// getLegacyCertBundle returns the legacy CA bundle along with its hash, or
+// nil if there is none or it has already been migrated.
+func getLegacyCertBundle(ctx context.Context, s logical.Storage) (*certutil.CertBundle, string, error) {
+	entry, err := s.Get(ctx, legacyCertBundlePath)
+	if err != nil {
+		return nil, "", errutil.InternalError{Err: fmt.Sprintf("unable to fetch local CA certificate/key: %v", err)}
+	}
+	if entry == nil {
+		return nil, "", nil
+	}
+
+	sum := sha256.Sum256(entry.Value)
+	hash := hex.EncodeToString(sum[:])
+
+	logEntry, err := s.Get(ctx, legacyBundleMigrationLogPath)
+	if err != nil {
+		return nil, "", errutil.InternalError{Err: fmt.Sprintf("unable to fetch legacy CA migration log: %v", err)}
+	}
+	if logEntry != nil {
+		var migrationLog legacyBundleMigrationLog
+		if err := logEntry.DecodeJSON(&migrationLog); err != nil {
+			return nil, "", errutil.InternalError{Err: fmt.Sprintf("unable to decode legacy CA migration log: %v", err)}
+		}
+		if migrationLog.Hash == hash {
+			return nil, "", nil
+		}
+	}
+
+	var cb certutil.CertBundle
+	if err := entry.DecodeJSON(&cb); err != nil {
+		return nil, "", errutil.InternalError{Err: fmt.Sprintf("unable to decode local CA certificate/key: %v", err)}
+	}
+
+	return &cb, hash, nil
+}
+
+// migrateLegacyCertBundle copies a CA configured before multiple issuers
+// were supported into the issuer and key storage. The legacy bundle itself
+// is left in place.
+func migrateLegacyCertBundle(ctx context.Context, s logical.Storage) (bool, error) {
+	legacy, hash, err := getLegacyCertBundle(ctx, s)
+	if err != nil || legacy == nil {
+		return false, err
+	}
+
+	if legacy.PrivateKey != "" {
+		if _, _, err := importKey(ctx, s, legacy.PrivateKey, ""); err != nil {
+			return false, errwrap.Wrapf("error migrating legacy CA key: {{err}}", err)
+		}
+	}
+	if legacy.Certificate != "" {
+		if _, _, err := importIssuer(ctx, s, legacy.Certificate, legacy.CAChain, ""); err != nil {
+			return false, errwrap.Wrapf("error migrating legacy CA certificate: {{err}}", err)
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(legacyBundleMigrationLogPath, &legacyBundleMigrationLog{
+		Hash:     hash,
+		Migrated: time.Now(),
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, s.Put(ctx, entry)
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) (bool, error) {
+	aBytes, err := x509.MarshalPKIXPublicKey(a)
+	if err != nil {
+		return false, err
+	}
+	bBytes, err := x509.MarshalPKIXPublicKey(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aBytes, bBytes), nil
+}
+
+// writeDefaultIssuerCert stores the certificate of the default issuer at the
+// well-known "ca" location served by the legacy fetch endpoints.
+func writeDefaultIssuerCert(ctx context.Context, s logical.Storage) error {
+	config, err := getIssuersConfig(ctx, s)
+	if err != nil {
+		return err
+	}
+	if config.DefaultIssuerID == "" {
+		return s.Delete(ctx, "ca")
+	}
+
+	issuer, err := fetchIssuerByID(ctx, s, config.DefaultIssuerID)
+	if err != nil {
+		return err
+	}
+	cert, err := issuer.getCertificate()
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, &logical.StorageEntry{
+		Key:   "ca",
+		Value: cert.Raw,
+	})
+}
+
+// caConfigured reports whether any CA certificate or key, including one
+// awaiting a signed intermediate certificate, exists on this mount.
+func caConfigured(ctx context.Context, s logical.Storage) (bool, error) {
+	for _, prefix := range []string{issuerPrefix, keyPrefix} {
+		entries, err := s.List(ctx, prefix)
+		if err != nil {
+			return false, err
+		}
+		if len(entries) > 0 {
+			return true, nil
+		}
+	}
+
+	legacy, _, err := getLegacyCertBundle(ctx, s)
+	if err != nil {
+		return false, err
+	}
+	return legacy != nil, nil
+}
+
+// findKeyForCertificate returns the key matching the certificate's public
+// key, or nil if this mount does not hold it.
+func findKeyForCertificate(ctx context.Context, s logical.Storage, cert *x509.Certificate) (*keyEntry, error) {
+	keys, err := listKeys(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, keyID := range keys {
+		key, err := fetchKeyByID(ctx, s, keyID)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := key.getSigner()
+		if err != nil {
+			return nil, err
+		}
+		equal, err := publicKeysEqual(cert.PublicKey, signer.Public())
+		if err != nil {
+			return nil, err
+		}
+		if equal {
+			return key, nil
+		}
+	}
+
+	return nil, nil
+}
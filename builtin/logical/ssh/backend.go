@@ -15,6 +15,15 @@ type backend struct {
 	view      logical.Storage
 	salt      *salt.Salt
 	saltMutex sync.RWMutex
+
+	// revokeStorageLock serializes revocations
+	revokeStorageLock sync.Mutex
+
+	// caKeysLock serializes changes to the CA keys
+	caKeysLock sync.Mutex
+
+	// tidyCASGuard is set while a tidy operation runs
+	tidyCASGuard *uint32
 }
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -31,6 +40,7 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 func Backend(conf *logical.BackendConfig) (*backend, error) {
 	var b backend
 	b.view = conf.StorageView
+	b.tidyCASGuard = new(uint32)
 	b.Backend = &framework.Backend{
 		Help: strings.TrimSpace(backendHelp),
 
@@ -38,15 +48,20 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			Unauthenticated: []string{
 				"verify",
 				"public_key",
+				"known_hosts",
+				"krl",
 			},
 
 			LocalStorage: []string{
 				"otp/",
+				certStoragePrefix,
+				revokedStoragePrefix,
 			},
 
 			SealWrapStorage: []string{
 				caPrivateKey,
 				caPrivateKeyStoragePath,
+				caKeyPrefix,
 				"keys/",
 			},
 		},
@@ -60,8 +75,18 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			pathLookup(&b),
 			pathVerify(&b),
 			pathConfigCA(&b),
+			pathConfigCAKeys(&b),
+			pathListCAKeys(&b),
+			pathCAKeys(&b),
 			pathSign(&b),
+			pathSignHost(&b),
 			pathFetchPublicKey(&b),
+			pathFetchKnownHosts(&b),
+			pathFetchListCerts(&b),
+			pathFetchCert(&b),
+			pathRevoke(&b),
+			pathFetchKRL(&b),
+			pathTidy(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -69,8 +94,9 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			secretOTP(&b),
 		},
 
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		InitializeFunc: b.initialize,
+		Invalidate:     b.invalidate,
+		BackendType:    logical.TypeLogical,
 	}
 	return &b, nil
 }
@@ -0,0 +1,94 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// The OpenSSH Key Revocation List format, as described in PROTOCOL.krl of
+// the OpenSSH sources
+const (
+	krlMagic         uint64 = 0x5353484b524c0a00
+	krlFormatVersion uint32 = 1
+
+	krlSectionCertificates   byte = 1
+	krlCertSectionSerialList byte = 0x20
+)
+
+// krlRevokedCertificates holds the serials of the revoked certificates
+// signed by a CA
+type krlRevokedCertificates struct {
+	CAKey   ssh.PublicKey
+	Serials []uint64
+}
+
+type krlBuffer struct {
+	bytes.Buffer
+}
+
+func (b *krlBuffer) writeUint32(v uint32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	b.Write(buf[:])
+}
+
+func (b *krlBuffer) writeUint64(v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	b.Write(buf[:])
+}
+
+func (b *krlBuffer) writeString(s []byte) {
+	b.writeUint32(uint32(len(s)))
+	b.Write(s)
+}
+
+// marshalKRL encodes a KRL revoking the given certificates, suitable for
+// the RevokedKeys option of sshd
+func marshalKRL(version uint64, generated time.Time, comment string, revoked []krlRevokedCertificates) []byte {
+	var krl krlBuffer
+	krl.writeUint64(krlMagic)
+	krl.writeUint32(krlFormatVersion)
+	krl.writeUint64(version)
+	krl.writeUint64(uint64(generated.Unix()))
+	// flags
+	krl.writeUint64(0)
+	// reserved
+	krl.writeString(nil)
+	krl.writeString([]byte(comment))
+
+	for _, certs := range revoked {
+		serials := make([]uint64, 0, len(certs.Serials))
+		for _, serial := range certs.Serials {
+			// A serial of zero cannot be revoked by serial
+			if serial != 0 {
+				serials = append(serials, serial)
+			}
+		}
+		if len(serials) == 0 {
+			continue
+		}
+		sort.Slice(serials, func(i, j int) bool { return serials[i] < serials[j] })
+
+		var serialList krlBuffer
+		for _, serial := range serials {
+			serialList.writeUint64(serial)
+		}
+
+		var section krlBuffer
+		section.writeString(certs.CAKey.Marshal())
+		// reserved
+		section.writeString(nil)
+		section.WriteByte(krlCertSectionSerialList)
+		section.writeString(serialList.Bytes())
+
+		krl.WriteByte(krlSectionCertificates)
+		krl.writeString(section.Bytes())
+	}
+
+	return krl.Bytes()
+}
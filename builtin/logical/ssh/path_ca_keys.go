@@ -0,0 +1,648 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	caKeyPrefix      = "config/ca-key/"
+	caKeysConfigPath = "config/ca-keys"
+
+	// legacyCAKeyName is the name given to the key configured through
+	// config/ca, and to a key configured before multiple keys were supported
+	legacyCAKeyName = "default"
+)
+
+// caKeyEntry holds a named CA key pair of this mount
+type caKeyEntry struct {
+	Name       string `json:"name"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
+
+	// RetiredAt is when the key stopped being the current signing key. It
+	// is zero for the current key and for keys that were never current.
+	RetiredAt time.Time `json:"retired_at"`
+}
+
+type caKeysConfigEntry struct {
+	// Current is the name of the key that signs certificates unless a role
+	// pins another one
+	Current string `json:"current"`
+
+	// OverlapPeriod is how long a retired key remains trusted. Zero means
+	// retired keys remain trusted until they are deleted.
+	OverlapPeriod time.Duration `json:"overlap_period"`
+}
+
+// trusted reports whether certificates signed by the key should still be
+// accepted, that is whether the key is published by the public_key endpoint
+func (k *caKeyEntry) trusted(config *caKeysConfigEntry, now time.Time) bool {
+	if k.Name == config.Current || k.RetiredAt.IsZero() || config.OverlapPeriod == 0 {
+		return true
+	}
+	return now.Before(k.RetiredAt.Add(config.OverlapPeriod))
+}
+
+func pathListCAKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "ca-keys/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathCAKeysList,
+		},
+
+		HelpSynopsis:    pathCAKeysHelpSyn,
+		HelpDescription: pathCAKeysHelpDesc,
+	}
+}
+
+func pathCAKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "ca-keys/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Name of the CA key.`,
+			},
+			"private_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Private half of the SSH key that will be used to sign certificates.`,
+			},
+			"public_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Public half of the SSH key that will be used to sign certificates.`,
+			},
+			"generate_signing_key": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `Generate SSH key pair internally rather than use the private_key and public_key fields.`,
+				Default:     true,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCAKeysWrite,
+			logical.ReadOperation:   b.pathCAKeysRead,
+			logical.DeleteOperation: b.pathCAKeysDelete,
+		},
+
+		HelpSynopsis:    pathCAKeysHelpSyn,
+		HelpDescription: pathCAKeysHelpDesc,
+	}
+}
+
+func pathConfigCAKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/ca-keys",
+		Fields: map[string]*framework.FieldSchema{
+			"current": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Name of the CA key that signs certificates, unless a role pins another key.`,
+			},
+			"overlap_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `How long a CA key remains trusted after another key
+became current. Should be at least the longest TTL of the certificates it
+signed. If zero, retired keys remain trusted until they are deleted.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigCAKeysRead,
+			logical.UpdateOperation: b.pathConfigCAKeysWrite,
+		},
+
+		HelpSynopsis:    pathConfigCAKeysHelpSyn,
+		HelpDescription: pathConfigCAKeysHelpDesc,
+	}
+}
+
+func (b *backend) pathCAKeysList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	names, err := req.Storage.List(ctx, caKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := getCAKeysConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	keyInfo := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		key, err := getCAKey(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			continue
+		}
+		keyInfo[name] = map[string]interface{}{
+			"is_current": name == config.Current,
+			"trusted":    key.trusted(config, now),
+		}
+	}
+
+	return logical.ListResponseWithInfo(names, keyInfo), nil
+}
+
+func (b *backend) pathCAKeysRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	key, err := getCAKey(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, nil
+	}
+
+	config, err := getCAKeysConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	var retiredAt int64
+	if !key.RetiredAt.IsZero() {
+		retiredAt = key.RetiredAt.Unix()
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":       key.Name,
+			"public_key": key.PublicKey,
+			"is_current": name == config.Current,
+			"trusted":    key.trusted(config, time.Now()),
+			"retired_at": retiredAt,
+		},
+	}, nil
+}
+
+func (b *backend) pathCAKeysWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	b.caKeysLock.Lock()
+	defer b.caKeysLock.Unlock()
+
+	existing, err := getCAKey(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return logical.ErrorResponse(fmt.Sprintf("CA key %q already exists; delete it before reconfiguring", name)), nil
+	}
+
+	publicKey, privateKey, generated, resp, err := caKeyPairFromRequest(data)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	// The first key of the mount becomes the current one
+	config, err := getCAKeysConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if err := putCAKey(ctx, req.Storage, &caKeyEntry{
+		Name:       name,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}); err != nil {
+		return nil, err
+	}
+	if config.Current == "" {
+		config.Current = name
+		if err := putCAKeysConfig(ctx, req.Storage, config); err != nil {
+			return nil, err
+		}
+	}
+
+	if generated {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"public_key": publicKey,
+			},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathCAKeysDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	b.caKeysLock.Lock()
+	defer b.caKeysLock.Unlock()
+
+	config, err := getCAKeysConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if name == config.Current {
+		return logical.ErrorResponse("cannot delete the current CA key; make another key current first"), nil
+	}
+
+	// Refuse to break the roles pinned to the key
+	roles, err := req.Storage.List(ctx, "roles/")
+	if err != nil {
+		return nil, err
+	}
+	var pinned []string
+	for _, roleName := range roles {
+		role, err := b.getRole(ctx, req.Storage, roleName)
+		if err != nil {
+			return nil, err
+		}
+		if role != nil && role.CAKeyName == name {
+			pinned = append(pinned, roleName)
+		}
+	}
+	if len(pinned) > 0 {
+		return logical.ErrorResponse(fmt.Sprintf("CA key is in use by roles %s", strings.Join(pinned, ", "))), nil
+	}
+
+	if err := req.Storage.Delete(ctx, caKeyPrefix+name); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigCAKeysRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := getCAKeysConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"current":        config.Current,
+			"overlap_period": int64(config.OverlapPeriod.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigCAKeysWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.caKeysLock.Lock()
+	defer b.caKeysLock.Unlock()
+
+	config, err := getCAKeysConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if overlapRaw, ok := data.GetOk("overlap_period"); ok {
+		if overlapRaw.(int) < 0 {
+			return logical.ErrorResponse("overlap_period must not be negative"), nil
+		}
+		config.OverlapPeriod = time.Duration(overlapRaw.(int)) * time.Second
+	}
+
+	if currentRaw, ok := data.GetOk("current"); ok && currentRaw.(string) != config.Current {
+		current, err := getCAKey(ctx, req.Storage, currentRaw.(string))
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			return logical.ErrorResponse(fmt.Sprintf("CA key %q not found", currentRaw.(string))), nil
+		}
+
+		// The previous key stays trusted for the overlap period, so that
+		// the certificates it signed remain usable while they expire
+		if config.Current != "" {
+			previous, err := getCAKey(ctx, req.Storage, config.Current)
+			if err != nil {
+				return nil, err
+			}
+			if previous != nil {
+				previous.RetiredAt = time.Now()
+				if err := putCAKey(ctx, req.Storage, previous); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		current.RetiredAt = time.Time{}
+		if err := putCAKey(ctx, req.Storage, current); err != nil {
+			return nil, err
+		}
+		config.Current = current.Name
+	}
+
+	if err := putCAKeysConfig(ctx, req.Storage, config); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// caKeyPairFromRequest returns the key pair given in the request, or
+// generates one, following the rules of config/ca
+func caKeyPairFromRequest(data *framework.FieldData) (string, string, bool, *logical.Response, error) {
+	publicKey := data.Get("public_key").(string)
+	privateKey := data.Get("private_key").(string)
+
+	var generateSigningKey bool
+
+	generateSigningKeyRaw, ok := data.GetOk("generate_signing_key")
+	switch {
+	// explicitly set true
+	case ok && generateSigningKeyRaw.(bool):
+		if publicKey != "" || privateKey != "" {
+			return "", "", false, logical.ErrorResponse("public_key and private_key must not be set when generate_signing_key is set to true"), nil
+		}
+
+		generateSigningKey = true
+
+	// explicitly set to false, or not set and we have both a public and private key
+	case ok, publicKey != "" && privateKey != "":
+		if publicKey == "" {
+			return "", "", false, logical.ErrorResponse("missing public_key"), nil
+		}
+
+		if privateKey == "" {
+			return "", "", false, logical.ErrorResponse("missing private_key"), nil
+		}
+
+		_, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			return "", "", false, logical.ErrorResponse(fmt.Sprintf("Unable to parse private_key as an SSH private key: %v", err)), nil
+		}
+
+		_, err = parsePublicSSHKey(publicKey)
+		if err != nil {
+			return "", "", false, logical.ErrorResponse(fmt.Sprintf("Unable to parse public_key as an SSH public key: %v", err)), nil
+		}
+
+	// not set and no public/private key provided so generate
+	case publicKey == "" && privateKey == "":
+		generateSigningKey = true
+
+	// not set, but one or the other supplied
+	default:
+		return "", "", false, logical.ErrorResponse("only one of public_key and private_key set; both must be set to use, or both must be blank to auto-generate"), nil
+	}
+
+	if generateSigningKey {
+		var err error
+		publicKey, privateKey, err = generateSSHKeyPair()
+		if err != nil {
+			return "", "", false, nil, err
+		}
+	}
+
+	if publicKey == "" || privateKey == "" {
+		return "", "", false, nil, fmt.Errorf("failed to generate or parse the keys")
+	}
+
+	return publicKey, privateKey, generateSigningKey, nil, nil
+}
+
+func getCAKey(ctx context.Context, s logical.Storage, name string) (*caKeyEntry, error) {
+	raw, err := s.Get(ctx, caKeyPrefix+name)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to read CA key %q: {{err}}", name), err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var key caKeyEntry
+	if err := raw.DecodeJSON(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func putCAKey(ctx context.Context, s logical.Storage, key *caKeyEntry) error {
+	entry, err := logical.StorageEntryJSON(caKeyPrefix+key.Name, key)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func getCAKeysConfig(ctx context.Context, s logical.Storage) (*caKeysConfigEntry, error) {
+	config := &caKeysConfigEntry{}
+
+	raw, err := s.Get(ctx, caKeysConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return config, nil
+	}
+	if err := raw.DecodeJSON(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func putCAKeysConfig(ctx context.Context, s logical.Storage, config *caKeysConfigEntry) error {
+	entry, err := logical.StorageEntryJSON(caKeysConfigPath, config)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// legacyCAKey returns the CA key pair configured before multiple keys were
+// supported, or nil once it has been migrated by migrateLegacyCAKey
+func legacyCAKey(ctx context.Context, s logical.Storage) (*caKeyEntry, error) {
+	publicKeyEntry, err := caKey(ctx, s, caPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	privateKeyEntry, err := caKey(ctx, s, caPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if publicKeyEntry == nil || privateKeyEntry == nil || publicKeyEntry.Key == "" || privateKeyEntry.Key == "" {
+		return nil, nil
+	}
+
+	return &caKeyEntry{
+		Name:       legacyCAKeyName,
+		PublicKey:  publicKeyEntry.Key,
+		PrivateKey: privateKeyEntry.Key,
+	}, nil
+}
+
+// currentCAKey returns the key that signs certificates by default, or nil
+// if no CA key has been configured
+func currentCAKey(ctx context.Context, s logical.Storage) (*caKeyEntry, error) {
+	config, err := getCAKeysConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if config.Current == "" {
+		// The legacy key is still in use until the migration has run, as on
+		// a standby before the active node migrated it
+		return legacyCAKey(ctx, s)
+	}
+	return getCAKey(ctx, s, config.Current)
+}
+
+// signingCAKey returns the key that signs the certificates of the role
+func signingCAKey(ctx context.Context, s logical.Storage, role *sshRole) (*caKeyEntry, error) {
+	if role.CAKeyName == "" {
+		key, err := currentCAKey(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, fmt.Errorf("no CA key has been configured")
+		}
+		return key, nil
+	}
+
+	key, err := getCAKey(ctx, s, role.CAKeyName)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil && role.CAKeyName == legacyCAKeyName {
+		key, err = legacyCAKey(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("CA key %q not found", role.CAKeyName)
+	}
+	config, err := getCAKeysConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if !key.trusted(config, time.Now()) {
+		return nil, fmt.Errorf("CA key %q is no longer trusted", role.CAKeyName)
+	}
+	return key, nil
+}
+
+// trustedCAPublicKeys returns the public keys of the CA keys that are
+// trusted, the current key first
+func trustedCAPublicKeys(ctx context.Context, s logical.Storage) ([]string, error) {
+	names, err := s.List(ctx, caKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	config, err := getCAKeysConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		return names[i] == config.Current && names[j] != config.Current
+	})
+
+	var publicKeys []string
+	if config.Current == "" {
+		legacy, err := legacyCAKey(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		if legacy != nil {
+			publicKeys = append(publicKeys, legacy.PublicKey)
+		}
+	}
+
+	now := time.Now()
+	for _, name := range names {
+		key, err := getCAKey(ctx, s, name)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil && key.trusted(config, now) {
+			publicKeys = append(publicKeys, key.PublicKey)
+		}
+	}
+	return publicKeys, nil
+}
+
+// migrateLegacyCAKey moves a CA key pair configured before multiple keys
+// were supported into the named key storage, as the current key
+func migrateLegacyCAKey(ctx context.Context, s logical.Storage) (bool, error) {
+	legacy, err := legacyCAKey(ctx, s)
+	if err != nil {
+		return false, err
+	}
+	if legacy == nil {
+		return false, nil
+	}
+
+	existing, err := getCAKey(ctx, s, legacyCAKeyName)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		if err := putCAKey(ctx, s, legacy); err != nil {
+			return false, err
+		}
+
+		config, err := getCAKeysConfig(ctx, s)
+		if err != nil {
+			return false, err
+		}
+		if config.Current == "" {
+			config.Current = legacyCAKeyName
+			if err := putCAKeysConfig(ctx, s, config); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err := s.Delete(ctx, caPrivateKeyStoragePath); err != nil {
+		return false, err
+	}
+	return true, s.Delete(ctx, caPublicKeyStoragePath)
+}
+
+// initialize migrates a CA key configured before multiple keys were
+// supported into the named key storage
+func (b *backend) initialize(ctx context.Context, req *logical.InitializationRequest) error {
+	// on standbys and DR secondaries we do not want to run any kind of upgrade logic
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby | consts.ReplicationDRSecondary) {
+		return nil
+	}
+
+	if b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		migrated, err := migrateLegacyCAKey(ctx, req.Storage)
+		if err != nil {
+			return err
+		}
+		if migrated {
+			b.Logger().Info("migrated legacy CA key to named CA key storage", "name", legacyCAKeyName)
+		}
+	}
+
+	return nil
+}
+
+const pathCAKeysHelpSyn = `Manage the named CA keys of this mount.`
+
+const pathCAKeysHelpDesc = `
+A mount may hold several named CA keys, one of which is the current key
+signing certificates. Roles may pin another key with "ca_key_name".
+
+To rotate the CA without downtime, create the new key first: it is published
+by the "public_key" endpoint right away, next to the current key, so that
+servers can learn to trust it. Then make it current through
+"config/ca-keys". The previous key remains trusted for the overlap period
+configured there, after which it can be deleted.
+`
+
+const pathConfigCAKeysHelpSyn = `Configure the current CA key and the rotation overlap.`
+
+const pathConfigCAKeysHelpDesc = `
+This sets the CA key that signs certificates unless a role pins another key,
+and how long a key remains trusted after another key became current.
+`
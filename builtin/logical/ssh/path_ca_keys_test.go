@@ -0,0 +1,238 @@
+package ssh
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSH_CAKeys(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	mustFail := func(op logical.Operation, path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+		}
+	}
+	publicKeys := func() []string {
+		t.Helper()
+		resp := mustRequest(logical.ReadOperation, "public_key", nil)
+		return strings.Split(strings.TrimSpace(string(resp.Data[logical.HTTPRawBody].([]byte))), "\n")
+	}
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userKey := string(ssh.MarshalAuthorizedKey(sshPub))
+	signedBy := func(role string) string {
+		t.Helper()
+		resp := mustRequest(logical.UpdateOperation, "sign/"+role, map[string]interface{}{
+			"public_key": userKey,
+		})
+		certificate, err := parseCertificate(resp.Data["signed_key"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(ssh.MarshalAuthorizedKey(certificate.SignatureKey))
+	}
+
+	// config/ca configures the current key named "default"
+	resp := mustRequest(logical.UpdateOperation, "config/ca", nil)
+	oldKey := resp.Data["public_key"].(string)
+	resp = mustRequest(logical.ReadOperation, "config/ca-keys", nil)
+	if resp.Data["current"] != "default" {
+		t.Fatalf("bad CA keys config: %#v", resp.Data)
+	}
+
+	// A new key is trusted as soon as it is created, but does not sign yet
+	resp = mustRequest(logical.UpdateOperation, "ca-keys/next", nil)
+	newKey := resp.Data["public_key"].(string)
+	mustFail(logical.UpdateOperation, "ca-keys/next", nil)
+	if keys := publicKeys(); len(keys) != 2 || keys[0]+"\n" != oldKey || keys[1]+"\n" != newKey {
+		t.Fatalf("bad trusted keys: %#v", keys)
+	}
+	resp = mustRequest(logical.ReadOperation, "known_hosts", nil)
+	if lines := strings.Split(strings.TrimSpace(string(resp.Data[logical.HTTPRawBody].([]byte))), "\n"); len(lines) != 2 {
+		t.Fatalf("bad known_hosts: %#v", lines)
+	}
+	resp = mustRequest(logical.ListOperation, "ca-keys/", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 2 {
+		t.Fatalf("bad CA key list: %#v", resp.Data)
+	}
+	if info := resp.Data["key_info"].(map[string]interface{})["default"].(map[string]interface{}); info["is_current"] != true {
+		t.Fatalf("bad CA key info: %#v", info)
+	}
+
+	mustRequest(logical.UpdateOperation, "roles/current", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+		"default_user":            "ubuntu",
+	})
+	mustRequest(logical.UpdateOperation, "roles/pinned", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+		"default_user":            "ubuntu",
+		"ca_key_name":             "next",
+	})
+	mustFail(logical.UpdateOperation, "roles/missing", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"ca_key_name":             "missing",
+	})
+	if signedBy("current") != oldKey || signedBy("pinned") != newKey {
+		t.Fatal("certificates signed by the wrong CA key")
+	}
+
+	// Rotate; the old key remains trusted for the overlap period
+	mustFail(logical.UpdateOperation, "config/ca-keys", map[string]interface{}{
+		"current": "missing",
+	})
+	mustFail(logical.UpdateOperation, "config/ca-keys", map[string]interface{}{
+		"overlap_period": -1,
+	})
+	mustRequest(logical.UpdateOperation, "config/ca-keys", map[string]interface{}{
+		"current":        "next",
+		"overlap_period": "1h",
+	})
+	if signedBy("current") != newKey {
+		t.Fatal("certificate not signed by the new current key")
+	}
+	if keys := publicKeys(); len(keys) != 2 || keys[0]+"\n" != newKey {
+		t.Fatalf("bad trusted keys during overlap: %#v", keys)
+	}
+	resp = mustRequest(logical.ReadOperation, "ca-keys/default", nil)
+	if resp.Data["is_current"] != false || resp.Data["trusted"] != true || resp.Data["retired_at"].(int64) == 0 {
+		t.Fatalf("bad retired key: %#v", resp.Data)
+	}
+
+	// Once the overlap period has passed, only the new key is trusted
+	retired, err := getCAKey(context.Background(), config.StorageView, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired.RetiredAt = time.Now().Add(-2 * time.Hour)
+	if err := putCAKey(context.Background(), config.StorageView, retired); err != nil {
+		t.Fatal(err)
+	}
+	if keys := publicKeys(); len(keys) != 1 || keys[0]+"\n" != newKey {
+		t.Fatalf("bad trusted keys after overlap: %#v", keys)
+	}
+
+	mustFail(logical.DeleteOperation, "ca-keys/next", nil)
+	mustRequest(logical.DeleteOperation, "ca-keys/default", nil)
+	mustFail(logical.UpdateOperation, "config/ca", nil)
+
+	// config/ca deletes all keys
+	mustRequest(logical.DeleteOperation, "roles/pinned", nil)
+	mustRequest(logical.DeleteOperation, "config/ca", nil)
+	if resp, err := request(logical.ReadOperation, "public_key", nil); err != nil || resp != nil {
+		t.Fatalf("expected no public key, got err: %v resp: %#v", err, resp)
+	}
+}
+
+func TestSSH_CAKeysMigration(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, privateKey, err := generateSSHKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, key := range map[string]string{
+		caPublicKeyStoragePath:  publicKey,
+		caPrivateKeyStoragePath: privateKey,
+	} {
+		entry, err := logical.StorageEntryJSON(path, &keyStorageEntry{Key: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := config.StorageView.Put(context.Background(), entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The legacy key is used until it has been migrated
+	key, err := currentCAKey(context.Background(), config.StorageView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key == nil || key.Name != legacyCAKeyName || key.PrivateKey != privateKey {
+		t.Fatalf("bad legacy key: %#v", key)
+	}
+	key, err = signingCAKey(context.Background(), config.StorageView, &sshRole{CAKeyName: legacyCAKeyName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key == nil || key.PrivateKey != privateKey {
+		t.Fatalf("bad legacy signing key: %#v", key)
+	}
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   config.StorageView,
+		Operation: logical.ReadOperation,
+		Path:      "public_key",
+	})
+	if err != nil || resp == nil || string(resp.Data[logical.HTTPRawBody].([]byte)) != publicKey {
+		t.Fatalf("bad legacy public key: err: %v resp: %#v", err, resp)
+	}
+
+	if err := b.Initialize(context.Background(), &logical.InitializationRequest{Storage: config.StorageView}); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err = currentCAKey(context.Background(), config.StorageView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key == nil || key.Name != legacyCAKeyName || key.PublicKey != publicKey || key.PrivateKey != privateKey {
+		t.Fatalf("bad migrated key: %#v", key)
+	}
+	for _, path := range []string{caPublicKeyStoragePath, caPrivateKeyStoragePath} {
+		entry, err := config.StorageView.Get(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry != nil {
+			t.Fatalf("legacy key %s not removed", path)
+		}
+	}
+}
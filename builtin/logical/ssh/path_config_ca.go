@@ -9,7 +9,6 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/errwrap"
-	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"golang.org/x/crypto/ssh"
@@ -56,38 +55,53 @@ func pathConfigCA(b *backend) *framework.Path {
 		HelpSynopsis: `Set the SSH private key used for signing certificates.`,
 		HelpDescription: `This sets the CA information used for certificates generated by this
 by this mount. The fields must be in the standard private and public SSH format.
+The key is stored as the CA key named "default" and becomes the current key.
 
 For security reasons, the private key cannot be retrieved later.
 
-Read operations will return the public key, if already stored/generated.`,
+Read operations will return the public key of the current CA key, if already
+stored/generated. Delete operations delete all the CA keys of the mount.`,
 	}
 }
 
 func (b *backend) pathConfigCARead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
+	key, err := currentCAKey(ctx, req.Storage)
 	if err != nil {
 		return nil, errwrap.Wrapf("failed to read CA public key: {{err}}", err)
 	}
 
-	if publicKeyEntry == nil {
+	if key == nil {
 		return logical.ErrorResponse("keys haven't been configured yet"), nil
 	}
 
 	response := &logical.Response{
 		Data: map[string]interface{}{
-			"public_key": publicKeyEntry.Key,
+			"public_key": key.PublicKey,
 		},
 	}
 
 	return response, nil
 }
 
+// pathConfigCADelete deletes all the CA keys of the mount
 func (b *backend) pathConfigCADelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	if err := req.Storage.Delete(ctx, caPrivateKeyStoragePath); err != nil {
+	b.caKeysLock.Lock()
+	defer b.caKeysLock.Unlock()
+
+	names, err := req.Storage.List(ctx, caKeyPrefix)
+	if err != nil {
 		return nil, err
 	}
-	if err := req.Storage.Delete(ctx, caPublicKeyStoragePath); err != nil {
-		return nil, err
+	for _, name := range names {
+		if err := req.Storage.Delete(ctx, caKeyPrefix+name); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range []string{caKeysConfigPath, caPrivateKeyStoragePath, caPublicKeyStoragePath} {
+		if err := req.Storage.Delete(ctx, path); err != nil {
+			return nil, err
+		}
 	}
 	return nil, nil
 }
@@ -144,61 +158,15 @@ func caKey(ctx context.Context, storage logical.Storage, keyType string) (*keySt
 	return &keyEntry, nil
 }
 
+// pathConfigCAUpdate configures the first CA key of the mount, named
+// "default"
 func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	var err error
-	publicKey := data.Get("public_key").(string)
-	privateKey := data.Get("private_key").(string)
-
-	var generateSigningKey bool
-
-	generateSigningKeyRaw, ok := data.GetOk("generate_signing_key")
-	switch {
-	// explicitly set true
-	case ok && generateSigningKeyRaw.(bool):
-		if publicKey != "" || privateKey != "" {
-			return logical.ErrorResponse("public_key and private_key must not be set when generate_signing_key is set to true"), nil
-		}
+	b.caKeysLock.Lock()
+	defer b.caKeysLock.Unlock()
 
-		generateSigningKey = true
-
-	// explicitly set to false, or not set and we have both a public and private key
-	case ok, publicKey != "" && privateKey != "":
-		if publicKey == "" {
-			return logical.ErrorResponse("missing public_key"), nil
-		}
-
-		if privateKey == "" {
-			return logical.ErrorResponse("missing private_key"), nil
-		}
-
-		_, err := ssh.ParsePrivateKey([]byte(privateKey))
-		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("Unable to parse private_key as an SSH private key: %v", err)), nil
-		}
-
-		_, err = parsePublicSSHKey(publicKey)
-		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("Unable to parse public_key as an SSH public key: %v", err)), nil
-		}
-
-	// not set and no public/private key provided so generate
-	case publicKey == "" && privateKey == "":
-		generateSigningKey = true
-
-	// not set, but one or the other supplied
-	default:
-		return logical.ErrorResponse("only one of public_key and private_key set; both must be set to use, or both must be blank to auto-generate"), nil
-	}
-
-	if generateSigningKey {
-		publicKey, privateKey, err = generateSSHKeyPair()
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if publicKey == "" || privateKey == "" {
-		return nil, fmt.Errorf("failed to generate or parse the keys")
+	names, err := req.Storage.List(ctx, caKeyPrefix)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list CA keys: {{err}}", err)
 	}
 
 	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
@@ -211,48 +179,33 @@ func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request,
 		return nil, errwrap.Wrapf("failed to read CA private key: {{err}}", err)
 	}
 
-	if (publicKeyEntry != nil && publicKeyEntry.Key != "") || (privateKeyEntry != nil && privateKeyEntry.Key != "") {
+	if len(names) > 0 || (publicKeyEntry != nil && publicKeyEntry.Key != "") || (privateKeyEntry != nil && privateKeyEntry.Key != "") {
 		return logical.ErrorResponse("keys are already configured; delete them before reconfiguring"), nil
 	}
 
-	entry, err := logical.StorageEntryJSON(caPublicKeyStoragePath, &keyStorageEntry{
-		Key: publicKey,
-	})
-	if err != nil {
-		return nil, err
+	publicKey, privateKey, generated, resp, err := caKeyPairFromRequest(data)
+	if resp != nil || err != nil {
+		return resp, err
 	}
 
-	// Save the public key
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
+	if err := putCAKey(ctx, req.Storage, &caKeyEntry{
+		Name:       legacyCAKeyName,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}); err != nil {
 		return nil, err
 	}
 
-	entry, err = logical.StorageEntryJSON(caPrivateKeyStoragePath, &keyStorageEntry{
-		Key: privateKey,
-	})
+	config, err := getCAKeysConfig(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
-
-	// Save the private key
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		var mErr *multierror.Error
-
-		mErr = multierror.Append(mErr, errwrap.Wrapf("failed to store CA private key: {{err}}", err))
-
-		// If storing private key fails, the corresponding public key should be
-		// removed
-		if delErr := req.Storage.Delete(ctx, caPublicKeyStoragePath); delErr != nil {
-			mErr = multierror.Append(mErr, errwrap.Wrapf("failed to cleanup CA public key: {{err}}", delErr))
-			return nil, mErr
-		}
-
+	config.Current = legacyCAKeyName
+	if err := putCAKeysConfig(ctx, req.Storage, config); err != nil {
 		return nil, err
 	}
 
-	if generateSigningKey {
+	if generated {
 		response := &logical.Response{
 			Data: map[string]interface{}{
 				"public_key": publicKey,
@@ -2,6 +2,8 @@ package ssh
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -16,26 +18,165 @@ func pathFetchPublicKey(b *backend) *framework.Path {
 		},
 
 		HelpSynopsis:    `Retrieve the public key.`,
-		HelpDescription: `This allows the public key, that this backend has been configured with, to be fetched.`,
+		HelpDescription: `This allows the public keys, that this backend has been configured with, to be fetched. All the trusted CA keys are returned, one per line, the current key first, in the format of the TrustedUserCAKeys file of sshd.`,
 	}
 }
 
 func (b *backend) pathFetchPublicKey(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
+	publicKeys, err := trustedCAPublicKeys(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
-	if publicKeyEntry == nil || publicKeyEntry.Key == "" {
+	if len(publicKeys) == 0 {
 		return nil, nil
 	}
 
+	var body strings.Builder
+	for i, publicKey := range publicKeys {
+		if i > 0 && !strings.HasSuffix(publicKeys[i-1], "\n") {
+			body.WriteString("\n")
+		}
+		body.WriteString(publicKey)
+	}
+
 	response := &logical.Response{
 		Data: map[string]interface{}{
 			logical.HTTPContentType: "text/plain",
-			logical.HTTPRawBody:     []byte(publicKeyEntry.Key),
+			logical.HTTPRawBody:     []byte(body.String()),
 			logical.HTTPStatusCode:  200,
 		},
 	}
 
 	return response, nil
 }
+
+func pathFetchKnownHosts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `known_hosts`,
+
+		Fields: map[string]*framework.FieldSchema{
+			"host_patterns": &framework.FieldSchema{
+				Type:    framework.TypeCommaStringSlice,
+				Default: []string{"*"},
+				Description: `Comma separated list of host name patterns that the CA is
+trusted for, in the format of the known_hosts file. Defaults to "*".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchKnownHosts,
+		},
+
+		HelpSynopsis:    `Retrieve a known_hosts line trusting the CA for host keys.`,
+		HelpDescription: `This returns the trusted public keys, that this backend has been configured with, as "@cert-authority" lines of the known_hosts file, so that clients accept the host certificates signed by this backend.`,
+	}
+}
+
+func (b *backend) pathFetchKnownHosts(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	hostPatterns := data.Get("host_patterns").([]string)
+	if len(hostPatterns) == 0 {
+		return logical.ErrorResponse("missing host_patterns"), nil
+	}
+	for _, pattern := range hostPatterns {
+		if pattern == "" || strings.ContainsAny(pattern, " \t\r\n") {
+			return logical.ErrorResponse(fmt.Sprintf("invalid host pattern %q", pattern)), nil
+		}
+	}
+
+	publicKeys, err := trustedCAPublicKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKeys) == 0 {
+		return nil, nil
+	}
+
+	var lines strings.Builder
+	for _, publicKey := range publicKeys {
+		lines.WriteString(fmt.Sprintf("@cert-authority %s %s\n", strings.Join(hostPatterns, ","), strings.TrimSpace(publicKey)))
+	}
+
+	response := &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "text/plain",
+			logical.HTTPRawBody:     []byte(lines.String()),
+			logical.HTTPStatusCode:  200,
+		},
+	}
+
+	return response, nil
+}
+
+func pathFetchListCerts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "certs/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathFetchCertList,
+		},
+
+		HelpSynopsis:    `List the serial numbers of the certificates signed by this backend.`,
+		HelpDescription: `This lists the serial numbers of the certificates signed by this backend, in hex.`,
+	}
+}
+
+func pathFetchCert(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `cert/(?P<serial>[0-9A-Fa-f-:]+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"serial": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Certificate serial number, in hex.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchCertRead,
+		},
+
+		HelpSynopsis:    `Retrieve a certificate signed by this backend.`,
+		HelpDescription: `This returns the certificate with the given serial number along with its key ID and revocation time, which is zero unless the certificate was revoked.`,
+	}
+}
+
+func (b *backend) pathFetchCertList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, certStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathFetchCertRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	serial, err := normalizeSerial(data.Get("serial").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	certEntry, err := getCertificateEntry(ctx, req.Storage, serial)
+	if err != nil {
+		return nil, err
+	}
+	if certEntry == nil {
+		return nil, nil
+	}
+
+	var revocationTime int64
+	revEntry, err := getRevocationEntry(ctx, req.Storage, serial)
+	if err != nil {
+		return nil, err
+	}
+	if revEntry != nil {
+		revocationTime = revEntry.RevocationTime
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"serial_number":   certEntry.SerialNumber,
+			"key_id":          certEntry.KeyID,
+			"signed_key":      certEntry.SignedKey,
+			"revocation_time": revocationTime,
+		},
+	}, nil
+}
@@ -0,0 +1,271 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	certStoragePrefix    = "certs/"
+	revokedStoragePrefix = "revoked/"
+)
+
+// certificateEntry records a certificate signed by the backend, keyed by
+// its serial number
+type certificateEntry struct {
+	SerialNumber string `json:"serial_number"`
+	KeyID        string `json:"key_id"`
+	SignedKey    string `json:"signed_key"`
+}
+
+// revocationEntry marks the certificate with the same serial number as
+// revoked. It holds a copy of the certificate so that the KRL can be built
+// without reading the certificate entries.
+type revocationEntry struct {
+	SerialNumber   string `json:"serial_number"`
+	RevocationTime int64  `json:"revocation_time"`
+	SignedKey      string `json:"signed_key"`
+}
+
+func pathRevoke(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `revoke`,
+		Fields: map[string]*framework.FieldSchema{
+			"serial_number": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Serial number of the certificate to revoke, in hex.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathRevokeWrite,
+		},
+
+		HelpSynopsis:    pathRevokeHelpSyn,
+		HelpDescription: pathRevokeHelpDesc,
+	}
+}
+
+func pathFetchKRL(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `krl`,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchKRL,
+		},
+
+		HelpSynopsis:    pathKRLHelpSyn,
+		HelpDescription: pathKRLHelpDesc,
+	}
+}
+
+// normalizeSerial returns the serial number in the format of the responses
+// of the sign endpoints, accepting colon- or hyphen-separated hex as well
+func normalizeSerial(serial string) (string, error) {
+	serial = strings.NewReplacer(":", "", "-", "").Replace(strings.TrimSpace(serial))
+	value, err := strconv.ParseUint(serial, 16, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid serial number %q", serial)
+	}
+	return strconv.FormatUint(value, 16), nil
+}
+
+// storeCertificate records a signed certificate so that it can be revoked
+func storeCertificate(ctx context.Context, s logical.Storage, certificate *ssh.Certificate, signedKey string) error {
+	serial := strconv.FormatUint(certificate.Serial, 16)
+	entry, err := logical.StorageEntryJSON(certStoragePrefix+serial, &certificateEntry{
+		SerialNumber: serial,
+		KeyID:        certificate.KeyId,
+		SignedKey:    signedKey,
+	})
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func getCertificateEntry(ctx context.Context, s logical.Storage, serial string) (*certificateEntry, error) {
+	raw, err := s.Get(ctx, certStoragePrefix+serial)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var entry certificateEntry
+	if err := raw.DecodeJSON(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func getRevocationEntry(ctx context.Context, s logical.Storage, serial string) (*revocationEntry, error) {
+	raw, err := s.Get(ctx, revokedStoragePrefix+serial)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var entry revocationEntry
+	if err := raw.DecodeJSON(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func parseCertificate(signedKey string) (*ssh.Certificate, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(signedKey))
+	if err != nil {
+		return nil, err
+	}
+	certificate, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("signed key is not a certificate")
+	}
+	return certificate, nil
+}
+
+func (b *backend) pathRevokeWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	serialRaw := data.Get("serial_number").(string)
+	if serialRaw == "" {
+		return logical.ErrorResponse("missing serial_number"), nil
+	}
+	serial, err := normalizeSerial(serialRaw)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	certEntry, err := getCertificateEntry(ctx, req.Storage, serial)
+	if err != nil {
+		return nil, err
+	}
+	if certEntry == nil {
+		return logical.ErrorResponse(fmt.Sprintf("certificate with serial %s not found", serial)), nil
+	}
+
+	revEntry, err := getRevocationEntry(ctx, req.Storage, serial)
+	if err != nil {
+		return nil, err
+	}
+	if revEntry == nil {
+		revEntry = &revocationEntry{
+			SerialNumber:   serial,
+			RevocationTime: time.Now().Unix(),
+			SignedKey:      certEntry.SignedKey,
+		}
+		entry, err := logical.StorageEntryJSON(revokedStoragePrefix+serial, revEntry)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.Storage.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"revocation_time": revEntry.RevocationTime,
+		},
+	}, nil
+}
+
+func (b *backend) pathFetchKRL(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	krl, err := b.buildKRL(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/octet-stream",
+			logical.HTTPRawBody:     krl,
+			logical.HTTPStatusCode:  200,
+		},
+	}, nil
+}
+
+// buildKRL produces a KRL of the revoked certificates which have not
+// expired yet. Its version is the time of the latest revocation, so that
+// it only changes when a certificate is revoked.
+func (b *backend) buildKRL(ctx context.Context, s logical.Storage) ([]byte, error) {
+	serials, err := s.List(ctx, revokedStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var version uint64
+	var revoked []krlRevokedCertificates
+	caIndexes := make(map[string]int)
+	for _, serial := range serials {
+		revEntry, err := getRevocationEntry(ctx, s, serial)
+		if err != nil {
+			return nil, err
+		}
+		if revEntry == nil {
+			continue
+		}
+		if uint64(revEntry.RevocationTime) > version {
+			version = uint64(revEntry.RevocationTime)
+		}
+
+		certificate, err := parseCertificate(revEntry.SignedKey)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse stored certificate %s: {{err}}", serial), err)
+		}
+		if certificate.ValidBefore != ssh.CertTimeInfinity && int64(certificate.ValidBefore) < now.Unix() {
+			continue
+		}
+
+		// Serials are only meaningful together with the key of the CA
+		// that signed the certificate
+		caKey := string(certificate.SignatureKey.Marshal())
+		i, ok := caIndexes[caKey]
+		if !ok {
+			i = len(revoked)
+			caIndexes[caKey] = i
+			revoked = append(revoked, krlRevokedCertificates{
+				CAKey: certificate.SignatureKey,
+			})
+		}
+		revoked[i].Serials = append(revoked[i].Serials, certificate.Serial)
+	}
+
+	return marshalKRL(version, now, "", revoked), nil
+}
+
+const pathRevokeHelpSyn = `Revoke a certificate signed by this backend.`
+
+const pathRevokeHelpDesc = `
+This path revokes the certificate with the given serial number. Revoked
+certificates are listed in the key revocation list returned by the "krl"
+endpoint until they expire, and their records are kept until removed by the
+"tidy" endpoint.
+`
+
+const pathKRLHelpSyn = `Fetch the OpenSSH key revocation list.`
+
+const pathKRLHelpDesc = `
+This returns the revoked certificates that have not expired yet as an
+OpenSSH Key Revocation List, in binary form. Hosts can fetch it periodically
+and point the RevokedKeys option of sshd to it.
+`
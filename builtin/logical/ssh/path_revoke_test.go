@@ -0,0 +1,299 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+)
+
+// testParseKRL returns the revoked serials of each CA key in a KRL
+func testParseKRL(t *testing.T, krl []byte) map[string][]uint64 {
+	t.Helper()
+
+	r := bytes.NewReader(krl)
+	readUint64 := func() uint64 {
+		var v uint64
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	if readUint64() != krlMagic {
+		t.Fatal("bad KRL magic")
+	}
+	var formatVersion uint32
+	binary.Read(r, binary.BigEndian, &formatVersion)
+	if formatVersion != krlFormatVersion {
+		t.Fatalf("bad KRL format version %d", formatVersion)
+	}
+	readUint64()            // krl_version
+	readUint64()            // generated_date
+	readUint64()            // flags
+	testReadKRLString(t, r) // reserved
+	testReadKRLString(t, r) // comment
+
+	revoked := make(map[string][]uint64)
+	for r.Len() > 0 {
+		sectionType, _ := r.ReadByte()
+		section := testReadKRLString(t, r)
+		if sectionType != krlSectionCertificates {
+			t.Fatalf("unexpected KRL section %d", sectionType)
+		}
+
+		sr := bytes.NewReader(section)
+		caKey := testReadKRLString(t, sr)
+		testReadKRLString(t, sr)
+		for sr.Len() > 0 {
+			certSectionType, _ := sr.ReadByte()
+			serials := bytes.NewReader(testReadKRLString(t, sr))
+			if certSectionType != krlCertSectionSerialList {
+				t.Fatalf("unexpected KRL certificate section %d", certSectionType)
+			}
+			for serials.Len() > 0 {
+				var serial uint64
+				binary.Read(serials, binary.BigEndian, &serial)
+				revoked[string(caKey)] = append(revoked[string(caKey)], serial)
+			}
+		}
+	}
+	return revoked
+}
+
+func testReadKRLString(t *testing.T, r *bytes.Reader) []byte {
+	t.Helper()
+	var l uint32
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		t.Fatal(err)
+	}
+	s := make([]byte, l)
+	if _, err := r.Read(s); l > 0 && err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSSH_RevokeAndKRL(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	mustRequest(logical.UpdateOperation, "config/ca", map[string]interface{}{
+		"generate_signing_key": true,
+	})
+	caPublicKey, _, _, _, err := ssh.ParseAuthorizedKey(mustRequest(logical.ReadOperation, "public_key", nil).Data[logical.HTTPRawBody].([]byte))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustRequest(logical.UpdateOperation, "roles/users", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+		"default_user":            "ubuntu",
+	})
+
+	sign := func() (string, string) {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sshPub, err := ssh.NewPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp := mustRequest(logical.UpdateOperation, "sign/users", map[string]interface{}{
+			"public_key": string(ssh.MarshalAuthorizedKey(sshPub)),
+		})
+		return resp.Data["serial_number"].(string), resp.Data["signed_key"].(string)
+	}
+	serial1, signedKey1 := sign()
+	serial2, _ := sign()
+
+	resp := mustRequest(logical.ListOperation, "certs/", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 2 {
+		t.Fatalf("bad certificate list: %#v", resp.Data)
+	}
+	resp = mustRequest(logical.ReadOperation, "cert/"+serial1, nil)
+	if resp.Data["signed_key"] != signedKey1 || resp.Data["key_id"] == "" || resp.Data["revocation_time"] != int64(0) {
+		t.Fatalf("bad certificate: %#v", resp.Data)
+	}
+
+	// Nothing is revoked yet
+	resp = mustRequest(logical.ReadOperation, "krl", nil)
+	if revoked := testParseKRL(t, resp.Data[logical.HTTPRawBody].([]byte)); len(revoked) != 0 {
+		t.Fatalf("bad KRL: %#v", revoked)
+	}
+
+	if resp, err := request(logical.UpdateOperation, "revoke", map[string]interface{}{"serial_number": "123abc"}); err == nil && !resp.IsError() {
+		t.Fatal("expected error revoking unknown certificate")
+	}
+	if resp, err := request(logical.UpdateOperation, "revoke", map[string]interface{}{"serial_number": "xyz"}); err == nil && !resp.IsError() {
+		t.Fatal("expected error revoking invalid serial")
+	}
+	resp = mustRequest(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": serial1,
+	})
+	revocationTime := resp.Data["revocation_time"].(int64)
+	if revocationTime == 0 {
+		t.Fatalf("bad revocation: %#v", resp.Data)
+	}
+
+	// Revoking again keeps the original revocation time
+	resp = mustRequest(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": serial1,
+	})
+	if resp.Data["revocation_time"] != revocationTime {
+		t.Fatalf("bad second revocation: %#v", resp.Data)
+	}
+	resp = mustRequest(logical.ReadOperation, "cert/"+serial1, nil)
+	if resp.Data["revocation_time"] != revocationTime {
+		t.Fatalf("bad revoked certificate: %#v", resp.Data)
+	}
+
+	resp = mustRequest(logical.ReadOperation, "krl", nil)
+	if resp.Data[logical.HTTPContentType] != "application/octet-stream" {
+		t.Fatalf("bad KRL content type: %#v", resp.Data)
+	}
+	revoked := testParseKRL(t, resp.Data[logical.HTTPRawBody].([]byte))
+	serials := revoked[string(caPublicKey.Marshal())]
+	if len(revoked) != 1 || len(serials) != 1 {
+		t.Fatalf("bad KRL: %#v", revoked)
+	}
+	certificate, err := parseCertificate(signedKey1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serials[0] != certificate.Serial {
+		t.Fatalf("bad revoked serial %x, expected %s", serials[0], serial1)
+	}
+	if serial1 == serial2 {
+		t.Fatal("serials are not unique")
+	}
+}
+
+func TestSSH_Tidy(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Backend(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := func(serial uint64, validBefore time.Time) string {
+		certificate := &ssh.Certificate{
+			Key:         signer.PublicKey(),
+			Serial:      serial,
+			CertType:    ssh.UserCert,
+			ValidBefore: uint64(validBefore.Unix()),
+		}
+		if err := certificate.SignCert(rand.Reader, signer); err != nil {
+			t.Fatal(err)
+		}
+		if err := storeCertificate(context.Background(), config.StorageView, certificate, string(ssh.MarshalAuthorizedKey(certificate))); err != nil {
+			t.Fatal(err)
+		}
+		return strconv.FormatUint(serial, 16)
+	}
+	expired := store(1, time.Now().Add(-100*time.Hour))
+	recent := store(2, time.Now().Add(-time.Hour))
+	valid := store(3, time.Now().Add(time.Hour))
+	for _, serial := range []string{expired, recent, valid} {
+		request(logical.UpdateOperation, "revoke", map[string]interface{}{
+			"serial_number": serial,
+		})
+	}
+	entry, err := logical.StorageEntryJSON(revokedStoragePrefix+"4", &revocationEntry{SerialNumber: "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.StorageView.Put(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+
+	request(logical.UpdateOperation, "tidy", nil)
+	for i := 0; atomic.LoadUint32(b.tidyCASGuard) != 0; i++ {
+		if i == 100 {
+			t.Fatal("tidy did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	list := func(prefix string) []string {
+		t.Helper()
+		keys, err := config.StorageView.List(context.Background(), prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	if certs := list(certStoragePrefix); !reflect.DeepEqual(certs, []string{recent, valid}) {
+		t.Fatalf("bad certificates after tidy: %v", certs)
+	}
+	if revoked := list(revokedStoragePrefix); !reflect.DeepEqual(revoked, []string{recent, valid}) {
+		t.Fatalf("bad revocations after tidy: %v", revoked)
+	}
+
+	// Only the certificates which have not expired are in the KRL
+	resp := request(logical.ReadOperation, "krl", nil)
+	serials := testParseKRL(t, resp.Data[logical.HTTPRawBody].([]byte))[string(signer.PublicKey().Marshal())]
+	if !reflect.DeepEqual(serials, []uint64{3}) {
+		t.Fatalf("bad KRL serials: %v", serials)
+	}
+}
@@ -39,6 +39,7 @@ type sshRole struct {
 	AllowedUsers           string            `mapstructure:"allowed_users" json:"allowed_users"`
 	AllowedUsersTemplate   bool              `mapstructure:"allowed_users_template" json:"allowed_users_template"`
 	AllowedDomains         string            `mapstructure:"allowed_domains" json:"allowed_domains"`
+	AllowedDomainsTemplate bool              `mapstructure:"allowed_domains_template" json:"allowed_domains_template"`
 	KeyOptionSpecs         string            `mapstructure:"key_option_specs" json:"key_option_specs"`
 	MaxTTL                 string            `mapstructure:"max_ttl" json:"max_ttl"`
 	TTL                    string            `mapstructure:"ttl" json:"ttl"`
@@ -54,6 +55,7 @@ type sshRole struct {
 	KeyIDFormat            string            `mapstructure:"key_id_format" json:"key_id_format"`
 	AllowedUserKeyLengths  map[string]int    `mapstructure:"allowed_user_key_lengths" json:"allowed_user_key_lengths"`
 	AlgorithmSigner        string            `mapstructure:"algorithm_signer" json:"algorithm_signer"`
+	CAKeyName              string            `mapstructure:"ca_key_name" json:"ca_key_name"`
 }
 
 func pathListRoles(b *backend) *framework.Path {
@@ -201,6 +203,16 @@ func pathRoles(b *backend) *framework.Path {
 				valid host. If only certain domains are allowed, then this list enforces it.
 				`,
 			},
+			"allowed_domains_template": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `
+				[Not applicable for Dynamic type] [Not applicable for OTP type] [Optional for CA type]
+				If set, Allowed domains can be specified using identity template policies,
+				binding the hosts a token may sign keys for to its entity.
+				Non-templated domains are also permitted.
+				`,
+				Default: false,
+			},
 			"key_option_specs": &framework.FieldSchema{
 				Type: framework.TypeString,
 				Description: `
@@ -342,6 +354,14 @@ func pathRoles(b *backend) *framework.Path {
 					Name: "Signing Algorithm",
 				},
 			},
+			"ca_key_name": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `
+				[Not applicable for Dynamic type] [Not applicable for OTP type] [Optional for CA type]
+				Name of the CA key that signs the certificates of this role. If not set,
+				the current CA key of the mount is used.
+				`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -495,6 +515,16 @@ func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, d *fr
 		if errorResponse != nil {
 			return errorResponse, nil
 		}
+
+		if role.CAKeyName != "" {
+			caKeyEntry, err := getCAKey(ctx, req.Storage, role.CAKeyName)
+			if err != nil {
+				return nil, err
+			}
+			if caKeyEntry == nil {
+				return logical.ErrorResponse(fmt.Sprintf("CA key %q not found", role.CAKeyName)), nil
+			}
+		}
 		roleEntry = *role
 	} else {
 		return logical.ErrorResponse("invalid key type"), nil
@@ -522,6 +552,7 @@ func (b *backend) createCARole(allowedUsers, defaultUser, signer string, data *f
 		AllowedUsers:           allowedUsers,
 		AllowedUsersTemplate:   data.Get("allowed_users_template").(bool),
 		AllowedDomains:         data.Get("allowed_domains").(string),
+		AllowedDomainsTemplate: data.Get("allowed_domains_template").(bool),
 		DefaultUser:            defaultUser,
 		AllowBareDomains:       data.Get("allow_bare_domains").(bool),
 		AllowSubdomains:        data.Get("allow_subdomains").(bool),
@@ -529,6 +560,7 @@ func (b *backend) createCARole(allowedUsers, defaultUser, signer string, data *f
 		KeyIDFormat:            data.Get("key_id_format").(string),
 		KeyType:                KeyTypeCA,
 		AlgorithmSigner:        signer,
+		CAKeyName:              data.Get("ca_key_name").(string),
 	}
 
 	if !role.AllowUserCertificates && !role.AllowHostCertificates {
@@ -604,6 +636,7 @@ func (b *backend) parseRole(role *sshRole) (map[string]interface{}, error) {
 			"allowed_users":            role.AllowedUsers,
 			"allowed_users_template":   role.AllowedUsersTemplate,
 			"allowed_domains":          role.AllowedDomains,
+			"allowed_domains_template": role.AllowedDomainsTemplate,
 			"default_user":             role.DefaultUser,
 			"ttl":                      int64(ttl.Seconds()),
 			"max_ttl":                  int64(maxTTL.Seconds()),
@@ -621,6 +654,7 @@ func (b *backend) parseRole(role *sshRole) (map[string]interface{}, error) {
 			"default_extensions":       role.DefaultExtensions,
 			"allowed_user_key_lengths": role.AllowedUserKeyLengths,
 			"algorithm_signer":         role.AlgorithmSigner,
+			"ca_key_name":              role.CAKeyName,
 		}
 	case KeyTypeDynamic:
 		result = map[string]interface{}{
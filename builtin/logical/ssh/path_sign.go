@@ -102,10 +102,10 @@ func (b *backend) pathSign(ctx context.Context, req *logical.Request, data *fram
 		return logical.ErrorResponse(fmt.Sprintf("Unknown role: %s", roleName)), nil
 	}
 
-	return b.pathSignCertificate(ctx, req, data, role)
+	return b.pathSignCertificate(ctx, req, data, role, data.Get("cert_type").(string))
 }
 
-func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request, data *framework.FieldData, role *sshRole) (*logical.Response, error) {
+func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request, data *framework.FieldData, role *sshRole, requestedCertificateType string) (*logical.Response, error) {
 	publicKey := data.Get("public_key").(string)
 	if publicKey == "" {
 		return logical.ErrorResponse("missing public_key"), nil
@@ -128,19 +128,19 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	certificateType, err := b.calculateCertificateType(data, role)
+	certificateType, err := b.calculateCertificateType(requestedCertificateType, role)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
 	var parsedPrincipals []string
 	if certificateType == ssh.HostCert {
-		parsedPrincipals, err = b.calculateValidPrincipals(data, req, role, "", role.AllowedDomains, validateValidPrincipalForHosts(role))
+		parsedPrincipals, err = b.calculateValidPrincipals(data, req, role.AllowedDomainsTemplate, "", role.AllowedDomains, validateValidPrincipalForHosts(role))
 		if err != nil {
 			return logical.ErrorResponse(err.Error()), nil
 		}
 	} else {
-		parsedPrincipals, err = b.calculateValidPrincipals(data, req, role, role.DefaultUser, role.AllowedUsers, strutil.StrListContains)
+		parsedPrincipals, err = b.calculateValidPrincipals(data, req, role.AllowedUsersTemplate, role.DefaultUser, role.AllowedUsers, strutil.StrListContains)
 		if err != nil {
 			return logical.ErrorResponse(err.Error()), nil
 		}
@@ -161,15 +161,12 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	privateKeyEntry, err := caKey(ctx, req.Storage, caPrivateKey)
+	caKeyEntry, err := signingCAKey(ctx, req.Storage, role)
 	if err != nil {
 		return nil, errwrap.Wrapf("failed to read CA private key: {{err}}", err)
 	}
-	if privateKeyEntry == nil || privateKeyEntry.Key == "" {
-		return nil, fmt.Errorf("failed to read CA private key")
-	}
 
-	signer, err := ssh.ParsePrivateKey([]byte(privateKeyEntry.Key))
+	signer, err := ssh.ParsePrivateKey([]byte(caKeyEntry.PrivateKey))
 	if err != nil {
 		return nil, errwrap.Wrapf("failed to parse stored CA private key: {{err}}", err)
 	}
@@ -196,6 +193,10 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		return nil, fmt.Errorf("error marshaling signed certificate")
 	}
 
+	if err := storeCertificate(ctx, req.Storage, certificate, string(signedSSHCertificate)); err != nil {
+		return nil, errwrap.Wrapf("unable to store certificate locally: {{err}}", err)
+	}
+
 	response := &logical.Response{
 		Data: map[string]interface{}{
 			"serial_number": strconv.FormatUint(certificate.Serial, 16),
@@ -206,7 +207,7 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 	return response, nil
 }
 
-func (b *backend) calculateValidPrincipals(data *framework.FieldData, req *logical.Request, role *sshRole, defaultPrincipal, principalsAllowedByRole string, validatePrincipal func([]string, string) bool) ([]string, error) {
+func (b *backend) calculateValidPrincipals(data *framework.FieldData, req *logical.Request, templated bool, defaultPrincipal, principalsAllowedByRole string, validatePrincipal func([]string, string) bool) ([]string, error) {
 	validPrincipals := ""
 	validPrincipalsRaw, ok := data.GetOk("valid_principals")
 	if ok {
@@ -219,7 +220,7 @@ func (b *backend) calculateValidPrincipals(data *framework.FieldData, req *logic
 	// Build list of allowed Principals from template and static principalsAllowedByRole
 	var allowedPrincipals []string
 	for _, principal := range strutil.RemoveDuplicates(strutil.ParseStringSlice(principalsAllowedByRole, ","), false) {
-		if role.AllowedUsersTemplate {
+		if templated {
 			// Look for templating markers {{ .* }}
 			matched, _ := regexp.MatchString(`^{{.+?}}$`, principal)
 			if matched {
@@ -281,9 +282,7 @@ func validateValidPrincipalForHosts(role *sshRole) func([]string, string) bool {
 	}
 }
 
-func (b *backend) calculateCertificateType(data *framework.FieldData, role *sshRole) (uint32, error) {
-	requestedCertificateType := data.Get("cert_type").(string)
-
+func (b *backend) calculateCertificateType(requestedCertificateType string, role *sshRole) (uint32, error) {
 	var certificateType uint32
 	switch requestedCertificateType {
 	case "user":
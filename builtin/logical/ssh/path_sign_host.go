@@ -0,0 +1,113 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+func pathSignHost(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "sign-host/" + framework.GenericNameWithAtRegex("role"),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathSignHost,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"role": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The desired role with configuration for this request.`,
+			},
+			"ttl": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The requested Time To Live for the SSH certificate;
+sets the expiration date. If not specified
+the role default, backend default, or system
+default TTL is used, in that order. Cannot
+be later than the role max TTL.`,
+			},
+			"public_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `SSH host public key that should be signed.`,
+			},
+			"valid_principals": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Hostnames that the certificate should be signed for. Required.`,
+			},
+			"key_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Key id that the created certificate should have. If not specified, the display name of the token will be used.`,
+			},
+			"critical_options": &framework.FieldSchema{
+				Type:        framework.TypeMap,
+				Description: `Critical options that the certificate should be signed for.`,
+			},
+			"extensions": &framework.FieldSchema{
+				Type:        framework.TypeMap,
+				Description: `Extensions that the certificate should be signed for.`,
+			},
+		},
+
+		HelpSynopsis:    pathSignHostHelpSyn,
+		HelpDescription: pathSignHostHelpDesc,
+	}
+}
+
+func (b *backend) pathSignHost(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role").(string)
+
+	// Get the role
+	role, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("Unknown role: %s", roleName)), nil
+	}
+	if role.KeyType != KeyTypeCA {
+		return logical.ErrorResponse(fmt.Sprintf("role %q is not a CA role", roleName)), nil
+	}
+
+	// A host certificate without principals is valid for any host, which a
+	// host proving its own identity never needs
+	if strings.TrimSpace(data.Get("valid_principals").(string)) == "" {
+		return logical.ErrorResponse("missing valid_principals"), nil
+	}
+
+	resp, err := b.pathSignCertificate(ctx, req, data, role, "host")
+	if err != nil || resp == nil || resp.IsError() {
+		return resp, err
+	}
+
+	// Hand out the CA public key along with the certificate, so that the host
+	// can also trust the user certificates signed by this backend
+	certificate, err := parseCertificate(resp.Data["signed_key"].(string))
+	if err != nil {
+		return nil, err
+	}
+	resp.Data["ca_public_key"] = string(ssh.MarshalAuthorizedKey(certificate.SignatureKey))
+
+	return resp, nil
+}
+
+const pathSignHostHelpSyn = `Request signing an SSH host key using a certain role.`
+
+const pathSignHostHelpDesc = `
+This path signs the public key of a host with the CA of this backend, so
+that clients trusting the CA can connect to the host without accepting its
+key on first use. The certificate is always a host certificate, so the role
+must allow host certificates.
+
+Hosts authenticate with a token of their own. When the role sets
+"allowed_domains_template", the entries of "allowed_domains" may be identity
+templates such as "{{identity.entity.metadata.hostname}}", which binds the
+hostnames a token can request to the entity of the token.
+
+The CA public key is returned along with the certificate, for use with the
+TrustedUserCAKeys option of sshd.
+`
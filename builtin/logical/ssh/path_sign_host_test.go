@@ -0,0 +1,135 @@
+package ssh
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSH_SignHost(t *testing.T) {
+	sysView := logical.TestSystemView()
+	sysView.EntityVal = &logical.Entity{
+		ID:       "host-entity",
+		Name:     "web1",
+		Metadata: map[string]string{"hostname": "web1.example.com"},
+	}
+
+	config := logical.TestBackendConfig()
+	config.System = sysView
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path, entityID string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      path,
+			EntityID:  entityID,
+			Data:      data,
+		})
+	}
+	mustRequest := func(op logical.Operation, path, entityID string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, entityID, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	mustFail := func(op logical.Operation, path, entityID string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(op, path, entityID, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+		}
+	}
+
+	mustRequest(logical.UpdateOperation, "config/ca", "", map[string]interface{}{
+		"generate_signing_key": true,
+	})
+	caPublicKey := string(mustRequest(logical.ReadOperation, "public_key", "", nil).Data[logical.HTTPRawBody].([]byte))
+
+	mustRequest(logical.UpdateOperation, "roles/hosts", "", map[string]interface{}{
+		"key_type":                 "ca",
+		"allow_host_certificates":  true,
+		"allowed_domains":          "{{identity.entity.metadata.hostname}}",
+		"allowed_domains_template": true,
+		"allow_bare_domains":       true,
+	})
+	mustRequest(logical.UpdateOperation, "roles/users", "", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+	})
+
+	hostPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPublicKey, err := ssh.NewPublicKey(hostPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := string(ssh.MarshalAuthorizedKey(sshPublicKey))
+
+	// The host may only request the hostname bound to its entity
+	resp := mustRequest(logical.UpdateOperation, "sign-host/hosts", "host-entity", map[string]interface{}{
+		"public_key":       publicKey,
+		"valid_principals": "web1.example.com",
+	})
+	signedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Data["signed_key"].(string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := signedKey.(*ssh.Certificate)
+	if cert.CertType != ssh.HostCert || len(cert.ValidPrincipals) != 1 || cert.ValidPrincipals[0] != "web1.example.com" {
+		t.Fatalf("bad certificate: %#v", cert)
+	}
+	if resp.Data["ca_public_key"] != caPublicKey {
+		t.Fatalf("bad CA public key: %#v", resp.Data)
+	}
+
+	mustFail(logical.UpdateOperation, "sign-host/hosts", "host-entity", map[string]interface{}{
+		"public_key":       publicKey,
+		"valid_principals": "web2.example.com",
+	})
+	mustFail(logical.UpdateOperation, "sign-host/hosts", "", map[string]interface{}{
+		"public_key":       publicKey,
+		"valid_principals": "web1.example.com",
+	})
+	mustFail(logical.UpdateOperation, "sign-host/hosts", "host-entity", map[string]interface{}{
+		"public_key": publicKey,
+	})
+	mustFail(logical.UpdateOperation, "sign-host/users", "host-entity", map[string]interface{}{
+		"public_key":       publicKey,
+		"valid_principals": "web1.example.com",
+	})
+
+	// The known_hosts line trusts the CA for the requested hosts
+	resp = mustRequest(logical.ReadOperation, "known_hosts", "", map[string]interface{}{
+		"host_patterns": "*.example.com,10.0.0.*",
+	})
+	line := string(resp.Data[logical.HTTPRawBody].([]byte))
+	marker, hosts, key, _, _, err := ssh.ParseKnownHosts([]byte(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if marker != "cert-authority" || strings.Join(hosts, ",") != "*.example.com,10.0.0.*" || string(ssh.MarshalAuthorizedKey(key)) != caPublicKey {
+		t.Fatalf("bad known_hosts line: %q", line)
+	}
+	resp = mustRequest(logical.ReadOperation, "known_hosts", "", nil)
+	if line := string(resp.Data[logical.HTTPRawBody].([]byte)); !strings.HasPrefix(line, "@cert-authority * ") {
+		t.Fatalf("bad known_hosts line: %q", line)
+	}
+	mustFail(logical.ReadOperation, "known_hosts", "", map[string]interface{}{
+		"host_patterns": "bad pattern",
+	})
+}
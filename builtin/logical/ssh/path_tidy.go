@@ -0,0 +1,143 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+const defaultTidySafetyBuffer = 72 * time.Hour
+
+func pathTidy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy$",
+		Fields: map[string]*framework.FieldSchema{
+			"safety_buffer": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The amount of extra time that must have passed
+beyond certificate expiration before it is removed
+from the backend storage. Defaults to 72 hours.`,
+				Default: int(defaultTidySafetyBuffer / time.Second),
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTidyWrite,
+		},
+
+		HelpSynopsis:    pathTidyHelpSyn,
+		HelpDescription: pathTidyHelpDesc,
+	}
+}
+
+func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	// If we are a performance standby forward the request to the active node
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	safetyBuffer := data.Get("safety_buffer").(int)
+	if safetyBuffer < 1 {
+		return logical.ErrorResponse("safety_buffer must be greater than zero"), nil
+	}
+
+	if !atomic.CompareAndSwapUint32(b.tidyCASGuard, 0, 1) {
+		resp := &logical.Response{}
+		resp.AddWarning("Tidy operation already in progress.")
+		return resp, nil
+	}
+
+	go func() {
+		defer atomic.StoreUint32(b.tidyCASGuard, 0)
+
+		// Don't cancel when the original client request goes away
+		if err := b.tidyCertificates(context.Background(), req.Storage, time.Duration(safetyBuffer)*time.Second); err != nil {
+			b.Logger().Error("error tidying certificates", "error", err)
+		}
+	}()
+
+	resp := &logical.Response{}
+	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
+	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+}
+
+// tidyCertificates removes the records of the certificates, revoked or not,
+// which expired more than safetyBuffer ago, as well as revocations of
+// certificates which are no longer recorded
+func (b *backend) tidyCertificates(ctx context.Context, s logical.Storage, safetyBuffer time.Duration) error {
+	// Revocations are written under this lock, so that a certificate
+	// cannot be revoked as its record is removed
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	cutoff := time.Now().Add(-safetyBuffer).Unix()
+
+	serials, err := s.List(ctx, certStoragePrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list certificates: {{err}}", err)
+	}
+	var deleted int
+	for _, serial := range serials {
+		certEntry, err := getCertificateEntry(ctx, s, serial)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to read certificate %s: {{err}}", serial), err)
+		}
+		if certEntry == nil {
+			continue
+		}
+
+		certificate, err := parseCertificate(certEntry.SignedKey)
+		if err != nil {
+			b.Logger().Warn("removing unparsable certificate", "serial_number", serial, "error", err)
+		} else if certificate.ValidBefore == ssh.CertTimeInfinity || int64(certificate.ValidBefore) >= cutoff {
+			continue
+		}
+
+		if err := s.Delete(ctx, revokedStoragePrefix+serial); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to delete revocation of certificate %s: {{err}}", serial), err)
+		}
+		if err := s.Delete(ctx, certStoragePrefix+serial); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to delete certificate %s: {{err}}", serial), err)
+		}
+		deleted++
+	}
+
+	revoked, err := s.List(ctx, revokedStoragePrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list revoked certificates: {{err}}", err)
+	}
+	for _, serial := range revoked {
+		certEntry, err := s.Get(ctx, certStoragePrefix+serial)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to read certificate %s: {{err}}", serial), err)
+		}
+		if certEntry != nil {
+			continue
+		}
+		if err := s.Delete(ctx, revokedStoragePrefix+serial); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to delete revocation of certificate %s: {{err}}", serial), err)
+		}
+	}
+
+	b.Logger().Debug("tidied certificates", "num_checked", len(serials), "num_deleted", deleted)
+	return nil
+}
+
+const pathTidyHelpSyn = `
+Tidy up the records of expired certificates.
+`
+
+const pathTidyHelpDesc = `
+This endpoint removes the records of the certificates signed by the backend,
+revoked or not, once they have expired for longer than the safety buffer.
+Expired certificates are already left out of the KRL, so this only bounds the
+growth of the storage of the backend.
+`
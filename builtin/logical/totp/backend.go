@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	cache "github.com/patrickmn/go-cache"
 )
@@ -40,6 +41,7 @@ func Backend() *backend {
 	}
 
 	b.usedCodes = cache.New(0, 30*time.Second)
+	b.keyLocks = locksutil.CreateLocks()
 
 	return &b
 }
@@ -48,8 +50,13 @@ type backend struct {
 	*framework.Backend
 
 	usedCodes *cache.Cache
+
+	// keyLocks serialize the updates of a key, so that the counter of a
+	// HOTP key and the last used step of a TOTP key each accept a code once
+	keyLocks []*locksutil.LockEntry
 }
 
 const backendHelp = `
-The TOTP backend dynamically generates time-based one-time use passwords.
+The TOTP backend dynamically generates time-based and counter-based one-time
+use passwords.
 `
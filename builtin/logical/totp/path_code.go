@@ -2,13 +2,16 @@ package totp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	otplib "github.com/pquerna/otp"
+	hotplib "github.com/pquerna/otp/hotp"
 	totplib "github.com/pquerna/otp/totp"
 )
 
@@ -22,7 +25,7 @@ func pathCode(b *backend) *framework.Path {
 			},
 			"code": &framework.FieldSchema{
 				Type:        framework.TypeString,
-				Description: "TOTP code to be validated.",
+				Description: "TOTP or HOTP code to be validated.",
 			},
 		},
 
@@ -39,6 +42,10 @@ func pathCode(b *backend) *framework.Path {
 func (b *backend) pathReadCode(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
 
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
 	// Get the key
 	key, err := b.Key(ctx, req.Storage, name)
 	if err != nil {
@@ -48,20 +55,39 @@ func (b *backend) pathReadCode(ctx context.Context, req *logical.Request, data *
 		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
 	}
 
-	// Generate password using totp library
-	totpToken, err := totplib.GenerateCodeCustom(key.Key, time.Now(), totplib.ValidateOpts{
-		Period:    key.Period,
-		Digits:    key.Digits,
-		Algorithm: key.Algorithm,
-	})
-	if err != nil {
-		return nil, err
+	var token string
+	switch key.keyType() {
+	case keyTypeHOTP:
+		// Generate password using hotp library; each code is handed out
+		// once, so the counter moves on
+		token, err = hotplib.GenerateCodeCustom(key.Key, key.Counter, hotplib.ValidateOpts{
+			Digits:    key.Digits,
+			Algorithm: key.Algorithm,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		key.Counter++
+		if err := b.putKey(ctx, req.Storage, name, key); err != nil {
+			return nil, err
+		}
+	default:
+		// Generate password using totp library
+		token, err = totplib.GenerateCodeCustom(key.Key, time.Now(), totplib.ValidateOpts{
+			Period:    key.Period,
+			Digits:    key.Digits,
+			Algorithm: key.Algorithm,
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Return the secret
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"code": totpToken,
+			"code": token,
 		},
 	}, nil
 }
@@ -75,6 +101,10 @@ func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, da
 		return logical.ErrorResponse("the code value is required"), nil
 	}
 
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
 	// Get the key's stored values
 	key, err := b.Key(ctx, req.Storage, name)
 	if err != nil {
@@ -84,44 +114,139 @@ func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, da
 		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
 	}
 
+	var valid bool
+	switch key.keyType() {
+	case keyTypeHOTP:
+		valid, err = b.validateHOTP(ctx, req.Storage, name, key, code)
+	default:
+		valid, err = b.validateTOTP(ctx, req.Storage, name, key, code)
+	}
+	if err == errCodeUsed {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"valid": valid,
+		},
+	}, nil
+}
+
+// validateHOTP checks the code against the counter of the key and the
+// look-ahead window after it. A match moves the stored counter past the
+// matching value, which both resynchronizes with the token generator and
+// refuses the code from then on.
+func (b *backend) validateHOTP(ctx context.Context, s logical.Storage, name string, key *keyEntry, code string) (bool, error) {
+	for i := uint64(0); i <= uint64(key.LookAhead); i++ {
+		counter := key.Counter + i
+		valid, err := hotplib.ValidateCustom(code, counter, key.Key, hotplib.ValidateOpts{
+			Digits:    key.Digits,
+			Algorithm: key.Algorithm,
+		})
+		if err == otplib.ErrValidateInputInvalidLength {
+			return false, nil
+		}
+		if err != nil {
+			return false, errwrap.Wrapf("an error occurred while validating the code: {{err}}", err)
+		}
+		if !valid {
+			continue
+		}
+
+		key.Counter = counter + 1
+		if err := b.putKey(ctx, s, name, key); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// validateTOTP checks the code against the time steps allowed by the skew
+// of the key. The step of the last accepted code is stored with the key and
+// a code of that or an earlier step is refused, so a code cannot be replayed
+// within its window.
+func (b *backend) validateTOTP(ctx context.Context, s logical.Storage, name string, key *keyEntry, code string) (bool, error) {
 	usedName := fmt.Sprintf("%s_%s", name, code)
 
 	_, ok := b.usedCodes.Get(usedName)
 	if ok {
-		return logical.ErrorResponse("code already used; wait until the next time period"), nil
+		return false, errCodeUsed
 	}
 
-	valid, err := totplib.ValidateCustom(code, key.Key, time.Now(), totplib.ValidateOpts{
-		Period:    key.Period,
-		Skew:      key.Skew,
-		Digits:    key.Digits,
-		Algorithm: key.Algorithm,
-	})
-	if err != nil && err != otplib.ErrValidateInputInvalidLength {
-		return logical.ErrorResponse("an error occurred while validating the code"), err
+	period := key.Period
+	if period == 0 {
+		period = 30
+	}
+	current := uint64(time.Now().Unix()) / uint64(period)
+
+	steps := []uint64{current}
+	for i := uint64(1); i <= uint64(key.Skew); i++ {
+		steps = append(steps, current+i)
+		if current >= i {
+			steps = append(steps, current-i)
+		}
+	}
+
+	var valid bool
+	var step uint64
+	for _, step = range steps {
+		var err error
+		valid, err = hotplib.ValidateCustom(code, step, key.Key, hotplib.ValidateOpts{
+			Digits:    key.Digits,
+			Algorithm: key.Algorithm,
+		})
+		if err == otplib.ErrValidateInputInvalidLength {
+			break
+		}
+		if err != nil {
+			return false, errwrap.Wrapf("an error occurred while validating the code: {{err}}", err)
+		}
+		if valid {
+			break
+		}
 	}
 
 	// Take the key skew, add two for behind and in front, and multiple that by
 	// the period to cover the full possibility of the validity of the key
-	err = b.usedCodes.Add(usedName, nil, time.Duration(
+	err := b.usedCodes.Add(usedName, nil, time.Duration(
 		int64(time.Second)*
-			int64(key.Period)*
+			int64(period)*
 			int64((2+key.Skew))))
 	if err != nil {
-		return nil, errwrap.Wrapf("error adding code to used cache: {{err}}", err)
+		return false, errwrap.Wrapf("error adding code to used cache: {{err}}", err)
 	}
 
-	return &logical.Response{
-		Data: map[string]interface{}{
-			"valid": valid,
-		},
-	}, nil
+	if !valid {
+		return false, nil
+	}
+	if step <= key.LastUsedStep {
+		return false, errCodeUsed
+	}
+
+	key.LastUsedStep = step
+	if err := b.putKey(ctx, s, name, key); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
+var errCodeUsed = errors.New("code already used; wait until the next time period")
+
 const pathCodeHelpSyn = `
-Request time-based one-time use password or validate a password for a certain key .
+Request a one-time use password or validate a password for a certain key.
 `
 const pathCodeHelpDesc = `
-This path generates and validates time-based one-time use passwords for a certain key. 
+This path generates and validates one-time use passwords for a certain key.
+
+For time-based keys, a code is accepted once: after a successful validation,
+codes of the same or an earlier time step are refused. For counter-based
+keys, reading a code advances the counter of the key, and validation tries
+the counter and the look-ahead window after it. A valid code moves the
+counter past the matching value.
 
 `
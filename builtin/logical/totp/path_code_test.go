@@ -0,0 +1,238 @@
+package totp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	otplib "github.com/pquerna/otp"
+	hotplib "github.com/pquerna/otp/hotp"
+	totplib "github.com/pquerna/otp/totp"
+)
+
+const testCodeKey = "HTXT7KJFVNAJUPYWQRWMNVQE5AF5YZI2"
+
+func testCodeBackend(t *testing.T) (logical.Backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func TestBackend_HOTP(t *testing.T) {
+	b, storage := testCodeBackend(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	mustFail := func(op logical.Operation, path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+		}
+	}
+	validate := func(code string) bool {
+		t.Helper()
+		resp := mustRequest(logical.UpdateOperation, "code/hotp", map[string]interface{}{
+			"code": code,
+		})
+		return resp.Data["valid"].(bool)
+	}
+	hotpCode := func(counter uint64) string {
+		t.Helper()
+		code, err := hotplib.GenerateCode(testCodeKey, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	mustFail(logical.UpdateOperation, "keys/bad", map[string]interface{}{
+		"type": "motp",
+		"key":  testCodeKey,
+	})
+	mustFail(logical.UpdateOperation, "keys/bad", map[string]interface{}{
+		"type":    "hotp",
+		"key":     testCodeKey,
+		"counter": -1,
+	})
+
+	mustRequest(logical.UpdateOperation, "keys/hotp", map[string]interface{}{
+		"type":       "hotp",
+		"key":        testCodeKey,
+		"counter":    5,
+		"look_ahead": 3,
+	})
+	resp := mustRequest(logical.ReadOperation, "keys/hotp", nil)
+	if resp.Data["type"] != "hotp" || resp.Data["counter"] != uint64(5) || resp.Data["look_ahead"] != uint(3) {
+		t.Fatalf("bad key: %#v", resp.Data)
+	}
+
+	// A code behind the counter is refused
+	if validate(hotpCode(4)) {
+		t.Fatal("code behind the counter was accepted")
+	}
+
+	// A code at the counter is accepted once
+	code := hotpCode(5)
+	if !validate(code) {
+		t.Fatal("code at the counter was refused")
+	}
+	if validate(code) {
+		t.Fatal("code was accepted twice")
+	}
+
+	// The look-ahead window resynchronizes the counter
+	if validate(hotpCode(10)) {
+		t.Fatal("code past the look-ahead window was accepted")
+	}
+	if !validate(hotpCode(9)) {
+		t.Fatal("code in the look-ahead window was refused")
+	}
+	if validate(hotpCode(8)) {
+		t.Fatal("code skipped by the resynchronization was accepted")
+	}
+	resp = mustRequest(logical.ReadOperation, "keys/hotp", nil)
+	if resp.Data["counter"] != uint64(10) {
+		t.Fatalf("bad counter: %#v", resp.Data)
+	}
+
+	// Reading a code consumes the counter
+	resp = mustRequest(logical.ReadOperation, "code/hotp", nil)
+	if resp.Data["code"] != hotpCode(10) {
+		t.Fatalf("bad code: %#v", resp.Data)
+	}
+	resp = mustRequest(logical.ReadOperation, "code/hotp", nil)
+	if resp.Data["code"] != hotpCode(11) {
+		t.Fatalf("bad code: %#v", resp.Data)
+	}
+
+	// Concurrent validations of the same code accept it once
+	code = hotpCode(12)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := request(logical.UpdateOperation, "code/hotp", map[string]interface{}{
+				"code": code,
+			})
+			if err == nil && resp != nil && resp.Data["valid"] == true {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 1 {
+		t.Fatalf("code was accepted %d times", accepted)
+	}
+
+	// Generated keys carry the counter in their url
+	resp = mustRequest(logical.UpdateOperation, "keys/generated", map[string]interface{}{
+		"type":         "hotp",
+		"generate":     true,
+		"issuer":       "Vault",
+		"account_name": "Test",
+		"counter":      7,
+		"qr_size":      0,
+	})
+	key, err := otplib.NewKeyFromURL(resp.Data["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Type() != "hotp" {
+		t.Fatalf("bad url: %s", key)
+	}
+	mustRequest(logical.UpdateOperation, "keys/imported", map[string]interface{}{
+		"url": key.String(),
+	})
+	resp = mustRequest(logical.ReadOperation, "keys/imported", nil)
+	if resp.Data["type"] != "hotp" || resp.Data["counter"] != uint64(7) {
+		t.Fatalf("bad imported key: %#v", resp.Data)
+	}
+}
+
+func TestBackend_TOTPReplay(t *testing.T) {
+	b, storage := testCodeBackend(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("bad: path: %s err: %v", path, err)
+		}
+		return resp
+	}
+
+	request(logical.UpdateOperation, "keys/totp", map[string]interface{}{
+		"key": testCodeKey,
+	})
+	resp := request(logical.ReadOperation, "keys/totp", nil)
+	if resp.Data["type"] != "totp" {
+		t.Fatalf("bad key: %#v", resp.Data)
+	}
+
+	now := time.Now()
+	code, err := totplib.GenerateCode(testCodeKey, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = request(logical.UpdateOperation, "code/totp", map[string]interface{}{
+		"code": code,
+	})
+	if resp.IsError() || resp.Data["valid"] != true {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The code of the previous step is within the skew, but a later step
+	// was already used
+	previous, err := totplib.GenerateCode(testCodeKey, now.Add(-30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous != code {
+		resp = request(logical.UpdateOperation, "code/totp", map[string]interface{}{
+			"code": previous,
+		})
+		if !resp.IsError() {
+			t.Fatalf("code of an earlier step was accepted: %#v", resp)
+		}
+	}
+
+	// The used step survives the in-memory cache of used codes
+	b.(*backend).usedCodes.Flush()
+	resp = request(logical.UpdateOperation, "code/totp", map[string]interface{}{
+		"code": code,
+	})
+	if !resp.IsError() {
+		t.Fatalf("code was accepted twice: %#v", resp)
+	}
+}
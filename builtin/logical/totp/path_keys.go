@@ -13,8 +13,10 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	otplib "github.com/pquerna/otp"
+	hotplib "github.com/pquerna/otp/hotp"
 	totplib "github.com/pquerna/otp/totp"
 )
 
@@ -40,6 +42,12 @@ func pathKeys(b *backend) *framework.Path {
 				Description: "Name of the key.",
 			},
 
+			"type": {
+				Type:        framework.TypeString,
+				Default:     keyTypeTOTP,
+				Description: `The type of one-time password generated by the key. Options include "totp" for time-based (RFC 6238) and "hotp" for counter-based (RFC 4226) passwords.`,
+			},
+
 			"generate": {
 				Type:        framework.TypeBool,
 				Default:     false,
@@ -97,6 +105,18 @@ func pathKeys(b *backend) *framework.Path {
 				Description: `The number of delay periods that are allowed when validating a TOTP token. This value can either be 0 or 1. Only used if generate is true.`,
 			},
 
+			"counter": {
+				Type:        framework.TypeInt,
+				Default:     0,
+				Description: `The initial counter of the HOTP token calculation. Only used if type is hotp.`,
+			},
+
+			"look_ahead": {
+				Type:        framework.TypeInt,
+				Default:     10,
+				Description: `The number of counter values after the stored counter that are tried when validating a HOTP token, to resynchronize with a token generator that has moved ahead. Only used if type is hotp.`,
+			},
+
 			"qr_size": {
 				Type:        framework.TypeInt,
 				Default:     200,
@@ -137,8 +157,22 @@ func (b *backend) Key(ctx context.Context, s logical.Storage, n string) (*keyEnt
 	return &result, nil
 }
 
+func (b *backend) putKey(ctx context.Context, s logical.Storage, n string, key *keyEntry) error {
+	entry, err := logical.StorageEntryJSON("key/"+n, key)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
 func (b *backend) pathKeyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	err := req.Storage.Delete(ctx, "key/"+data.Get("name").(string))
+	name := data.Get("name").(string)
+
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	err := req.Storage.Delete(ctx, "key/"+name)
 	if err != nil {
 		return nil, err
 	}
@@ -159,15 +193,24 @@ func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *f
 	algorithm := key.Algorithm.String()
 
 	// Return values of key
-	return &logical.Response{
+	resp := &logical.Response{
 		Data: map[string]interface{}{
+			"type":         key.keyType(),
 			"issuer":       key.Issuer,
 			"account_name": key.AccountName,
-			"period":       key.Period,
 			"algorithm":    algorithm,
 			"digits":       key.Digits,
 		},
-	}, nil
+	}
+	switch key.keyType() {
+	case keyTypeHOTP:
+		resp.Data["counter"] = key.Counter
+		resp.Data["look_ahead"] = key.LookAhead
+	default:
+		resp.Data["period"] = key.Period
+	}
+
+	return resp, nil
 }
 
 func (b *backend) pathKeyList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -181,6 +224,7 @@ func (b *backend) pathKeyList(ctx context.Context, req *logical.Request, d *fram
 
 func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
+	keyType := data.Get("type").(string)
 	generate := data.Get("generate").(bool)
 	exported := data.Get("exported").(bool)
 	keyString := data.Get("key").(string)
@@ -192,6 +236,8 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 	skew := data.Get("skew").(int)
 	qrSize := data.Get("qr_size").(int)
 	keySize := data.Get("key_size").(int)
+	counter := data.Get("counter").(int)
+	lookAhead := data.Get("look_ahead").(int)
 	inputURL := data.Get("url").(string)
 
 	if generate {
@@ -216,6 +262,12 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		path := strings.TrimPrefix(urlObject.Path, "/")
 		index := strings.Index(path, ":")
 
+		//Read type
+		switch urlObject.Host {
+		case keyTypeTOTP, keyTypeHOTP:
+			keyType = urlObject.Host
+		}
+
 		//Read issuer
 		urlIssuer := urlQuery.Get("issuer")
 		if urlIssuer != "" {
@@ -256,6 +308,16 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 			digits = digitsInt
 		}
 
+		//Read counter
+		counterQuery := urlQuery.Get("counter")
+		if counterQuery != "" {
+			counterInt, err := strconv.Atoi(counterQuery)
+			if err != nil {
+				return logical.ErrorResponse("an error occurred while parsing counter value in url"), err
+			}
+			counter = counterInt
+		}
+
 		//Read algorithm
 		algorithmQuery := urlQuery.Get("algorithm")
 		if algorithmQuery != "" {
@@ -263,6 +325,12 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		}
 	}
 
+	switch keyType {
+	case keyTypeTOTP, keyTypeHOTP:
+	default:
+		return logical.ErrorResponse(`the type value must be "totp" or "hotp"`), nil
+	}
+
 	// Translate digits and algorithm to a format the totp library understands
 	var keyDigits otplib.Digits
 	switch digits {
@@ -298,6 +366,14 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		return logical.ErrorResponse("the skew value must be 0 or 1"), nil
 	}
 
+	if counter < 0 {
+		return logical.ErrorResponse("the counter value must be greater than or equal to zero"), nil
+	}
+
+	if lookAhead < 0 {
+		return logical.ErrorResponse("the look_ahead value must be greater than or equal to zero"), nil
+	}
+
 	// QR size can be zero but it shouldn't be negative
 	if qrSize < 0 {
 		return logical.ErrorResponse("the qr_size value must be greater than or equal to zero"), nil
@@ -326,15 +402,32 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		}
 
 		// Generate a new key
-		keyObject, err := totplib.Generate(totplib.GenerateOpts{
-			Issuer:      issuer,
-			AccountName: accountName,
-			Period:      uintPeriod,
-			Digits:      keyDigits,
-			Algorithm:   keyAlgorithm,
-			SecretSize:  uintKeySize,
-			Rand:        b.GetRandomReader(),
-		})
+		var keyObject *otplib.Key
+		var err error
+		switch keyType {
+		case keyTypeHOTP:
+			keyObject, err = hotplib.Generate(hotplib.GenerateOpts{
+				Issuer:      issuer,
+				AccountName: accountName,
+				Digits:      keyDigits,
+				Algorithm:   keyAlgorithm,
+				SecretSize:  uintKeySize,
+				Rand:        b.GetRandomReader(),
+			})
+			if err == nil {
+				keyObject, err = hotpKeyWithCounter(keyObject, uint64(counter))
+			}
+		default:
+			keyObject, err = totplib.Generate(totplib.GenerateOpts{
+				Issuer:      issuer,
+				AccountName: accountName,
+				Period:      uintPeriod,
+				Digits:      keyDigits,
+				Algorithm:   keyAlgorithm,
+				SecretSize:  uintKeySize,
+				Rand:        b.GetRandomReader(),
+			})
+		}
 		if err != nil {
 			return logical.ErrorResponse("an error occurred while generating a key"), err
 		}
@@ -384,7 +477,12 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 	}
 
 	// Store it
-	entry, err := logical.StorageEntryJSON("key/"+name, &keyEntry{
+	lock := locksutil.LockForKey(b.keyLocks, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	err := b.putKey(ctx, req.Storage, name, &keyEntry{
+		Type:        keyType,
 		Key:         keyString,
 		Issuer:      issuer,
 		AccountName: accountName,
@@ -392,18 +490,36 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		Algorithm:   keyAlgorithm,
 		Digits:      keyDigits,
 		Skew:        uintSkew,
+		Counter:     uint64(counter),
+		LookAhead:   uint(lookAhead),
 	})
 	if err != nil {
 		return nil, err
 	}
-	if err := req.Storage.Put(ctx, entry); err != nil {
-		return nil, err
-	}
 
 	return response, nil
 }
 
+// hotpKeyWithCounter adds the initial counter to the url of a generated HOTP
+// key, which token generators require to set up the key
+func hotpKeyWithCounter(key *otplib.Key, counter uint64) (*otplib.Key, error) {
+	u, err := url.Parse(key.String())
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	query.Set("counter", strconv.FormatUint(counter, 10))
+	u.RawQuery = query.Encode()
+	return otplib.NewKeyFromURL(u.String())
+}
+
+const (
+	keyTypeTOTP = "totp"
+	keyTypeHOTP = "hotp"
+)
+
 type keyEntry struct {
+	Type        string           `json:"type" mapstructure:"type" structs:"type"`
 	Key         string           `json:"key" mapstructure:"key" structs:"key"`
 	Issuer      string           `json:"issuer" mapstructure:"issuer" structs:"issuer"`
 	AccountName string           `json:"account_name" mapstructure:"account_name" structs:"account_name"`
@@ -411,6 +527,23 @@ type keyEntry struct {
 	Algorithm   otplib.Algorithm `json:"algorithm" mapstructure:"algorithm" structs:"algorithm"`
 	Digits      otplib.Digits    `json:"digits" mapstructure:"digits" structs:"digits"`
 	Skew        uint             `json:"skew" mapstructure:"skew" structs:"skew"`
+
+	// Counter is the next counter value of a HOTP key
+	Counter   uint64 `json:"counter" mapstructure:"counter" structs:"counter"`
+	LookAhead uint   `json:"look_ahead" mapstructure:"look_ahead" structs:"look_ahead"`
+
+	// LastUsedStep is the time step of the last TOTP code that was
+	// successfully validated; codes of this or an earlier step are refused
+	LastUsedStep uint64 `json:"last_used_step" mapstructure:"last_used_step" structs:"last_used_step"`
+}
+
+// keyType returns the type of the key; keys stored before HOTP support
+// was added are TOTP keys
+func (k *keyEntry) keyType() string {
+	if k.Type == "" {
+		return keyTypeTOTP
+	}
+	return k.Type
 }
 
 const pathKeyHelpSyn = `
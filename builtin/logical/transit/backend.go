@@ -3,9 +3,14 @@ package transit
 import (
 	"context"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/errwrap"
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -29,6 +34,8 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			SealWrapStorage: []string{
 				"archive/",
 				"policy/",
+				"import/",
+				"managed-keys/",
 			},
 		},
 
@@ -38,15 +45,24 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			b.pathConfig(),
 			b.pathRotate(),
 			b.pathRewrap(),
+			b.pathImport(),
+			b.pathImportVersion(),
+			b.pathWrappingKey(),
+			b.pathManagedKeys(),
+			b.pathListManagedKeys(),
 			b.pathKeys(),
 			b.pathListKeys(),
 			b.pathExportKeys(),
 			b.pathEncrypt(),
 			b.pathDecrypt(),
 			b.pathDatakey(),
+			b.pathStreamDecrypt(),
+			b.pathStreamVerify(),
 			b.pathRandom(),
 			b.pathHash(),
 			b.pathHMAC(),
+			b.pathCMAC(),
+			b.pathDerive(),
 			b.pathSign(),
 			b.pathVerify(),
 			b.pathBackup(),
@@ -55,11 +71,15 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			b.pathCacheConfig(),
 		},
 
-		Secrets:     []*framework.Secret{},
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		Secrets:      []*framework.Secret{},
+		Invalidate:   b.invalidate,
+		Clean:        b.cleanup,
+		BackendType:  logical.TypeLogical,
+		PeriodicFunc: b.periodicFunc,
 	}
 
+	b.managedKeys = make(map[string]wrapping.Wrapper)
+
 	// determine cacheSize to use. Defaults to 0 which means unlimited
 	cacheSize := 0
 	useCache := !conf.System.CachingDisabled()
@@ -83,6 +103,15 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 type backend struct {
 	*framework.Backend
 	lm *keysutil.LockManager
+
+	// wrappingKeyLock guards the generation of the key used to wrap
+	// imported keys
+	wrappingKeyLock sync.Mutex
+
+	// managedKeysLock guards managedKeys, the configured KMS wrappers of
+	// the managed keys by name
+	managedKeysLock sync.RWMutex
+	managedKeys     map[string]wrapping.Wrapper
 }
 
 func GetCacheSizeFromStorage(ctx context.Context, s logical.Storage) (int, error) {
@@ -101,7 +130,7 @@ func GetCacheSizeFromStorage(ctx context.Context, s logical.Storage) (int, error
 	return size, nil
 }
 
-func (b *backend) invalidate(_ context.Context, key string) {
+func (b *backend) invalidate(ctx context.Context, key string) {
 	if b.Logger().IsDebug() {
 		b.Logger().Debug("invalidating key", "key", key)
 	}
@@ -109,5 +138,60 @@ func (b *backend) invalidate(_ context.Context, key string) {
 	case strings.HasPrefix(key, "policy/"):
 		name := strings.TrimPrefix(key, "policy/")
 		b.lm.InvalidatePolicy(name)
+	case strings.HasPrefix(key, managedKeyStoragePrefix):
+		name := strings.TrimPrefix(key, managedKeyStoragePrefix)
+		b.resetManagedKey(ctx, name)
+	}
+}
+
+// periodicFunc rotates the keys whose auto rotate period has passed. Only the
+// node that can write to the mount's storage does so; the last rotation time
+// is persisted with each key, so a node taking over after a failover picks up
+// where the previous one left off.
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	replState := b.System().ReplicationState()
+	if replState.HasState(consts.ReplicationPerformanceStandby | consts.ReplicationDRSecondary) {
+		return nil
+	}
+	if !b.System().LocalMount() && replState.HasState(consts.ReplicationPerformanceSecondary) {
+		return nil
+	}
+
+	names, err := req.Storage.List(ctx, "policy/")
+	if err != nil {
+		return err
+	}
+
+	var result error
+	for _, name := range names {
+		if err := b.autoRotateKey(ctx, req, name); err != nil {
+			result = multierror.Append(result, errwrap.Wrapf("failed to auto rotate key "+name+": {{err}}", err))
+		}
+	}
+	return result
+}
+
+// autoRotateKey rotates the named key if its auto rotate period has passed
+func (b *backend) autoRotateKey(ctx context.Context, req *logical.Request, name string) error {
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return err
 	}
+	if p == nil {
+		return nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	if !p.AutoRotationDue(time.Now()) {
+		return nil
+	}
+
+	b.Logger().Debug("automatically rotating key", "key", name)
+	return p.Rotate(ctx, req.Storage, b.GetRandomReader())
 }
@@ -0,0 +1,102 @@
+package transit
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// kwpIV is the alternative initial value prefix of RFC 5649
+var kwpIV = []byte{0xa6, 0x59, 0x59, 0xa6}
+
+// kwpWrap wraps the given plaintext with the key encryption key using the
+// AES key wrap with padding algorithm of RFC 5649
+func kwpWrap(kek, plaintext []byte) ([]byte, error) {
+	if len(plaintext) == 0 {
+		return nil, errors.New("no key material to wrap")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	paddedLen := (len(plaintext) + 7) / 8 * 8
+	out := make([]byte, 8+paddedLen)
+	copy(out, kwpIV)
+	binary.BigEndian.PutUint32(out[4:8], uint32(len(plaintext)))
+	copy(out[8:], plaintext)
+
+	// A single block is encrypted directly with the initial value
+	if paddedLen == 8 {
+		block.Encrypt(out, out)
+		return out, nil
+	}
+
+	n := paddedLen / 8
+	var buf [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[8*i:8*i+8])
+			block.Encrypt(buf[:], buf[:])
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[8*i:8*i+8], buf[8:])
+		}
+	}
+
+	return out, nil
+}
+
+// kwpUnwrap reverses kwpWrap, verifying the integrity of the wrapped key
+func kwpUnwrap(kek, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 16 || len(ciphertext)%8 != 0 {
+		return nil, fmt.Errorf("invalid wrapped key length %d", len(ciphertext))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(ciphertext))
+	copy(out, ciphertext)
+
+	n := len(ciphertext)/8 - 1
+	if n == 1 {
+		block.Decrypt(out, out)
+	} else {
+		var buf [16]byte
+		for j := 5; j >= 0; j-- {
+			for i := n; i >= 1; i-- {
+				t := uint64(n*j + i)
+				binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(out[:8])^t)
+				copy(buf[8:], out[8*i:8*i+8])
+				block.Decrypt(buf[:], buf[:])
+
+				copy(out[:8], buf[:8])
+				copy(out[8*i:8*i+8], buf[8:])
+			}
+		}
+	}
+
+	errUnwrap := errors.New("failed to unwrap key: integrity check failed")
+	if subtle.ConstantTimeCompare(out[:4], kwpIV) != 1 {
+		return nil, errUnwrap
+	}
+	length := int(binary.BigEndian.Uint32(out[4:8]))
+	if length <= 8*(n-1) || length > 8*n {
+		return nil, errUnwrap
+	}
+	for _, b := range out[8+length:] {
+		if b != 0 {
+			return nil, errUnwrap
+		}
+	}
+
+	return out[8 : 8+length], nil
+}
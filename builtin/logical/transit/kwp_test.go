@@ -0,0 +1,51 @@
+package transit
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestTransit_KWP(t *testing.T) {
+	// Test vectors from RFC 5649, section 6
+	kek, _ := hex.DecodeString("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	vectors := []struct {
+		key     string
+		wrapped string
+	}{
+		{
+			key:     "c37b7e6492584340bed12207808941155068f738",
+			wrapped: "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		},
+		{
+			key:     "466f7250617369",
+			wrapped: "afbeb0f07dfbf5419200f2ccb50bb24f",
+		},
+	}
+
+	for _, v := range vectors {
+		key, _ := hex.DecodeString(v.key)
+		expected, _ := hex.DecodeString(v.wrapped)
+
+		wrapped, err := kwpWrap(kek, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(wrapped, expected) {
+			t.Fatalf("bad wrapped key: expected %x, got %x", expected, wrapped)
+		}
+
+		unwrapped, err := kwpUnwrap(kek, wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(unwrapped, key) {
+			t.Fatalf("bad unwrapped key: expected %x, got %x", key, unwrapped)
+		}
+
+		wrapped[len(wrapped)-1] ^= 1
+		if _, err := kwpUnwrap(kek, wrapped); err == nil {
+			t.Fatal("expected error unwrapping tampered key")
+		}
+	}
+}
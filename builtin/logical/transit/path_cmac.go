@@ -0,0 +1,369 @@
+package transit
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// batchRequestCMACItem represents a request item for batch processing.
+// A map type allows us to distinguish between empty and missing values.
+type batchRequestCMACItem map[string]string
+
+// batchResponseCMACItem represents a response item for batch processing
+type batchResponseCMACItem struct {
+	// CMAC for the input present in the corresponding batch request item
+	CMAC string `json:"cmac,omitempty" mapstructure:"cmac"`
+
+	// Valid indicates whether the CMAC matches the one computed from the input
+	Valid bool `json:"valid,omitempty" mapstructure:"valid"`
+
+	// Error, if set represents a failure encountered while processing a
+	// corresponding batch request item
+	Error string `json:"error,omitempty" mapstructure:"error"`
+
+	// See batchResponseHMACItem; never serialized
+	err error
+}
+
+func (b *backend) pathCMAC() *framework.Path {
+	return &framework.Path{
+		Pattern: "cmac/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The key to use for the CMAC function",
+			},
+
+			"input": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The base64-encoded input data",
+			},
+
+			"context": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Base64 encoded context for key derivation. Required if key
+derivation is enabled.`,
+			},
+
+			"key_version": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The version of the key to use for generating the CMAC.
+Must be 0 (for latest) or a value greater than or equal
+to the min_encryption_version configured on the key.`,
+			},
+
+			"batch_input": &framework.FieldSchema{
+				Type: framework.TypeSlice,
+				Description: `Specifies a list of items to be processed in a single batch.
+Each item may set "input" and "context". If this parameter is set, the
+top-level "input" and "context" parameters are ignored and the results
+are returned in "batch_results".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCMACWrite,
+		},
+
+		HelpSynopsis:    pathCMACHelpSyn,
+		HelpDescription: pathCMACHelpDesc,
+	}
+}
+
+// cmacItemError converts a keysutil error into the pair of values stored
+// on a batch response item
+func cmacItemError(err error) (string, error) {
+	switch err.(type) {
+	case errutil.UserError:
+		return err.Error(), logical.ErrInvalidRequest
+	default:
+		return "", err
+	}
+}
+
+func (b *backend) pathCMACWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	ver := d.Get("key_version").(int)
+
+	// Get the policy
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+
+	if !p.Type.CMACSupported() {
+		p.Unlock()
+		return logical.ErrorResponse("CMAC not supported for key type %v", p.Type), logical.ErrInvalidRequest
+	}
+
+	switch {
+	case ver == 0:
+		// Allowed, will use latest; set explicitly here to ensure the string
+		// is generated properly
+		ver = p.LatestVersion
+	case ver == p.LatestVersion:
+		// Allowed
+	case p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
+		p.Unlock()
+		return logical.ErrorResponse("cannot generate CMAC: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+	}
+
+	batchInputRaw := d.Raw["batch_input"]
+	var batchInputItems []batchRequestCMACItem
+	if batchInputRaw != nil {
+		err = decodeBatchStringItems(batchInputRaw, &batchInputItems)
+		if err != nil {
+			p.Unlock()
+			return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
+		}
+
+		if len(batchInputItems) == 0 {
+			p.Unlock()
+			return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+		}
+	} else {
+		valueRaw, ok := d.GetOk("input")
+		if !ok {
+			p.Unlock()
+			return logical.ErrorResponse("missing input for CMAC"), logical.ErrInvalidRequest
+		}
+
+		batchInputItems = make([]batchRequestCMACItem, 1)
+		batchInputItems[0] = batchRequestCMACItem{
+			"input":   valueRaw.(string),
+			"context": d.Get("context").(string),
+		}
+	}
+
+	response := make([]batchResponseCMACItem, len(batchInputItems))
+
+	for i, item := range batchInputItems {
+		rawInput, ok := item["input"]
+		if !ok {
+			response[i].Error = "missing input for CMAC"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		input, err := base64.StdEncoding.DecodeString(rawInput)
+		if err != nil {
+			response[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		context, err := base64.StdEncoding.DecodeString(item["context"])
+		if err != nil {
+			response[i].Error = "failed to base64-decode context"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		mac, err := p.CMAC(context, ver, input)
+		if err != nil {
+			response[i].Error, response[i].err = cmacItemError(err)
+			continue
+		}
+
+		retStr := base64.StdEncoding.EncodeToString(mac)
+		retStr = fmt.Sprintf("vault:v%s:%s", strconv.Itoa(ver), retStr)
+		response[i].CMAC = retStr
+	}
+
+	p.Unlock()
+
+	// Generate the response
+	resp := &logical.Response{}
+	if batchInputRaw != nil {
+		resp.Data = map[string]interface{}{
+			"batch_results": response,
+		}
+	} else {
+		if response[0].Error != "" || response[0].err != nil {
+			if response[0].Error != "" {
+				return logical.ErrorResponse(response[0].Error), response[0].err
+			}
+			return nil, response[0].err
+		}
+		resp.Data = map[string]interface{}{
+			"cmac": response[0].CMAC,
+		}
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathCMACVerify(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	// Get the policy
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+
+	if !p.Type.CMACSupported() {
+		p.Unlock()
+		return logical.ErrorResponse("CMAC not supported for key type %v", p.Type), logical.ErrInvalidRequest
+	}
+
+	batchInputRaw := d.Raw["batch_input"]
+	var batchInputItems []batchRequestCMACItem
+	if batchInputRaw != nil {
+		err := decodeBatchStringItems(batchInputRaw, &batchInputItems)
+		if err != nil {
+			p.Unlock()
+			return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
+		}
+
+		if len(batchInputItems) == 0 {
+			p.Unlock()
+			return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+		}
+	} else {
+		// use empty string if input is missing - not an error
+		batchInputItems = make([]batchRequestCMACItem, 1)
+		batchInputItems[0] = batchRequestCMACItem{
+			"input":   d.Get("input").(string),
+			"cmac":    d.Get("cmac").(string),
+			"context": d.Get("context").(string),
+		}
+	}
+
+	response := make([]batchResponseCMACItem, len(batchInputItems))
+
+	for i, item := range batchInputItems {
+		rawInput, ok := item["input"]
+		if !ok {
+			response[i].Error = "missing input"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		input, err := base64.StdEncoding.DecodeString(rawInput)
+		if err != nil {
+			response[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		context, err := base64.StdEncoding.DecodeString(item["context"])
+		if err != nil {
+			response[i].Error = "failed to base64-decode context"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		verificationCMAC, ok := item["cmac"]
+		if !ok {
+			response[i].Error = "missing cmac"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		// Verify the prefix
+		if !strings.HasPrefix(verificationCMAC, "vault:v") {
+			response[i].Error = "invalid CMAC to verify: no prefix"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		splitVerificationCMAC := strings.SplitN(strings.TrimPrefix(verificationCMAC, "vault:v"), ":", 2)
+		if len(splitVerificationCMAC) != 2 {
+			response[i].Error = "invalid CMAC: wrong number of fields"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		ver, err := strconv.Atoi(splitVerificationCMAC[0])
+		if err != nil {
+			response[i].Error = "invalid CMAC: version number could not be decoded"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		verBytes, err := base64.StdEncoding.DecodeString(splitVerificationCMAC[1])
+		if err != nil {
+			response[i].Error = fmt.Sprintf("unable to decode verification CMAC as base64: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		if ver > p.LatestVersion {
+			response[i].Error = "invalid CMAC: version is too new"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		if p.MinDecryptionVersion > 0 && ver < p.MinDecryptionVersion {
+			response[i].Error = "cannot verify CMAC: version is too old (disallowed by policy)"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		mac, err := p.CMAC(context, ver, input)
+		if err != nil {
+			response[i].Error, response[i].err = cmacItemError(err)
+			continue
+		}
+		response[i].Valid = hmac.Equal(mac, verBytes)
+	}
+
+	p.Unlock()
+
+	// Generate the response
+	resp := &logical.Response{}
+	if batchInputRaw != nil {
+		resp.Data = map[string]interface{}{
+			"batch_results": response,
+		}
+	} else {
+		if response[0].Error != "" || response[0].err != nil {
+			if response[0].Error != "" {
+				return logical.ErrorResponse(response[0].Error), response[0].err
+			}
+			return nil, response[0].err
+		}
+		resp.Data = map[string]interface{}{
+			"valid": response[0].Valid,
+		}
+	}
+
+	return resp, nil
+}
+
+const pathCMACHelpSyn = `Generate an AES-CMAC for input data using the named key`
+
+const pathCMACHelpDesc = `
+Generates an AES-CMAC (RFC 4493) of the given input data with a CMAC key
+derived from the named symmetric key. The CMAC can be verified with the
+"verify" endpoint by passing it in the "cmac" parameter.
+`
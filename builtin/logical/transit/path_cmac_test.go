@@ -0,0 +1,125 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_CMAC(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	mustRequest("keys/foo", nil)
+	mustRequest("keys/derived", map[string]interface{}{
+		"derived": true,
+	})
+	mustRequest("keys/ecdsa", map[string]interface{}{
+		"type": "ecdsa-p256",
+	})
+
+	input := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+
+	resp := mustRequest("cmac/foo", map[string]interface{}{
+		"input": input,
+	})
+	cmac := resp.Data["cmac"].(string)
+	if !strings.HasPrefix(cmac, "vault:v1:") {
+		t.Fatalf("bad cmac: %s", cmac)
+	}
+	if again := mustRequest("cmac/foo", map[string]interface{}{"input": input}); again.Data["cmac"] != cmac {
+		t.Fatalf("CMAC is not deterministic: %s vs %s", cmac, again.Data["cmac"])
+	}
+
+	resp = mustRequest("verify/foo", map[string]interface{}{
+		"input": input,
+		"cmac":  cmac,
+	})
+	if resp.Data["valid"] != true {
+		t.Fatalf("expected valid CMAC, got %#v", resp.Data)
+	}
+	resp = mustRequest("verify/foo", map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString([]byte("the quick brown dog")),
+		"cmac":  cmac,
+	})
+	if resp.Data["valid"] != false {
+		t.Fatalf("expected invalid CMAC, got %#v", resp.Data)
+	}
+
+	// A CMAC cannot be verified together with an HMAC
+	resp, err := request("verify/foo", map[string]interface{}{
+		"input": input,
+		"cmac":  cmac,
+		"hmac":  cmac,
+	})
+	if err == nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error mixing cmac and hmac, got err: %v resp: %#v", err, resp)
+	}
+
+	// Rotate and enforce the minimum versions
+	mustRequest("keys/foo/rotate", nil)
+	resp = mustRequest("cmac/foo", map[string]interface{}{"input": input})
+	if !strings.HasPrefix(resp.Data["cmac"].(string), "vault:v2:") {
+		t.Fatalf("bad cmac after rotation: %s", resp.Data["cmac"])
+	}
+	mustRequest("keys/foo/config", map[string]interface{}{
+		"min_decryption_version": 2,
+		"min_encryption_version": 2,
+	})
+	if resp, err := request("cmac/foo", map[string]interface{}{"input": input, "key_version": 1}); err == nil || !resp.IsError() {
+		t.Fatalf("expected error generating CMAC with old version, got err: %v resp: %#v", err, resp)
+	}
+	if resp, err := request("verify/foo", map[string]interface{}{"input": input, "cmac": cmac}); err == nil || !resp.IsError() {
+		t.Fatalf("expected error verifying CMAC with old version, got err: %v resp: %#v", err, resp)
+	}
+
+	// Derived keys require a context, and the CMAC depends on it
+	if resp, err := request("cmac/derived", map[string]interface{}{"input": input}); err == nil || !resp.IsError() {
+		t.Fatalf("expected error without context, got err: %v resp: %#v", err, resp)
+	}
+	resp = mustRequest("cmac/derived", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"input": input, "context": "YWJj"},
+			map[string]interface{}{"input": input, "context": "ZGVm"},
+			map[string]interface{}{"input": input},
+		},
+	})
+	results := resp.Data["batch_results"].([]batchResponseCMACItem)
+	if results[0].CMAC == "" || results[0].CMAC == results[1].CMAC || results[2].Error == "" {
+		t.Fatalf("bad batch results: %#v", results)
+	}
+	resp = mustRequest("verify/derived", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"input": input, "context": "YWJj", "cmac": results[0].CMAC},
+			map[string]interface{}{"input": input, "context": "YWJj", "cmac": results[1].CMAC},
+		},
+	})
+	verified := resp.Data["batch_results"].([]batchResponseCMACItem)
+	if !verified[0].Valid || verified[1].Valid {
+		t.Fatalf("bad batch verification results: %#v", verified)
+	}
+
+	// Asymmetric keys do not support CMAC
+	if resp, err := request("cmac/ecdsa", map[string]interface{}{"input": input}); err == nil || !resp.IsError() {
+		t.Fatalf("expected error for asymmetric key, got err: %v resp: %#v", err, resp)
+	}
+}
@@ -3,6 +3,7 @@ package transit
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
@@ -47,6 +48,13 @@ the latest version of the key is allowed.`,
 				Type:        framework.TypeBool,
 				Description: `Enables taking a backup of the named key in plaintext format. Once set, this cannot be disabled.`,
 			},
+
+			"auto_rotate_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Amount of time the key should live before
+being automatically rotated. A value of 0
+disables automatic rotation for the key.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -84,6 +92,7 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	originalDeletionAllowed := p.DeletionAllowed
 	originalExportable := p.Exportable
 	originalAllowPlaintextBackup := p.AllowPlaintextBackup
+	originalAutoRotatePeriod := p.AutoRotatePeriod
 
 	defer func() {
 		if retErr != nil || (resp != nil && resp.IsError()) {
@@ -92,6 +101,7 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 			p.DeletionAllowed = originalDeletionAllowed
 			p.Exportable = originalExportable
 			p.AllowPlaintextBackup = originalAllowPlaintextBackup
+			p.AutoRotatePeriod = originalAutoRotatePeriod
 		}
 	}()
 
@@ -168,6 +178,9 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	exportableRaw, ok := d.GetOk("exportable")
 	if ok {
 		exportable := exportableRaw.(bool)
+		if exportable && p.Type == keysutil.KeyType_MANAGED_KEY {
+			return logical.ErrorResponse("managed keys cannot be exported"), nil
+		}
 		// Don't unset the already set value
 		if exportable && !p.Exportable {
 			p.Exportable = exportable
@@ -185,6 +198,25 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		}
 	}
 
+	autoRotatePeriodRaw, ok := d.GetOk("auto_rotate_period")
+	if ok {
+		autoRotatePeriod := time.Second * time.Duration(autoRotatePeriodRaw.(int))
+		if err := validateAutoRotatePeriod(autoRotatePeriod); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if autoRotatePeriod != 0 && p.Imported && !p.AllowImportedKeyRotation {
+			return logical.ErrorResponse("imported key %q does not allow rotation within Vault", p.Name), nil
+		}
+		if autoRotatePeriod != 0 && p.Type == keysutil.KeyType_MANAGED_KEY {
+			return logical.ErrorResponse("managed key %q must be rotated in its external KMS", p.Name), nil
+		}
+
+		if autoRotatePeriod != p.AutoRotatePeriod {
+			p.AutoRotatePeriod = autoRotatePeriod
+			persistNeeded = true
+		}
+	}
+
 	if !persistNeeded {
 		return nil, nil
 	}
@@ -203,10 +235,23 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	return resp, p.Persist(ctx, req.Storage)
 }
 
+// validateAutoRotatePeriod checks that an auto rotate period either disables
+// automatic rotation or is long enough to not rotate on every periodic run
+func validateAutoRotatePeriod(period time.Duration) error {
+	switch {
+	case period < 0:
+		return fmt.Errorf("auto rotate period cannot be negative")
+	case period != 0 && period < time.Hour:
+		return fmt.Errorf("auto rotate period must be 0 to disable or at least an hour")
+	}
+	return nil
+}
+
 const pathConfigHelpSyn = `Configure a named encryption key`
 
 const pathConfigHelpDesc = `
 This path is used to configure the named key. Currently, this
 supports adjusting the minimum version of the key allowed to
-be used for decryption via the min_decryption_version parameter.
+be used for decryption via the min_decryption_version parameter,
+and rotating the key automatically via auto_rotate_period.
 `
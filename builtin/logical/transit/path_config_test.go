@@ -5,7 +5,9 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -283,3 +285,116 @@ func TestTransit_ConfigSettings(t *testing.T) {
 	testHMAC(3, true)
 	testHMAC(2, false)
 }
+
+func TestTransit_AutoRotate(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	mustRequest(logical.UpdateOperation, "keys/manual", nil)
+	mustRequest(logical.UpdateOperation, "keys/auto", map[string]interface{}{
+		"auto_rotate_period": "24h",
+	})
+	mustRequest(logical.UpdateOperation, "keys/later", nil)
+
+	for _, period := range []string{"30m", "-1h"} {
+		if resp, err := request(logical.UpdateOperation, "keys/later/config", map[string]interface{}{"auto_rotate_period": period}); err == nil && !resp.IsError() {
+			t.Fatalf("expected error for auto rotate period %s", period)
+		}
+	}
+	mustRequest(logical.UpdateOperation, "keys/later/config", map[string]interface{}{
+		"auto_rotate_period": "2h",
+	})
+
+	resp := mustRequest(logical.ReadOperation, "keys/auto", nil)
+	if resp.Data["auto_rotate_period"] != int64(86400) {
+		t.Fatalf("bad auto rotate period: %#v", resp.Data["auto_rotate_period"])
+	}
+	if _, ok := resp.Data["last_rotation_time"].(time.Time); !ok {
+		t.Fatalf("missing last rotation time: %#v", resp.Data)
+	}
+
+	// Nothing is due yet
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	latestVersion := func(name string) int {
+		t.Helper()
+		return mustRequest(logical.ReadOperation, "keys/"+name, nil).Data["latest_version"].(int)
+	}
+	if latestVersion("auto") != 1 || latestVersion("later") != 1 {
+		t.Fatal("expected no rotation before the period has passed")
+	}
+
+	// Move the last rotation back in time; keys without a recorded rotation
+	// time fall back to the creation time of their latest version
+	backdate := func(name string, age time.Duration) {
+		t.Helper()
+		p, _, err := b.lm.GetPolicy(context.Background(), keysutil.PolicyRequest{
+			Storage: storage,
+			Name:    name,
+		}, b.GetRandomReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.LastRotationTime = time.Time{}
+		entry := p.Keys[strconv.Itoa(p.LatestVersion)]
+		entry.CreationTime = time.Now().Add(-age)
+		p.Keys[strconv.Itoa(p.LatestVersion)] = entry
+		if err := p.Persist(context.Background(), storage); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backdate("auto", 25*time.Hour)
+	backdate("later", time.Hour)
+	backdate("manual", 1000*time.Hour)
+
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if latestVersion("auto") != 2 {
+		t.Fatal("expected key to be rotated automatically")
+	}
+	if latestVersion("later") != 1 || latestVersion("manual") != 1 {
+		t.Fatal("expected keys not to be rotated")
+	}
+	resp = mustRequest(logical.ReadOperation, "keys/auto", nil)
+	if time.Since(resp.Data["last_rotation_time"].(time.Time)) > time.Minute {
+		t.Fatalf("bad last rotation time: %#v", resp.Data["last_rotation_time"])
+	}
+
+	// The rotation is not repeated until the period passes again
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if latestVersion("auto") != 2 {
+		t.Fatal("expected no further rotation")
+	}
+
+	// Disabling stops automatic rotation
+	mustRequest(logical.UpdateOperation, "keys/auto/config", map[string]interface{}{
+		"auto_rotate_period": 0,
+	})
+	backdate("auto", 100*time.Hour)
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if latestVersion("auto") != 2 {
+		t.Fatal("expected no rotation after disabling auto rotation")
+	}
+}
@@ -114,6 +114,11 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 	}
 	defer p.Unlock()
 
+	managedKey, err := b.getManagedKey(ctx, req.Storage, p)
+	if err != nil {
+		return nil, err
+	}
+
 	newKey := make([]byte, 32)
 	bits := d.Get("bits").(int)
 	switch bits {
@@ -130,7 +135,7 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 		return nil, err
 	}
 
-	ciphertext, err := p.Encrypt(ver, context, nonce, base64.StdEncoding.EncodeToString(newKey))
+	ciphertext, err := p.EncryptWithFactory(ver, context, nonce, base64.StdEncoding.EncodeToString(newKey), managedKey)
 	if err != nil {
 		switch err.(type) {
 		case errutil.UserError:
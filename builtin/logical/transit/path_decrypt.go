@@ -135,12 +135,18 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 		p.Lock(false)
 	}
 
+	managedKey, err := b.getManagedKey(ctx, req.Storage, p)
+	if err != nil {
+		p.Unlock()
+		return nil, err
+	}
+
 	for i, item := range batchInputItems {
 		if batchResponseItems[i].Error != "" {
 			continue
 		}
 
-		plaintext, err := p.Decrypt(item.DecodedContext, item.DecodedNonce, item.Ciphertext)
+		plaintext, err := p.DecryptWithFactory(item.DecodedContext, item.DecodedNonce, item.Ciphertext, managedKey)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
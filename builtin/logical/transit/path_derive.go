@@ -0,0 +1,149 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *backend) pathDerive() *framework.Path {
+	return &framework.Path{
+		Pattern: "derive/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The key to derive key material from",
+			},
+
+			"context": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Base64 encoded context for key derivation, such as a
+device identifier. Required.`,
+			},
+
+			"salt": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Base64 encoded salt for key derivation. Optional.",
+			},
+
+			"kdf": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "hkdf_sha256",
+				Description: `The KDF to use. Valid values are "hkdf_sha256" and
+"hmac-sha256-counter". Defaults to "hkdf_sha256".`,
+			},
+
+			"bits": &framework.FieldSchema{
+				Type:    framework.TypeInt,
+				Default: 256,
+				Description: `Number of bits of key material to derive. Valid values
+are 128, 256 and 512. Defaults to 256.`,
+			},
+
+			"key_version": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The version of the key to derive from. Must be 0 (for
+latest) or a value greater than or equal to the min_encryption_version
+configured on the key.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathDeriveWrite,
+		},
+
+		HelpSynopsis:    pathDeriveHelpSyn,
+		HelpDescription: pathDeriveHelpDesc,
+	}
+}
+
+func (b *backend) pathDeriveWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	ver := d.Get("key_version").(int)
+
+	var kdfMode int
+	switch d.Get("kdf").(string) {
+	case "hkdf_sha256":
+		kdfMode = keysutil.Kdf_hkdf_sha256
+	case "hmac-sha256-counter", "hmac_sha256_counter":
+		kdfMode = keysutil.Kdf_hmac_sha256_counter
+	default:
+		return logical.ErrorResponse("unsupported kdf %q", d.Get("kdf").(string)), logical.ErrInvalidRequest
+	}
+
+	bits := d.Get("bits").(int)
+	switch bits {
+	case 128, 256, 512:
+	default:
+		return logical.ErrorResponse("invalid bits %d; must be 128, 256 or 512", bits), logical.ErrInvalidRequest
+	}
+
+	contextRaw := d.Get("context").(string)
+	if contextRaw == "" {
+		return logical.ErrorResponse("missing context"), logical.ErrInvalidRequest
+	}
+	derivationContext, err := base64.StdEncoding.DecodeString(contextRaw)
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode context"), logical.ErrInvalidRequest
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(d.Get("salt").(string))
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode salt"), logical.ErrInvalidRequest
+	}
+
+	// Get the policy
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	switch {
+	case ver == 0:
+		ver = p.LatestVersion
+	case ver < 0 || ver > p.LatestVersion:
+		return logical.ErrorResponse("invalid key version"), logical.ErrInvalidRequest
+	case p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
+		return logical.ErrorResponse("cannot derive key: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+	}
+
+	key, err := p.DeriveExternalKey(kdfMode, derivationContext, salt, ver, bits/8)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"key":         base64.StdEncoding.EncodeToString(key),
+			"key_version": ver,
+		},
+	}, nil
+}
+
+const pathDeriveHelpSyn = `Derive key material from the named key`
+
+const pathDeriveHelpDesc = `
+Derives key material from a symmetric key using HKDF-SHA256 or the NIST
+SP 800-108 HMAC-SHA256 counter mode KDF, bound to the given context and
+optional salt. The returned key is suitable for handing to devices; it is
+never equal to a key that Vault uses for encryption with the named key.
+`
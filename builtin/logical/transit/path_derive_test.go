@@ -0,0 +1,92 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_Derive(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	mustRequest("keys/foo", nil)
+	mustRequest("keys/ecdsa", map[string]interface{}{
+		"type": "ecdsa-p256",
+	})
+
+	derive := func(data map[string]interface{}) []byte {
+		t.Helper()
+		resp := mustRequest("derive/foo", data)
+		key, err := base64.StdEncoding.DecodeString(resp.Data["key"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	device1 := derive(map[string]interface{}{"context": "ZGV2aWNlLTE="})
+	if len(device1) != 32 {
+		t.Fatalf("bad derived key length %d", len(device1))
+	}
+	if string(derive(map[string]interface{}{"context": "ZGV2aWNlLTE="})) != string(device1) {
+		t.Fatal("derivation is not deterministic")
+	}
+	if string(derive(map[string]interface{}{"context": "ZGV2aWNlLTI="})) == string(device1) {
+		t.Fatal("different contexts derived the same key")
+	}
+	if string(derive(map[string]interface{}{"context": "ZGV2aWNlLTE=", "salt": "c2FsdA=="})) == string(device1) {
+		t.Fatal("salt did not change the derived key")
+	}
+	if string(derive(map[string]interface{}{"context": "ZGV2aWNlLTE=", "kdf": "hmac-sha256-counter"})) == string(device1) {
+		t.Fatal("different KDFs derived the same key")
+	}
+	if key := derive(map[string]interface{}{"context": "ZGV2aWNlLTE=", "bits": 512}); len(key) != 64 {
+		t.Fatalf("bad derived key length %d", len(key))
+	}
+
+	mustRequest("keys/foo/rotate", nil)
+	resp := mustRequest("derive/foo", map[string]interface{}{"context": "ZGV2aWNlLTE="})
+	if resp.Data["key_version"] != 2 || resp.Data["key"] == base64.StdEncoding.EncodeToString(device1) {
+		t.Fatalf("bad derive response after rotation: %#v", resp.Data)
+	}
+	if key := derive(map[string]interface{}{"context": "ZGV2aWNlLTE=", "key_version": 1}); string(key) != string(device1) {
+		t.Fatal("derivation with an explicit key version did not match")
+	}
+	mustRequest("keys/foo/config", map[string]interface{}{
+		"min_decryption_version": 2,
+		"min_encryption_version": 2,
+	})
+
+	for name, data := range map[string]map[string]interface{}{
+		"old version":     {"context": "ZGV2aWNlLTE=", "key_version": 1},
+		"missing context": {},
+		"bad bits":        {"context": "ZGV2aWNlLTE=", "bits": 100},
+		"bad kdf":         {"context": "ZGV2aWNlLTE=", "kdf": "pbkdf2"},
+	} {
+		if resp, err := request("derive/foo", data); err == nil || !resp.IsError() {
+			t.Fatalf("%s: expected error, got err: %v resp: %#v", name, err, resp)
+		}
+	}
+	if resp, err := request("derive/ecdsa", map[string]interface{}{"context": "ZGV2aWNlLTE="}); err == nil || !resp.IsError() {
+		t.Fatalf("expected error for asymmetric key, got err: %v resp: %#v", err, resp)
+	}
+}
@@ -339,6 +339,12 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 		p.Lock(false)
 	}
 
+	managedKey, err := b.getManagedKey(ctx, req.Storage, p)
+	if err != nil {
+		p.Unlock()
+		return nil, err
+	}
+
 	// Process batch request items. If encryption of any request
 	// item fails, respectively mark the error in the response
 	// collection and continue to process other items.
@@ -347,7 +353,7 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 			continue
 		}
 
-		ciphertext, err := p.Encrypt(item.KeyVersion, item.DecodedContext, item.DecodedNonce, item.Plaintext)
+		ciphertext, err := p.EncryptWithFactory(item.KeyVersion, item.DecodedContext, item.DecodedNonce, item.Plaintext, managedKey)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
@@ -12,7 +12,6 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
-	"github.com/mitchellh/mapstructure"
 )
 
 // BatchRequestHMACItem represents a request item for batch processing.
@@ -76,6 +75,15 @@ Defaults to "sha2-256".`,
 Must be 0 (for latest) or a value greater than or equal
 to the min_encryption_version configured on the key.`,
 			},
+
+			"batch_input": &framework.FieldSchema{
+				Type: framework.TypeSlice,
+				Description: `Specifies a list of items to be HMACed in a single batch.
+Each item may set "input" and "key_version". If this parameter is set,
+the top-level "input" parameter is ignored and the results are returned
+in "batch_results", with an "error" set on each item that could not be
+processed.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -111,28 +119,6 @@ func (b *backend) pathHMACWrite(ctx context.Context, req *logical.Request, d *fr
 		p.Lock(false)
 	}
 
-	switch {
-	case ver == 0:
-		// Allowed, will use latest; set explicitly here to ensure the string
-		// is generated properly
-		ver = p.LatestVersion
-	case ver == p.LatestVersion:
-		// Allowed
-	case p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
-		p.Unlock()
-		return logical.ErrorResponse("cannot generate HMAC: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
-	}
-
-	key, err := p.HMACKey(ver)
-	if err != nil {
-		p.Unlock()
-		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
-	}
-	if key == nil {
-		p.Unlock()
-		return nil, fmt.Errorf("HMAC key value could not be computed")
-	}
-
 	hashAlgorithm, ok := keysutil.HashTypeMap[algorithm]
 	if !ok {
 		p.Unlock()
@@ -144,7 +130,7 @@ func (b *backend) pathHMACWrite(ctx context.Context, req *logical.Request, d *fr
 	batchInputRaw := d.Raw["batch_input"]
 	var batchInputItems []batchRequestHMACItem
 	if batchInputRaw != nil {
-		err = mapstructure.Decode(batchInputRaw, &batchInputItems)
+		err = decodeBatchStringItems(batchInputRaw, &batchInputItems)
 		if err != nil {
 			p.Unlock()
 			return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
@@ -184,12 +170,46 @@ func (b *backend) pathHMACWrite(ctx context.Context, req *logical.Request, d *fr
 			continue
 		}
 
+		keyVersion, err := batchItemKeyVersion(item["key_version"], ver)
+		if err != nil {
+			response[i].Error = err.Error()
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		switch {
+		case keyVersion == 0:
+			// Allowed, will use latest; set explicitly here to ensure the string
+			// is generated properly
+			keyVersion = p.LatestVersion
+		case keyVersion == p.LatestVersion:
+			// Allowed
+		case p.MinEncryptionVersion > 0 && keyVersion < p.MinEncryptionVersion:
+			response[i].Error = "cannot generate HMAC: version is too old (disallowed by policy)"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		key, err := p.HMACKey(keyVersion)
+		if err != nil {
+			response[i].Error = err.Error()
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+		if key == nil {
+			response[i].err = fmt.Errorf("HMAC key value could not be computed")
+			if batchInputRaw != nil {
+				response[i].Error = response[i].err.Error()
+			}
+			continue
+		}
+
 		var hf = hmac.New(hashAlg, key)
 		hf.Write(input)
 		retBytes := hf.Sum(nil)
 
 		retStr := base64.StdEncoding.EncodeToString(retBytes)
-		retStr = fmt.Sprintf("vault:v%s:%s", strconv.Itoa(ver), retStr)
+		retStr = fmt.Sprintf("vault:v%s:%s", strconv.Itoa(keyVersion), retStr)
 		response[i].HMAC = retStr
 	}
 
@@ -251,7 +271,7 @@ func (b *backend) pathHMACVerify(ctx context.Context, req *logical.Request, d *f
 	batchInputRaw := d.Raw["batch_input"]
 	var batchInputItems []batchRequestHMACItem
 	if batchInputRaw != nil {
-		err := mapstructure.Decode(batchInputRaw, &batchInputItems)
+		err := decodeBatchStringItems(batchInputRaw, &batchInputItems)
 		if err != nil {
 			p.Unlock()
 			return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
@@ -332,3 +332,58 @@ func TestTransit_batchHMAC(t *testing.T) {
 		t.Fatalf("expected error validating hmac\nreq\n%#v\nresp\n%#v", *req, *resp)
 	}
 }
+
+func TestTransit_batchHMAC_KeyVersion(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	request("keys/foo", nil)
+	request("keys/foo/rotate", nil)
+	request("keys/foo/rotate", nil)
+	request("keys/foo/config", map[string]interface{}{
+		"min_decryption_version": 2,
+		"min_encryption_version": 2,
+	})
+
+	input := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	resp := request("hmac/foo", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"input": input},
+			map[string]interface{}{"input": input, "key_version": 2},
+			map[string]interface{}{"input": input, "key_version": "2"},
+			map[string]interface{}{"input": input, "key_version": 1},
+			map[string]interface{}{"input": input, "key_version": "two"},
+		},
+	})
+	results := resp.Data["batch_results"].([]batchResponseHMACItem)
+	if !strings.HasPrefix(results[0].HMAC, "vault:v3:") || !strings.HasPrefix(results[1].HMAC, "vault:v2:") || results[1].HMAC != results[2].HMAC {
+		t.Fatalf("bad batch results: %#v", results)
+	}
+	if results[3].Error != "cannot generate HMAC: version is too old (disallowed by policy)" || results[4].Error == "" {
+		t.Fatalf("expected per-item version errors: %#v", results)
+	}
+
+	resp = request("verify/foo", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"input": input, "hmac": results[0].HMAC},
+			map[string]interface{}{"input": input, "hmac": results[1].HMAC},
+		},
+	})
+	verified := resp.Data["batch_results"].([]batchResponseHMACItem)
+	if !verified[0].Valid || !verified[1].Valid {
+		t.Fatalf("bad batch verification results: %#v", verified)
+	}
+}
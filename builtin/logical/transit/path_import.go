@@ -0,0 +1,273 @@
+package transit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// The wrapped ephemeral AES key is the size of the RSA-4096 modulus
+const wrappedEphemeralKeySize = 512
+
+var importHashFunctions = map[string]crypto.Hash{
+	"SHA1":   crypto.SHA1,
+	"SHA224": crypto.SHA224,
+	"SHA256": crypto.SHA256,
+	"SHA384": crypto.SHA384,
+	"SHA512": crypto.SHA512,
+}
+
+func (b *backend) pathImport() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/import",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"type": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "aes256-gcm96",
+				Description: `The type of the imported key. Any of the key types
+supported by the "keys" path can be imported. Defaults to "aes256-gcm96".`,
+			},
+
+			"ciphertext": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The base64-encoded wrapped key material: an
+ephemeral AES-256 key encrypted with the wrapping key using RSA-OAEP,
+followed by the target key wrapped with the ephemeral key using AES-KWP.
+Symmetric keys are wrapped as raw bytes, asymmetric keys as a PKCS#8
+DER-encoded private key.`,
+			},
+
+			"hash_function": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "SHA256",
+				Description: `The hash function used for RSA-OAEP. One of
+"SHA1", "SHA224", "SHA256", "SHA384" or "SHA512". Defaults to "SHA256".`,
+			},
+
+			"allow_rotation": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Whether Vault may rotate the imported key,
+generating new key material within Vault. Defaults to false.`,
+			},
+
+			"derived": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables key derivation mode. This
+allows for per-transaction unique
+keys for encryption operations.`,
+			},
+
+			"convergent_encryption": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Whether to support convergent encryption.
+This is only supported when using a key with
+key derivation enabled.`,
+			},
+
+			"exportable": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables keys to be exportable.
+This allows for all the valid keys
+in the key ring to be exported.`,
+			},
+
+			"allow_plaintext_backup": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables taking a backup of the named
+key in plaintext format. Once set,
+this cannot be disabled.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportWrite,
+		},
+
+		HelpSynopsis:    pathImportHelpSyn,
+		HelpDescription: pathImportHelpDesc,
+	}
+}
+
+func (b *backend) pathImportVersion() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/import_version",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"ciphertext": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The base64-encoded wrapped key material, in the
+same format as for the "import" path.`,
+			},
+
+			"hash_function": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "SHA256",
+				Description: `The hash function used for RSA-OAEP. One of
+"SHA1", "SHA224", "SHA256", "SHA384" or "SHA512". Defaults to "SHA256".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportVersionWrite,
+		},
+
+		HelpSynopsis:    pathImportVersionHelpSyn,
+		HelpDescription: pathImportVersionHelpDesc,
+	}
+}
+
+func (b *backend) pathImportWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	derived := d.Get("derived").(bool)
+	convergent := d.Get("convergent_encryption").(bool)
+
+	if !derived && convergent {
+		return logical.ErrorResponse("convergent encryption requires derivation to be enabled"), nil
+	}
+
+	keyType, ok := parseKeyType(d.Get("type").(string))
+	if !ok {
+		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", d.Get("type").(string))), logical.ErrInvalidRequest
+	}
+	if keyType == keysutil.KeyType_MANAGED_KEY {
+		return logical.ErrorResponse("managed keys cannot be imported"), logical.ErrInvalidRequest
+	}
+
+	key, resp, err := b.unwrapImportedKey(ctx, req.Storage, d)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	polReq := keysutil.PolicyRequest{
+		Storage:                  req.Storage,
+		Name:                     name,
+		KeyType:                  keyType,
+		Derived:                  derived,
+		Convergent:               convergent,
+		Exportable:               d.Get("exportable").(bool),
+		AllowPlaintextBackup:     d.Get("allow_plaintext_backup").(bool),
+		AllowImportedKeyRotation: d.Get("allow_rotation").(bool),
+	}
+
+	if err := b.lm.ImportPolicy(ctx, polReq, key, b.GetRandomReader()); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("error importing key: %s", err)), logical.ErrInvalidRequest
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathImportVersionWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	if !p.Imported {
+		return logical.ErrorResponse("new versions can only be imported into keys which were imported"), logical.ErrInvalidRequest
+	}
+
+	key, resp, err := b.unwrapImportedKey(ctx, req.Storage, d)
+	if resp != nil || err != nil {
+		return resp, err
+	}
+
+	if err := p.Import(ctx, req.Storage, key, b.GetRandomReader()); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("error importing key version: %s", err)), logical.ErrInvalidRequest
+	}
+
+	return nil, nil
+}
+
+// unwrapImportedKey decrypts the wrapped key material of the request with
+// the wrapping key
+func (b *backend) unwrapImportedKey(ctx context.Context, s logical.Storage, d *framework.FieldData) ([]byte, *logical.Response, error) {
+	hashFunction := strings.ToUpper(d.Get("hash_function").(string))
+	hash, ok := importHashFunctions[hashFunction]
+	if !ok {
+		return nil, logical.ErrorResponse(fmt.Sprintf("unsupported hash function %q", hashFunction)), logical.ErrInvalidRequest
+	}
+
+	ciphertextB64 := d.Get("ciphertext").(string)
+	if ciphertextB64 == "" {
+		return nil, logical.ErrorResponse("missing ciphertext"), logical.ErrInvalidRequest
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(ciphertextB64)
+	if err != nil {
+		return nil, logical.ErrorResponse("failed to base64-decode ciphertext"), logical.ErrInvalidRequest
+	}
+	if len(ciphertext) <= wrappedEphemeralKeySize {
+		return nil, logical.ErrorResponse("ciphertext is too short to contain a wrapped key"), logical.ErrInvalidRequest
+	}
+
+	p, err := b.getWrappingKey(ctx, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, ok := p.Keys[strconv.Itoa(p.LatestVersion)]
+	if !ok {
+		return nil, nil, fmt.Errorf("wrapping key version %d not found", p.LatestVersion)
+	}
+
+	ephemeralKey, err := rsa.DecryptOAEP(hash.New(), b.GetRandomReader(), entry.RSAKey, ciphertext[:wrappedEphemeralKeySize], nil)
+	if err != nil {
+		return nil, logical.ErrorResponse("failed to decrypt the ephemeral key with the wrapping key"), logical.ErrInvalidRequest
+	}
+
+	key, err := kwpUnwrap(ephemeralKey, ciphertext[wrappedEphemeralKeySize:])
+	if err != nil {
+		return nil, logical.ErrorResponse(fmt.Sprintf("failed to unwrap key material: %s", err)), logical.ErrInvalidRequest
+	}
+
+	return key, nil, nil
+}
+
+const pathImportHelpSyn = `Imports an externally generated key into a new transit key`
+
+const pathImportHelpDesc = `
+This path is used to import externally generated key material into a new
+named key. The key material must be wrapped with the public key returned by
+the "wrapping_key" path: an ephemeral AES-256 key is encrypted with the
+wrapping key using RSA-OAEP, and the key material is wrapped with the
+ephemeral key using AES-KWP (RFC 5649). The two are concatenated and
+base64-encoded.
+
+Imported keys cannot be rotated within Vault unless "allow_rotation" is set;
+new versions can be imported with the "import_version" path instead.
+`
+
+const pathImportVersionHelpSyn = `Imports an externally generated key as a new version of an imported key`
+
+const pathImportVersionHelpDesc = `
+This path is used to import externally generated key material as the new
+latest version of a key which was created through the "import" path. The key
+material must be wrapped in the same way as for the "import" path.
+`
@@ -0,0 +1,230 @@
+package transit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// wrapKeyForImport wraps the key material in the format expected by the
+// import paths, using the mount's wrapping key
+func wrapKeyForImport(t *testing.T, b *backend, storage logical.Storage, key []byte) string {
+	t.Helper()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "wrapping_key",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	block, _ := pem.Decode([]byte(resp.Data["public_key"].(string)))
+	if block == nil {
+		t.Fatal("failed to decode wrapping key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.(*rsa.PublicKey).N.BitLen() != 4096 {
+		t.Fatalf("expected a 4096-bit wrapping key")
+	}
+
+	ephemeralKey := make([]byte, 32)
+	if _, err := rand.Read(ephemeralKey); err != nil {
+		t.Fatal(err)
+	}
+	wrappedEphemeralKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub.(*rsa.PublicKey), ephemeralKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrappedKey, err := kwpWrap(ephemeralKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(append(wrappedEphemeralKey, wrappedKey...))
+}
+
+func TestTransit_Import(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	mustRequest := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	readKey := func(name string) map[string]interface{} {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "keys/" + name,
+			Storage:   storage,
+		})
+		if err != nil || resp == nil || resp.IsError() {
+			t.Fatalf("bad: err: %v resp: %#v", err, resp)
+		}
+		return resp.Data
+	}
+
+	// Symmetric keys are imported as raw bytes
+	aesKey := make([]byte, 32)
+	if _, err := rand.Read(aesKey); err != nil {
+		t.Fatal(err)
+	}
+	mustRequest("keys/aes/import", map[string]interface{}{
+		"ciphertext": wrapKeyForImport(t, b, storage, aesKey),
+		"exportable": true,
+	})
+	data := readKey("aes")
+	if data["imported_key"] != true || data["imported_key_allow_rotation"] != false || data["type"] != "aes256-gcm96" {
+		t.Fatalf("bad imported key: %#v", data)
+	}
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "export/encryption-key/aes/1",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	if exported := resp.Data["keys"].(map[string]string)["1"]; exported != base64.StdEncoding.EncodeToString(aesKey) {
+		t.Fatalf("exported key does not match imported key")
+	}
+
+	resp = mustRequest("encrypt/aes", map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString([]byte(testPlaintext)),
+	})
+	resp = mustRequest("decrypt/aes", map[string]interface{}{
+		"ciphertext": resp.Data["ciphertext"],
+	})
+	if plaintext, _ := base64.StdEncoding.DecodeString(resp.Data["plaintext"].(string)); string(plaintext) != testPlaintext {
+		t.Fatalf("bad decrypted plaintext %q", plaintext)
+	}
+
+	// Imported keys can't be rotated unless allowed, nor imported twice
+	if resp, err := request("keys/aes/rotate", nil); err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error rotating imported key")
+	}
+	if resp, err := request("keys/aes/import", map[string]interface{}{
+		"ciphertext": wrapKeyForImport(t, b, storage, aesKey),
+	}); err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error importing existing key")
+	}
+
+	// New versions are imported instead
+	mustRequest("keys/aes/import_version", map[string]interface{}{
+		"ciphertext": wrapKeyForImport(t, b, storage, aesKey),
+	})
+	if data := readKey("aes"); data["latest_version"] != 2 {
+		t.Fatalf("expected new key version, got %#v", data)
+	}
+
+	mustRequest("keys/generated", nil)
+	if resp, err := request("keys/generated/import_version", map[string]interface{}{
+		"ciphertext": wrapKeyForImport(t, b, storage, aesKey),
+	}); err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error importing version into generated key")
+	}
+
+	// Asymmetric keys are imported as PKCS#8
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemPublicKey := func(pub interface{}) string {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+
+	for _, tc := range []struct {
+		keyType   string
+		key       interface{}
+		publicKey string
+	}{
+		{"ecdsa-p256", ecKey, pemPublicKey(ecKey.Public())},
+		{"rsa-2048", rsaKey, pemPublicKey(rsaKey.Public())},
+		{"ed25519", edKey, base64.StdEncoding.EncodeToString(edPub)},
+	} {
+		der, err := x509.MarshalPKCS8PrivateKey(tc.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp, err := request("keys/mismatched-"+tc.keyType+"/import", map[string]interface{}{
+			"ciphertext": wrapKeyForImport(t, b, storage, der),
+			"type":       "ecdsa-p384",
+		}); err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error importing %s key as another type", tc.keyType)
+		}
+
+		mustRequest("keys/"+tc.keyType+"/import", map[string]interface{}{
+			"ciphertext":     wrapKeyForImport(t, b, storage, der),
+			"type":           tc.keyType,
+			"allow_rotation": true,
+		})
+		data := readKey(tc.keyType)
+		publicKey := data["keys"].(map[string]map[string]interface{})["1"]["public_key"]
+		if publicKey != tc.publicKey {
+			t.Fatalf("bad %s public key: expected %q, got %q", tc.keyType, tc.publicKey, publicKey)
+		}
+
+		resp := mustRequest("sign/"+tc.keyType, map[string]interface{}{
+			"input": base64.StdEncoding.EncodeToString([]byte(testPlaintext)),
+		})
+		resp = mustRequest("verify/"+tc.keyType, map[string]interface{}{
+			"input":     base64.StdEncoding.EncodeToString([]byte(testPlaintext)),
+			"signature": resp.Data["signature"],
+		})
+		if resp.Data["valid"] != true {
+			t.Fatalf("expected valid %s signature", tc.keyType)
+		}
+
+		// Rotation was allowed on import
+		mustRequest("keys/"+tc.keyType+"/rotate", nil)
+	}
+
+	// Tampered key material is rejected
+	ciphertext, _ := base64.StdEncoding.DecodeString(wrapKeyForImport(t, b, storage, aesKey))
+	ciphertext[len(ciphertext)-1] ^= 1
+	resp, err = request("keys/tampered/import", map[string]interface{}{
+		"ciphertext": base64.StdEncoding.EncodeToString(ciphertext),
+	})
+	if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "unwrap") {
+		t.Fatalf("expected error importing tampered key, got err: %v resp: %#v", err, resp)
+	}
+}
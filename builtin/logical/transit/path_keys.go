@@ -47,10 +47,17 @@ func (b *backend) pathKeys() *framework.Path {
 				Description: `
 The type of key to create. Currently, "aes128-gcm96" (symmetric), "aes256-gcm96" (symmetric), "ecdsa-p256"
 (asymmetric), "ecdsa-p384" (asymmetric), "ecdsa-p521" (asymmetric), "ed25519" (asymmetric), "rsa-2048" (asymmetric), "rsa-3072"
-(asymmetric), "rsa-4096" (asymmetric) are supported.  Defaults to "aes256-gcm96".
+(asymmetric), "rsa-4096" (asymmetric) and "managed_key" (held in an external KMS configured under
+"managed-keys/") are supported.  Defaults to "aes256-gcm96".
 `,
 			},
 
+			"managed_key_name": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The name of the managed key holding the key material.
+Required for keys of type "managed_key".`,
+			},
+
 			"derived": &framework.FieldSchema{
 				Type: framework.TypeBool,
 				Description: `Enables key derivation mode. This
@@ -88,6 +95,15 @@ key in plaintext format. Once set,
 this cannot be disabled.`,
 			},
 
+			"auto_rotate_period": &framework.FieldSchema{
+				Type:    framework.TypeDurationSecond,
+				Default: 0,
+				Description: `Amount of time the key should live before
+being automatically rotated. A value of 0
+(default) disables automatic rotation for the
+key.`,
+			},
+
 			"context": &framework.FieldSchema{
 				Type: framework.TypeString,
 				Description: `Base64 encoded context for key derivation.
@@ -124,11 +140,17 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 	keyType := d.Get("type").(string)
 	exportable := d.Get("exportable").(bool)
 	allowPlaintextBackup := d.Get("allow_plaintext_backup").(bool)
+	autoRotatePeriod := time.Second * time.Duration(d.Get("auto_rotate_period").(int))
+	managedKeyName := d.Get("managed_key_name").(string)
 
 	if !derived && convergent {
 		return logical.ErrorResponse("convergent encryption requires derivation to be enabled"), nil
 	}
 
+	if err := validateAutoRotatePeriod(autoRotatePeriod); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	polReq := keysutil.PolicyRequest{
 		Upsert:               true,
 		Storage:              req.Storage,
@@ -137,32 +159,34 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 		Convergent:           convergent,
 		Exportable:           exportable,
 		AllowPlaintextBackup: allowPlaintextBackup,
+		AutoRotatePeriod:     autoRotatePeriod,
+		ManagedKeyName:       managedKeyName,
 	}
-	switch keyType {
-	case "aes128-gcm96":
-		polReq.KeyType = keysutil.KeyType_AES128_GCM96
-	case "aes256-gcm96":
-		polReq.KeyType = keysutil.KeyType_AES256_GCM96
-	case "chacha20-poly1305":
-		polReq.KeyType = keysutil.KeyType_ChaCha20_Poly1305
-	case "ecdsa-p256":
-		polReq.KeyType = keysutil.KeyType_ECDSA_P256
-	case "ecdsa-p384":
-		polReq.KeyType = keysutil.KeyType_ECDSA_P384
-	case "ecdsa-p521":
-		polReq.KeyType = keysutil.KeyType_ECDSA_P521
-	case "ed25519":
-		polReq.KeyType = keysutil.KeyType_ED25519
-	case "rsa-2048":
-		polReq.KeyType = keysutil.KeyType_RSA2048
-	case "rsa-3072":
-		polReq.KeyType = keysutil.KeyType_RSA3072
-	case "rsa-4096":
-		polReq.KeyType = keysutil.KeyType_RSA4096
-	default:
+	var ok bool
+	polReq.KeyType, ok = parseKeyType(keyType)
+	if !ok {
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
 	}
 
+	switch {
+	case polReq.KeyType == keysutil.KeyType_MANAGED_KEY:
+		if managedKeyName == "" {
+			return logical.ErrorResponse("managed_key_name is required for keys of type managed_key"), logical.ErrInvalidRequest
+		}
+		if derived || exportable || autoRotatePeriod != 0 {
+			return logical.ErrorResponse("key derivation, export and automatic rotation are not supported for managed keys"), logical.ErrInvalidRequest
+		}
+		entry, err := getManagedKeyEntry(ctx, req.Storage, managedKeyName)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return logical.ErrorResponse(fmt.Sprintf("managed key %q not found", managedKeyName)), logical.ErrInvalidRequest
+		}
+	case managedKeyName != "":
+		return logical.ErrorResponse("managed_key_name is only valid for keys of type managed_key"), logical.ErrInvalidRequest
+	}
+
 	p, upserted, err := b.lm.GetPolicy(ctx, polReq, b.GetRandomReader())
 	if err != nil {
 		return nil, err
@@ -182,6 +206,35 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 	return nil, nil
 }
 
+// parseKeyType returns the key type for its name as accepted over the API
+func parseKeyType(keyType string) (keysutil.KeyType, bool) {
+	switch keyType {
+	case "aes128-gcm96":
+		return keysutil.KeyType_AES128_GCM96, true
+	case "aes256-gcm96":
+		return keysutil.KeyType_AES256_GCM96, true
+	case "chacha20-poly1305":
+		return keysutil.KeyType_ChaCha20_Poly1305, true
+	case "ecdsa-p256":
+		return keysutil.KeyType_ECDSA_P256, true
+	case "ecdsa-p384":
+		return keysutil.KeyType_ECDSA_P384, true
+	case "ecdsa-p521":
+		return keysutil.KeyType_ECDSA_P521, true
+	case "ed25519":
+		return keysutil.KeyType_ED25519, true
+	case "rsa-2048":
+		return keysutil.KeyType_RSA2048, true
+	case "rsa-3072":
+		return keysutil.KeyType_RSA3072, true
+	case "rsa-4096":
+		return keysutil.KeyType_RSA4096, true
+	case "managed_key":
+		return keysutil.KeyType_MANAGED_KEY, true
+	}
+	return 0, false
+}
+
 // Built-in helper type for returning asymmetric keys
 type asymKey struct {
 	Name         string    `json:"name" structs:"name" mapstructure:"name"`
@@ -224,9 +277,23 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 			"supports_decryption":    p.Type.DecryptionSupported(),
 			"supports_signing":       p.Type.SigningSupported(),
 			"supports_derivation":    p.Type.DerivationSupported(),
+			"imported_key":           p.Imported,
+			"auto_rotate_period":     int64(p.AutoRotatePeriod.Seconds()),
 		},
 	}
 
+	if !p.LastRotationTime.IsZero() {
+		resp.Data["last_rotation_time"] = p.LastRotationTime
+	}
+
+	if p.Imported {
+		resp.Data["imported_key_allow_rotation"] = p.AllowImportedKeyRotation
+	}
+
+	if p.Type == keysutil.KeyType_MANAGED_KEY {
+		resp.Data["managed_key_name"] = p.ManagedKeyName
+	}
+
 	if p.BackupInfo != nil {
 		resp.Data["backup_info"] = map[string]interface{}{
 			"time":    p.BackupInfo.Time,
@@ -264,7 +331,7 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 	}
 
 	switch p.Type {
-	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305:
+	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305, keysutil.KeyType_MANAGED_KEY:
 		retKeys := map[string]int64{}
 		for k, v := range p.Keys {
 			retKeys[k] = v.DeprecatedCreationTime
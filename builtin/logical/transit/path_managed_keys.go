@@ -0,0 +1,325 @@
+package transit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/errwrap"
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const managedKeyStoragePrefix = "managed-keys/"
+
+// managedKeyEntry is the stored configuration of an external key
+type managedKeyEntry struct {
+	// Type is the KMS type, as used in the seal stanza of the server
+	// configuration
+	Type string `json:"type"`
+
+	// Config is handed to the KMS wrapper as is
+	Config map[string]string `json:"config"`
+
+	// Info is the non-sensitive description of the KMS key reported by the
+	// wrapper when it was configured
+	Info map[string]string `json:"info"`
+}
+
+// managedKeyWrapper adapts a KMS wrapper to keysutil.ManagedKey for the
+// duration of a request
+type managedKeyWrapper struct {
+	ctx     context.Context
+	wrapper wrapping.Wrapper
+}
+
+var _ keysutil.ManagedKey = (*managedKeyWrapper)(nil)
+
+// Encrypt returns the marshaled blob info produced by the wrapper, which
+// carries everything the wrapper needs to decrypt it again
+func (w *managedKeyWrapper) Encrypt(plaintext []byte) ([]byte, error) {
+	blobInfo, err := w.wrapper.Encrypt(w.ctx, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(blobInfo)
+}
+
+func (w *managedKeyWrapper) Decrypt(ciphertext []byte) ([]byte, error) {
+	blobInfo := &wrapping.EncryptedBlobInfo{}
+	if err := proto.Unmarshal(ciphertext, blobInfo); err != nil {
+		return nil, errwrap.Wrapf("failed to unmarshal ciphertext: {{err}}", err)
+	}
+	return w.wrapper.Decrypt(w.ctx, blobInfo, nil)
+}
+
+func (b *backend) pathListManagedKeys() *framework.Path {
+	return &framework.Path{
+		Pattern: "managed-keys/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathManagedKeysList,
+		},
+
+		HelpSynopsis:    pathManagedKeysHelpSyn,
+		HelpDescription: pathManagedKeysHelpDesc,
+	}
+}
+
+func (b *backend) pathManagedKeys() *framework.Path {
+	return &framework.Path{
+		Pattern: "managed-keys/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the managed key",
+			},
+
+			"type": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The type of KMS holding the key. Supported types are
+"aead", "alicloudkms", "awskms", "azurekeyvault", "gcpckms", "ocikms"
+and "transit".`,
+			},
+
+			"config": &framework.FieldSchema{
+				Type: framework.TypeKVPairs,
+				Description: `The configuration of the KMS key. The parameters are
+the same as those of the corresponding seal stanza in the server
+configuration.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathManagedKeysWrite,
+			logical.ReadOperation:   b.pathManagedKeysRead,
+			logical.DeleteOperation: b.pathManagedKeysDelete,
+		},
+
+		HelpSynopsis:    pathManagedKeysHelpSyn,
+		HelpDescription: pathManagedKeysHelpDesc,
+	}
+}
+
+func (b *backend) pathManagedKeysList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, managedKeyStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathManagedKeysWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	entry := &managedKeyEntry{
+		Type:   d.Get("type").(string),
+		Config: d.Get("config").(map[string]string),
+	}
+	if entry.Type == "" {
+		return logical.ErrorResponse("missing type"), logical.ErrInvalidRequest
+	}
+
+	// Configuring the wrapper validates the configuration; most wrappers
+	// also contact the KMS to check the key
+	wrapper, info, err := newManagedKeyWrapper(ctx, entry)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("error configuring managed key: %s", err)), logical.ErrInvalidRequest
+	}
+	entry.Info = info
+
+	storageEntry, err := logical.StorageEntryJSON(managedKeyStoragePrefix+name, entry)
+	if err != nil {
+		wrapper.Finalize(ctx)
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, storageEntry); err != nil {
+		wrapper.Finalize(ctx)
+		return nil, err
+	}
+
+	b.managedKeysLock.Lock()
+	defer b.managedKeysLock.Unlock()
+	if old, ok := b.managedKeys[name]; ok {
+		old.Finalize(ctx)
+	}
+	b.managedKeys[name] = wrapper
+
+	return nil, nil
+}
+
+func (b *backend) pathManagedKeysRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entry, err := getManagedKeyEntry(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	// The configuration holds credentials and is never returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"type": entry.Type,
+			"info": entry.Info,
+		},
+	}, nil
+}
+
+func (b *backend) pathManagedKeysDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	// Refuse to orphan the keys whose material lives in the KMS key
+	policies, err := req.Storage.List(ctx, "policy/")
+	if err != nil {
+		return nil, err
+	}
+	var inUse []string
+	for _, policyName := range policies {
+		p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: req.Storage,
+			Name:    policyName,
+		}, b.GetRandomReader())
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			continue
+		}
+		if !b.System().CachingDisabled() {
+			p.Lock(false)
+		}
+		if p.Type == keysutil.KeyType_MANAGED_KEY && p.ManagedKeyName == name {
+			inUse = append(inUse, policyName)
+		}
+		p.Unlock()
+	}
+	if len(inUse) > 0 {
+		return logical.ErrorResponse(fmt.Sprintf("managed key is in use by keys %s", strings.Join(inUse, ", "))), logical.ErrInvalidRequest
+	}
+
+	if err := req.Storage.Delete(ctx, managedKeyStoragePrefix+name); err != nil {
+		return nil, err
+	}
+	b.resetManagedKey(ctx, name)
+
+	return nil, nil
+}
+
+func getManagedKeyEntry(ctx context.Context, s logical.Storage, name string) (*managedKeyEntry, error) {
+	raw, err := s.Get(ctx, managedKeyStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var entry managedKeyEntry
+	if err := raw.DecodeJSON(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// newManagedKeyWrapper configures and initializes the KMS wrapper for the
+// managed key
+func newManagedKeyWrapper(ctx context.Context, entry *managedKeyEntry) (wrapping.Wrapper, map[string]string, error) {
+	if entry.Type == wrapping.Shamir {
+		return nil, nil, fmt.Errorf("unsupported KMS type %q", entry.Type)
+	}
+
+	var infoKeys []string
+	info := make(map[string]string)
+	wrapper, err := configutil.ConfigureWrapper(&configutil.KMS{
+		Type:   entry.Type,
+		Config: entry.Config,
+	}, &infoKeys, &info, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := wrapper.Init(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return wrapper, info, nil
+}
+
+// getManagedKey returns the external key of a managed key policy, bound to
+// the given request context. It returns nil for other policies.
+func (b *backend) getManagedKey(ctx context.Context, s logical.Storage, p *keysutil.Policy) (keysutil.ManagedKey, error) {
+	if p.Type != keysutil.KeyType_MANAGED_KEY {
+		return nil, nil
+	}
+
+	name := p.ManagedKeyName
+
+	b.managedKeysLock.RLock()
+	wrapper, ok := b.managedKeys[name]
+	b.managedKeysLock.RUnlock()
+	if ok {
+		return &managedKeyWrapper{ctx: ctx, wrapper: wrapper}, nil
+	}
+
+	b.managedKeysLock.Lock()
+	defer b.managedKeysLock.Unlock()
+
+	// Check again, another request may have configured the wrapper while we
+	// waited for the lock
+	if wrapper, ok := b.managedKeys[name]; ok {
+		return &managedKeyWrapper{ctx: ctx, wrapper: wrapper}, nil
+	}
+
+	entry, err := getManagedKeyEntry(ctx, s, name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("managed key %q not found", name)
+	}
+
+	wrapper, _, err = newManagedKeyWrapper(ctx, entry)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error configuring managed key %q: {{err}}", name), err)
+	}
+	b.managedKeys[name] = wrapper
+
+	return &managedKeyWrapper{ctx: ctx, wrapper: wrapper}, nil
+}
+
+// resetManagedKey finalizes and forgets the cached wrapper of the managed
+// key, so that it is configured again from storage on next use
+func (b *backend) resetManagedKey(ctx context.Context, name string) {
+	b.managedKeysLock.Lock()
+	defer b.managedKeysLock.Unlock()
+
+	if wrapper, ok := b.managedKeys[name]; ok {
+		wrapper.Finalize(ctx)
+		delete(b.managedKeys, name)
+	}
+}
+
+// cleanup finalizes the wrappers of all managed keys
+func (b *backend) cleanup(ctx context.Context) {
+	b.managedKeysLock.Lock()
+	defer b.managedKeysLock.Unlock()
+
+	for name, wrapper := range b.managedKeys {
+		wrapper.Finalize(ctx)
+		delete(b.managedKeys, name)
+	}
+}
+
+const pathManagedKeysHelpSyn = `Manage external keys backing transit keys`
+
+const pathManagedKeysHelpDesc = `
+This path configures keys held in an external KMS, such as a cloud KMS or
+the transit engine of another Vault. A transit key of type "managed_key"
+refers to one of these by name and delegates its cryptographic operations
+to the KMS, so that Vault never holds its key material.
+`
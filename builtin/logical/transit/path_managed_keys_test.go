@@ -0,0 +1,291 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// testTransitServer serves the write operations of a transit backend over
+// HTTP, standing in for the transit engine of another Vault
+func testTransitServer(b *backend, storage logical.Storage) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp, err := b.HandleRequest(r.Context(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      strings.TrimPrefix(r.URL.Path, "/v1/transit/"),
+			Data:      data,
+		})
+		if err == nil && resp != nil && resp.IsError() {
+			err = resp.Error()
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"errors": []string{err.Error()},
+			})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": resp.Data,
+		})
+	}))
+}
+
+func TestTransit_ManagedKeys(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	mustFail := func(op logical.Operation, path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+		}
+	}
+
+	// The external KMS is the transit engine of a second backend
+	remote, remoteStorage := createBackendWithSysView(t)
+	if _, err := remote.HandleRequest(context.Background(), &logical.Request{
+		Storage:   remoteStorage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/external",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	server := testTransitServer(remote, remoteStorage)
+	defer server.Close()
+
+	transitConfig := map[string]interface{}{
+		"address":         server.URL,
+		"token":           "root",
+		"mount_path":      "transit",
+		"key_name":        "external",
+		"disable_renewal": "true",
+	}
+
+	mustFail(logical.UpdateOperation, "managed-keys/kms", map[string]interface{}{
+		"type": "shamir",
+	})
+	mustFail(logical.UpdateOperation, "managed-keys/kms", map[string]interface{}{
+		"type":   "transit",
+		"config": map[string]interface{}{"mount_path": "transit"},
+	})
+	mustRequest(logical.UpdateOperation, "managed-keys/kms", map[string]interface{}{
+		"type":   "transit",
+		"config": transitConfig,
+	})
+	resp := mustRequest(logical.ReadOperation, "managed-keys/kms", nil)
+	if resp.Data["type"] != "transit" || resp.Data["info"].(map[string]string)["Transit Key Name"] != "external" {
+		t.Fatalf("bad managed key: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["config"]; ok {
+		t.Fatal("managed key configuration was returned")
+	}
+	resp = mustRequest(logical.ListOperation, "managed-keys/", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "kms" {
+		t.Fatalf("bad managed key list: %#v", resp.Data)
+	}
+
+	mustFail(logical.UpdateOperation, "keys/mk", map[string]interface{}{
+		"type": "managed_key",
+	})
+	mustFail(logical.UpdateOperation, "keys/mk", map[string]interface{}{
+		"type":             "managed_key",
+		"managed_key_name": "missing",
+	})
+	mustFail(logical.UpdateOperation, "keys/mk", map[string]interface{}{
+		"type":             "managed_key",
+		"managed_key_name": "kms",
+		"exportable":       true,
+	})
+	mustFail(logical.UpdateOperation, "keys/aes", map[string]interface{}{
+		"managed_key_name": "kms",
+	})
+	mustRequest(logical.UpdateOperation, "keys/mk", map[string]interface{}{
+		"type":             "managed_key",
+		"managed_key_name": "kms",
+	})
+	resp = mustRequest(logical.ReadOperation, "keys/mk", nil)
+	if resp.Data["type"] != "managed_key" || resp.Data["managed_key_name"] != "kms" || resp.Data["supports_encryption"] != true || resp.Data["supports_signing"] != false {
+		t.Fatalf("bad key: %#v", resp.Data)
+	}
+
+	// Encryption is delegated to the remote transit key
+	plaintext := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+	resp = mustRequest(logical.UpdateOperation, "encrypt/mk", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+	ciphertext := resp.Data["ciphertext"].(string)
+	if !strings.HasPrefix(ciphertext, "vault:v1:") {
+		t.Fatalf("bad ciphertext: %s", ciphertext)
+	}
+	resp = mustRequest(logical.UpdateOperation, "decrypt/mk", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if resp.Data["plaintext"] != plaintext {
+		t.Fatalf("bad plaintext: %#v", resp.Data)
+	}
+
+	resp = mustRequest(logical.UpdateOperation, "rewrap/mk", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	rewrapped := resp.Data["ciphertext"].(string)
+	resp = mustRequest(logical.UpdateOperation, "decrypt/mk", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"ciphertext": ciphertext},
+			map[string]interface{}{"ciphertext": rewrapped},
+		},
+	})
+	for _, item := range resp.Data["batch_results"].([]DecryptBatchResponseItem) {
+		if item.Plaintext != plaintext {
+			t.Fatalf("bad batch decryption: %#v", resp.Data)
+		}
+	}
+
+	resp = mustRequest(logical.UpdateOperation, "datakey/plaintext/mk", nil)
+	resp = mustRequest(logical.UpdateOperation, "decrypt/mk", map[string]interface{}{
+		"ciphertext": resp.Data["ciphertext"],
+	})
+	if key, _ := base64.StdEncoding.DecodeString(resp.Data["plaintext"].(string)); len(key) != 32 {
+		t.Fatalf("bad data key: %#v", resp.Data)
+	}
+
+	// Managed keys cannot sign, and the key material is rotated in the
+	// external KMS
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/mk",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"input": plaintext,
+		},
+	})
+	if err != logical.ErrInvalidRequest || resp == nil || !strings.Contains(resp.Error().Error(), "does not support signing") {
+		t.Fatalf("expected signing to be unsupported: err: %v resp: %#v", err, resp)
+	}
+	mustFail(logical.UpdateOperation, "keys/mk/rotate", nil)
+	mustFail(logical.UpdateOperation, "keys/mk/config", map[string]interface{}{
+		"auto_rotate_period": "24h",
+	})
+	mustFail(logical.UpdateOperation, "keys/mk/config", map[string]interface{}{
+		"exportable": true,
+	})
+
+	// Rotating the remote key is transparent
+	if _, err := remote.HandleRequest(context.Background(), &logical.Request{
+		Storage:   remoteStorage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/external/rotate",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp = mustRequest(logical.UpdateOperation, "decrypt/mk", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if resp.Data["plaintext"] != plaintext {
+		t.Fatalf("bad plaintext after remote rotation: %#v", resp.Data)
+	}
+
+	// The wrapper is configured again from storage after invalidation
+	b.invalidate(context.Background(), "managed-keys/kms")
+	resp = mustRequest(logical.UpdateOperation, "decrypt/mk", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if resp.Data["plaintext"] != plaintext {
+		t.Fatalf("bad plaintext after invalidation: %#v", resp.Data)
+	}
+
+	// The managed key cannot be deleted while a key uses it
+	mustFail(logical.DeleteOperation, "managed-keys/kms", nil)
+	mustRequest(logical.UpdateOperation, "keys/mk/config", map[string]interface{}{
+		"deletion_allowed": true,
+	})
+	mustRequest(logical.DeleteOperation, "keys/mk", nil)
+	mustRequest(logical.DeleteOperation, "managed-keys/kms", nil)
+	if resp := mustRequest(logical.ReadOperation, "managed-keys/kms", nil); resp != nil {
+		t.Fatalf("managed key not deleted: %#v", resp)
+	}
+}
+
+func TestTransit_ManagedKeys_AEAD(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	request("managed-keys/aead", map[string]interface{}{
+		"type": "aead",
+		"config": map[string]interface{}{
+			"aead_type": "aes-gcm",
+			"key":       base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+			"key_id":    "aead-key",
+		},
+	})
+	request("keys/mk", map[string]interface{}{
+		"type":             "managed_key",
+		"managed_key_name": "aead",
+	})
+
+	plaintext := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+	resp := request("encrypt/mk", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+	resp = request("decrypt/mk", map[string]interface{}{
+		"ciphertext": resp.Data["ciphertext"],
+	})
+	if resp.Data["plaintext"] != plaintext {
+		t.Fatalf("bad plaintext: %#v", resp.Data)
+	}
+
+	// HMACs use a key held by Vault
+	resp = request("hmac/mk", map[string]interface{}{
+		"input": plaintext,
+	})
+	resp = request("verify/mk", map[string]interface{}{
+		"input": plaintext,
+		"hmac":  resp.Data["hmac"],
+	})
+	if resp.Data["valid"] != true {
+		t.Fatalf("bad hmac verification: %#v", resp.Data)
+	}
+}
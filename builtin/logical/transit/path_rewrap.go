@@ -129,12 +129,18 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 		p.Lock(false)
 	}
 
+	managedKey, err := b.getManagedKey(ctx, req.Storage, p)
+	if err != nil {
+		p.Unlock()
+		return nil, err
+	}
+
 	for i, item := range batchInputItems {
 		if batchResponseItems[i].Error != "" {
 			continue
 		}
 
-		plaintext, err := p.Decrypt(item.DecodedContext, item.DecodedNonce, item.Ciphertext)
+		plaintext, err := p.DecryptWithFactory(item.DecodedContext, item.DecodedNonce, item.Ciphertext, managedKey)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
@@ -146,7 +152,7 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 			}
 		}
 
-		ciphertext, err := p.Encrypt(item.KeyVersion, item.DecodedContext, item.DecodedNonce, plaintext)
+		ciphertext, err := p.EncryptWithFactory(item.KeyVersion, item.DecodedContext, item.DecodedNonce, plaintext, managedKey)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
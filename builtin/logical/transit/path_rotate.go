@@ -45,6 +45,15 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 		p.Lock(true)
 	}
 
+	if p.Imported && !p.AllowImportedKeyRotation {
+		p.Unlock()
+		return logical.ErrorResponse("imported key does not allow rotation within Vault; import a new version instead"), logical.ErrInvalidRequest
+	}
+	if p.Type == keysutil.KeyType_MANAGED_KEY {
+		p.Unlock()
+		return logical.ErrorResponse("managed keys must be rotated in their external KMS"), logical.ErrInvalidRequest
+	}
+
 	// Rotate the policy
 	err = p.Rotate(ctx, req.Storage, b.GetRandomReader())
 
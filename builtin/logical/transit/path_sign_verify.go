@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"strconv"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/framework"
@@ -59,6 +60,52 @@ type batchResponseVerifyItem struct {
 	err error
 }
 
+// decodeBatchStringItems decodes the raw batch_input of the sign, verify, HMAC
+// and CMAC endpoints into a slice of string maps. Non-string values such as a
+// numeric key_version are converted to their string form.
+func decodeBatchStringItems(batchInputRaw interface{}, dst interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           dst,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(batchInputRaw)
+}
+
+// batchItemKeyVersion returns the key version requested by a batch item,
+// falling back to the request-level version if the item doesn't set one
+func batchItemKeyVersion(raw string, defaultVersion int) (int, error) {
+	if raw == "" {
+		return defaultVersion, nil
+	}
+	ver, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid key_version %q", raw)
+	}
+	return ver, nil
+}
+
+// parseSaltLength parses the salt_length parameter of the sign and verify
+// endpoints
+func parseSaltLength(raw string) (int, error) {
+	switch raw {
+	case "", "auto":
+		return keysutil.PSSSaltLengthAuto, nil
+	case "hash":
+		return keysutil.PSSSaltLengthEqualsHash, nil
+	}
+
+	// A salt length of 0 means auto to the rsa package, which would sign with
+	// the maximum salt length and verify any, so it can't be requested
+	saltLength, err := strconv.Atoi(raw)
+	if err != nil || saltLength <= 0 {
+		return 0, fmt.Errorf("invalid salt length %q; must be 'auto', 'hash' or a positive integer", raw)
+	}
+	return saltLength, nil
+}
+
 func (b *backend) pathSign() *framework.Path {
 	return &framework.Path{
 		Pattern: "sign/" + framework.GenericNameRegex("name") + framework.OptionalParamRegex("urlalgorithm"),
@@ -117,16 +164,35 @@ to the min_encryption_version configured on the key.`,
 				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048', 'rsa-3072' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter.`,
 			},
 
+			"salt_length": {
+				Type:    framework.TypeString,
+				Default: "auto",
+				Description: `The salt length used to sign with the RSA-PSS signature algorithm.
+Options are 'auto' (the default, which signs with the maximum salt length
+and detects it on verification), 'hash' (the length of the hash, as used by
+JWS) or a positive integer number of bytes. Only applies to RSA key types
+with the 'pss' signature algorithm.`,
+			},
+
 			"signature_algorithm": {
 				Type: framework.TypeString,
 				Description: `The signature algorithm to use for signing. Currently only applies to RSA key types.
 Options are 'pss' or 'pkcs1v15'. Defaults to 'pss'`,
 			},
 
+			"batch_input": {
+				Type: framework.TypeSlice,
+				Description: `Specifies a list of items to be signed in a single batch.
+Each item may set "input", "context" and "key_version". If this parameter
+is set, the top-level "input" and "context" parameters are ignored and
+the results are returned in "batch_results", with an "error" set on each
+item that could not be signed.`,
+			},
+
 			"marshaling_algorithm": {
 				Type:        framework.TypeString,
 				Default:     "asn1",
-				Description: `The method by which to marshal the signature. The default is 'asn1' which is used by openssl and X.509. It can also be set to 'jws' which is used for JWT signatures; setting it to this will also cause the encoding of the signature to be url-safe base64 instead of using standard base64 encoding. Currently only valid for ECDSA P-256 key types".`,
+				Description: `The method by which to marshal the signature. The default is 'asn1' which is used by openssl and X.509. It can also be set to 'jws' which is used for JWT signatures; setting it to this will also cause the encoding of the signature to be url-safe base64 instead of using standard base64 encoding. For ECDSA keys, 'jws' marshals the signature as the fixed-size concatenation of R and S.`,
 			},
 		},
 
@@ -164,6 +230,11 @@ derivation is enabled; currently only available with ed25519 keys.`,
 				Description: "The HMAC, including vault header/key version",
 			},
 
+			"cmac": {
+				Type:        framework.TypeString,
+				Description: "The CMAC, including vault header/key version",
+			},
+
 			"input": {
 				Type:        framework.TypeString,
 				Description: "The base64-encoded input data to verify",
@@ -199,16 +270,36 @@ Defaults to "sha2-256". Not valid for all key types.`,
 				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048', 'rsa-3072' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter.`,
 			},
 
+			"salt_length": {
+				Type:    framework.TypeString,
+				Default: "auto",
+				Description: `The salt length used to sign with the RSA-PSS signature algorithm.
+Options are 'auto' (the default, which signs with the maximum salt length
+and detects it on verification), 'hash' (the length of the hash, as used by
+JWS) or a positive integer number of bytes. Only applies to RSA key types
+with the 'pss' signature algorithm.`,
+			},
+
 			"signature_algorithm": {
 				Type: framework.TypeString,
 				Description: `The signature algorithm to use for signature verification. Currently only applies to RSA key types. 
 Options are 'pss' or 'pkcs1v15'. Defaults to 'pss'`,
 			},
 
+			"batch_input": {
+				Type: framework.TypeSlice,
+				Description: `Specifies a list of items to be verified in a single batch.
+Each item sets "input" and one of "signature", "hmac" or "cmac", and may set
+"context". All items must use the same kind of verification. If this
+parameter is set, the top-level parameters other than the algorithms are
+ignored and the results are returned in "batch_results", with an "error"
+set on each item that could not be verified.`,
+			},
+
 			"marshaling_algorithm": {
 				Type:        framework.TypeString,
 				Default:     "asn1",
-				Description: `The method by which to unmarshal the signature when verifying. The default is 'asn1' which is used by openssl and X.509; can also be set to 'jws' which is used for JWT signatures in which case the signature is also expected to be url-safe base64 encoding instead of standard base64 encoding. Currently only valid for ECDSA P-256 key types".`,
+				Description: `The method by which to unmarshal the signature when verifying. The default is 'asn1' which is used by openssl and X.509; can also be set to 'jws' which is used for JWT signatures in which case the signature is also expected to be url-safe base64 encoding instead of standard base64 encoding. For ECDSA keys, 'jws' expects the fixed-size concatenation of R and S.`,
 			},
 		},
 
@@ -245,6 +336,23 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 
 	prehashed := d.Get("prehashed").(bool)
 	sigAlgorithm := d.Get("signature_algorithm").(string)
+	switch sigAlgorithm {
+	case "", "pss", "pkcs1v15":
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("invalid signature algorithm %q", sigAlgorithm)), logical.ErrInvalidRequest
+	}
+
+	saltLength, err := parseSaltLength(d.Get("salt_length").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	signingOptions := &keysutil.SigningOptions{
+		HashAlgorithm: hashAlgorithm,
+		Marshaling:    marshaling,
+		SigAlgorithm:  sigAlgorithm,
+		SaltLength:    saltLength,
+	}
 
 	// Get the policy
 	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
@@ -269,7 +377,7 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 	batchInputRaw := d.Raw["batch_input"]
 	var batchInputItems []batchRequestSignItem
 	if batchInputRaw != nil {
-		err = mapstructure.Decode(batchInputRaw, &batchInputItems)
+		err = decodeBatchStringItems(batchInputRaw, &batchInputItems)
 		if err != nil {
 			p.Unlock()
 			return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
@@ -323,16 +431,31 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 			}
 		}
 
-		sig, err := p.Sign(ver, context, input, hashAlgorithm, sigAlgorithm, marshaling)
+		keyVersion, err := batchItemKeyVersion(item["key_version"], ver)
 		if err != nil {
-			if batchInputRaw != nil {
+			response[i].Error = err.Error()
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		sig, err := p.SignWithOptions(keyVersion, context, input, signingOptions)
+		if err != nil {
+			switch err.(type) {
+			case errutil.UserError:
 				response[i].Error = err.Error()
+				response[i].err = logical.ErrInvalidRequest
+			default:
+				if batchInputRaw != nil {
+					response[i].Error = err.Error()
+				}
+				response[i].err = err
 			}
-			response[i].err = err
 		} else if sig == nil {
 			response[i].err = fmt.Errorf("signature could not be computed")
+			if batchInputRaw != nil {
+				response[i].Error = response[i].err.Error()
+			}
 		} else {
-			keyVersion := ver
 			if keyVersion == 0 {
 				keyVersion = p.LatestVersion
 			}
@@ -377,7 +500,7 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 	batchInputRaw := d.Raw["batch_input"]
 	var batchInputItems []batchRequestVerifyItem
 	if batchInputRaw != nil {
-		err := mapstructure.Decode(batchInputRaw, &batchInputItems)
+		err := decodeBatchStringItems(batchInputRaw, &batchInputItems)
 		if err != nil {
 			return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
 		}
@@ -399,26 +522,47 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 		if hmac, ok := d.GetOk("hmac"); ok {
 			batchInputItems[0]["hmac"] = hmac.(string)
 		}
+		if cmac, ok := d.GetOk("cmac"); ok {
+			batchInputItems[0]["cmac"] = cmac.(string)
+		}
 		batchInputItems[0]["context"] = d.Get("context").(string)
 	}
 
 	// For simplicity, 'signature' and 'hmac' cannot be mixed across batch_input elements.
 	// If one batch_input item is 'signature', they all must be 'signature'.
 	// If one batch_input item is 'hmac', they all must be 'hmac'.
+	// The same holds for 'cmac', which cannot be mixed with either.
 	sigFound := false
 	hmacFound := false
+	cmacFound := false
 	missing := false
 	for _, v := range batchInputItems {
+		_, hasCMAC := v["cmac"]
+		if hasCMAC {
+			cmacFound = true
+		}
 		if _, ok := v["signature"]; ok {
 			sigFound = true
 		} else if _, ok := v["hmac"]; ok {
 			hmacFound = true
-		} else {
+		} else if !hasCMAC {
 			missing = true
 		}
 	}
 
 	switch {
+	case batchInputRaw == nil && cmacFound && (sigFound || hmacFound):
+		return logical.ErrorResponse("provide one of 'signature', 'hmac' or 'cmac'"), logical.ErrInvalidRequest
+
+	case cmacFound && (sigFound || hmacFound):
+		return logical.ErrorResponse("elements of batch_input providing 'cmac' cannot be mixed with 'signature' or 'hmac'"), logical.ErrInvalidRequest
+
+	case cmacFound && missing:
+		return logical.ErrorResponse("some elements of batch_input are missing 'cmac'"), logical.ErrInvalidRequest
+
+	case cmacFound:
+		return b.pathCMACVerify(ctx, req, d)
+
 	case batchInputRaw == nil && sigFound && hmacFound:
 		return logical.ErrorResponse("provide one of 'signature' or 'hmac'"), logical.ErrInvalidRequest
 
@@ -463,6 +607,23 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 
 	prehashed := d.Get("prehashed").(bool)
 	sigAlgorithm := d.Get("signature_algorithm").(string)
+	switch sigAlgorithm {
+	case "", "pss", "pkcs1v15":
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("invalid signature algorithm %q", sigAlgorithm)), logical.ErrInvalidRequest
+	}
+
+	saltLength, err := parseSaltLength(d.Get("salt_length").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	signingOptions := &keysutil.SigningOptions{
+		HashAlgorithm: hashAlgorithm,
+		Marshaling:    marshaling,
+		SigAlgorithm:  sigAlgorithm,
+		SaltLength:    saltLength,
+	}
 
 	// Get the policy
 	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
@@ -526,7 +687,7 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 			}
 		}
 
-		valid, err := p.VerifySignature(context, input, hashAlgorithm, sigAlgorithm, marshaling, sig)
+		valid, err := p.VerifySignatureWithOptions(context, input, sig, signingOptions)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
@@ -2,6 +2,7 @@ package transit
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"strconv"
@@ -680,3 +681,221 @@ func TestTransit_SignVerify_ED25519(t *testing.T) {
 	outcome[1].valid = false
 	verifyRequest(req, false, outcome, "bar", goodsig, true)
 }
+
+func TestTransit_SignVerify_BatchKeyTypes(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	input := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+
+	for _, keyType := range []string{"ecdsa-p256", "ecdsa-p384", "ecdsa-p521", "ed25519", "rsa-2048"} {
+		name := "key-" + keyType
+		request("keys/"+name, map[string]interface{}{"type": keyType})
+		request("keys/"+name+"/rotate", nil)
+
+		// Items decoded from JSON carry numeric key versions
+		resp := request("sign/"+name, map[string]interface{}{
+			"batch_input": []interface{}{
+				map[string]interface{}{"input": input},
+				map[string]interface{}{"input": input, "key_version": 1},
+				map[string]interface{}{"input": input, "key_version": 3},
+				map[string]interface{}{"input": "not base64!"},
+				map[string]interface{}{},
+			},
+		})
+		signed := resp.Data["batch_results"].([]batchResponseSignItem)
+		if len(signed) != 5 {
+			t.Fatalf("%s: bad number of batch results: %#v", keyType, signed)
+		}
+		if signed[0].KeyVersion != 2 || !strings.HasPrefix(signed[0].Signature, "vault:v2:") {
+			t.Fatalf("%s: bad latest version signature: %#v", keyType, signed[0])
+		}
+		if signed[1].KeyVersion != 1 || !strings.HasPrefix(signed[1].Signature, "vault:v1:") {
+			t.Fatalf("%s: bad explicit version signature: %#v", keyType, signed[1])
+		}
+		for i := 2; i < 5; i++ {
+			if signed[i].Error == "" || signed[i].Signature != "" {
+				t.Fatalf("%s: expected error in batch result %d: %#v", keyType, i, signed[i])
+			}
+		}
+
+		resp = request("verify/"+name, map[string]interface{}{
+			"batch_input": []interface{}{
+				map[string]interface{}{"input": input, "signature": signed[0].Signature},
+				map[string]interface{}{"input": input, "signature": signed[1].Signature},
+				map[string]interface{}{"input": base64.StdEncoding.EncodeToString([]byte("tampered")), "signature": signed[0].Signature},
+				map[string]interface{}{"input": input, "signature": "vault:v9:AAAA"},
+			},
+		})
+		verified := resp.Data["batch_results"].([]batchResponseVerifyItem)
+		if !verified[0].Valid || !verified[1].Valid || verified[2].Valid {
+			t.Fatalf("%s: bad batch verification results: %#v", keyType, verified)
+		}
+		if verified[3].Valid || verified[3].Error == "" {
+			t.Fatalf("%s: expected error for unknown key version: %#v", keyType, verified[3])
+		}
+	}
+}
+
+func TestTransit_SignVerify_SigningOptions(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	mustFail := func(path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(path, data)
+		if err == nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected error: path: %s err: %v resp: %#v", path, err, resp)
+		}
+	}
+	verify := func(name string, data map[string]interface{}) bool {
+		t.Helper()
+		return mustRequest("verify/"+name, data).Data["valid"].(bool)
+	}
+
+	input := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+	digest := sha256.Sum256([]byte("the quick brown fox"))
+	prehashedInput := base64.StdEncoding.EncodeToString(digest[:])
+
+	mustRequest("keys/rsa", map[string]interface{}{"type": "rsa-2048"})
+
+	// PSS with the various salt lengths; verification must use the same one
+	// unless the salt length is detected automatically
+	for _, saltLength := range []string{"auto", "hash", "1", "20", "222"} {
+		sig := mustRequest("sign/rsa", map[string]interface{}{
+			"input":               input,
+			"signature_algorithm": "pss",
+			"salt_length":         saltLength,
+		}).Data["signature"].(string)
+
+		if !verify("rsa", map[string]interface{}{
+			"input":               input,
+			"signature":           sig,
+			"signature_algorithm": "pss",
+			"salt_length":         saltLength,
+		}) {
+			t.Fatalf("salt length %s: signature did not verify", saltLength)
+		}
+		if !verify("rsa", map[string]interface{}{
+			"input":     input,
+			"signature": sig,
+		}) {
+			t.Fatalf("salt length %s: signature did not verify with automatic salt length", saltLength)
+		}
+		if saltLength != "20" && verify("rsa", map[string]interface{}{
+			"input":               input,
+			"signature":           sig,
+			"signature_algorithm": "pss",
+			"salt_length":         "20",
+		}) {
+			t.Fatalf("salt length %s: signature verified with mismatched salt length", saltLength)
+		}
+	}
+	for _, saltLength := range []string{"-1", "0", "foo", "223"} {
+		mustFail("sign/rsa", map[string]interface{}{
+			"input":               input,
+			"signature_algorithm": "pss",
+			"salt_length":         saltLength,
+		})
+	}
+	mustFail("verify/rsa", map[string]interface{}{
+		"input": input,
+		"signature": mustRequest("sign/rsa", map[string]interface{}{
+			"input": input,
+		}).Data["signature"].(string),
+		"signature_algorithm": "pss",
+		"salt_length":         "0",
+	})
+	mustFail("sign/rsa", map[string]interface{}{
+		"input":               input,
+		"signature_algorithm": "rsassa",
+	})
+
+	// PKCS#1v15 signatures do not verify as PSS
+	sig := mustRequest("sign/rsa", map[string]interface{}{
+		"input":               input,
+		"signature_algorithm": "pkcs1v15",
+	}).Data["signature"].(string)
+	if !verify("rsa", map[string]interface{}{"input": input, "signature": sig, "signature_algorithm": "pkcs1v15"}) {
+		t.Fatal("pkcs1v15 signature did not verify")
+	}
+	if verify("rsa", map[string]interface{}{"input": input, "signature": sig, "signature_algorithm": "pss"}) {
+		t.Fatal("pkcs1v15 signature verified as pss")
+	}
+
+	// Prehashed input must be a digest of the hash algorithm, and verifies
+	// against a signature of the original input
+	for _, sigAlgorithm := range []string{"pss", "pkcs1v15"} {
+		sig = mustRequest("sign/rsa", map[string]interface{}{
+			"input":               prehashedInput,
+			"prehashed":           true,
+			"signature_algorithm": sigAlgorithm,
+		}).Data["signature"].(string)
+		if !verify("rsa", map[string]interface{}{"input": input, "signature": sig, "signature_algorithm": sigAlgorithm}) {
+			t.Fatalf("%s: prehashed signature did not verify", sigAlgorithm)
+		}
+		mustFail("sign/rsa", map[string]interface{}{
+			"input":               input,
+			"prehashed":           true,
+			"signature_algorithm": sigAlgorithm,
+		})
+	}
+
+	// JWS marshaling is supported for all ECDSA curves and uses fixed-size
+	// signatures
+	for keyType, sigLen := range map[string]int{"ecdsa-p256": 64, "ecdsa-p384": 96, "ecdsa-p521": 132} {
+		mustRequest("keys/"+keyType, map[string]interface{}{"type": keyType})
+		sig := mustRequest("sign/"+keyType, map[string]interface{}{
+			"input":                input,
+			"marshaling_algorithm": "jws",
+		}).Data["signature"].(string)
+
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(sig, "vault:v1:"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(raw) != sigLen {
+			t.Fatalf("%s: expected signature of %d bytes, got %d", keyType, sigLen, len(raw))
+		}
+		if !verify(keyType, map[string]interface{}{"input": input, "signature": sig, "marshaling_algorithm": "jws"}) {
+			t.Fatalf("%s: jws signature did not verify", keyType)
+		}
+		truncated := "vault:v1:" + base64.RawURLEncoding.EncodeToString(raw[:sigLen-1])
+		if resp, err := request("verify/"+keyType, map[string]interface{}{
+			"input":                input,
+			"signature":            truncated,
+			"marshaling_algorithm": "jws",
+		}); err == nil && resp.Data["valid"] == true {
+			t.Fatalf("%s: truncated jws signature verified", keyType)
+		}
+
+	}
+}
@@ -0,0 +1,350 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// The encrypted stream format written by the EncryptStream function of the
+// api package: the magic bytes, the big-endian length of the JSON-encoded
+// header and the header itself, followed by chunks sealed with AES-256-GCM
+// under a data key of the named transit key. Every chunk holds chunk_size
+// bytes of plaintext but the last one, and authenticates the whole header as
+// additional data.
+const (
+	streamNoncePrefixSize = 7
+	streamMaxHeaderSize   = 64 * 1024
+	streamMaxChunkSize    = 16 * 1024 * 1024
+)
+
+var streamMagic = []byte("VTS\x01")
+
+type streamHeader struct {
+	Key         string `json:"key"`
+	Ciphertext  string `json:"ciphertext"`
+	ChunkSize   int    `json:"chunk_size"`
+	NoncePrefix []byte `json:"nonce_prefix"`
+	Context     []byte `json:"context,omitempty"`
+}
+
+func (b *backend) pathStreamDecrypt() *framework.Path {
+	return &framework.Path{
+		Pattern: "stream/decrypt/" + framework.GenericNameRegex("name"),
+		Fields:  streamFields(),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathStreamDecryptWrite,
+		},
+
+		HelpSynopsis:    pathStreamDecryptHelpSyn,
+		HelpDescription: pathStreamDecryptHelpDesc,
+	}
+}
+
+func (b *backend) pathStreamVerify() *framework.Path {
+	return &framework.Path{
+		Pattern: "stream/verify/" + framework.GenericNameRegex("name"),
+		Fields:  streamFields(),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathStreamVerifyWrite,
+		},
+
+		HelpSynopsis:    pathStreamVerifyHelpSyn,
+		HelpDescription: pathStreamVerifyHelpDesc,
+	}
+}
+
+func streamFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the key the stream was encrypted with",
+		},
+
+		"header": &framework.FieldSchema{
+			Type: framework.TypeString,
+			Description: `
+Base64 encoded header of the encrypted stream, as found at its start.`,
+		},
+
+		"chunks": &framework.FieldSchema{
+			Type: framework.TypeString,
+			Description: `
+Base64 encoded consecutive chunks of the encrypted stream, starting at the
+chunk given by first_chunk.`,
+		},
+
+		"first_chunk": &framework.FieldSchema{
+			Type:        framework.TypeInt,
+			Description: `Index of the first chunk provided. Defaults to 0.`,
+		},
+
+		"final": &framework.FieldSchema{
+			Type:    framework.TypeBool,
+			Default: true,
+			Description: `
+Whether the chunks provided run to the end of the stream. If set, the last
+chunk is authenticated as the final chunk of the stream, which detects
+truncation. Defaults to true.`,
+		},
+
+		"offset": &framework.FieldSchema{
+			Type: framework.TypeInt,
+			Description: `
+Offset in the plaintext of the stream of the range to read. Defaults to the
+start of the first chunk provided.`,
+		},
+
+		"length": &framework.FieldSchema{
+			Type: framework.TypeInt,
+			Description: `
+Length of the range to read. Defaults to the end of the chunks provided.`,
+		},
+	}
+}
+
+// parseStreamHeader decodes the header of an encrypted stream
+func parseStreamHeader(headerBytes []byte) (*streamHeader, error) {
+	prefixSize := len(streamMagic) + 4
+	if len(headerBytes) < prefixSize || !bytes.Equal(headerBytes[:len(streamMagic)], streamMagic) {
+		return nil, fmt.Errorf("unknown encrypted stream format")
+	}
+	length := binary.BigEndian.Uint32(headerBytes[len(streamMagic):prefixSize])
+	if length > streamMaxHeaderSize || int(length) != len(headerBytes)-prefixSize {
+		return nil, fmt.Errorf("invalid encrypted stream header length")
+	}
+
+	var header streamHeader
+	if err := json.Unmarshal(headerBytes[prefixSize:], &header); err != nil {
+		return nil, fmt.Errorf("invalid encrypted stream header: %s", err)
+	}
+	if header.Ciphertext == "" || header.ChunkSize <= 0 || header.ChunkSize > streamMaxChunkSize || len(header.NoncePrefix) != streamNoncePrefixSize {
+		return nil, fmt.Errorf("invalid encrypted stream header")
+	}
+	return &header, nil
+}
+
+// streamNonce returns the nonce of the chunk at the given index: the random
+// prefix of the stream, the big-endian index and a flag marking the final
+// chunk
+func streamNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, streamNoncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamNoncePrefixSize:], index)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+func (b *backend) pathStreamDecryptWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.readStream(ctx, req, d, false)
+}
+
+func (b *backend) pathStreamVerifyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.readStream(ctx, req, d, true)
+}
+
+// readStream authenticates the chunks of an encrypted stream covering the
+// requested range, returning the plaintext of the range unless verifyOnly is
+// set
+func (b *backend) readStream(ctx context.Context, req *logical.Request, d *framework.FieldData, verifyOnly bool) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	headerBytes, err := base64.StdEncoding.DecodeString(d.Get("header").(string))
+	if err != nil || len(headerBytes) == 0 {
+		return logical.ErrorResponse("missing or invalid base64 header"), logical.ErrInvalidRequest
+	}
+	header, err := parseStreamHeader(headerBytes)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if header.Key != name {
+		return logical.ErrorResponse("stream was encrypted with key %q", header.Key), logical.ErrInvalidRequest
+	}
+
+	chunks, err := base64.StdEncoding.DecodeString(d.Get("chunks").(string))
+	if err != nil || len(chunks) == 0 {
+		return logical.ErrorResponse("missing or invalid base64 chunks"), logical.ErrInvalidRequest
+	}
+	firstChunk := int64(d.Get("first_chunk").(int))
+	final := d.Get("final").(bool)
+
+	// Every chunk but the final one of the stream is full, and the final one
+	// holds at least the authentication tag
+	const overhead = 16
+	chunkSize := int64(header.ChunkSize)
+	sealedChunkSize := chunkSize + overhead
+	numChunks := (int64(len(chunks)) + sealedChunkSize - 1) / sealedChunkSize
+	lastSize := int64(len(chunks)) - (numChunks-1)*sealedChunkSize
+	switch {
+	case firstChunk < 0 || firstChunk+numChunks-1 > math.MaxUint32:
+		return logical.ErrorResponse("invalid first_chunk"), logical.ErrInvalidRequest
+	case !final && lastSize != sealedChunkSize:
+		return logical.ErrorResponse("chunks must be whole unless they run to the end of the stream"), logical.ErrInvalidRequest
+	case lastSize < overhead:
+		return logical.ErrorResponse("truncated final chunk"), logical.ErrInvalidRequest
+	}
+
+	start := firstChunk * chunkSize
+	end := start + int64(len(chunks)) - numChunks*overhead
+	offset := start
+	if offsetRaw, ok := d.GetOk("offset"); ok {
+		offset = int64(offsetRaw.(int))
+	}
+	length := end - offset
+	if lengthRaw, ok := d.GetOk("length"); ok {
+		length = int64(lengthRaw.(int))
+		if length <= 0 {
+			return logical.ErrorResponse("length must be positive"), logical.ErrInvalidRequest
+		}
+	}
+	if offset < start || length < 0 || offset+length > end {
+		return logical.ErrorResponse("range [%d, %d) is not covered by the chunks provided, which hold [%d, %d)", offset, offset+length, start, end), logical.ErrInvalidRequest
+	}
+
+	// Get the policy
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+
+	managedKey, err := b.getManagedKey(ctx, req.Storage, p)
+	if err != nil {
+		p.Unlock()
+		return nil, err
+	}
+
+	encodedKey, err := p.DecryptWithFactory(header.Context, nil, header.Ciphertext, managedKey)
+	p.Unlock()
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(dataKey) != 32 {
+		return logical.ErrorResponse("invalid data key in the stream header"), logical.ErrInvalidRequest
+	}
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the chunks covering the range are authenticated. An empty range
+	// lies at the end of the chunks provided, whose last chunk is checked.
+	firstIndex, lastIndex := offset/chunkSize, (offset+length-1)/chunkSize
+	if length == 0 {
+		firstIndex, lastIndex = firstChunk+numChunks-1, firstChunk+numChunks-1
+	}
+
+	var plaintext []byte
+	if !verifyOnly {
+		plaintext = make([]byte, 0, length)
+	}
+	opened := make([]byte, 0, chunkSize)
+	for index := firstIndex; index <= lastIndex; index++ {
+		i := index - firstChunk
+		sealed := chunks[i*sealedChunkSize:]
+		if int64(len(sealed)) > sealedChunkSize {
+			sealed = sealed[:sealedChunkSize]
+		}
+		last := final && i == numChunks-1
+
+		opened, err = aead.Open(opened[:0], streamNonce(header.NoncePrefix, uint32(index), last), sealed, headerBytes)
+		if err != nil {
+			if verifyOnly {
+				return &logical.Response{
+					Data: map[string]interface{}{
+						"valid": false,
+						"error": fmt.Sprintf("chunk %d failed to authenticate", index),
+					},
+				}, nil
+			}
+			return logical.ErrorResponse("chunk %d failed to authenticate", index), logical.ErrInvalidRequest
+		}
+		if verifyOnly {
+			continue
+		}
+
+		chunkStart := index * chunkSize
+		from, to := int64(0), int64(len(opened))
+		if offset > chunkStart {
+			from = offset - chunkStart
+		}
+		if offset+length < chunkStart+to {
+			to = offset + length - chunkStart
+		}
+		if from < to {
+			plaintext = append(plaintext, opened[from:to]...)
+		}
+	}
+
+	if verifyOnly {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"valid": true,
+			},
+		}, nil
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+			"offset":    offset,
+			"length":    length,
+		},
+	}, nil
+}
+
+const pathStreamDecryptHelpSyn = `Decrypt a range of a stream encrypted by the API client`
+
+const pathStreamDecryptHelpDesc = `
+This path decrypts part of a stream encrypted with a data key of the named key
+by the streaming encryption of the API client and the "transit encrypt-file"
+command. The header of the stream and the chunks covering the range to read
+are provided, base64 encoded; only the chunks covering the range are
+authenticated and decrypted. The plaintext of the range is returned base64
+encoded.
+
+Providing the chunks up to the end of the stream with "final" set detects
+truncation of the stream. The size of the chunks provided is limited by the
+maximum request size of Vault.
+`
+
+const pathStreamVerifyHelpSyn = `Verify a range of a stream encrypted by the API client`
+
+const pathStreamVerifyHelpDesc = `
+This path authenticates the chunks of a stream encrypted by the API client
+which cover the range given, without returning the plaintext. See the
+"stream/decrypt" path for the parameters. Returns "valid" set to false, along
+with the chunk which failed, if any chunk fails to authenticate.
+`
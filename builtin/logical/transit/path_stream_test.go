@@ -0,0 +1,148 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_Stream(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustRequest := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s err: %v resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+	mustFail := func(path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(path, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error: path: %s resp: %#v", path, resp)
+		}
+	}
+
+	mustRequest("keys/foo", nil)
+
+	// Encrypt a stream the way the API client does
+	const chunkSize = 16
+	plaintext := []byte("the quick brown fox jumps over the lazy dog")
+	resp := mustRequest("datakey/plaintext/foo", nil)
+	dataKey, err := base64.StdEncoding.DecodeString(resp.Data["plaintext"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &streamHeader{
+		Key:         "foo",
+		Ciphertext:  resp.Data["ciphertext"].(string),
+		ChunkSize:   chunkSize,
+		NoncePrefix: make([]byte, streamNoncePrefixSize),
+	}
+	if _, err := rand.Read(header.NoncePrefix); err != nil {
+		t.Fatal(err)
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headerBuf bytes.Buffer
+	headerBuf.Write(streamMagic)
+	binary.Write(&headerBuf, binary.BigEndian, uint32(len(headerJSON)))
+	headerBuf.Write(headerJSON)
+	headerBytes := headerBuf.Bytes()
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sealed [][]byte
+	for i := 0; i*chunkSize < len(plaintext); i++ {
+		end := (i + 1) * chunkSize
+		last := end >= len(plaintext)
+		if last {
+			end = len(plaintext)
+		}
+		sealed = append(sealed, aead.Seal(nil, streamNonce(header.NoncePrefix, uint32(i), last), plaintext[i*chunkSize:end], headerBytes))
+	}
+
+	data := func(firstChunk int, final bool, chunks ...[]byte) map[string]interface{} {
+		return map[string]interface{}{
+			"header":      base64.StdEncoding.EncodeToString(headerBytes),
+			"chunks":      base64.StdEncoding.EncodeToString(bytes.Join(chunks, nil)),
+			"first_chunk": firstChunk,
+			"final":       final,
+		}
+	}
+	decrypted := func(resp *logical.Response) string {
+		t.Helper()
+		plaintext, err := base64.StdEncoding.DecodeString(resp.Data["plaintext"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(plaintext)
+	}
+
+	resp = mustRequest("stream/decrypt/foo", data(0, true, sealed...))
+	if decrypted(resp) != string(plaintext) {
+		t.Fatalf("bad plaintext: %#v", resp.Data)
+	}
+	resp = mustRequest("stream/verify/foo", data(0, true, sealed...))
+	if resp.Data["valid"] != true {
+		t.Fatalf("bad verification: %#v", resp.Data)
+	}
+
+	// Ranged reads only need the chunks covering the range
+	rangeData := data(1, false, sealed[1])
+	rangeData["offset"] = 20
+	rangeData["length"] = 10
+	resp = mustRequest("stream/decrypt/foo", rangeData)
+	if decrypted(resp) != string(plaintext[20:30]) {
+		t.Fatalf("bad range plaintext: %#v", resp.Data)
+	}
+	rangeData = data(1, true, sealed[1], sealed[2])
+	rangeData["offset"] = 30
+	resp = mustRequest("stream/decrypt/foo", rangeData)
+	if decrypted(resp) != string(plaintext[30:]) {
+		t.Fatalf("bad range plaintext: %#v", resp.Data)
+	}
+	rangeData["offset"] = 10
+	mustFail("stream/decrypt/foo", rangeData)
+
+	// Truncated, reordered and tampered streams fail to authenticate
+	mustFail("stream/decrypt/foo", data(0, true, sealed[0], sealed[1]))
+	mustFail("stream/decrypt/foo", data(0, true, sealed[1], sealed[0], sealed[2]))
+	tampered := append([]byte(nil), sealed[2]...)
+	tampered[0] ^= 1
+	mustFail("stream/decrypt/foo", data(0, true, sealed[0], sealed[1], tampered))
+	resp = mustRequest("stream/verify/foo", data(0, true, sealed[0], sealed[1], tampered))
+	if resp.Data["valid"] != false {
+		t.Fatalf("bad verification: %#v", resp.Data)
+	}
+
+	// The stream must be read with the key it was encrypted with
+	mustRequest("keys/bar", nil)
+	mustFail("stream/decrypt/bar", data(0, true, sealed...))
+}
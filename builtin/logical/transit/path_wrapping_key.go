@@ -0,0 +1,93 @@
+package transit
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	wrappingKeyName          = "wrapping-key"
+	wrappingKeyStoragePrefix = "import/"
+)
+
+func (b *backend) pathWrappingKey() *framework.Path {
+	return &framework.Path{
+		Pattern: "wrapping_key",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathWrappingKeyRead,
+		},
+
+		HelpSynopsis:    pathWrappingKeyHelpSyn,
+		HelpDescription: pathWrappingKeyHelpDesc,
+	}
+}
+
+func (b *backend) pathWrappingKeyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	p, err := b.getWrappingKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := p.Keys[strconv.Itoa(p.LatestVersion)]
+	if !ok {
+		return nil, fmt.Errorf("wrapping key version %d not found", p.LatestVersion)
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(entry.RSAKey.Public())
+	if err != nil {
+		return nil, errwrap.Wrapf("error marshaling wrapping key: {{err}}", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	})
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": string(pemBytes),
+		},
+	}, nil
+}
+
+// getWrappingKey returns the RSA-4096 key used to wrap key material for
+// import, generating it on first use
+func (b *backend) getWrappingKey(ctx context.Context, s logical.Storage) (*keysutil.Policy, error) {
+	b.wrappingKeyLock.Lock()
+	defer b.wrappingKeyLock.Unlock()
+
+	p, err := keysutil.LoadPolicy(ctx, s, wrappingKeyStoragePrefix+"policy/"+wrappingKeyName)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return p, nil
+	}
+
+	p = keysutil.NewPolicy(keysutil.PolicyConfig{
+		Name:          wrappingKeyName,
+		Type:          keysutil.KeyType_RSA4096,
+		StoragePrefix: wrappingKeyStoragePrefix,
+	})
+	if err := p.Rotate(ctx, s, b.GetRandomReader()); err != nil {
+		return nil, errwrap.Wrapf("error generating wrapping key: {{err}}", err)
+	}
+
+	return p, nil
+}
+
+const pathWrappingKeyHelpSyn = `Returns the public key to use for wrapping imported keys`
+
+const pathWrappingKeyHelpDesc = `
+This path is used to retrieve the RSA-4096 public key which must be used to
+wrap key material before importing it with the "keys/<name>/import" and
+"keys/<name>/import_version" paths. The key is generated on first use.
+`
@@ -593,6 +593,21 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"transit": func() (cli.Command, error) {
+			return &TransitCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit decrypt-file": func() (cli.Command, error) {
+			return &TransitDecryptFileCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit encrypt-file": func() (cli.Command, error) {
+			return &TransitEncryptFileCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"unwrap": func() (cli.Command, error) {
 			return &UnwrapCommand{
 				BaseCommand: getBaseCommand(),
@@ -609,6 +624,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"pki": func() (cli.Command, error) {
+			return &PKICommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"pki list-certs": func() (cli.Command, error) {
+			return &PKIListCertsCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"kv": func() (cli.Command, error) {
 			return &KVCommand{
 				BaseCommand: getBaseCommand(),
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*PKICommand)(nil)
+
+type PKICommand struct {
+	*BaseCommand
+}
+
+func (c *PKICommand) Synopsis() string {
+	return "Interact with Vault's PKI secrets engine"
+}
+
+func (c *PKICommand) Help() string {
+	helpText := `
+Usage: vault pki <subcommand> [options] [args]
+
+  This command has subcommands for interacting with Vault's PKI secrets
+  engine. Here are some simple examples, and more detailed examples are
+  available in the subcommands or the documentation.
+
+  List the certificates issued by the "pki" mount:
+
+      $ vault pki list-certs pki
+
+  List the certificates issued by the "web" role which expire within a week:
+
+      $ vault pki list-certs -role=web -expiring-within=168h pki
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PKICommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
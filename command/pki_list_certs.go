@@ -0,0 +1,203 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*PKIListCertsCommand)(nil)
+var _ cli.CommandAutocomplete = (*PKIListCertsCommand)(nil)
+
+type PKIListCertsCommand struct {
+	*BaseCommand
+
+	flagRole           string
+	flagExpiringWithin time.Duration
+	flagRevoked        string
+	flagAfter          string
+	flagLimit          int
+}
+
+func (c *PKIListCertsCommand) Synopsis() string {
+	return "List certificates along with their metadata"
+}
+
+func (c *PKIListCertsCommand) Help() string {
+	helpText := `
+Usage: vault pki list-certs [options] MOUNT
+
+  Lists the certificates stored by the PKI secrets engine mounted at the given
+  path, along with their common name, the role which issued them, their
+  expiration and whether they were revoked.
+
+  List all certificates of the "pki" mount:
+
+      $ vault pki list-certs pki
+
+  List the revoked certificates issued by the "web" role:
+
+      $ vault pki list-certs -role=web -revoked=true pki
+
+  List the next page of 100 certificates, after the last serial number of the
+  previous page:
+
+      $ vault pki list-certs -limit=100 -after=17-67-16-b0-b9-45-58-c0-3a-29-e3-cb-d6-98-33-7a-a6-3b-df-07 pki
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PKIListCertsCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	f.StringVar(&StringVar{
+		Name:    "role",
+		Target:  &c.flagRole,
+		Default: "",
+		Usage:   "Only list certificates issued by this role.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "expiring-within",
+		Target:     &c.flagExpiringWithin,
+		Completion: complete.PredictAnything,
+		Usage: "Only list certificates which have not expired yet, but expire " +
+			"within this duration.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "revoked",
+		Target:     &c.flagRevoked,
+		Default:    "",
+		Completion: complete.PredictSet("true", "false"),
+		Usage: "If set to true, only list revoked certificates. If set to " +
+			"false, only list certificates which were not revoked.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "after",
+		Target:  &c.flagAfter,
+		Default: "",
+		Usage:   "Only list certificates whose serial number sorts after this one.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "limit",
+		Target:  &c.flagLimit,
+		Default: 0,
+		Usage:   "The maximum number of certificates to list. By default, all are listed.",
+	})
+
+	return set
+}
+
+func (c *PKIListCertsCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultMounts()
+}
+
+func (c *PKIListCertsCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PKIListCertsCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	data := map[string][]string{}
+	if c.flagRole != "" {
+		data["role"] = []string{c.flagRole}
+	}
+	if c.flagExpiringWithin > 0 {
+		data["expiring_within"] = []string{c.flagExpiringWithin.String()}
+	}
+	if c.flagRevoked != "" {
+		revoked, err := strconv.ParseBool(c.flagRevoked)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Invalid value for -revoked: %s", c.flagRevoked))
+			return 1
+		}
+		data["revoked"] = []string{strconv.FormatBool(revoked)}
+	}
+	if c.flagAfter != "" {
+		data["after"] = []string{c.flagAfter}
+	}
+	if c.flagLimit > 0 {
+		data["limit"] = []string{strconv.Itoa(c.flagLimit)}
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	path := sanitizePath(args[0]) + "/certs/detailed"
+	secret, err := client.Logical().ReadWithData(path, data)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing certificates at %s: %s", path, err))
+		return 2
+	}
+
+	if secret == nil || secret.Data == nil {
+		if Format(c.UI) != "table" {
+			OutputData(c.UI, map[string]interface{}{})
+		} else {
+			c.UI.Error(fmt.Sprintf("No certificates found at %s", path))
+		}
+		return 2
+	}
+
+	// If the secret is wrapped, return the wrapped response.
+	if secret.WrapInfo != nil && secret.WrapInfo.TTL != 0 {
+		return OutputSecret(c.UI, secret)
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, secret.Data)
+	}
+
+	keyInfo, _ := secret.Data["key_info"].(map[string]interface{})
+	serials := make([]string, 0, len(keyInfo))
+	for serial := range keyInfo {
+		serials = append(serials, serial)
+	}
+	sort.Strings(serials)
+
+	out := []string{"Serial Number | Common Name | Role | Not After | Revoked"}
+	for _, serial := range serials {
+		info, ok := keyInfo[serial].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s | %v | %v | %v | %v",
+			info["serial_number"], info["common_name"], info["role"], info["not_after"], info["revoked"]))
+	}
+	c.UI.Output(tableOutput(out, nil))
+	return 0
+}
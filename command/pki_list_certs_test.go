@@ -0,0 +1,194 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testPKIListCertsCommand(tb testing.TB) (*cli.MockUi, *PKIListCertsCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PKIListCertsCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testPKIMount mounts the PKI secrets engine with a root CA and issues a
+// certificate from each of the "web" and "db" roles
+func testPKIMount(tb testing.TB, client *api.Client) {
+	tb.Helper()
+
+	if err := client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "example.com",
+		"ttl":         "48h",
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	for _, role := range []string{"web", "db"} {
+		if _, err := client.Logical().Write("pki/roles/"+role, map[string]interface{}{
+			"allowed_domains":  "example.com",
+			"allow_subdomains": true,
+		}); err != nil {
+			tb.Fatal(err)
+		}
+		if _, err := client.Logical().Write("pki/issue/"+role, map[string]interface{}{
+			"common_name": role + ".example.com",
+			"ttl":         "1h",
+		}); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestPKIListCertsCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"pki", "foo"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"bad_revoked",
+			[]string{"-revoked=maybe", "pki"},
+			"Invalid value for -revoked",
+			1,
+		},
+		{
+			"all",
+			[]string{"pki"},
+			"web.example.com",
+			0,
+		},
+		{
+			"role",
+			[]string{"-role=db", "pki"},
+			"db.example.com",
+			0,
+		},
+		{
+			"expiring_within",
+			[]string{"-expiring-within=2h", "pki"},
+			"Not After",
+			0,
+		},
+		{
+			"no_revoked",
+			[]string{"-revoked=true", "pki"},
+			"No certificates found",
+			2,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+				testPKIMount(t, client)
+
+				ui, cmd := testPKIListCertsCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+		testPKIMount(t, client)
+
+		ui, cmd := testPKIListCertsCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-role=web", "pki"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		combined := ui.OutputWriter.String()
+		if !strings.Contains(combined, "web.example.com") || strings.Contains(combined, "db.example.com") {
+			t.Errorf("expected only the web certificate, got %q", combined)
+		}
+
+		ui, cmd = testPKIListCertsCommand(t)
+		cmd.client = client
+
+		code = cmd.Run([]string{"-limit=1", "pki"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n"); len(lines) != 3 {
+			t.Errorf("expected a single certificate, got %q", ui.OutputWriter.String())
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testPKIListCertsCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"pki"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error listing certificates at pki/certs/detailed: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPKIListCertsCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -40,6 +40,7 @@ type SSHCommand struct {
 	flagPrivateKeyPath    string
 	flagHostKeyMountPoint string
 	flagHostKeyHostnames  string
+	flagHostCAPinning     bool
 	flagValidPrincipals   string
 }
 
@@ -194,6 +195,19 @@ func (c *SSHCommand) Flags() *FlagSets {
 			"list of values.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:       "host-ca-pinning",
+		Target:     &c.flagHostCAPinning,
+		Default:    true,
+		EnvVar:     "VAULT_SSH_HOST_CA_PINNING",
+		Completion: complete.PredictNothing,
+		Usage: "Trust the CA of the SSH secrets engine given by -mount-point for " +
+			"the host keys of the hostnames given by -host-key-hostnames, in " +
+			"addition to the user's \"known_hosts\" file. Hosts whose keys were " +
+			"signed by the CA are verified without being trusted on first use. " +
+			"This has no effect when -host-key-mount-point is set.",
+	})
+
 	f.StringVar(&StringVar{
 		Name:       "valid-principals",
 		Target:     &c.flagValidPrincipals,
@@ -456,6 +470,30 @@ func (c *SSHCommand) handleTypeCA(username, ip, port string, sshArgs []string) i
 		// Update the variables
 		userKnownHostsFile = knownHosts
 		strictHostKeyChecking = "yes"
+	} else if c.flagHostCAPinning {
+		// Otherwise pin the CA of the mount for host certificates, next to the
+		// user's known_hosts file so that hosts without a signed host key
+		// still verify as before. Older servers and mounts without a CA
+		// cannot provide the CA, which is not fatal.
+		knownHostsLine, err := sshClient.KnownHosts(c.flagHostKeyHostnames)
+		if err != nil {
+			c.UI.Warn(fmt.Sprintf("failed to get host CA, not pinning it: %s", err))
+		} else if knownHostsLine != "" {
+			name := fmt.Sprintf("vault_ssh_ca_known_hosts_%s_%s", username, ip)
+			knownHosts, err, closer := c.writeTemporaryFile(name, []byte(knownHostsLine), 0644)
+			defer closer()
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("failed to write host CA: %s", err))
+				return 1
+			}
+
+			// ssh adds the keys accepted by the user to the first file, so
+			// the user's file must come first
+			if userKnownHostsFile == "" {
+				userKnownHostsFile = "~/.ssh/known_hosts"
+			}
+			userKnownHostsFile = fmt.Sprintf(`"%s" "%s"`, userKnownHostsFile, knownHosts)
+		}
 	}
 
 	// Write the signed public key to disk
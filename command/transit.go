@@ -0,0 +1,43 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*TransitCommand)(nil)
+
+type TransitCommand struct {
+	*BaseCommand
+}
+
+func (c *TransitCommand) Synopsis() string {
+	return "Interact with Vault's Transit secrets engine"
+}
+
+func (c *TransitCommand) Help() string {
+	helpText := `
+Usage: vault transit <subcommand> [options] [args]
+
+  This command has subcommands for interacting with Vault's Transit secrets
+  engine. Here are some simple examples, and more detailed examples are
+  available in the subcommands or the documentation.
+
+  Encrypt a file of any size under a data key of the transit key "backups":
+
+      $ vault transit encrypt-file backups backup.tar backup.tar.enc
+
+  Decrypt it again:
+
+      $ vault transit decrypt-file backup.tar.enc backup.tar
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,207 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testTransitDecryptFileCommand(tb testing.TB) (*cli.MockUi, *TransitDecryptFileCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitDecryptFileCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testTransitEncryptedFile writes the plaintext encrypted with "my-key" to a
+// temporary file, returning its path
+func testTransitEncryptedFile(tb testing.TB, client *api.Client, plaintext string) string {
+	tb.Helper()
+
+	var encrypted bytes.Buffer
+	err := client.Transit().EncryptStream(context.Background(), "my-key", &encrypted, strings.NewReader(plaintext), &api.TransitStreamOptions{
+		ChunkSize: 100,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "vault-transit")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "encrypted")
+	if err := ioutil.WriteFile(path, encrypted.Bytes(), 0600); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestTransitDecryptFileCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	plaintext := strings.Repeat("The quick brown fox", 1000)
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"-", "-", "-"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"negative_offset",
+			[]string{"-offset=-1", "-"},
+			"Offset must not be negative",
+			1,
+		},
+		{
+			"ranged_stdin",
+			[]string{"-offset=10", "-"},
+			"requires the input to be a file",
+			1,
+		},
+		{
+			"ranged_verify",
+			[]string{"-verify", "-length=10", "-"},
+			"Cannot verify a range",
+			1,
+		},
+		{
+			"verify_output",
+			[]string{"-verify", "-", "out"},
+			"No output may be given",
+			1,
+		},
+		{
+			"not_encrypted",
+			[]string{"-"},
+			"Error decrypting file",
+			2,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testTransitDecryptFileCommand(t)
+				cmd.client = client
+				cmd.testStdin = strings.NewReader("not encrypted")
+				cmd.testStdout = &bytes.Buffer{}
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+		testTransitKey(t, client)
+
+		path := testTransitEncryptedFile(t, client, plaintext)
+
+		for _, tc := range []struct {
+			args     []string
+			expected string
+		}{
+			{[]string{path}, plaintext},
+			{[]string{"-offset=150", "-length=250", path, "-"}, plaintext[150:400]},
+			{[]string{"-offset=18990", path}, plaintext[18990:]},
+			{[]string{"-length=10", path}, plaintext[:10]},
+		} {
+			ui, cmd := testTransitDecryptFileCommand(t)
+			cmd.client = client
+			stdout := &bytes.Buffer{}
+			cmd.testStdout = stdout
+
+			code := cmd.Run(tc.args)
+			if exp := 0; code != exp {
+				t.Fatalf("%v: expected %d to be %d: %s", tc.args, code, exp, ui.ErrorWriter.String())
+			}
+			if stdout.String() != tc.expected {
+				t.Errorf("%v: bad decrypted output", tc.args)
+			}
+		}
+
+		ui, cmd := testTransitDecryptFileCommand(t)
+		cmd.client = client
+		code := cmd.Run([]string{"-verify", path})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if expected := "Success! The file is intact."; !strings.Contains(ui.OutputWriter.String(), expected) {
+			t.Errorf("expected %q to contain %q", ui.OutputWriter.String(), expected)
+		}
+
+		// Tamper with the last chunk
+		encrypted, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encrypted[len(encrypted)-1] ^= 1
+		if err := ioutil.WriteFile(path, encrypted, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd = testTransitDecryptFileCommand(t)
+		cmd.client = client
+		code = cmd.Run([]string{"-verify", path})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+		if expected := "failed to authenticate"; !strings.Contains(ui.ErrorWriter.String(), expected) {
+			t.Errorf("expected %q to contain %q", ui.ErrorWriter.String(), expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransitDecryptFileCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,172 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testTransitEncryptFileCommand(tb testing.TB) (*cli.MockUi, *TransitEncryptFileCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitEncryptFileCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testTransitKey mounts the transit secrets engine with an encryption key
+// named "my-key"
+func testTransitKey(tb testing.TB, client *api.Client) {
+	tb.Helper()
+
+	if err := client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := client.Logical().Write("transit/keys/my-key", nil); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestTransitEncryptFileCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"my-key", "-"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"my-key", "-", "-", "-"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"bad_context",
+			[]string{"-context=%%%", "my-key", "-", "-"},
+			"Failed to base64-decode context",
+			1,
+		},
+		{
+			"missing_input",
+			[]string{"my-key", "/nope/not/real", "-"},
+			"Error opening input file",
+			1,
+		},
+		{
+			"missing_key",
+			[]string{"not-a-key", "-", "-"},
+			"Error encrypting file",
+			2,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+				testTransitKey(t, client)
+
+				ui, cmd := testTransitEncryptFileCommand(t)
+				cmd.client = client
+				cmd.testStdin = strings.NewReader("")
+				cmd.testStdout = &bytes.Buffer{}
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+		testTransitKey(t, client)
+
+		plaintext := strings.Repeat("The quick brown fox", 1000)
+		encrypted := &bytes.Buffer{}
+
+		ui, cmd := testTransitEncryptFileCommand(t)
+		cmd.client = client
+		cmd.testStdin = strings.NewReader(plaintext)
+		cmd.testStdout = encrypted
+
+		code := cmd.Run([]string{"-chunk-size=100", "my-key", "-", "-"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if strings.Contains(encrypted.String(), "quick brown fox") {
+			t.Fatal("plaintext found in encrypted output")
+		}
+
+		decrypted := &bytes.Buffer{}
+		if err := client.Transit().DecryptStream(context.Background(), decrypted, encrypted); err != nil {
+			t.Fatal(err)
+		}
+		if decrypted.String() != plaintext {
+			t.Errorf("expected decrypted output to match the plaintext")
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testTransitEncryptFileCommand(t)
+		cmd.client = client
+		cmd.testStdin = strings.NewReader("foo")
+		cmd.testStdout = &bytes.Buffer{}
+
+		code := cmd.Run([]string{"my-key", "-", "-"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error encrypting file: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransitEncryptFileCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
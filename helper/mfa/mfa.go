@@ -36,6 +36,19 @@ func MFARootPaths() []string {
 	return append(duo.DuoRootPaths(), "mfa_config")
 }
 
+// MFAEnabled reports whether MFA of a supported type is configured on the
+// backend using the given storage. Backends use it to refuse operations which
+// would let users authenticate without MFA.
+func MFAEnabled(ctx context.Context, s logical.Storage) (bool, error) {
+	var b backend
+	config, err := b.MFAConfig(ctx, &logical.Request{Storage: s})
+	if err != nil || config == nil {
+		return false, err
+	}
+	_, ok := handlers[config.Type]
+	return ok, nil
+}
+
 // HandlerFunc is the callback called to handle MFA for a login request.
 type HandlerFunc func(context.Context, *logical.Request, *framework.FieldData, *logical.Response) (*logical.Response, error)
 
@@ -112,6 +112,36 @@ LOOP:
 	}
 }
 
+// Validate a candidate string against the generator. The string must be at least as long as the length specified,
+// only contain characters from the charset and pass all of the rules. This allows user-provided values such as
+// passwords to be checked against the same policies used to generate them.
+func (g *StringGenerator) Validate(str string) (err error) {
+	// Ensure the generator is configured well since it may be manually created rather than parsed from HCL
+	err = g.validateConfig()
+	if err != nil {
+		return err
+	}
+
+	candidate := []rune(str)
+
+	merr := &multierror.Error{}
+	if len(candidate) < g.Length {
+		merr = multierror.Append(merr, fmt.Errorf("must be at least %d characters", g.Length))
+	}
+	for _, r := range candidate {
+		if !charIn(r, g.charset) {
+			merr = multierror.Append(merr, fmt.Errorf("contains characters not in the charset"))
+			break
+		}
+	}
+	for _, rule := range g.Rules {
+		if !rule.Pass(candidate) {
+			merr = multierror.Append(merr, fmt.Errorf("does not pass %s rule", rule.Type()))
+		}
+	}
+	return merr.ErrorOrNil()
+}
+
 func (g *StringGenerator) generate(rng io.Reader) (str string, err error) {
 	// If performance improvements need to be made, this can be changed to read a batch of
 	// potential strings at once rather than one at a time. This will significantly
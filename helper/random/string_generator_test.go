@@ -632,6 +632,62 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestStringGenerator_Validate(t *testing.T) {
+	type testCase struct {
+		value     string
+		expectErr bool
+	}
+
+	generator := &StringGenerator{
+		Length: 8,
+		Rules: []Rule{
+			CharsetRule{
+				Charset:  LowercaseRuneset,
+				MinChars: 1,
+			},
+			CharsetRule{
+				Charset:  NumericRuneset,
+				MinChars: 2,
+			},
+		},
+	}
+
+	tests := map[string]testCase{
+		"passes all rules": {
+			value:     "abcdef12",
+			expectErr: false,
+		},
+		"longer than length": {
+			value:     "abcdefghij1234",
+			expectErr: false,
+		},
+		"shorter than length": {
+			value:     "abc12",
+			expectErr: true,
+		},
+		"fails rule": {
+			value:     "abcdefg1",
+			expectErr: true,
+		},
+		"characters outside of charset": {
+			value:     "abcdef12-",
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := generator.Validate(test.value)
+			if test.expectErr && err == nil {
+				t.Fatalf("err expected, got nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+		})
+	}
+}
+
 type testNonCharsetRule struct {
 	String string `mapstructure:"string" json:"string"`
 }
@@ -1062,6 +1062,26 @@ func isForm(head []byte, contentType string) bool {
 	return true
 }
 
+// isOcspRequest returns true if the content type is that of a DER encoded
+// OCSP request, which must be passed through to the backend unparsed.
+func isOcspRequest(contentType string) bool {
+	contentType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return contentType == "application/ocsp-request"
+}
+
+// isACMENewNonceRequest returns true if the path is the new-nonce endpoint of
+// an ACME server of the PKI secrets engine, "<mount>/acme/<role>/new-nonce",
+// which RFC 8555 requires to answer HEAD requests.
+func isACMENewNonceRequest(path string) bool {
+	segments := strings.Split(path, "/")
+	n := len(segments)
+	return n >= 4 && segments[n-1] == "new-nonce" && segments[n-3] == "acme"
+}
+
 func respondError(w http.ResponseWriter, status int, err error) {
 	logical.RespondError(w, status, err)
 }
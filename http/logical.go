@@ -99,10 +99,11 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 		bufferedBody := newBufferedReader(r.Body)
 		r.Body = bufferedBody
 
-		// If we are uploading a snapshot we don't want to parse it. Instead
-		// we will simply add the HTTP request to the logical request object
-		// for later consumption.
-		if path == "sys/storage/raft/snapshot" || path == "sys/storage/raft/snapshot-force" {
+		// If we are uploading a snapshot or receiving an OCSP request (which
+		// is DER encoded) we don't want to parse it. Instead we will simply
+		// add the HTTP request to the logical request object for later
+		// consumption.
+		if path == "sys/storage/raft/snapshot" || path == "sys/storage/raft/snapshot-force" || isOcspRequest(r.Header.Get("Content-Type")) {
 			passHTTPReq = true
 			origBody = r.Body
 		} else {
@@ -146,7 +147,14 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 			path += "/"
 		}
 
-	case "OPTIONS", "HEAD":
+	case "HEAD":
+		// HEAD is only served by the ACME new-nonce endpoints of the PKI
+		// secrets engine
+		if isACMENewNonceRequest(path) {
+			op = logical.HeaderOperation
+		}
+
+	case "OPTIONS":
 	default:
 		return nil, nil, http.StatusMethodNotAllowed, nil
 	}
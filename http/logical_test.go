@@ -356,6 +356,54 @@ func TestLogical_ListSuffix(t *testing.T) {
 	}
 }
 
+func TestLogical_ListQueryParams(t *testing.T) {
+	// Query parameters are only passed along as data to reads
+	cases := map[string]map[string]interface{}{
+		"GET /v1/pki/certs/detailed?role=web&limit=10": map[string]interface{}{
+			"role":  "web",
+			"limit": "10",
+		},
+		"GET /v1/pki/certs/detailed?list=true&role=web": nil,
+		"LIST /v1/pki/certs/detailed?role=web":          nil,
+	}
+	for request, expected := range cases {
+		parts := strings.SplitN(request, " ", 2)
+		req, _ := http.NewRequest(parts[0], "http://127.0.0.1:8200"+parts[1], nil)
+		req = req.WithContext(namespace.RootContext(nil))
+
+		lreq, _, status, err := buildLogicalRequestNoAuth(false, nil, req)
+		if err != nil || status != 0 {
+			t.Fatalf("%s: status: %d err: %v", request, status, err)
+		}
+		if !reflect.DeepEqual(lreq.Data, expected) {
+			t.Fatalf("%s: expected data %#v, got %#v", request, expected, lreq.Data)
+		}
+	}
+}
+
+func TestLogical_HeadACMENewNonce(t *testing.T) {
+	cases := map[string]logical.Operation{
+		"pki/acme/web/new-nonce":      logical.HeaderOperation,
+		"ns1/pki/acme/web/new-nonce":  logical.HeaderOperation,
+		"pki/acme/web/directory":      "",
+		"acme/web/new-nonce":          "",
+		"secret/foo":                  "",
+		"secret/acme/web/new-nonce/x": "",
+	}
+	for path, expected := range cases {
+		req, _ := http.NewRequest("HEAD", "http://127.0.0.1:8200/v1/"+path, nil)
+		req = req.WithContext(namespace.RootContext(nil))
+
+		lreq, _, status, err := buildLogicalRequestNoAuth(false, nil, req)
+		if err != nil || status != 0 {
+			t.Fatalf("%s: status: %d err: %v", path, status, err)
+		}
+		if lreq.Operation != expected {
+			t.Fatalf("%s: expected operation %q, got %q", path, expected, lreq.Operation)
+		}
+	}
+}
+
 func TestLogical_RespondWithStatusCode(t *testing.T) {
 	resp := &logical.Response{
 		Data: map[string]interface{}{
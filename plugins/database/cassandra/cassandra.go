@@ -97,6 +97,10 @@ func (c *Cassandra) Initialize(ctx context.Context, req dbplugin.InitializeReque
 // NewUser generates the username/password on the underlying Cassandra secret backend as instructed by
 // the statements provided.
 func (c *Cassandra) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	if req.CredentialType != dbplugin.CredentialTypePassword {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unsupported credential type %s", req.CredentialType)
+	}
+
 	c.Lock()
 	defer c.Unlock()
 
@@ -79,6 +79,10 @@ func (h *HANA) getConnection(ctx context.Context) (*sql.DB, error) {
 // NewUser generates the username/password on the underlying HANA secret backend
 // as instructed by the CreationStatement provided.
 func (h *HANA) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (response dbplugin.NewUserResponse, err error) {
+	if req.CredentialType != dbplugin.CredentialTypePassword {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unsupported credential type %s", req.CredentialType)
+	}
+
 	// Grab the lock
 	h.Lock()
 	defer h.Unlock()
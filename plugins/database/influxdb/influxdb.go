@@ -61,6 +61,10 @@ func (i *Influxdb) getConnection(ctx context.Context) (influx.Client, error) {
 // NewUser generates the username/password on the underlying Influxdb secret backend as instructed by
 // the statements provided.
 func (i *Influxdb) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (resp dbplugin.NewUserResponse, err error) {
+	if req.CredentialType != dbplugin.CredentialTypePassword {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unsupported credential type %s", req.CredentialType)
+	}
+
 	i.Lock()
 	defer i.Unlock()
 
@@ -104,6 +104,10 @@ func (m *MSSQL) Initialize(ctx context.Context, req dbplugin.InitializeRequest)
 // NewUser generates the username/password on the underlying MSSQL secret backend as instructed by
 // the statements provided.
 func (m *MSSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	if req.CredentialType != dbplugin.CredentialTypePassword {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unsupported credential type %s", req.CredentialType)
+	}
+
 	m.Lock()
 	defer m.Unlock()
 
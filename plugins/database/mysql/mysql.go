@@ -30,7 +30,10 @@ const (
 	DefaultLegacyUserNameTemplate = `{{ printf "v-%s-%s-%s" (.RoleName | truncate 4) (random 20) | truncate 16 }}`
 )
 
-var _ dbplugin.Database = (*MySQL)(nil)
+var (
+	_ dbplugin.Database           = (*MySQL)(nil)
+	_ dbplugin.StatementValidator = (*MySQL)(nil)
+)
 
 type MySQL struct {
 	*mySQLConnectionProducer
@@ -110,6 +113,10 @@ func (m *MySQL) Initialize(ctx context.Context, req dbplugin.InitializeRequest)
 }
 
 func (m *MySQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	if req.CredentialType != dbplugin.CredentialTypePassword {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unsupported credential type %s", req.CredentialType)
+	}
+
 	if len(req.Statements.Commands) == 0 {
 		return dbplugin.NewUserResponse{}, dbutil.ErrEmptyCreationStatement
 	}
@@ -140,6 +147,82 @@ func (m *MySQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplu
 	return resp, nil
 }
 
+// ValidateStatements prepares the statements without executing them. MySQL
+// implicitly commits statements such as CREATE USER, so they cannot be run in
+// a transaction that is rolled back; preparing a statement checks its syntax
+// and the objects it refers to. Statements that cannot be prepared are
+// reported as unvalidated.
+func (m *MySQL) ValidateStatements(ctx context.Context, req dbplugin.ValidateStatementsRequest) (dbplugin.ValidateStatementsResponse, error) {
+	username := req.Username
+	if len(req.CreationStatements.Commands) > 0 {
+		var err error
+		username, err = m.usernameProducer.Generate(req.UsernameConfig)
+		if err != nil {
+			return dbplugin.ValidateStatementsResponse{}, err
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	db, err := m.getConnection(ctx)
+	if err != nil {
+		return dbplugin.ValidateStatementsResponse{}, err
+	}
+
+	queryMap := map[string]string{
+		"name":       username,
+		"username":   username,
+		"password":   req.Password,
+		"expiration": req.Expiration.Format("2006-01-02 15:04:05-0700"),
+	}
+
+	var resp dbplugin.ValidateStatementsResponse
+	operations := []struct {
+		name       string
+		statements dbplugin.Statements
+	}{
+		{dbplugin.StatementOperationCreation, req.CreationStatements},
+		{dbplugin.StatementOperationRotation, req.RotationStatements},
+		{dbplugin.StatementOperationRevocation, req.RevocationStatements},
+	}
+	for _, operation := range operations {
+		for i, stmt := range operation.statements.Commands {
+			for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+				query = strings.TrimSpace(query)
+				if len(query) == 0 {
+					continue
+				}
+
+				prepared, err := db.PrepareContext(ctx, dbutil.QueryHelper(query, queryMap))
+				if err != nil {
+					// Error 1295: This command is not supported in the
+					// prepared statement protocol yet
+					if e, ok := err.(*stdmysql.MySQLError); ok && e.Number == 1295 {
+						resp.Unvalidated = append(resp.Unvalidated, dbplugin.UnvalidatedStatement{
+							Operation: operation.name,
+							Index:     i,
+							Statement: query,
+							Reason:    "the statement cannot be prepared: " + e.Message,
+						})
+						continue
+					}
+					resp.Failure = &dbplugin.StatementFailure{
+						Operation: operation.name,
+						Index:     i,
+						Statement: query,
+						Error:     err.Error(),
+					}
+					return resp, nil
+				}
+				prepared.Close()
+			}
+		}
+	}
+
+	return resp, nil
+}
+
 func (m *MySQL) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
 	// Grab the read lock
 	m.Lock()
@@ -739,6 +739,131 @@ func TestMySQL_UpdateUser(t *testing.T) {
 	}
 }
 
+func TestMySQL_ValidateStatements(t *testing.T) {
+	type testCase struct {
+		creationStmts       []string
+		expectedFailure     *dbplugin.StatementFailure
+		expectedUnvalidated []dbplugin.UnvalidatedStatement
+	}
+
+	tests := map[string]testCase{
+		"valid statements": {
+			creationStmts: []string{`
+				CREATE USER '{{name}}'@'%' IDENTIFIED BY '{{password}}';
+				GRANT SELECT ON *.* TO '{{name}}'@'%';`},
+		},
+		"failed creation statement": {
+			creationStmts: []string{
+				`CREATE USER '{{name}}'@'%' IDENTIFIED BY '{{password}}';`,
+				`CREATE USR '{{name}}'@'%' IDENTIFIED BY '{{password}}';`,
+			},
+			expectedFailure: &dbplugin.StatementFailure{
+				Operation: dbplugin.StatementOperationCreation,
+				Index:     1,
+			},
+		},
+		"statement that cannot be prepared": {
+			creationStmts: []string{
+				`CREATE USER '{{name}}'@'%' IDENTIFIED BY '{{password}}';`,
+				`UNLOCK TABLES;`,
+			},
+			expectedUnvalidated: []dbplugin.UnvalidatedStatement{
+				{
+					Operation: dbplugin.StatementOperationCreation,
+					Index:     1,
+					Statement: "UNLOCK TABLES",
+				},
+			},
+		},
+	}
+
+	cleanup, connURL := mysqlhelper.PrepareTestContainer(t, false, "secret")
+	defer cleanup()
+
+	connectionDetails := map[string]interface{}{
+		"connection_url": connURL,
+	}
+
+	initReq := dbplugin.InitializeRequest{
+		Config:           connectionDetails,
+		VerifyConnection: true,
+	}
+
+	db := newMySQL(DefaultUserNameTemplate)
+	defer db.Close()
+	_, err := db.Initialize(context.Background(), initReq)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	countUsers := func(t *testing.T) int {
+		t.Helper()
+		conn, err := sql.Open("mysql", connURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		var count int
+		if err := conn.QueryRow("SELECT COUNT(*) FROM mysql.user").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			usersBefore := countUsers(t)
+
+			req := dbplugin.ValidateStatementsRequest{
+				UsernameConfig: dbplugin.UsernameMetadata{
+					DisplayName: "test",
+					RoleName:    "test",
+				},
+				CreationStatements: dbplugin.Statements{
+					Commands: test.creationStmts,
+				},
+				Password:   "09g8hanbdfkVSM",
+				Expiration: time.Now().Add(time.Minute),
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			resp, err := db.ValidateStatements(ctx, req)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			if test.expectedFailure == nil {
+				if resp.Failure != nil {
+					t.Fatalf("unexpected failure: %#v", resp.Failure)
+				}
+			} else if resp.Failure == nil ||
+				resp.Failure.Operation != test.expectedFailure.Operation ||
+				resp.Failure.Index != test.expectedFailure.Index {
+				t.Fatalf("Actual failure: %#v\nExpected failure: %#v", resp.Failure, test.expectedFailure)
+			}
+
+			if len(resp.Unvalidated) != len(test.expectedUnvalidated) {
+				t.Fatalf("Actual unvalidated: %#v\nExpected unvalidated: %#v", resp.Unvalidated, test.expectedUnvalidated)
+			}
+			for i, expected := range test.expectedUnvalidated {
+				actual := resp.Unvalidated[i]
+				if actual.Operation != expected.Operation || actual.Index != expected.Index ||
+					actual.Statement != expected.Statement || actual.Reason == "" {
+					t.Fatalf("Actual unvalidated: %#v\nExpected unvalidated: %#v", actual, expected)
+				}
+			}
+
+			// Preparing the statements does not create any user
+			if usersAfter := countUsers(t); usersAfter != usersBefore {
+				t.Fatalf("expected %d users, found %d", usersBefore, usersAfter)
+			}
+		})
+	}
+}
+
 func createTestMySQLUser(t *testing.T, connURL, username, password, query string) {
 	t.Helper()
 	db, err := sql.Open("mysql", connURL)
@@ -33,7 +33,8 @@ ALTER ROLE "{{username}}" WITH PASSWORD '{{password}}';
 )
 
 var (
-	_ dbplugin.Database = &PostgreSQL{}
+	_ dbplugin.Database           = &PostgreSQL{}
+	_ dbplugin.StatementValidator = &PostgreSQL{}
 
 	// postgresEndStatement is basically the word "END" but
 	// surrounded by a word boundary to differentiate it from
@@ -101,6 +102,9 @@ func (p *PostgreSQL) Initialize(ctx context.Context, req dbplugin.InitializeRequ
 
 	resp := dbplugin.InitializeResponse{
 		Config: newConf,
+		SupportedCredentialTypes: []dbplugin.CredentialType{
+			dbplugin.CredentialTypeClientCertificate,
+		},
 	}
 	return resp, nil
 }
@@ -248,9 +252,20 @@ func (p *PostgreSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (
 	p.Lock()
 	defer p.Unlock()
 
-	username, err := p.usernameProducer.Generate(req.UsernameConfig)
-	if err != nil {
-		return dbplugin.NewUserResponse{}, err
+	var username string
+	switch req.CredentialType {
+	case dbplugin.CredentialTypePassword:
+		var err error
+		username, err = p.usernameProducer.Generate(req.UsernameConfig)
+		if err != nil {
+			return dbplugin.NewUserResponse{}, err
+		}
+	case dbplugin.CredentialTypeClientCertificate:
+		// PostgreSQL certificate authentication maps the common name of the
+		// client certificate to the role of the same name
+		username = req.Subject
+	default:
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unsupported credential type %s", req.CredentialType)
 	}
 
 	expirationStr := req.Expiration.Format(expirationFormat)
@@ -310,6 +325,79 @@ func (p *PostgreSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (
 	return resp, nil
 }
 
+// ValidateStatements runs the statements in a transaction that is rolled back,
+// so that neither the test user nor the effects of the statements remain
+func (p *PostgreSQL) ValidateStatements(ctx context.Context, req dbplugin.ValidateStatementsRequest) (dbplugin.ValidateStatementsResponse, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	username := req.Username
+	if len(req.CreationStatements.Commands) > 0 {
+		var err error
+		username, err = p.usernameProducer.Generate(req.UsernameConfig)
+		if err != nil {
+			return dbplugin.ValidateStatementsResponse{}, err
+		}
+	}
+
+	db, err := p.getConnection(ctx)
+	if err != nil {
+		return dbplugin.ValidateStatementsResponse{}, fmt.Errorf("unable to get connection: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbplugin.ValidateStatementsResponse{}, fmt.Errorf("unable to start transaction: %w", err)
+	}
+	// Never committed
+	defer tx.Rollback()
+
+	m := map[string]string{
+		"name":       username,
+		"username":   username,
+		"password":   req.Password,
+		"expiration": req.Expiration.Format(expirationFormat),
+	}
+
+	operations := []struct {
+		name       string
+		statements dbplugin.Statements
+	}{
+		{dbplugin.StatementOperationCreation, req.CreationStatements},
+		{dbplugin.StatementOperationRotation, req.RotationStatements},
+		{dbplugin.StatementOperationRevocation, req.RevocationStatements},
+	}
+	for _, operation := range operations {
+		for i, stmt := range operation.statements.Commands {
+			queries := []string{stmt}
+			if !containsMultilineStatement(stmt) {
+				queries = strutil.ParseArbitraryStringSlice(stmt, ";")
+			}
+
+			for _, query := range queries {
+				query = strings.TrimSpace(query)
+				if len(query) == 0 {
+					continue
+				}
+
+				if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+					resp := dbplugin.ValidateStatementsResponse{
+						Failure: &dbplugin.StatementFailure{
+							Operation: operation.name,
+							Index:     i,
+							Statement: query,
+							Error:     err.Error(),
+						},
+					}
+					return resp, nil
+				}
+			}
+		}
+	}
+
+	return dbplugin.ValidateStatementsResponse{}, nil
+}
+
 func (p *PostgreSQL) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
 	p.Lock()
 	defer p.Unlock()
@@ -602,6 +602,152 @@ func TestDeleteUser(t *testing.T) {
 	}
 }
 
+func TestValidateStatements(t *testing.T) {
+	type testCase struct {
+		req             dbplugin.ValidateStatementsRequest
+		expectedFailure *dbplugin.StatementFailure
+	}
+
+	tests := map[string]testCase{
+		"valid statements": {
+			req: dbplugin.ValidateStatementsRequest{
+				CreationStatements: dbplugin.Statements{
+					Commands: []string{createAdminUser},
+				},
+				RevocationStatements: dbplugin.Statements{
+					Commands: []string{`DROP ROLE "{{name}}";`},
+				},
+			},
+		},
+		"failed creation statement": {
+			req: dbplugin.ValidateStatementsRequest{
+				CreationStatements: dbplugin.Statements{
+					Commands: []string{
+						`CREATE ROLE "{{name}}" WITH LOGIN PASSWORD '{{password}}';`,
+						`GRANT SELECT ON ALL TABLES IN SCHEMA missing TO "{{name}}";`,
+					},
+				},
+			},
+			expectedFailure: &dbplugin.StatementFailure{
+				Operation: dbplugin.StatementOperationCreation,
+				Index:     1,
+				Statement: `GRANT SELECT ON ALL TABLES IN SCHEMA missing TO "{{name}}"`,
+				Error:     `pq: schema "missing" does not exist`,
+			},
+		},
+		"failed revocation statement": {
+			req: dbplugin.ValidateStatementsRequest{
+				CreationStatements: dbplugin.Statements{
+					Commands: []string{createAdminUser},
+				},
+				RevocationStatements: dbplugin.Statements{
+					Commands: []string{`DROP ROLE "{{name}}-missing";`},
+				},
+			},
+			expectedFailure: &dbplugin.StatementFailure{
+				Operation: dbplugin.StatementOperationRevocation,
+				Index:     0,
+				Statement: `DROP ROLE "{{name}}-missing"`,
+			},
+		},
+	}
+
+	// Shared test container for speed - there should not be any overlap between the tests
+	db, cleanup := getPostgreSQL(t, nil)
+	defer cleanup()
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.req.UsernameConfig = dbplugin.UsernameMetadata{
+				DisplayName: "validate",
+				RoleName:    "test",
+			}
+			test.req.Password = "myreallysecurepassword"
+			test.req.Expiration = time.Now().Add(time.Minute)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			resp, err := db.ValidateStatements(ctx, test.req)
+			if err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+			if len(resp.Unvalidated) != 0 {
+				t.Fatalf("unexpected unvalidated statements: %#v", resp.Unvalidated)
+			}
+			if test.expectedFailure == nil {
+				if resp.Failure != nil {
+					t.Fatalf("unexpected failure: %#v", resp.Failure)
+				}
+			} else {
+				if resp.Failure == nil {
+					t.Fatalf("expected failure, got none")
+				}
+				if resp.Failure.Operation != test.expectedFailure.Operation ||
+					resp.Failure.Index != test.expectedFailure.Index ||
+					resp.Failure.Statement != test.expectedFailure.Statement {
+					t.Fatalf("Actual failure: %#v\nExpected failure: %#v", resp.Failure, test.expectedFailure)
+				}
+				if test.expectedFailure.Error != "" && resp.Failure.Error != test.expectedFailure.Error {
+					t.Fatalf("Actual error: %q Expected error: %q", resp.Failure.Error, test.expectedFailure.Error)
+				}
+			}
+
+			// The test users were rolled back
+			assertNoRoles(t, db.ConnectionURL, "v-validate-%")
+		})
+	}
+
+	t.Run("rotation statements of existing user", func(t *testing.T) {
+		initialPass := "myreallysecurepassword"
+		createResp := dbtesting.AssertNewUser(t, db, dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{
+				DisplayName: "test",
+				RoleName:    "test",
+			},
+			Statements: dbplugin.Statements{
+				Commands: []string{createAdminUser},
+			},
+			Password:   initialPass,
+			Expiration: time.Now().Add(time.Minute),
+		})
+
+		resp, err := db.ValidateStatements(context.Background(), dbplugin.ValidateStatementsRequest{
+			Username: createResp.Username,
+			Password: "somenewpassword",
+			RotationStatements: dbplugin.Statements{
+				Commands: []string{defaultChangePasswordStatement},
+			},
+		})
+		if err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		if resp.Failure != nil || len(resp.Unvalidated) != 0 {
+			t.Fatalf("bad response: %#v", resp)
+		}
+
+		// The password was not changed
+		assertCredsExist(t, db.ConnectionURL, createResp.Username, initialPass)
+	})
+}
+
+func assertNoRoles(t testing.TB, connURL, pattern string) {
+	t.Helper()
+	db, err := sql.Open("postgres", connURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pg_catalog.pg_roles WHERE rolname LIKE $1", pattern).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("found %d roles matching %q", count, pattern)
+	}
+}
+
 type credsAssertion func(t testing.TB, connURL, username, password string)
 
 func assertCreds(assertions ...credsAssertion) credsAssertion {
@@ -98,6 +98,10 @@ func (r *RedShift) getConnection(ctx context.Context) (*sql.DB, error) {
 // creating users, so one must be specified in the plugin config.
 // Generated usernames are of the form v-{display-name}-{role-name}-{UUID}-{timestamp}
 func (r *RedShift) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	if req.CredentialType != dbplugin.CredentialTypePassword {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unsupported credential type %s", req.CredentialType)
+	}
+
 	if len(req.Statements.Commands) == 0 {
 		return dbplugin.NewUserResponse{}, dbutil.ErrEmptyCreationStatement
 	}
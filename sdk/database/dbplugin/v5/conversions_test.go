@@ -60,8 +60,11 @@ func TestConversionsHaveAllFields(t *testing.T) {
 					"rollback_statement",
 				},
 			},
-			Password:   "password",
-			Expiration: time.Now(),
+			CredentialType: CredentialTypeRSAPrivateKey,
+			Password:       "password",
+			PublicKey:      []byte("public_key"),
+			Subject:        "subject",
+			Expiration:     time.Now(),
 		}
 
 		protoReq, err := newUserReqToProto(req)
@@ -152,6 +155,75 @@ func TestConversionsHaveAllFields(t *testing.T) {
 		}
 	})
 
+	t.Run("validateStatementsReqToProto", func(t *testing.T) {
+		req := ValidateStatementsRequest{
+			UsernameConfig: UsernameMetadata{
+				DisplayName: "dispName",
+				RoleName:    "roleName",
+			},
+			Username:   "username",
+			Password:   "password",
+			Expiration: time.Now(),
+			CreationStatements: Statements{
+				Commands: []string{
+					"creation_statement",
+				},
+			},
+			RotationStatements: Statements{
+				Commands: []string{
+					"rotation_statement",
+				},
+			},
+			RevocationStatements: Statements{
+				Commands: []string{
+					"revocation_statement",
+				},
+			},
+		}
+
+		protoReq, err := validateStatementsReqToProto(req)
+		if err != nil {
+			t.Fatalf("Failed to convert request to proto request: %s", err)
+		}
+
+		values := getAllGetterValues(protoReq)
+		if len(values) == 0 {
+			// Probably a test failure - the protos used in these tests should have Get functions on them
+			t.Fatalf("No values found from Get functions!")
+		}
+
+		for _, gtr := range values {
+			err := assertAllFieldsSet(fmt.Sprintf("ValidateStatementsRequest.%s", gtr.name), gtr.value)
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+		}
+	})
+
+	t.Run("validateStatementsRespFromProto", func(t *testing.T) {
+		resp := &proto.ValidateStatementsResponse{
+			Failure: &proto.StatementFailure{
+				Operation: StatementOperationRevocation,
+				Index:     1,
+				Statement: "statement",
+				Error:     "error",
+			},
+			Unvalidated: []*proto.UnvalidatedStatement{
+				{
+					Operation: StatementOperationCreation,
+					Index:     1,
+					Statement: "statement",
+					Reason:    "reason",
+				},
+			},
+		}
+
+		err := assertAllFieldsSet("ValidateStatementsResponse", validateStatementsRespFromProto(resp))
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+	})
+
 	t.Run("getUpdateUserRequest", func(t *testing.T) {
 		req := &proto.UpdateUserRequest{
 			Username: "username",
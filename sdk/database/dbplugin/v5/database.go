@@ -2,6 +2,8 @@ package dbplugin
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -69,6 +71,26 @@ type InitializeResponse struct {
 	// but should contain everything required to Initialize the database.
 	// REQUIRED in order to save the configuration into Vault after initialization
 	Config map[string]interface{}
+
+	// SupportedCredentialTypes lists the credential types the database supports
+	// besides passwords, which are always supported. Vault refuses to issue the
+	// other credential types, so that plugins unaware of the credential type of
+	// a request do not create users without credentials.
+	SupportedCredentialTypes []CredentialType
+}
+
+// SupportsCredentialType returns whether the database supports issuing
+// credentials of the given type
+func (r InitializeResponse) SupportsCredentialType(credentialType CredentialType) bool {
+	if credentialType == CredentialTypePassword {
+		return true
+	}
+	for _, supported := range r.SupportedCredentialTypes {
+		if supported == credentialType {
+			return true
+		}
+	}
+	return false
 }
 
 // ///////////////////////////////////////////////////////
@@ -90,9 +112,24 @@ type NewUserRequest struct {
 	// if the new user creation process fails.
 	RollbackStatements Statements
 
-	// Password credentials to use when creating the user
+	// CredentialType is the type of credential to use when creating the user.
+	// The field of the credential type contains the credential value.
+	CredentialType CredentialType
+
+	// Password credentials to use when creating the user. Set when
+	// CredentialType is CredentialTypePassword.
 	Password string
 
+	// PublicKey is the PEM encoded PKIX public key the user authenticates
+	// with, by proving possession of the private key. Set when CredentialType
+	// is CredentialTypeRSAPrivateKey.
+	PublicKey []byte
+
+	// Subject is the common name of the subject of the client certificate the
+	// user authenticates with. Set when CredentialType is
+	// CredentialTypeClientCertificate.
+	Subject string
+
 	// Expiration of the user. Not all database plugins will support this.
 	Expiration time.Time
 }
@@ -164,10 +201,155 @@ type DeleteUserRequest struct {
 
 type DeleteUserResponse struct{}
 
+// ///////////////////////////////////////////////////////
+// ValidateStatements()
+// ///////////////////////////////////////////////////////
+
+// StatementValidator is implemented by databases that can run the statements
+// of a role without keeping their effects, so that mistakes in the statements
+// are found when the role is written rather than when a credential is first
+// requested. It is optional, ValidateStatements should be used to call it.
+type StatementValidator interface {
+	// ValidateStatements checks the statements in order against a test user,
+	// stopping at the first statement that fails, without keeping their
+	// effects. Failing statements, and statements that could not be checked,
+	// are reported in the response; errors are returned when the statements
+	// could not be checked at all.
+	ValidateStatements(ctx context.Context, req ValidateStatementsRequest) (ValidateStatementsResponse, error)
+}
+
+// ErrValidateStatementsUnsupported is returned by ValidateStatements when the
+// database cannot validate statements
+var ErrValidateStatementsUnsupported = errors.New("database does not support statement validation")
+
+// ValidateStatements validates the statements with the given database, if it
+// supports it
+func ValidateStatements(ctx context.Context, db Database, req ValidateStatementsRequest) (ValidateStatementsResponse, error) {
+	validator, ok := db.(StatementValidator)
+	if !ok {
+		return ValidateStatementsResponse{}, ErrValidateStatementsUnsupported
+	}
+	return validator.ValidateStatements(ctx, req)
+}
+
+// Operations of the statements reported in a StatementFailure
+const (
+	StatementOperationCreation   = "creation"
+	StatementOperationRotation   = "rotation"
+	StatementOperationRevocation = "revocation"
+)
+
+type ValidateStatementsRequest struct {
+	// UsernameConfig is used to generate the name of the test user created by
+	// the creation statements
+	UsernameConfig UsernameMetadata
+
+	// Username of an existing user the rotation and revocation statements
+	// apply to when there are no creation statements, as for static roles
+	Username string
+
+	// Password of the test user
+	Password string
+
+	// Expiration of the test user
+	Expiration time.Time
+
+	// CreationStatements create the test user
+	CreationStatements Statements
+
+	// RotationStatements change the password of the test user
+	RotationStatements Statements
+
+	// RevocationStatements delete the test user
+	RevocationStatements Statements
+}
+
+type ValidateStatementsResponse struct {
+	// Failure describes the statement that failed. It is nil if all the
+	// statements ran successfully.
+	Failure *StatementFailure
+
+	// Unvalidated lists the statements the database could not check, which
+	// may still fail when they are run
+	Unvalidated []UnvalidatedStatement
+}
+
+// StatementFailure describes a statement that failed to run
+type StatementFailure struct {
+	// Operation is the operation the statement belongs to: creation, rotation
+	// or revocation
+	Operation string
+
+	// Index of the command holding the statement in the statements of the
+	// operation
+	Index int
+
+	// Statement is the failed statement, before any value is substituted
+	Statement string
+
+	// Error returned by the database
+	Error string
+}
+
+// UnvalidatedStatement describes a statement that the database could not
+// check
+type UnvalidatedStatement struct {
+	// Operation is the operation the statement belongs to: creation, rotation
+	// or revocation
+	Operation string
+
+	// Index of the command holding the statement in the statements of the
+	// operation
+	Index int
+
+	// Statement is the unvalidated statement, before any value is substituted
+	Statement string
+
+	// Reason the statement could not be checked
+	Reason string
+}
+
 // ///////////////////////////////////////////////////////
 // Used across multiple functions
 // ///////////////////////////////////////////////////////
 
+// CredentialType is the type of credential a user authenticates with
+type CredentialType int
+
+const (
+	CredentialTypePassword CredentialType = iota
+	CredentialTypeRSAPrivateKey
+	CredentialTypeClientCertificate
+)
+
+func (k CredentialType) String() string {
+	switch k {
+	case CredentialTypePassword:
+		return "password"
+	case CredentialTypeRSAPrivateKey:
+		return "rsa_private_key"
+	case CredentialTypeClientCertificate:
+		return "client_certificate"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseCredentialType returns the credential type named by s, as returned by
+// CredentialType.String
+func ParseCredentialType(s string) (CredentialType, error) {
+	switch s {
+	case "password":
+		return CredentialTypePassword, nil
+	case "rsa_private_key":
+		return CredentialTypeRSAPrivateKey, nil
+	case "client_certificate":
+		return CredentialTypeClientCertificate, nil
+	default:
+		return 0, fmt.Errorf("invalid credential type %q", s)
+	}
+}
+
 // Statements wraps a collection of statements to run in a database when an
 // operation is performed (create, update, etc.). This is a struct rather than
 // a string slice so we can easily add more information to this in the future.
@@ -9,10 +9,13 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5/proto"
 	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	_ Database = gRPCClient{}
+	_ Database           = gRPCClient{}
+	_ StatementValidator = gRPCClient{}
 
 	ErrPluginShutdown = errors.New("plugin shutdown")
 )
@@ -52,8 +55,14 @@ func initReqToProto(req InitializeRequest) (*proto.InitializeRequest, error) {
 func initRespFromProto(rpcResp *proto.InitializeResponse) (InitializeResponse, error) {
 	newConfig := structToMap(rpcResp.GetConfigData())
 
+	var credentialTypes []CredentialType
+	for _, credentialType := range rpcResp.GetSupportedCredentialTypes() {
+		credentialTypes = append(credentialTypes, CredentialType(credentialType))
+	}
+
 	resp := InitializeResponse{
-		Config: newConfig,
+		Config:                   newConfig,
+		SupportedCredentialTypes: credentialTypes,
 	}
 	return resp, nil
 }
@@ -81,8 +90,21 @@ func (c gRPCClient) NewUser(ctx context.Context, req NewUserRequest) (NewUserRes
 }
 
 func newUserReqToProto(req NewUserRequest) (*proto.NewUserRequest, error) {
-	if req.Password == "" {
-		return nil, fmt.Errorf("missing password")
+	switch req.CredentialType {
+	case CredentialTypePassword:
+		if req.Password == "" {
+			return nil, fmt.Errorf("missing password")
+		}
+	case CredentialTypeRSAPrivateKey:
+		if len(req.PublicKey) == 0 {
+			return nil, fmt.Errorf("missing public key")
+		}
+	case CredentialTypeClientCertificate:
+		if req.Subject == "" {
+			return nil, fmt.Errorf("missing certificate subject")
+		}
+	default:
+		return nil, fmt.Errorf("unknown credential type %d", req.CredentialType)
 	}
 
 	expiration, err := ptypes.TimestampProto(req.Expiration)
@@ -95,8 +117,11 @@ func newUserReqToProto(req NewUserRequest) (*proto.NewUserRequest, error) {
 			DisplayName: req.UsernameConfig.DisplayName,
 			RoleName:    req.UsernameConfig.RoleName,
 		},
-		Password:   req.Password,
-		Expiration: expiration,
+		CredentialType: int32(req.CredentialType),
+		Password:       req.Password,
+		PublicKey:      req.PublicKey,
+		Subject:        req.Subject,
+		Expiration:     expiration,
 		Statements: &proto.Statements{
 			Commands: req.Statements.Commands,
 		},
@@ -137,8 +162,7 @@ func updateUserReqToProto(req UpdateUserRequest) (*proto.UpdateUserRequest, erro
 		return nil, fmt.Errorf("missing username")
 	}
 
-	if (req.Password == nil || req.Password.NewPassword == "") &&
-		(req.Expiration == nil || req.Expiration.NewExpiration.IsZero()) {
+	if !hasChange(req) {
 		return nil, fmt.Errorf("missing changes")
 	}
 
@@ -225,6 +249,85 @@ func deleteUserRespFromProto(rpcResp *proto.DeleteUserResponse) (DeleteUserRespo
 	return DeleteUserResponse{}, nil
 }
 
+func (c gRPCClient) ValidateStatements(ctx context.Context, req ValidateStatementsRequest) (ValidateStatementsResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	quitCh := pluginutil.CtxCancelIfCanceled(cancel, c.doneCtx)
+	defer close(quitCh)
+	defer cancel()
+
+	rpcReq, err := validateStatementsReqToProto(req)
+	if err != nil {
+		return ValidateStatementsResponse{}, err
+	}
+
+	rpcResp, err := c.client.ValidateStatements(ctx, rpcReq)
+	if err != nil {
+		if c.doneCtx.Err() != nil {
+			return ValidateStatementsResponse{}, ErrPluginShutdown
+		}
+		// Plugins built before statement validation was introduced do not
+		// implement the RPC at all
+		if status.Code(err) == codes.Unimplemented {
+			return ValidateStatementsResponse{}, ErrValidateStatementsUnsupported
+		}
+		return ValidateStatementsResponse{}, fmt.Errorf("unable to validate statements: %w", err)
+	}
+
+	return validateStatementsRespFromProto(rpcResp), nil
+}
+
+func validateStatementsReqToProto(req ValidateStatementsRequest) (*proto.ValidateStatementsRequest, error) {
+	if len(req.CreationStatements.Commands) == 0 && req.Username == "" {
+		return nil, fmt.Errorf("missing creation statements or username")
+	}
+
+	expiration, err := ptypes.TimestampProto(req.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal expiration date: %w", err)
+	}
+
+	rpcReq := &proto.ValidateStatementsRequest{
+		UsernameConfig: &proto.UsernameConfig{
+			DisplayName: req.UsernameConfig.DisplayName,
+			RoleName:    req.UsernameConfig.RoleName,
+		},
+		Username:   req.Username,
+		Password:   req.Password,
+		Expiration: expiration,
+		CreationStatements: &proto.Statements{
+			Commands: req.CreationStatements.Commands,
+		},
+		RotationStatements: &proto.Statements{
+			Commands: req.RotationStatements.Commands,
+		},
+		RevocationStatements: &proto.Statements{
+			Commands: req.RevocationStatements.Commands,
+		},
+	}
+	return rpcReq, nil
+}
+
+func validateStatementsRespFromProto(rpcResp *proto.ValidateStatementsResponse) ValidateStatementsResponse {
+	resp := ValidateStatementsResponse{}
+	if failure := rpcResp.GetFailure(); failure != nil {
+		resp.Failure = &StatementFailure{
+			Operation: failure.GetOperation(),
+			Index:     int(failure.GetIndex()),
+			Statement: failure.GetStatement(),
+			Error:     failure.GetError(),
+		}
+	}
+	for _, unvalidated := range rpcResp.GetUnvalidated() {
+		resp.Unvalidated = append(resp.Unvalidated, UnvalidatedStatement{
+			Operation: unvalidated.GetOperation(),
+			Index:     int(unvalidated.GetIndex()),
+			Statement: unvalidated.GetStatement(),
+			Reason:    unvalidated.GetReason(),
+		})
+	}
+	return resp
+}
+
 func (c gRPCClient) Type() (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
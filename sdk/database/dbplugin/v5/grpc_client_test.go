@@ -9,6 +9,8 @@ import (
 
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestGRPCClient_Initialize(t *testing.T) {
@@ -62,6 +64,34 @@ func TestGRPCClient_Initialize(t *testing.T) {
 			},
 			assertErr: assertErrNil,
 		},
+		"credential types": {
+			client: fakeClient{
+				initResp: &proto.InitializeResponse{
+					ConfigData: marshal(t, map[string]interface{}{
+						"foo": "bar",
+					}),
+					SupportedCredentialTypes: []int32{
+						int32(CredentialTypeRSAPrivateKey),
+						int32(CredentialTypeClientCertificate),
+					},
+				},
+			},
+			req: InitializeRequest{
+				Config: map[string]interface{}{
+					"foo": "bar",
+				},
+			},
+			expectedResp: InitializeResponse{
+				Config: map[string]interface{}{
+					"foo": "bar",
+				},
+				SupportedCredentialTypes: []CredentialType{
+					CredentialTypeRSAPrivateKey,
+					CredentialTypeClientCertificate,
+				},
+			},
+			assertErr: assertErrNil,
+		},
 	}
 
 	for name, test := range tests {
@@ -107,6 +137,34 @@ func TestGRPCClient_NewUser(t *testing.T) {
 			doneCtx:   runningCtx,
 			assertErr: assertErrNotNil,
 		},
+		"missing public key": {
+			client: fakeClient{},
+			req: NewUserRequest{
+				CredentialType: CredentialTypeRSAPrivateKey,
+				Expiration:     time.Now(),
+			},
+			doneCtx:   runningCtx,
+			assertErr: assertErrNotNil,
+		},
+		"missing certificate subject": {
+			client: fakeClient{},
+			req: NewUserRequest{
+				CredentialType: CredentialTypeClientCertificate,
+				Expiration:     time.Now(),
+			},
+			doneCtx:   runningCtx,
+			assertErr: assertErrNotNil,
+		},
+		"unknown credential type": {
+			client: fakeClient{},
+			req: NewUserRequest{
+				CredentialType: CredentialType(42),
+				Password:       "njkvcb8y934u90grsnkjl",
+				Expiration:     time.Now(),
+			},
+			doneCtx:   runningCtx,
+			assertErr: assertErrNotNil,
+		},
 		"bad expiration": {
 			client: fakeClient{},
 			req: NewUserRequest{
@@ -364,6 +422,140 @@ func TestGRPCClient_DeleteUser(t *testing.T) {
 	}
 }
 
+func TestGRPCClient_ValidateStatements(t *testing.T) {
+	runningCtx := context.Background()
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type testCase struct {
+		client       proto.DatabaseClient
+		req          ValidateStatementsRequest
+		doneCtx      context.Context
+		expectedResp ValidateStatementsResponse
+		assertErr    errorAssertion
+	}
+
+	req := ValidateStatementsRequest{
+		Password:   "njkvcb8y934u90grsnkjl",
+		Expiration: time.Now(),
+		CreationStatements: Statements{
+			Commands: []string{"CREATE USER"},
+		},
+	}
+
+	tests := map[string]testCase{
+		"missing creation statements and username": {
+			client: fakeClient{},
+			req: ValidateStatementsRequest{
+				Password:   "njkvcb8y934u90grsnkjl",
+				Expiration: time.Now(),
+			},
+			doneCtx:   runningCtx,
+			assertErr: assertErrNotNil,
+		},
+		"unsupported": {
+			client: fakeClient{
+				validateStatementsErr: status.Error(codes.Unimplemented, "unknown method"),
+			},
+			req:       req,
+			doneCtx:   runningCtx,
+			assertErr: assertErrEquals(ErrValidateStatementsUnsupported),
+		},
+		"database error": {
+			client: fakeClient{
+				validateStatementsErr: errors.New("validate statements error"),
+			},
+			req:       req,
+			doneCtx:   runningCtx,
+			assertErr: assertErrNotNil,
+		},
+		"plugin shut down": {
+			client: fakeClient{
+				validateStatementsErr: errors.New("validate statements error"),
+			},
+			req:       req,
+			doneCtx:   cancelledCtx,
+			assertErr: assertErrEquals(ErrPluginShutdown),
+		},
+		"failed statement": {
+			client: fakeClient{
+				validateStatementsResp: &proto.ValidateStatementsResponse{
+					Failure: &proto.StatementFailure{
+						Operation: StatementOperationCreation,
+						Index:     0,
+						Statement: "CREATE USER",
+						Error:     "syntax error",
+					},
+				},
+			},
+			req:     req,
+			doneCtx: runningCtx,
+			expectedResp: ValidateStatementsResponse{
+				Failure: &StatementFailure{
+					Operation: StatementOperationCreation,
+					Index:     0,
+					Statement: "CREATE USER",
+					Error:     "syntax error",
+				},
+			},
+			assertErr: assertErrNil,
+		},
+		"unvalidated statements": {
+			client: fakeClient{
+				validateStatementsResp: &proto.ValidateStatementsResponse{
+					Unvalidated: []*proto.UnvalidatedStatement{
+						{
+							Operation: StatementOperationCreation,
+							Index:     0,
+							Statement: "CREATE USER",
+							Reason:    "cannot be prepared",
+						},
+					},
+				},
+			},
+			req:     req,
+			doneCtx: runningCtx,
+			expectedResp: ValidateStatementsResponse{
+				Unvalidated: []UnvalidatedStatement{
+					{
+						Operation: StatementOperationCreation,
+						Index:     0,
+						Statement: "CREATE USER",
+						Reason:    "cannot be prepared",
+					},
+				},
+			},
+			assertErr: assertErrNil,
+		},
+		"happy path": {
+			client: fakeClient{
+				validateStatementsResp: &proto.ValidateStatementsResponse{},
+			},
+			req:       req,
+			doneCtx:   runningCtx,
+			assertErr: assertErrNil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := gRPCClient{
+				client:  test.client,
+				doneCtx: test.doneCtx,
+			}
+
+			ctx := context.Background()
+
+			resp, err := c.ValidateStatements(ctx, test.req)
+			test.assertErr(t, err)
+
+			if !reflect.DeepEqual(resp, test.expectedResp) {
+				t.Fatalf("Actual response: %#v\nExpected response: %#v", resp, test.expectedResp)
+			}
+		})
+	}
+}
+
 func TestGRPCClient_Type(t *testing.T) {
 	runningCtx := context.Background()
 	cancelledCtx, cancel := context.WithCancel(context.Background())
@@ -506,6 +698,9 @@ type fakeClient struct {
 	deleteUserResp *proto.DeleteUserResponse
 	deleteUserErr  error
 
+	validateStatementsResp *proto.ValidateStatementsResponse
+	validateStatementsErr  error
+
 	typeResp *proto.TypeResponse
 	typeErr  error
 
@@ -528,6 +723,10 @@ func (f fakeClient) DeleteUser(context.Context, *proto.DeleteUserRequest, ...grp
 	return f.deleteUserResp, f.deleteUserErr
 }
 
+func (f fakeClient) ValidateStatements(context.Context, *proto.ValidateStatementsRequest, ...grpc.CallOption) (*proto.ValidateStatementsResponse, error) {
+	return f.validateStatementsResp, f.validateStatementsErr
+}
+
 func (f fakeClient) Type(context.Context, *proto.Empty, ...grpc.CallOption) (*proto.TypeResponse, error) {
 	return f.typeResp, f.typeErr
 }
@@ -36,8 +36,14 @@ func (g gRPCServer) Initialize(ctx context.Context, request *proto.InitializeReq
 		return &proto.InitializeResponse{}, status.Errorf(codes.Internal, "failed to marshal new config to JSON: %s", err)
 	}
 
+	var credentialTypes []int32
+	for _, credentialType := range dbResp.SupportedCredentialTypes {
+		credentialTypes = append(credentialTypes, int32(credentialType))
+	}
+
 	resp := &proto.InitializeResponse{
-		ConfigData: newConfig,
+		ConfigData:               newConfig,
+		SupportedCredentialTypes: credentialTypes,
 	}
 
 	return resp, nil
@@ -63,7 +69,10 @@ func (g gRPCServer) NewUser(ctx context.Context, req *proto.NewUserRequest) (*pr
 			DisplayName: req.GetUsernameConfig().GetDisplayName(),
 			RoleName:    req.GetUsernameConfig().GetRoleName(),
 		},
+		CredentialType:     CredentialType(req.GetCredentialType()),
 		Password:           req.GetPassword(),
+		PublicKey:          req.GetPublicKey(),
+		Subject:            req.GetSubject(),
 		Expiration:         expiration,
 		Statements:         getStatementsFromProto(req.GetStatements()),
 		RollbackStatements: getStatementsFromProto(req.GetRollbackStatements()),
@@ -158,6 +167,62 @@ func (g gRPCServer) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest
 	return &proto.DeleteUserResponse{}, nil
 }
 
+func (g gRPCServer) ValidateStatements(ctx context.Context, req *proto.ValidateStatementsRequest) (*proto.ValidateStatementsResponse, error) {
+	validator, ok := g.impl.(StatementValidator)
+	if !ok {
+		return &proto.ValidateStatementsResponse{}, status.Error(codes.Unimplemented, ErrValidateStatementsUnsupported.Error())
+	}
+
+	var expiration time.Time
+	if req.GetExpiration() != nil {
+		exp, err := ptypes.Timestamp(req.GetExpiration())
+		if err != nil {
+			return &proto.ValidateStatementsResponse{}, status.Errorf(codes.InvalidArgument, "unable to parse expiration date: %s", err)
+		}
+		expiration = exp
+	}
+
+	dbReq := ValidateStatementsRequest{
+		UsernameConfig: UsernameMetadata{
+			DisplayName: req.GetUsernameConfig().GetDisplayName(),
+			RoleName:    req.GetUsernameConfig().GetRoleName(),
+		},
+		Username:             req.GetUsername(),
+		Password:             req.GetPassword(),
+		Expiration:           expiration,
+		CreationStatements:   getStatementsFromProto(req.GetCreationStatements()),
+		RotationStatements:   getStatementsFromProto(req.GetRotationStatements()),
+		RevocationStatements: getStatementsFromProto(req.GetRevocationStatements()),
+	}
+
+	dbResp, err := validator.ValidateStatements(ctx, dbReq)
+	if err != nil {
+		if err == ErrValidateStatementsUnsupported {
+			return &proto.ValidateStatementsResponse{}, status.Error(codes.Unimplemented, err.Error())
+		}
+		return &proto.ValidateStatementsResponse{}, status.Errorf(codes.Internal, "unable to validate statements: %s", err)
+	}
+
+	resp := &proto.ValidateStatementsResponse{}
+	if dbResp.Failure != nil {
+		resp.Failure = &proto.StatementFailure{
+			Operation: dbResp.Failure.Operation,
+			Index:     int32(dbResp.Failure.Index),
+			Statement: dbResp.Failure.Statement,
+			Error:     dbResp.Failure.Error,
+		}
+	}
+	for _, unvalidated := range dbResp.Unvalidated {
+		resp.Unvalidated = append(resp.Unvalidated, &proto.UnvalidatedStatement{
+			Operation: unvalidated.Operation,
+			Index:     int32(unvalidated.Index),
+			Statement: unvalidated.Statement,
+			Reason:    unvalidated.Reason,
+		})
+	}
+	return resp, nil
+}
+
 func (g gRPCServer) Type(ctx context.Context, _ *proto.Empty) (*proto.TypeResponse, error) {
 	t, err := g.impl.Type()
 	if err != nil {
@@ -75,6 +75,31 @@ func TestGRPCServer_Initialize(t *testing.T) {
 			expectErr:  false,
 			expectCode: codes.OK,
 		},
+		"happy path with credential types": {
+			db: fakeDatabase{
+				initResp: InitializeResponse{
+					Config: map[string]interface{}{
+						"foo": "bar",
+					},
+					SupportedCredentialTypes: []CredentialType{
+						CredentialTypeClientCertificate,
+					},
+				},
+			},
+			req: &proto.InitializeRequest{
+				ConfigData: marshal(t, map[string]interface{}{
+					"foo": "bar",
+				}),
+			},
+			expectedResp: &proto.InitializeResponse{
+				ConfigData: marshal(t, map[string]interface{}{
+					"foo": "bar",
+				}),
+				SupportedCredentialTypes: []int32{int32(CredentialTypeClientCertificate)},
+			},
+			expectErr:  false,
+			expectCode: codes.OK,
+		},
 	}
 
 	for name, test := range tests {
@@ -459,6 +484,139 @@ func TestGRPCServer_DeleteUser(t *testing.T) {
 	}
 }
 
+func TestGRPCServer_ValidateStatements(t *testing.T) {
+	type testCase struct {
+		db           Database
+		req          *proto.ValidateStatementsRequest
+		expectedResp *proto.ValidateStatementsResponse
+		expectErr    bool
+		expectCode   codes.Code
+	}
+
+	req := &proto.ValidateStatementsRequest{
+		Password:   "njkvcb8y934u90grsnkjl",
+		Expiration: ptypes.TimestampNow(),
+		CreationStatements: &proto.Statements{
+			Commands: []string{"CREATE USER"},
+		},
+	}
+
+	tests := map[string]testCase{
+		"unsupported": {
+			db:           fakeDatabase{},
+			req:          req,
+			expectedResp: &proto.ValidateStatementsResponse{},
+			expectErr:    true,
+			expectCode:   codes.Unimplemented,
+		},
+		"bad expiration": {
+			db: validatingDatabase{},
+			req: &proto.ValidateStatementsRequest{
+				Expiration: &timestamp.Timestamp{
+					Seconds: invalidExpiration.Unix(),
+				},
+			},
+			expectedResp: &proto.ValidateStatementsResponse{},
+			expectErr:    true,
+			expectCode:   codes.InvalidArgument,
+		},
+		"database error": {
+			db: validatingDatabase{
+				validateStatementsErr: errors.New("validate statements error"),
+			},
+			req:          req,
+			expectedResp: &proto.ValidateStatementsResponse{},
+			expectErr:    true,
+			expectCode:   codes.Internal,
+		},
+		"failed statement": {
+			db: validatingDatabase{
+				validateStatementsResp: ValidateStatementsResponse{
+					Failure: &StatementFailure{
+						Operation: StatementOperationCreation,
+						Index:     0,
+						Statement: "CREATE USER",
+						Error:     "syntax error",
+					},
+				},
+			},
+			req: req,
+			expectedResp: &proto.ValidateStatementsResponse{
+				Failure: &proto.StatementFailure{
+					Operation: StatementOperationCreation,
+					Index:     0,
+					Statement: "CREATE USER",
+					Error:     "syntax error",
+				},
+			},
+			expectErr:  false,
+			expectCode: codes.OK,
+		},
+		"unvalidated statements": {
+			db: validatingDatabase{
+				validateStatementsResp: ValidateStatementsResponse{
+					Unvalidated: []UnvalidatedStatement{
+						{
+							Operation: StatementOperationRevocation,
+							Index:     1,
+							Statement: "DROP USER",
+							Reason:    "cannot be prepared",
+						},
+					},
+				},
+			},
+			req: req,
+			expectedResp: &proto.ValidateStatementsResponse{
+				Unvalidated: []*proto.UnvalidatedStatement{
+					{
+						Operation: StatementOperationRevocation,
+						Index:     1,
+						Statement: "DROP USER",
+						Reason:    "cannot be prepared",
+					},
+				},
+			},
+			expectErr:  false,
+			expectCode: codes.OK,
+		},
+		"happy path": {
+			db:           validatingDatabase{},
+			req:          req,
+			expectedResp: &proto.ValidateStatementsResponse{},
+			expectErr:    false,
+			expectCode:   codes.OK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := gRPCServer{
+				impl: test.db,
+			}
+
+			// Context doesn't need to timeout since this is just passed through
+			ctx := context.Background()
+
+			resp, err := g.ValidateStatements(ctx, test.req)
+			if test.expectErr && err == nil {
+				t.Fatalf("err expected, got nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+
+			actualCode := status.Code(err)
+			if actualCode != test.expectCode {
+				t.Fatalf("Actual code: %s Expected code: %s", actualCode, test.expectCode)
+			}
+
+			if !reflect.DeepEqual(resp, test.expectedResp) {
+				t.Fatalf("Actual response: %#v\nExpected response: %#v", resp, test.expectedResp)
+			}
+		})
+	}
+}
+
 func TestGRPCServer_Type(t *testing.T) {
 	type testCase struct {
 		db           Database
@@ -629,6 +787,23 @@ func (e fakeDatabase) Close() error {
 	return e.closeErr
 }
 
+var (
+	_ Database           = validatingDatabase{}
+	_ StatementValidator = validatingDatabase{}
+)
+
+// validatingDatabase is a fakeDatabase supporting statement validation
+type validatingDatabase struct {
+	fakeDatabase
+
+	validateStatementsResp ValidateStatementsResponse
+	validateStatementsErr  error
+}
+
+func (e validatingDatabase) ValidateStatements(ctx context.Context, req ValidateStatementsRequest) (ValidateStatementsResponse, error) {
+	return e.validateStatementsResp, e.validateStatementsErr
+}
+
 var _ Database = &recordingDatabase{}
 
 type recordingDatabase struct {
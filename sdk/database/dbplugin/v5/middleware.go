@@ -17,7 +17,10 @@ import (
 // Tracing Middleware
 // ///////////////////////////////////////////////////
 
-var _ Database = databaseTracingMiddleware{}
+var (
+	_ Database           = databaseTracingMiddleware{}
+	_ StatementValidator = databaseTracingMiddleware{}
+)
 
 // databaseTracingMiddleware wraps a implementation of Database and executes
 // trace logging on function call.
@@ -77,6 +80,19 @@ func (mw databaseTracingMiddleware) DeleteUser(ctx context.Context, req DeleteUs
 	return mw.next.DeleteUser(ctx, req)
 }
 
+func (mw databaseTracingMiddleware) ValidateStatements(ctx context.Context, req ValidateStatementsRequest) (resp ValidateStatementsResponse, err error) {
+	defer func(then time.Time) {
+		mw.logger.Trace("validate statements",
+			"status", "finished",
+			"failed", resp.Failure != nil,
+			"err", err,
+			"took", time.Since(then))
+	}(time.Now())
+
+	mw.logger.Trace("validate statements", "status", "started")
+	return ValidateStatements(ctx, mw.next, req)
+}
+
 func (mw databaseTracingMiddleware) Type() (string, error) {
 	return mw.next.Type()
 }
@@ -98,7 +114,10 @@ func (mw databaseTracingMiddleware) Close() (err error) {
 // Metrics Middleware Domain
 // ///////////////////////////////////////////////////
 
-var _ Database = databaseMetricsMiddleware{}
+var (
+	_ Database           = databaseMetricsMiddleware{}
+	_ StatementValidator = databaseMetricsMiddleware{}
+)
 
 // databaseMetricsMiddleware wraps an implementation of Databases and on
 // function call logs metrics about this instance.
@@ -172,6 +191,22 @@ func (mw databaseMetricsMiddleware) DeleteUser(ctx context.Context, req DeleteUs
 	return mw.next.DeleteUser(ctx, req)
 }
 
+func (mw databaseMetricsMiddleware) ValidateStatements(ctx context.Context, req ValidateStatementsRequest) (resp ValidateStatementsResponse, err error) {
+	defer func(now time.Time) {
+		metrics.MeasureSince([]string{"database", "ValidateStatements"}, now)
+		metrics.MeasureSince([]string{"database", mw.typeStr, "ValidateStatements"}, now)
+
+		if err != nil {
+			metrics.IncrCounter([]string{"database", "ValidateStatements", "error"}, 1)
+			metrics.IncrCounter([]string{"database", mw.typeStr, "ValidateStatements", "error"}, 1)
+		}
+	}(time.Now())
+
+	metrics.IncrCounter([]string{"database", "ValidateStatements"}, 1)
+	metrics.IncrCounter([]string{"database", mw.typeStr, "ValidateStatements"}, 1)
+	return ValidateStatements(ctx, mw.next, req)
+}
+
 func (mw databaseMetricsMiddleware) Type() (string, error) {
 	return mw.next.Type()
 }
@@ -196,7 +231,10 @@ func (mw databaseMetricsMiddleware) Close() (err error) {
 // Error Sanitizer Middleware Domain
 // ///////////////////////////////////////////////////
 
-var _ Database = DatabaseErrorSanitizerMiddleware{}
+var (
+	_ Database           = DatabaseErrorSanitizerMiddleware{}
+	_ StatementValidator = DatabaseErrorSanitizerMiddleware{}
+)
 
 // DatabaseErrorSanitizerMiddleware wraps an implementation of Databases and
 // sanitizes returned error messages
@@ -234,6 +272,17 @@ func (mw DatabaseErrorSanitizerMiddleware) DeleteUser(ctx context.Context, req D
 	return resp, mw.sanitize(err)
 }
 
+func (mw DatabaseErrorSanitizerMiddleware) ValidateStatements(ctx context.Context, req ValidateStatementsRequest) (ValidateStatementsResponse, error) {
+	resp, err := ValidateStatements(ctx, mw.next, req)
+	if err == ErrValidateStatementsUnsupported {
+		return resp, err
+	}
+	if resp.Failure != nil {
+		resp.Failure.Error = mw.sanitize(errors.New(resp.Failure.Error)).Error()
+	}
+	return resp, mw.sanitize(err)
+}
+
 func (mw DatabaseErrorSanitizerMiddleware) Type() (string, error) {
 	dbType, err := mw.next.Type()
 	return dbType, mw.sanitize(err)
@@ -230,6 +230,36 @@ func TestDatabaseErrorSanitizerMiddleware(t *testing.T) {
 		assertEquals(t, db.typeCalls, 0)
 		assertEquals(t, db.closeCalls, 1)
 	})
+
+	t.Run("ValidateStatements", func(t *testing.T) {
+		mw := DatabaseErrorSanitizerMiddleware{
+			next: validatingDatabase{
+				validateStatementsResp: ValidateStatementsResponse{
+					Failure: &StatementFailure{
+						Operation: StatementOperationCreation,
+						Statement: "CREATE USER",
+						Error:     "password: iofsd9473tg with some stuff after it",
+					},
+				},
+			},
+			secretsFn: secretFunc(t, "iofsd9473tg", "<redacted>"),
+		}
+
+		resp, err := mw.ValidateStatements(context.Background(), ValidateStatementsRequest{})
+		if err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		if resp.Failure.Error != "password: <redacted> with some stuff after it" {
+			t.Fatalf("Actual failure: %s", resp.Failure.Error)
+		}
+
+		// Databases that cannot validate statements are reported as such
+		mw.next = fakeDatabase{}
+		_, err = mw.ValidateStatements(context.Background(), ValidateStatementsRequest{})
+		if err != ErrValidateStatementsUnsupported {
+			t.Fatalf("Actual err: %v", err)
+		}
+	})
 }
 
 func secretFunc(t *testing.T, vals ...string) func() map[string]string {
@@ -28,6 +28,12 @@ func (dc *DatabasePluginClient) Close() error {
 	return err
 }
 
+// ValidateStatements forwards to the embedded Database, which hides the
+// optional StatementValidator interface
+func (dc *DatabasePluginClient) ValidateStatements(ctx context.Context, req ValidateStatementsRequest) (ValidateStatementsResponse, error) {
+	return ValidateStatements(ctx, dc.Database, req)
+}
+
 // NewPluginClient returns a databaseRPCClient with a connection to a running
 // plugin. The client is wrapped in a DatabasePluginClient object to ensure the
 // plugin is killed on call of Close().
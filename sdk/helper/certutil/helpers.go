@@ -164,6 +164,10 @@ func ParsePEMBundle(pemBundle string) (*ParsedCertBundle, error) {
 				parsedBundle.PrivateKey = signer
 				parsedBundle.PrivateKeyType = ECPrivateKey
 				parsedBundle.PrivateKeyBytes = pemBlock.Bytes
+			case ed25519.PrivateKey:
+				parsedBundle.PrivateKey = signer
+				parsedBundle.PrivateKeyType = Ed25519PrivateKey
+				parsedBundle.PrivateKeyBytes = pemBlock.Bytes
 			}
 		} else if certificates, err := x509.ParseCertificates(pemBlock.Bytes); err == nil {
 			certPath = append(certPath, &CertBlock{
@@ -246,6 +250,17 @@ func generatePrivateKey(keyType string, keyBits int, container ParsedPrivateKeyC
 		if err != nil {
 			return errutil.InternalError{Err: fmt.Sprintf("error marshalling EC private key: %v", err)}
 		}
+	case "ed25519":
+		// Ed25519 keys have a fixed size, so keyBits is ignored
+		privateKeyType = Ed25519PrivateKey
+		_, privateKey, err = ed25519.GenerateKey(randReader)
+		if err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("error generating Ed25519 private key: %v", err)}
+		}
+		privateKeyBytes, err = x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("error marshalling Ed25519 private key: %v", err)}
+		}
 	default:
 		return errutil.UserError{Err: fmt.Sprintf("unknown key type: %s", keyType)}
 	}
@@ -310,12 +325,20 @@ func ComparePublicKeys(key1Iface, key2Iface crypto.PublicKey) (bool, error) {
 		}
 		return true, nil
 
+	case ed25519.PublicKey:
+		key1 := key1Iface.(ed25519.PublicKey)
+		key2, ok := key2Iface.(ed25519.PublicKey)
+		if !ok {
+			return false, fmt.Errorf("key types do not match: %T and %T", key1Iface, key2Iface)
+		}
+		return bytes.Equal(key1, key2), nil
+
 	default:
 		return false, fmt.Errorf("cannot compare key with type %T", key1Iface)
 	}
 }
 
-// ParsePublicKeyPEM is used to parse RSA and ECDSA public keys from PEMs
+// ParsePublicKeyPEM is used to parse RSA, ECDSA and Ed25519 public keys from PEMs
 func ParsePublicKeyPEM(data []byte) (interface{}, error) {
 	block, data := pem.Decode(data)
 	if block != nil {
@@ -510,6 +533,8 @@ func ValidateKeyTypeLength(keyType string, keyBits int) error {
 		default:
 			return fmt.Errorf("unsupported bit length for EC key: %d", keyBits)
 		}
+	case "ed25519":
+		// Ed25519 keys have a fixed size; any key_bits value is ignored
 	case "any":
 	default:
 		return fmt.Errorf("unknown key type %s", keyType)
@@ -601,6 +626,8 @@ func createCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertB
 			certTemplate.SignatureAlgorithm = x509.SHA256WithRSA
 		case ECPrivateKey:
 			certTemplate.SignatureAlgorithm = x509.ECDSAWithSHA256
+		case Ed25519PrivateKey:
+			certTemplate.SignatureAlgorithm = x509.PureEd25519
 		}
 
 		caCert := data.SigningBundle.Certificate
@@ -621,6 +648,8 @@ func createCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertB
 			certTemplate.SignatureAlgorithm = x509.SHA256WithRSA
 		case "ec":
 			certTemplate.SignatureAlgorithm = x509.ECDSAWithSHA256
+		case "ed25519":
+			certTemplate.SignatureAlgorithm = x509.PureEd25519
 		}
 
 		certTemplate.AuthorityKeyId = subjKeyID
@@ -715,6 +744,8 @@ func createCSR(data *CreationBundle, addBasicConstraints bool, randReader io.Rea
 		csrTemplate.SignatureAlgorithm = x509.SHA256WithRSA
 	case "ec":
 		csrTemplate.SignatureAlgorithm = x509.ECDSAWithSHA256
+	case "ed25519":
+		csrTemplate.SignatureAlgorithm = x509.PureEd25519
 	}
 
 	csr, err := x509.CreateCertificateRequest(randReader, csrTemplate, result.PrivateKey)
@@ -794,6 +825,8 @@ func signCertificate(data *CreationBundle, randReader io.Reader) (*ParsedCertBun
 		certTemplate.SignatureAlgorithm = x509.SHA256WithRSA
 	case ECPrivateKey:
 		certTemplate.SignatureAlgorithm = x509.ECDSAWithSHA256
+	case Ed25519PrivateKey:
+		certTemplate.SignatureAlgorithm = x509.PureEd25519
 	}
 
 	if data.Params.UseCSRValues {
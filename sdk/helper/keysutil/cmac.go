@@ -0,0 +1,170 @@
+package keysutil
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/kdf"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// cmacKeyLabel separates the CMAC key from the encryption key of the same
+	// key version
+	cmacKeyLabel = "cmac"
+
+	// externalKeyLabel separates key material handed out by DeriveExternalKey
+	// from the keys Vault uses internally
+	externalKeyLabel = "external"
+)
+
+// CMACSupported returns whether the key type can be used for AES-CMAC
+func (kt KeyType) CMACSupported() bool {
+	switch kt {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		return true
+	}
+	return false
+}
+
+// CMACKey returns the AES key used for CMAC with the given key version. It is
+// derived from the version's key material so that CMAC and encryption never
+// share a key. For derived policies the context is required.
+func (p *Policy) CMACKey(context []byte, ver int) ([]byte, error) {
+	if !p.Type.CMACSupported() {
+		return nil, errutil.UserError{Err: fmt.Sprintf("CMAC not supported for key type %v", p.Type)}
+	}
+
+	switch {
+	case ver <= 0:
+		return nil, errutil.UserError{Err: "invalid key version"}
+	case ver > p.LatestVersion:
+		return nil, errutil.UserError{Err: fmt.Sprintf("key version does not exist; latest key version is %d", p.LatestVersion)}
+	}
+
+	bits := uint32(256)
+	if p.Type == KeyType_AES128_GCM96 {
+		bits = 128
+	}
+
+	key, err := p.GetKey(context, ver, int(bits/8))
+	if err != nil {
+		return nil, err
+	}
+
+	return kdf.CounterMode(kdf.HMACSHA256PRF, kdf.HMACSHA256PRFLen, key, []byte(cmacKeyLabel), bits)
+}
+
+// CMAC computes the AES-CMAC of the input with the given key version
+func (p *Policy) CMAC(context []byte, ver int, input []byte) ([]byte, error) {
+	key, err := p.CMACKey(context, ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return CMAC(key, input)
+}
+
+// DeriveExternalKey derives numBytes of key material from the given key
+// version that is meant to leave Vault, for instance as a per-device key. The
+// derivation is separated from the one used by GetKey so that the result can
+// never be an encryption key of the policy itself. The context is required;
+// the salt is optional.
+func (p *Policy) DeriveExternalKey(kdfMode int, context, salt []byte, ver, numBytes int) ([]byte, error) {
+	if !p.Type.CMACSupported() {
+		return nil, errutil.UserError{Err: fmt.Sprintf("key derivation not supported for key type %v", p.Type)}
+	}
+
+	if len(context) == 0 {
+		return nil, errutil.UserError{Err: "missing 'context' for key derivation"}
+	}
+
+	if numBytes <= 0 {
+		return nil, errutil.UserError{Err: "invalid number of bytes to derive"}
+	}
+
+	keyEntry, err := p.safeGetKeyEntry(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	info := append([]byte(externalKeyLabel+"\x00"), context...)
+
+	switch kdfMode {
+	case Kdf_hmac_sha256_counter:
+		return kdf.CounterMode(kdf.HMACSHA256PRF, kdf.HMACSHA256PRFLen, keyEntry.Key, append(info, salt...), uint32(numBytes*8))
+
+	case Kdf_hkdf_sha256:
+		out := make([]byte, numBytes)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, keyEntry.Key, salt, info), out); err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error reading derived bytes: %v", err)}
+		}
+		return out, nil
+
+	default:
+		return nil, errutil.UserError{Err: fmt.Sprintf("unsupported key derivation function %d", kdfMode)}
+	}
+}
+
+// CMAC computes the AES-CMAC (RFC 4493) of msg with the given AES key
+func CMAC(key, msg []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate the subkeys from the encryption of the zero block
+	k1 := make([]byte, aes.BlockSize)
+	block.Encrypt(k1, k1)
+	cmacShift(k1)
+	k2 := make([]byte, aes.BlockSize)
+	copy(k2, k1)
+	cmacShift(k2)
+
+	n := (len(msg) + aes.BlockSize - 1) / aes.BlockSize
+	complete := n > 0 && len(msg)%aes.BlockSize == 0
+	if n == 0 {
+		n = 1
+	}
+
+	// The last block is padded if needed and masked with one of the subkeys
+	last := make([]byte, aes.BlockSize)
+	tail := msg[(n-1)*aes.BlockSize:]
+	if complete {
+		xorBytes(last, tail, k1)
+	} else {
+		copy(last, tail)
+		last[len(tail)] = 0x80
+		xorBytes(last, last, k2)
+	}
+
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		xorBytes(x, x, msg[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		block.Encrypt(x, x)
+	}
+	xorBytes(x, x, last)
+	block.Encrypt(x, x)
+
+	return x, nil
+}
+
+// cmacShift doubles b in GF(2^128) in place
+func cmacShift(b []byte) {
+	msb := b[0] >> 7
+	for i := 0; i < len(b)-1; i++ {
+		b[i] = b[i]<<1 | b[i+1]>>7
+	}
+	b[len(b)-1] <<= 1
+	b[len(b)-1] ^= 0x87 * msb
+}
+
+// xorBytes sets dst[i] = a[i] ^ b[i] for each byte of dst
+func xorBytes(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
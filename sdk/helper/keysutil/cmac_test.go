@@ -0,0 +1,104 @@
+package keysutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCMAC_RFC4493(t *testing.T) {
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172a" +
+		"ae2d8a571e03ac9c9eb76fac45af8e51" +
+		"30c81c46a35ce411e5fbc1191a0a52ef" +
+		"f69f2445df4f9b17ad2b417be66c3710")
+
+	tests := []struct {
+		length int
+		mac    string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+
+	for _, tc := range tests {
+		mac, err := CMAC(key, msg[:tc.length])
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := hex.DecodeString(tc.mac)
+		if !bytes.Equal(mac, expected) {
+			t.Fatalf("length %d: expected %x, got %x", tc.length, expected, mac)
+		}
+	}
+}
+
+func TestPolicy_CMACAndDeriveExternalKey(t *testing.T) {
+	p := &Policy{
+		Name: "test",
+		Type: KeyType_AES256_GCM96,
+		Keys: map[string]KeyEntry{
+			"1": KeyEntry{Key: bytes.Repeat([]byte{1}, 32)},
+		},
+		LatestVersion: 1,
+	}
+
+	mac, err := p.CMAC(nil, 1, []byte("input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mac) != 16 {
+		t.Fatalf("bad CMAC length %d", len(mac))
+	}
+	if _, err := p.CMAC(nil, 2, []byte("input")); err == nil {
+		t.Fatal("expected error for missing key version")
+	}
+
+	// The CMAC key must not be the encryption key
+	cmacKey, err := p.CMACKey(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(cmacKey, p.Keys["1"].Key) {
+		t.Fatal("CMAC key equals the encryption key")
+	}
+
+	for _, mode := range []int{Kdf_hmac_sha256_counter, Kdf_hkdf_sha256} {
+		key1, err := p.DeriveExternalKey(mode, []byte("device-1"), nil, 1, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(key1) != 64 {
+			t.Fatalf("bad derived key length %d", len(key1))
+		}
+		key2, err := p.DeriveExternalKey(mode, []byte("device-2"), nil, 1, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		salted, err := p.DeriveExternalKey(mode, []byte("device-1"), []byte("salt"), 1, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(key1, key2) || bytes.Equal(key1, salted) {
+			t.Fatalf("mode %d: derived keys are not distinct", mode)
+		}
+		again, err := p.DeriveExternalKey(mode, []byte("device-1"), nil, 1, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(key1, again) {
+			t.Fatalf("mode %d: derivation is not deterministic", mode)
+		}
+	}
+
+	if _, err := p.DeriveExternalKey(Kdf_hkdf_sha256, nil, nil, 1, 32); err == nil {
+		t.Fatal("expected error for missing context")
+	}
+
+	p.Type = KeyType_ECDSA_P256
+	if _, err := p.CMAC(nil, 1, []byte("input")); err == nil {
+		t.Fatal("expected error for asymmetric key type")
+	}
+}
@@ -1,6 +1,7 @@
 package keysutil
 
 import (
+	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -20,6 +21,16 @@ const (
 
 type MarshalingType uint32
 
+const (
+	// PSSSaltLengthAuto uses the largest possible salt when signing and
+	// detects the salt length when verifying
+	PSSSaltLengthAuto = rsa.PSSSaltLengthAuto
+
+	// PSSSaltLengthEqualsHash uses a salt as long as the hash, as required
+	// by JWS
+	PSSSaltLengthEqualsHash = rsa.PSSSaltLengthEqualsHash
+)
+
 const (
 	_                                 = iota
 	MarshalingTypeASN1 MarshalingType = iota
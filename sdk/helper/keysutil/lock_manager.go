@@ -52,6 +52,84 @@ type PolicyRequest struct {
 
 	// Whether to allow plaintext backup
 	AllowPlaintextBackup bool
+
+	// Whether to allow rotation of an imported key within Vault
+	AllowImportedKeyRotation bool
+
+	// How often the key should be rotated automatically; zero disables
+	AutoRotatePeriod time.Duration
+
+	// The name of the external key backing a managed key
+	ManagedKeyName string
+}
+
+// validate checks that the requested key options are supported by the key
+// type
+func (req PolicyRequest) validate() error {
+	switch req.KeyType {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		if req.Convergent && !req.Derived {
+			return fmt.Errorf("convergent encryption requires derivation to be enabled")
+		}
+
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		if req.Derived || req.Convergent {
+			return fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_ED25519:
+		if req.Convergent {
+			return fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+		if req.Derived || req.Convergent {
+			return fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_MANAGED_KEY:
+		if req.ManagedKeyName == "" {
+			return fmt.Errorf("managed_key_name is required for keys of type %v", req.KeyType)
+		}
+		if req.Derived || req.Convergent || req.Exportable || req.AutoRotatePeriod != 0 {
+			return fmt.Errorf("key derivation, convergent encryption, export and automatic rotation not supported for keys of type %v", req.KeyType)
+		}
+
+	default:
+		return fmt.Errorf("unsupported key type %v", req.KeyType)
+	}
+
+	return nil
+}
+
+// newPolicy returns a policy with the settings of the request, without any
+// key material
+func (req PolicyRequest) newPolicy() *Policy {
+	p := &Policy{
+		l:                    new(sync.RWMutex),
+		Name:                 req.Name,
+		Type:                 req.KeyType,
+		Derived:              req.Derived,
+		Exportable:           req.Exportable,
+		AllowPlaintextBackup: req.AllowPlaintextBackup,
+		AutoRotatePeriod:     req.AutoRotatePeriod,
+		ManagedKeyName:       req.ManagedKeyName,
+	}
+
+	if req.Derived {
+		p.KDF = Kdf_hkdf_sha256
+		if req.Convergent {
+			p.ConvergentEncryption = true
+			// As of version 3 we store the version within each key, so we
+			// set to -1 to indicate that the value in the policy has no
+			// meaning. We still, for backwards compatibility, fall back to
+			// this value if the key doesn't have one, which means it will
+			// only be -1 in the case where every key version is >= 3
+			p.ConvergentVersion = -1
+		}
+	}
+
+	return p
 }
 
 type LockManager struct {
@@ -327,57 +405,12 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 		// to the user to let them know that their request can't be satisfied
 		// because we don't know if the parameters match.
 
-		switch req.KeyType {
-		case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
-			if req.Convergent && !req.Derived {
-				cleanup()
-				return nil, false, fmt.Errorf("convergent encryption requires derivation to be enabled")
-			}
-
-		case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
-			if req.Derived || req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		case KeyType_ED25519:
-			if req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
-			if req.Derived || req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		default:
+		if err := req.validate(); err != nil {
 			cleanup()
-			return nil, false, fmt.Errorf("unsupported key type %v", req.KeyType)
-		}
-
-		p = &Policy{
-			l:                    new(sync.RWMutex),
-			Name:                 req.Name,
-			Type:                 req.KeyType,
-			Derived:              req.Derived,
-			Exportable:           req.Exportable,
-			AllowPlaintextBackup: req.AllowPlaintextBackup,
+			return nil, false, err
 		}
 
-		if req.Derived {
-			p.KDF = Kdf_hkdf_sha256
-			if req.Convergent {
-				p.ConvergentEncryption = true
-				// As of version 3 we store the version within each key, so we
-				// set to -1 to indicate that the value in the policy has no
-				// meaning. We still, for backwards compatibility, fall back to
-				// this value if the key doesn't have one, which means it will
-				// only be -1 in the case where every key version is >= 3
-				p.ConvergentVersion = -1
-			}
-		}
+		p = req.newPolicy()
 
 		// Performs the actual persist and does setup
 		err = p.Rotate(ctx, req.Storage, rand)
@@ -419,6 +452,47 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 	return
 }
 
+// ImportPolicy acquires an exclusive lock on the policy name and creates a new
+// policy from the request, using the given key material as its first version.
+// It is an error if the policy already exists.
+func (lm *LockManager) ImportPolicy(ctx context.Context, req PolicyRequest, key []byte, rand io.Reader) error {
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	lock := locksutil.LockForKey(lm.keyLocks, req.Name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if lm.useCache {
+		if _, ok := lm.cache.Load(req.Name); ok {
+			return fmt.Errorf("key %q already exists", req.Name)
+		}
+	}
+
+	p, err := lm.getPolicyFromStorage(ctx, req.Storage, req.Name)
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		return fmt.Errorf("key %q already exists", req.Name)
+	}
+
+	p = req.newPolicy()
+	p.AllowImportedKeyRotation = req.AllowImportedKeyRotation
+
+	// Performs the actual persist
+	if err := p.Import(ctx, req.Storage, key, rand); err != nil {
+		return err
+	}
+
+	if lm.useCache {
+		lm.cache.Store(req.Name, p)
+	}
+
+	return nil
+}
+
 func (lm *LockManager) DeletePolicy(ctx context.Context, storage logical.Storage, name string) error {
 	var p *Policy
 	var err error
@@ -0,0 +1,26 @@
+package keysutil
+
+import (
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+// ManagedKey performs operations with key material that is held outside of
+// Vault, for instance in a cloud KMS or in the transit engine of another
+// Vault. Policies of type KeyType_MANAGED_KEY hold no key material of their
+// own and delegate encryption and decryption to it; they do not support
+// signing. The ciphertext returned by Encrypt is opaque to Vault.
+type ManagedKey interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// managedKeyFromFactories returns the first managed key among the given
+// factories
+func managedKeyFromFactories(factories []interface{}) (ManagedKey, error) {
+	for _, f := range factories {
+		if mk, ok := f.(ManagedKey); ok && mk != nil {
+			return mk, nil
+		}
+	}
+	return nil, errutil.InternalError{Err: "no managed key provided for managed key policy"}
+}
@@ -0,0 +1,131 @@
+package keysutil
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// testManagedKey is a managed key backed by an in-memory AES key
+type testManagedKey struct {
+	policy *Policy
+}
+
+func (k *testManagedKey) Encrypt(plaintext []byte) ([]byte, error) {
+	ciphertext, err := k.policy.Encrypt(0, nil, nil, base64.StdEncoding.EncodeToString(plaintext))
+	return []byte(ciphertext), err
+}
+
+func (k *testManagedKey) Decrypt(ciphertext []byte) ([]byte, error) {
+	plaintext, err := k.policy.Decrypt(nil, nil, string(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+func TestPolicy_ManagedKey(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+	lm, _ := NewLockManager(true, 0)
+
+	external, _, err := lm.GetPolicy(ctx, PolicyRequest{
+		Upsert:  true,
+		Storage: storage,
+		KeyType: KeyType_AES256_GCM96,
+		Name:    "external",
+	}, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := lm.GetPolicy(ctx, PolicyRequest{
+		Upsert:  true,
+		Storage: storage,
+		KeyType: KeyType_MANAGED_KEY,
+		Name:    "no-managed-key-name",
+	}, rand.Reader); err == nil {
+		t.Fatal("expected error without a managed key name")
+	}
+	if _, _, err := lm.GetPolicy(ctx, PolicyRequest{
+		Upsert:         true,
+		Storage:        storage,
+		KeyType:        KeyType_MANAGED_KEY,
+		Name:           "exportable",
+		ManagedKeyName: "kms",
+		Exportable:     true,
+	}, rand.Reader); err == nil {
+		t.Fatal("expected error for exportable managed key")
+	}
+
+	p, _, err := lm.GetPolicy(ctx, PolicyRequest{
+		Upsert:         true,
+		Storage:        storage,
+		KeyType:        KeyType_MANAGED_KEY,
+		Name:           "managed",
+		ManagedKeyName: "kms",
+	}, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.LatestVersion != 1 || p.ManagedKeyName != "kms" {
+		t.Fatalf("bad managed policy: %#v", p)
+	}
+	if len(p.Keys["1"].Key) != 0 || p.Keys["1"].RSAKey != nil {
+		t.Fatal("managed policy holds key material")
+	}
+	if err := p.Rotate(ctx, storage, rand.Reader); err == nil {
+		t.Fatal("expected error rotating a managed key")
+	}
+
+	mk := &testManagedKey{policy: external}
+	plaintext := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+
+	if _, err := p.Encrypt(0, nil, nil, plaintext); err == nil {
+		t.Fatal("expected error encrypting without a managed key")
+	}
+	ciphertext, err := p.EncryptWithFactory(0, nil, nil, plaintext, mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := p.DecryptWithFactory(nil, nil, ciphertext, mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != plaintext {
+		t.Fatalf("bad plaintext: %s", decrypted)
+	}
+
+	// Managed keys cannot sign or verify
+	if p.Type.SigningSupported() {
+		t.Fatal("managed keys advertise signing support")
+	}
+	digest := sha256.Sum256([]byte("the quick brown fox"))
+	_, err = p.SignWithOptions(0, nil, digest[:], &SigningOptions{Marshaling: MarshalingTypeASN1})
+	if _, ok := err.(errutil.UserError); !ok {
+		t.Fatalf("expected user error signing with a managed key, got: %v", err)
+	}
+	_, err = p.VerifySignatureWithOptions(nil, digest[:], "vault:v1:c2lnbmF0dXJl", &SigningOptions{Marshaling: MarshalingTypeASN1})
+	if _, ok := err.(errutil.UserError); !ok {
+		t.Fatalf("expected user error verifying with a managed key, got: %v", err)
+	}
+
+	// The policy round trips through storage
+	lm.InvalidatePolicy("managed")
+	p, _, err = lm.GetPolicy(ctx, PolicyRequest{Storage: storage, Name: "managed"}, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != KeyType_MANAGED_KEY || p.ManagedKeyName != "kms" {
+		t.Fatalf("bad loaded policy: %#v", p)
+	}
+	decrypted, err = p.DecryptWithFactory(nil, nil, ciphertext, mk)
+	if err != nil || decrypted != plaintext {
+		t.Fatalf("bad plaintext after reload: %s %v", decrypted, err)
+	}
+}
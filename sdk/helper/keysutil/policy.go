@@ -7,6 +7,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/ecdsa"
+	stded25519 "crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/hmac"
 	"crypto/rand"
@@ -59,6 +60,7 @@ const (
 	KeyType_ECDSA_P521
 	KeyType_AES128_GCM96
 	KeyType_RSA3072
+	KeyType_MANAGED_KEY
 )
 
 const (
@@ -85,6 +87,21 @@ type SigningResult struct {
 	PublicKey []byte
 }
 
+// SigningOptions holds the parameters of a signing or verification
+// operation
+type SigningOptions struct {
+	HashAlgorithm HashType
+	Marshaling    MarshalingType
+
+	// SigAlgorithm is the RSA signature scheme, "pss" (the default) or
+	// "pkcs1v15"
+	SigAlgorithm string
+
+	// SaltLength is the RSA-PSS salt length; see PSSSaltLengthAuto and
+	// PSSSaltLengthEqualsHash for the special values
+	SaltLength int
+}
+
 type ecdsaSignature struct {
 	R, S *big.Int
 }
@@ -93,7 +110,7 @@ type KeyType int
 
 func (kt KeyType) EncryptionSupported() bool {
 	switch kt {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096, KeyType_MANAGED_KEY:
 		return true
 	}
 	return false
@@ -101,7 +118,7 @@ func (kt KeyType) EncryptionSupported() bool {
 
 func (kt KeyType) DecryptionSupported() bool {
 	switch kt {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096, KeyType_MANAGED_KEY:
 		return true
 	}
 	return false
@@ -153,6 +170,8 @@ func (kt KeyType) String() string {
 		return "rsa-3072"
 	case KeyType_RSA4096:
 		return "rsa-4096"
+	case KeyType_MANAGED_KEY:
+		return "managed_key"
 	}
 
 	return "[unknown]"
@@ -374,6 +393,25 @@ type Policy struct {
 	// policy object.
 	StoragePrefix string `json:"storage_prefix"`
 
+	// Imported indicates whether the key material was generated outside of
+	// Vault and imported
+	Imported bool `json:"imported"`
+
+	// AllowImportedKeyRotation allows Vault to generate new versions of an
+	// imported key on rotation
+	AllowImportedKeyRotation bool `json:"allow_imported_key_rotation"`
+
+	// AutoRotatePeriod is the period after which a new version of the key is
+	// generated by the backend. Zero disables automatic rotation.
+	AutoRotatePeriod time.Duration `json:"auto_rotate_period"`
+
+	// LastRotationTime is the time the latest version of the key was created
+	LastRotationTime time.Time `json:"last_rotation_time"`
+
+	// ManagedKeyName is the name of the external key that holds the key
+	// material of a managed key policy
+	ManagedKeyName string `json:"managed_key_name"`
+
 	// versionPrefixCache stores caches of version prefix strings and the split
 	// version template.
 	versionPrefixCache sync.Map
@@ -808,6 +846,12 @@ func (p *Policy) convergentVersion(ver int) int {
 }
 
 func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string, error) {
+	return p.EncryptWithFactory(ver, context, nonce, value)
+}
+
+// EncryptWithFactory encrypts the value like Encrypt. Managed key policies
+// require a ManagedKey among the factories.
+func (p *Policy) EncryptWithFactory(ver int, context, nonce []byte, value string, factories ...interface{}) (string, error) {
 	if !p.Type.EncryptionSupported() {
 		return "", errutil.UserError{Err: fmt.Sprintf("message encryption not supported for key type %v", p.Type)}
 	}
@@ -889,6 +933,16 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 			return "", errutil.InternalError{Err: fmt.Sprintf("failed to RSA encrypt the plaintext: %v", err)}
 		}
 
+	case KeyType_MANAGED_KEY:
+		mk, err := managedKeyFromFactories(factories)
+		if err != nil {
+			return "", err
+		}
+		ciphertext, err = mk.Encrypt(plaintext)
+		if err != nil {
+			return "", errutil.InternalError{Err: fmt.Sprintf("failed to encrypt the plaintext with managed key %q: %v", p.ManagedKeyName, err)}
+		}
+
 	default:
 		return "", errutil.InternalError{Err: fmt.Sprintf("unsupported key type %v", p.Type)}
 	}
@@ -903,6 +957,12 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 }
 
 func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
+	return p.DecryptWithFactory(context, nonce, value)
+}
+
+// DecryptWithFactory decrypts the value like Decrypt. Managed key policies
+// require a ManagedKey among the factories.
+func (p *Policy) DecryptWithFactory(context, nonce []byte, value string, factories ...interface{}) (string, error) {
 	if !p.Type.DecryptionSupported() {
 		return "", errutil.UserError{Err: fmt.Sprintf("message decryption not supported for key type %v", p.Type)}
 	}
@@ -989,6 +1049,16 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 			return "", errutil.InternalError{Err: fmt.Sprintf("failed to RSA decrypt the ciphertext: %v", err)}
 		}
 
+	case KeyType_MANAGED_KEY:
+		mk, err := managedKeyFromFactories(factories)
+		if err != nil {
+			return "", err
+		}
+		plain, err = mk.Decrypt(decoded)
+		if err != nil {
+			return "", errutil.InternalError{Err: fmt.Sprintf("failed to decrypt the ciphertext with managed key %q: %v", p.ManagedKeyName, err)}
+		}
+
 	default:
 		return "", errutil.InternalError{Err: fmt.Sprintf("unsupported key type %v", p.Type)}
 	}
@@ -1015,6 +1085,21 @@ func (p *Policy) HMACKey(version int) ([]byte, error) {
 }
 
 func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType) (*SigningResult, error) {
+	return p.SignWithOptions(ver, context, input, &SigningOptions{
+		HashAlgorithm: hashAlgorithm,
+		Marshaling:    marshaling,
+		SigAlgorithm:  sigAlgorithm,
+	})
+}
+
+// SignWithOptions signs the input with the given key version. For key types
+// that sign a digest the input must already be hashed with the hash
+// algorithm of the options.
+func (p *Policy) SignWithOptions(ver int, context, input []byte, options *SigningOptions) (*SigningResult, error) {
+	// The external keys of managed keys are only used for encryption
+	if p.Type == KeyType_MANAGED_KEY {
+		return nil, errutil.UserError{Err: fmt.Sprintf("managed key %q does not support signing", p.ManagedKeyName)}
+	}
 	if !p.Type.SigningSupported() {
 		return nil, fmt.Errorf("message signing not supported for key type %v", p.Type)
 	}
@@ -1030,6 +1115,10 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 		return nil, errutil.UserError{Err: "requested version for signing is less than the minimum encryption key version"}
 	}
 
+	hashAlgorithm := options.HashAlgorithm
+	marshaling := options.Marshaling
+	sigAlgorithm := options.SigAlgorithm
+
 	var sig []byte
 	var pubKey []byte
 	var err error
@@ -1082,15 +1171,10 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 		case MarshalingTypeJWS:
 			// This is used by JWS
 
-			// First we have to get the length of the curve in bytes. Although
-			// we only support 256 now, we'll do this in an agnostic way so we
-			// can reuse this marshaling if we support e.g. 521. Getting the
-			// number of bytes without rounding up would be 65.125 so we need
-			// to add one in that case.
-			keyLen := curveBits / 8
-			if curveBits%8 > 0 {
-				keyLen++
-			}
+			// First we have to get the length of the curve in bytes. Getting
+			// the number of bytes of P-521 without rounding up would be
+			// 65.125 so we need to add one in that case.
+			keyLen := ecdsaKeyLen(curveBits)
 
 			// Now create the output array
 			sig = make([]byte, keyLen*2)
@@ -1128,20 +1212,9 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		key := keyParams.RSAKey
 
-		var algo crypto.Hash
-		switch hashAlgorithm {
-		case HashTypeSHA1:
-			algo = crypto.SHA1
-		case HashTypeSHA2224:
-			algo = crypto.SHA224
-		case HashTypeSHA2256:
-			algo = crypto.SHA256
-		case HashTypeSHA2384:
-			algo = crypto.SHA384
-		case HashTypeSHA2512:
-			algo = crypto.SHA512
-		default:
-			return nil, errutil.InternalError{Err: "unsupported hash algorithm"}
+		algo, err := rsaSignatureHash(hashAlgorithm, input)
+		if err != nil {
+			return nil, err
 		}
 
 		if sigAlgorithm == "" {
@@ -1150,7 +1223,12 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 
 		switch sigAlgorithm {
 		case "pss":
-			sig, err = rsa.SignPSS(rand.Reader, key, algo, input, nil)
+			if err := validatePSSSaltLength(&key.PublicKey, algo, options.SaltLength); err != nil {
+				return nil, err
+			}
+			sig, err = rsa.SignPSS(rand.Reader, key, algo, input, &rsa.PSSOptions{
+				SaltLength: options.SaltLength,
+			})
 			if err != nil {
 				return nil, err
 			}
@@ -1160,7 +1238,7 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 				return nil, err
 			}
 		default:
-			return nil, errutil.InternalError{Err: fmt.Sprintf("unsupported rsa signature algorithm %s", sigAlgorithm)}
+			return nil, errutil.UserError{Err: fmt.Sprintf("unsupported rsa signature algorithm %s", sigAlgorithm)}
 		}
 
 	default:
@@ -1174,6 +1252,8 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 		encoded = base64.StdEncoding.EncodeToString(sig)
 	case MarshalingTypeJWS:
 		encoded = base64.RawURLEncoding.EncodeToString(sig)
+	default:
+		return nil, errutil.UserError{Err: "requested marshaling type is invalid"}
 	}
 	res := &SigningResult{
 		Signature: p.getVersionPrefix(ver) + encoded,
@@ -1184,10 +1264,27 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 }
 
 func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType, sig string) (bool, error) {
+	return p.VerifySignatureWithOptions(context, input, sig, &SigningOptions{
+		HashAlgorithm: hashAlgorithm,
+		Marshaling:    marshaling,
+		SigAlgorithm:  sigAlgorithm,
+	})
+}
+
+// VerifySignatureWithOptions verifies a signature produced by
+// SignWithOptions with the same options
+func (p *Policy) VerifySignatureWithOptions(context, input []byte, sig string, options *SigningOptions) (bool, error) {
+	if p.Type == KeyType_MANAGED_KEY {
+		return false, errutil.UserError{Err: fmt.Sprintf("managed key %q does not support verification", p.ManagedKeyName)}
+	}
 	if !p.Type.SigningSupported() {
 		return false, errutil.UserError{Err: fmt.Sprintf("message verification not supported for key type %v", p.Type)}
 	}
 
+	hashAlgorithm := options.HashAlgorithm
+	marshaling := options.Marshaling
+	sigAlgorithm := options.SigAlgorithm
+
 	tplParts, err := p.getTemplateParts()
 	if err != nil {
 		return false, err
@@ -1231,13 +1328,17 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 
 	switch p.Type {
 	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		var curveBits int
 		var curve elliptic.Curve
 		switch p.Type {
 		case KeyType_ECDSA_P384:
+			curveBits = 384
 			curve = elliptic.P384()
 		case KeyType_ECDSA_P521:
+			curveBits = 521
 			curve = elliptic.P521()
 		default:
+			curveBits = 256
 			curve = elliptic.P256()
 		}
 
@@ -1254,7 +1355,11 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 			}
 
 		case MarshalingTypeJWS:
-			paramLen := len(sigBytes) / 2
+			// JWS signatures are the fixed-size concatenation of R and S
+			paramLen := ecdsaKeyLen(curveBits)
+			if len(sigBytes) != 2*paramLen {
+				return false, errutil.UserError{Err: "supplied signature is invalid"}
+			}
 			rb := sigBytes[:paramLen]
 			sb := sigBytes[paramLen:]
 			ecdsaSig.R = new(big.Int)
@@ -1299,20 +1404,9 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 
 		key := keyEntry.RSAKey
 
-		var algo crypto.Hash
-		switch hashAlgorithm {
-		case HashTypeSHA1:
-			algo = crypto.SHA1
-		case HashTypeSHA2224:
-			algo = crypto.SHA224
-		case HashTypeSHA2256:
-			algo = crypto.SHA256
-		case HashTypeSHA2384:
-			algo = crypto.SHA384
-		case HashTypeSHA2512:
-			algo = crypto.SHA512
-		default:
-			return false, errutil.InternalError{Err: "unsupported hash algorithm"}
+		algo, err := rsaSignatureHash(hashAlgorithm, input)
+		if err != nil {
+			return false, err
 		}
 
 		if sigAlgorithm == "" {
@@ -1321,11 +1415,16 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 
 		switch sigAlgorithm {
 		case "pss":
-			err = rsa.VerifyPSS(&key.PublicKey, algo, input, sigBytes, nil)
+			if err := validatePSSSaltLength(&key.PublicKey, algo, options.SaltLength); err != nil {
+				return false, err
+			}
+			err = rsa.VerifyPSS(&key.PublicKey, algo, input, sigBytes, &rsa.PSSOptions{
+				SaltLength: options.SaltLength,
+			})
 		case "pkcs1v15":
 			err = rsa.VerifyPKCS1v15(&key.PublicKey, algo, input, sigBytes)
 		default:
-			return false, errutil.InternalError{Err: fmt.Sprintf("unsupported rsa signature algorithm %s", sigAlgorithm)}
+			return false, errutil.UserError{Err: fmt.Sprintf("unsupported rsa signature algorithm %s", sigAlgorithm)}
 		}
 
 		return err == nil, nil
@@ -1335,9 +1434,72 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 	}
 }
 
+// rsaSignatureHash returns the hash function for an RSA signature and checks
+// that the (hashed) input is a digest of that function
+func rsaSignatureHash(hashAlgorithm HashType, input []byte) (crypto.Hash, error) {
+	var algo crypto.Hash
+	switch hashAlgorithm {
+	case HashTypeSHA1:
+		algo = crypto.SHA1
+	case HashTypeSHA2224:
+		algo = crypto.SHA224
+	case HashTypeSHA2256:
+		algo = crypto.SHA256
+	case HashTypeSHA2384:
+		algo = crypto.SHA384
+	case HashTypeSHA2512:
+		algo = crypto.SHA512
+	default:
+		return 0, errutil.UserError{Err: "unsupported hash algorithm"}
+	}
+
+	if len(input) != algo.Size() {
+		return 0, errutil.UserError{Err: fmt.Sprintf("input length of %d bytes does not match the %d byte digest of the hash algorithm", len(input), algo.Size())}
+	}
+
+	return algo, nil
+}
+
+// validatePSSSaltLength checks that a salt length is one of the special
+// values or fits in an RSA-PSS signature with the given key and hash
+func validatePSSSaltLength(key *rsa.PublicKey, algo crypto.Hash, saltLength int) error {
+	switch {
+	case saltLength == PSSSaltLengthAuto, saltLength == PSSSaltLengthEqualsHash:
+		return nil
+	case saltLength < 0:
+		return errutil.UserError{Err: fmt.Sprintf("invalid salt length %d", saltLength)}
+	}
+
+	// The encoded message is one bit shorter than the modulus and has room
+	// for the hash, the salt and two bytes of padding
+	emLen := (key.N.BitLen() - 1 + 7) / 8
+	if max := emLen - algo.Size() - 2; saltLength > max {
+		return errutil.UserError{Err: fmt.Sprintf("salt length %d is larger than the maximum of %d for this key and hash algorithm", saltLength, max)}
+	}
+	return nil
+}
+
+// ecdsaKeyLen returns the byte length of the parameters of a curve with the
+// given number of bits
+func ecdsaKeyLen(curveBits int) int {
+	keyLen := curveBits / 8
+	if curveBits%8 > 0 {
+		keyLen++
+	}
+	return keyLen
+}
+
 func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader io.Reader) (retErr error) {
+	if p.Imported && !p.AllowImportedKeyRotation {
+		return fmt.Errorf("imported key %q does not allow rotation within Vault", p.Name)
+	}
+	if p.Type == KeyType_MANAGED_KEY && p.LatestVersion > 0 {
+		return fmt.Errorf("managed key %q must be rotated in its external KMS", p.Name)
+	}
+
 	priorLatestVersion := p.LatestVersion
 	priorMinDecryptionVersion := p.MinDecryptionVersion
+	priorLastRotationTime := p.LastRotationTime
 	var priorKeys keyEntryMap
 
 	if p.Keys != nil {
@@ -1351,6 +1513,7 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader
 		if retErr != nil {
 			p.LatestVersion = priorLatestVersion
 			p.MinDecryptionVersion = priorMinDecryptionVersion
+			p.LastRotationTime = priorLastRotationTime
 			p.Keys = priorKeys
 		}
 	}()
@@ -1368,6 +1531,7 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader
 		CreationTime:           now,
 		DeprecatedCreationTime: now.Unix(),
 	}
+	p.LastRotationTime = now
 
 	hmacKey, err := uuid.GenerateRandomBytesWithReader(32, randReader)
 	if err != nil {
@@ -1406,19 +1570,10 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader
 		entry.EC_D = privKey.D
 		entry.EC_X = privKey.X
 		entry.EC_Y = privKey.Y
-		derBytes, err := x509.MarshalPKIXPublicKey(privKey.Public())
+		entry.FormattedPublicKey, err = formatPublicKey(privKey.Public())
 		if err != nil {
-			return errwrap.Wrapf("error marshaling public key: {{err}}", err)
-		}
-		pemBlock := &pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: derBytes,
-		}
-		pemBytes := pem.EncodeToMemory(pemBlock)
-		if pemBytes == nil || len(pemBytes) == 0 {
-			return fmt.Errorf("error PEM-encoding public key")
+			return err
 		}
-		entry.FormattedPublicKey = string(pemBytes)
 
 	case KeyType_ED25519:
 		pub, pri, err := ed25519.GenerateKey(randReader)
@@ -1461,6 +1616,185 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader
 	return p.Persist(ctx, storage)
 }
 
+// AutoRotationDue returns whether a new version of the key should be
+// generated because the auto rotate period has passed since the last
+// rotation. Keys created before the last rotation time was recorded fall back
+// to the creation time of their latest version.
+func (p *Policy) AutoRotationDue(now time.Time) bool {
+	if p.AutoRotatePeriod <= 0 {
+		return false
+	}
+	if p.Imported && !p.AllowImportedKeyRotation {
+		return false
+	}
+	if p.Type == KeyType_MANAGED_KEY {
+		return false
+	}
+
+	lastRotation := p.LastRotationTime
+	if lastRotation.IsZero() {
+		keyEntry, err := p.safeGetKeyEntry(p.LatestVersion)
+		if err != nil {
+			return false
+		}
+		lastRotation = keyEntry.CreationTime
+	}
+
+	return !now.Before(lastRotation.Add(p.AutoRotatePeriod))
+}
+
+// Import adds the given externally generated key material as the new latest
+// version of the policy. Symmetric keys are given as raw bytes, asymmetric
+// keys as a PKCS#8 DER-encoded private key. This should be called with an
+// exclusive lock held on the policy.
+func (p *Policy) Import(ctx context.Context, storage logical.Storage, key []byte, randReader io.Reader) (retErr error) {
+	priorLatestVersion := p.LatestVersion
+	priorMinDecryptionVersion := p.MinDecryptionVersion
+	priorImported := p.Imported
+	priorLastRotationTime := p.LastRotationTime
+	var priorKeys keyEntryMap
+
+	if p.Keys != nil {
+		priorKeys = keyEntryMap{}
+		for k, v := range p.Keys {
+			priorKeys[k] = v
+		}
+	}
+
+	defer func() {
+		if retErr != nil {
+			p.LatestVersion = priorLatestVersion
+			p.MinDecryptionVersion = priorMinDecryptionVersion
+			p.Imported = priorImported
+			p.LastRotationTime = priorLastRotationTime
+			p.Keys = priorKeys
+		}
+	}()
+
+	now := time.Now()
+	entry := KeyEntry{
+		CreationTime:           now,
+		DeprecatedCreationTime: now.Unix(),
+	}
+	p.LastRotationTime = now
+
+	hmacKey, err := uuid.GenerateRandomBytesWithReader(32, randReader)
+	if err != nil {
+		return err
+	}
+	entry.HMACKey = hmacKey
+
+	switch p.Type {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		numBytes := 32
+		if p.Type == KeyType_AES128_GCM96 {
+			numBytes = 16
+		}
+		if len(key) != numBytes {
+			return fmt.Errorf("invalid key size %d bytes for key type %v; expected %d bytes", len(key), p.Type, numBytes)
+		}
+		entry.Key = key
+
+	default:
+		parsedKey, err := x509.ParsePKCS8PrivateKey(key)
+		if err != nil {
+			return errwrap.Wrapf("error parsing PKCS#8 private key: {{err}}", err)
+		}
+
+		switch parsedKey := parsedKey.(type) {
+		case *ecdsa.PrivateKey:
+			var curve elliptic.Curve
+			switch p.Type {
+			case KeyType_ECDSA_P256:
+				curve = elliptic.P256()
+			case KeyType_ECDSA_P384:
+				curve = elliptic.P384()
+			case KeyType_ECDSA_P521:
+				curve = elliptic.P521()
+			default:
+				return fmt.Errorf("cannot import an ECDSA key into a key of type %v", p.Type)
+			}
+			if parsedKey.Curve != curve {
+				return fmt.Errorf("ECDSA key uses curve %s, which does not match key type %v", parsedKey.Curve.Params().Name, p.Type)
+			}
+
+			entry.EC_D = parsedKey.D
+			entry.EC_X = parsedKey.X
+			entry.EC_Y = parsedKey.Y
+			entry.FormattedPublicKey, err = formatPublicKey(parsedKey.Public())
+			if err != nil {
+				return err
+			}
+
+		case *rsa.PrivateKey:
+			bitSize := 0
+			switch p.Type {
+			case KeyType_RSA2048:
+				bitSize = 2048
+			case KeyType_RSA3072:
+				bitSize = 3072
+			case KeyType_RSA4096:
+				bitSize = 4096
+			default:
+				return fmt.Errorf("cannot import an RSA key into a key of type %v", p.Type)
+			}
+			if parsedKey.N.BitLen() != bitSize {
+				return fmt.Errorf("RSA key has %d bits, which does not match key type %v", parsedKey.N.BitLen(), p.Type)
+			}
+
+			entry.RSAKey = parsedKey
+
+		case stded25519.PrivateKey:
+			if p.Type != KeyType_ED25519 {
+				return fmt.Errorf("cannot import an Ed25519 key into a key of type %v", p.Type)
+			}
+
+			entry.Key = []byte(parsedKey)
+			entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(parsedKey.Public().(stded25519.PublicKey))
+
+		default:
+			return fmt.Errorf("unsupported private key type %T", parsedKey)
+		}
+	}
+
+	if p.ConvergentEncryption {
+		if p.ConvergentVersion == -1 || p.ConvergentVersion > 1 {
+			entry.ConvergentVersion = currentConvergentVersion
+		}
+	}
+
+	if p.Keys == nil {
+		p.Keys = keyEntryMap{}
+	}
+
+	p.LatestVersion += 1
+	p.Keys[strconv.Itoa(p.LatestVersion)] = entry
+	p.Imported = true
+
+	if p.MinDecryptionVersion == 0 {
+		p.MinDecryptionVersion = 1
+	}
+
+	return p.Persist(ctx, storage)
+}
+
+// formatPublicKey returns the PEM encoding of the given public key
+func formatPublicKey(pub crypto.PublicKey) (string, error) {
+	derBytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", errwrap.Wrapf("error marshaling public key: {{err}}", err)
+	}
+	pemBlock := &pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	}
+	pemBytes := pem.EncodeToMemory(pemBlock)
+	if pemBytes == nil || len(pemBytes) == 0 {
+		return "", fmt.Errorf("error PEM-encoding public key")
+	}
+	return string(pemBytes), nil
+}
+
 func (p *Policy) MigrateKeyToKeysMap() {
 	now := time.Now()
 	p.Keys = keyEntryMap{
@@ -304,6 +304,10 @@ const (
 	HelpOperation                     = "help"
 	AliasLookaheadOperation           = "alias-lookahead"
 
+	// HeaderOperation is used for HTTP HEAD requests; backends may use it to
+	// return response headers without a body.
+	HeaderOperation = "header"
+
 	// The operations below are called globally, the path is less relevant.
 	RevokeOperation   Operation = "revoke"
 	RenewOperation              = "renew"
@@ -86,6 +86,14 @@ type ExtendedSystemView interface {
 	ForwardGenericRequest(context.Context, *Request) (*Response, error)
 }
 
+// PasswordPolicyValidator is an optional interface of system views able to
+// check user-provided passwords against the password policies
+type PasswordPolicyValidator interface {
+	// ValidatePasswordFromPolicy returns an error if the password does not
+	// satisfy the policy referenced or if the policy does not exist.
+	ValidatePasswordFromPolicy(ctx context.Context, policyName string, password string) error
+}
+
 type PasswordGenerator func() (password string, err error)
 
 type StaticSystemView struct {
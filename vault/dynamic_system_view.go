@@ -340,19 +340,40 @@ func (d dynamicSystemView) GeneratePasswordFromPolicy(ctx context.Context, polic
 		defer cancel()
 	}
 
+	passPolicy, err := d.passwordPolicy(ctx, policyName)
+	if err != nil {
+		return "", err
+	}
+
+	return passPolicy.Generate(ctx, nil)
+}
+
+func (d dynamicSystemView) ValidatePasswordFromPolicy(ctx context.Context, policyName string, password string) error {
+	if policyName == "" {
+		return fmt.Errorf("missing password policy name")
+	}
+
+	passPolicy, err := d.passwordPolicy(ctx, policyName)
+	if err != nil {
+		return err
+	}
+
+	return passPolicy.Validate(password)
+}
+
+func (d dynamicSystemView) passwordPolicy(ctx context.Context, policyName string) (random.StringGenerator, error) {
 	policyCfg, err := retrievePasswordPolicy(ctx, d.core.systemBarrierView, policyName)
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve password policy: %w", err)
+		return random.StringGenerator{}, fmt.Errorf("failed to retrieve password policy: %w", err)
 	}
 
 	if policyCfg == nil {
-		return "", fmt.Errorf("no password policy found")
+		return random.StringGenerator{}, fmt.Errorf("no password policy found")
 	}
 
 	passPolicy, err := random.ParsePolicy(policyCfg.HCLPolicy)
 	if err != nil {
-		return "", fmt.Errorf("stored password policy is invalid: %w", err)
+		return random.StringGenerator{}, fmt.Errorf("stored password policy is invalid: %w", err)
 	}
-
-	return passPolicy.Generate(ctx, nil)
+	return passPolicy, nil
 }
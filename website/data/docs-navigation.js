@@ -152,6 +152,10 @@ export default [
         ],
       },
       'path-help',
+      {
+        category: 'pki',
+        content: ['list-certs'],
+      },
       {
         category: 'plugin',
         content: ['deregister', 'info', 'list', 'register', 'reload'],
@@ -172,6 +176,10 @@ export default [
         category: 'token',
         content: ['capabilities', 'create', 'lookup', 'renew', 'revoke'],
       },
+      {
+        category: 'transit',
+        content: ['decrypt-file', 'encrypt-file'],
+      },
       'unwrap',
       'version',
       'write',